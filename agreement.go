@@ -0,0 +1,79 @@
+package wordentropy
+
+import (
+	"strings"
+)
+
+// Whether a verb is a third-person singular form ("runs") rather than a plural one ("run").
+// Guessed from the first word of the entry: singular forms end in "s" but not "ss". Verbs in
+// exceptions (lowercased) get the opposite guess, e.g. "bus".
+func is_singular_verb(verb string, exceptions map[string]bool) bool {
+	first, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(verb)), " ")
+	singular := strings.HasSuffix(first, "s") && !strings.HasSuffix(first, "ss")
+	if exceptions[first] {
+		return !singular
+	}
+	return singular
+}
+
+// Number of the subject a verb following these word types agrees with: "singular" or
+// "plural" for a noun before it, skipping adverbs ("otters quietly run"), or "" if there is
+// none (pronouns and everything else leave the verb free)
+func subject_number(types []string) string {
+	for i := len(types) - 1; i >= 0; i-- {
+		switch types[i] {
+		case "adverb":
+		case "snoun":
+			return "singular"
+		case "pnoun":
+			return "plural"
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// Number a word of a type drawn after these word types must agree with ("" if any word will
+// do)
+func (s *gen_state) agreement(word_type string, before []string) string {
+	if !s.o.Agreement || word_type != "verb" {
+		return ""
+	}
+	return subject_number(before)
+}
+
+// Verbs of a pool agreeing with number, shared with other calls with the same pools, and the
+// key they are cached under in the call
+func (s *gen_state) agreeing(word_type string, number string, words []string) ([]string, string) {
+	key := word_type + "/" + number
+	if pool, ok := s.agreed[key]; ok {
+		return pool, key
+	}
+	if s.agreed == nil {
+		s.agreed = make(map[string][]string)
+	}
+	pool := s.d.derived(s.pools_key()+"/agree/"+key, func() interface{} {
+		pool := []string{}
+		for _, w := range words {
+			if is_singular_verb(w, s.d.verb_exceptions) == (number == "singular") {
+				pool = append(pool, w)
+			}
+		}
+		return pool
+	}).([]string)
+	s.agreed[key] = pool
+	return pool, key
+}
+
+// Whether every verb of p agrees with its subject as Agreement requires
+func (s *gen_state) agrees(p Passphrase) bool {
+	i := 0
+	for _, n := range p.Entries {
+		if number := s.agreement(p.Types[i], p.Types[:i]); number != "" && is_singular_verb(p.Words[i], s.d.verb_exceptions) != (number == "singular") {
+			return false
+		}
+		i += n
+	}
+	return true
+}
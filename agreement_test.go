@@ -0,0 +1,115 @@
+package wordentropy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsSingularVerb(t *testing.T) {
+	exceptions := map[string]bool{"bus": true}
+	for verb, want := range map[string]bool{
+		"runs":     true,
+		"Sings":    true,
+		"run":      false,
+		"guess":    false,
+		"bus":      false, // exception
+		"buses":    true,
+		"gives up": true,
+		"give up":  false,
+	} {
+		if got := is_singular_verb(verb, exceptions); got != want {
+			t.Errorf("%q: expected singular %v, got %v", verb, want, got)
+		}
+	}
+}
+
+func TestSubjectNumber(t *testing.T) {
+	for _, c := range []struct {
+		types []string
+		want  string
+	}{
+		{[]string{"sarticle", "snoun"}, "singular"},
+		{[]string{"particle", "pnoun", "adverb", "adverb"}, "plural"},
+		{[]string{"snoun", "conjunction"}, ""},
+		{[]string{"pronoun"}, ""},
+		{[]string{"adverb"}, ""},
+		{nil, ""},
+	} {
+		if got := subject_number(c.types); got != c.want {
+			t.Errorf("%v: expected %q, got %q", c.types, c.want, got)
+		}
+	}
+}
+
+// On a fixture where the heuristic is exact, no verb disagrees with its noun
+func TestAgreement(t *testing.T) {
+	g, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/agreement.txt", PruneEmptyTypes: true, VerbExceptions: []string{"bus"}})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	var bad []string
+	for _, noun := range []string{"otter", "badger", "fox"} {
+		for _, verb := range []string{"run", "sing", "bus", "guess"} {
+			bad = append(bad, noun+" "+verb)
+		}
+	}
+	for _, noun := range []string{"otters", "badgers", "foxes"} {
+		for _, verb := range []string{"runs", "sings", "buses"} {
+			bad = append(bad, noun+" "+verb)
+		}
+	}
+	// Adverbs between the noun and the verb
+	disagree := func(phrase string) bool {
+		for _, adverb := range []string{" quietly", " slowly"} {
+			phrase = strings.ReplaceAll(phrase, adverb, "")
+		}
+		for _, pair := range bad {
+			if strings.Contains(" "+phrase+" ", " "+pair+" ") {
+				return true
+			}
+		}
+		return false
+	}
+
+	o := GenerateOptions{Count: 99, Length: 6}
+	found := false
+	for i := 0; i < 5 && !found; i++ {
+		p, err := g.GeneratePassphrases(&o)
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		for _, phrase := range p {
+			found = found || disagree(phrase)
+		}
+	}
+	if !found {
+		t.Fatalf("Expected disagreeing verbs without Agreement")
+	}
+
+	o.Agreement = true
+	for i := 0; i < 10; i++ {
+		p, err := g.GeneratePassphrases(&o)
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		for _, phrase := range p {
+			if disagree(phrase) {
+				t.Errorf("Verb disagrees with its noun in %q", phrase)
+			}
+		}
+	}
+
+	o.Length = 3
+	all, err := g.EnumerateAll(&o, 100000)
+	if err != nil {
+		t.Fatalf("Error enumerating passphrases: %v", err)
+	}
+	if len(all) == 0 {
+		t.Fatalf("Expected passphrases with Agreement")
+	}
+	for _, phrase := range all {
+		if disagree(phrase) {
+			t.Errorf("Verb disagrees with its noun in enumerated %q", phrase)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package wordentropy
+
+import (
+	"log"
+	"strings"
+	"unicode"
+)
+
+// Generate a passphrase sharing no word with a previous one, e.g. when rotating credentials.
+// The previous phrase is split into words at anything other than letters and apostrophes,
+// so it needs separators between words (it can't be split if generated with No_spaces).
+// Matching is case-insensitive, and a multiword entry is avoided if any of its component
+// words appear. Count in the options is ignored. If the remaining words cannot form a
+// passphrase within MaxRetries, a ConstraintError is returned.
+func (g *Generator) GenerateAvoiding(previous string, o *GenerateOptions) (_ string, err error) {
+	defer recover_internal(&err)
+	var options GenerateOptions
+	if o != nil {
+		options = *o
+	}
+	options.Count = 1
+	p, warnings, err := g.generate(&options, avoid_set(previous))
+	for _, w := range warnings {
+		log.Printf("WARNING: %v\n", w)
+	}
+	if err != nil {
+		return "", err
+	}
+	return p[0].Phrase, nil
+}
+
+// Avoid set extended with the ExtraDenyWords of the options, copied so neither is modified
+// (nil if both are empty)
+func with_denied(avoid map[string]uint, deny []string) map[string]uint {
+	if len(deny) == 0 {
+		return avoid
+	}
+	merged := make(map[string]uint, len(avoid)+len(deny))
+	for w, level := range avoid {
+		merged[w] = level
+	}
+	for _, w := range deny {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			merged[w] = 1
+		}
+	}
+	return merged
+}
+
+// Lowercased words of a phrase, in the form is_offensive checks against
+func avoid_set(phrase string) map[string]uint {
+	avoid := make(map[string]uint)
+	for _, w := range strings.FieldsFunc(strings.ToLower(phrase), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		avoid[w] = 1
+	}
+	return avoid
+}
@@ -0,0 +1,98 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGenerateAvoiding(t *testing.T) {
+	g := load_test_generator(t)
+	previous := "Damn the-OTTERS, quietly! 7"
+	for i := 0; i < 50; i++ {
+		p, err := g.GenerateAvoiding(previous, &GenerateOptions{Length: 6, Count: 5})
+		if err != nil {
+			t.Fatalf("Error generating passphrase: %v", err)
+		}
+		for w := range avoid_set(p) {
+			if _, ok := avoid_set(previous)[w]; ok {
+				t.Fatalf("%q shares %q with %q", p, w, previous)
+			}
+		}
+		if strings.Contains(p, "fool") {
+			t.Fatalf("Multiword entry with an avoided component in %q", p)
+		}
+	}
+
+	m := uniform_word_map("otter")
+	m["snoun"] = []string{"otter", "badger"}
+	m["verb"] = []string{"runs", "sings"}
+	o := GenerateOptions{AllowedTypes: []string{"snoun", "verb"}}
+	if _, err := generator_for(m).GenerateAvoiding("otter runs", &o); err != nil {
+		t.Fatalf("Expected badger and sings to be usable: %v", err)
+	}
+	_, err := generator_for(m).GenerateAvoiding("Runs, sings", &o)
+	var ce *ConstraintError
+	if !errors.As(err, &ce) || ce.Constraint != ConstraintAvoided || ce.WordType != "verb" || !errors.Is(err, ErrNoCandidates) {
+		t.Fatalf("Expected an Avoided ConstraintError for verbs, got %v", err)
+	}
+}
+
+func TestExtraDenyWords(t *testing.T) {
+	m := uniform_word_map("otter")
+	m["snoun"] = []string{"otter", "badger", "Acme corp"}
+	m["verb"] = []string{"runs", "sings"}
+	g := generator_for(m)
+	denied := GenerateOptions{Count: 20, AllowedTypes: []string{"snoun", "verb"}, ExtraDenyWords: []string{"BADGER", "acme"}}
+	plain := GenerateOptions{Count: 20, AllowedTypes: []string{"snoun", "verb"}}
+
+	// Calls with and without the deny set share the generator
+	seen := make(chan string, 100)
+	errs := make(chan error, 100)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			p, err := g.GeneratePassphrases(&denied)
+			if err != nil {
+				errs <- err
+				return
+			}
+			for _, phrase := range p {
+				if strings.Contains(phrase, "badger") || strings.Contains(phrase, "Acme") {
+					errs <- fmt.Errorf("denied word in %q", phrase)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			p, err := g.GeneratePassphrases(&plain)
+			if err != nil {
+				errs <- err
+				return
+			}
+			for _, phrase := range p {
+				seen <- phrase
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	close(seen)
+	for err := range errs {
+		t.Error(err)
+	}
+	badger := false
+	for phrase := range seen {
+		badger = badger || strings.Contains(phrase, "badger")
+	}
+	if !badger {
+		t.Errorf("Expected badger in passphrases generated without the deny set")
+	}
+	if denied.ExtraDenyWords[0] != "BADGER" {
+		t.Errorf("Options modified: %v", denied.ExtraDenyWords)
+	}
+}
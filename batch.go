@@ -0,0 +1,58 @@
+package wordentropy
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Error from GenerateBatches, naming the option set that failed
+type OptionSetError struct {
+	Index int // position of the failing option set in the request slice
+	Err   error
+}
+
+func (e *OptionSetError) Error() string {
+	return fmt.Sprintf("batch %v: %v", e.Index, e.Err)
+}
+
+func (e *OptionSetError) Unwrap() error {
+	return e.Err
+}
+
+// Generate passphrases for several option sets in one call (e.g. a short login passphrase
+// and a longer recovery phrase), returning each set's passphrases at the same position as
+// its options. All option sets are validated against the same word list before anything is
+// generated. Errors are a *OptionSetError; no results are returned if any batch fails.
+func (g *Generator) GenerateBatches(reqs []GenerateOptions) (_ [][]string, err error) {
+	defer recover_internal(&err)
+	start := time.Now()
+	d := g.words()
+	states := make([]*gen_state, len(reqs))
+	for i := range reqs {
+		s, err := g.prepare(&reqs[i], d, nil)
+		if err != nil {
+			g.counters.record(nil, 0, err, start)
+			return nil, &OptionSetError{Index: i, Err: err}
+		}
+		states[i] = s
+	}
+
+	results := make([][]string, len(reqs))
+	for i, s := range states {
+		start := time.Now()
+		p, warnings, err := g.run(s)
+		g.counters.record(s, len(p), err, start)
+		for _, w := range warnings {
+			log.Printf("WARNING: batch %v: %v\n", i, w)
+		}
+		if err != nil {
+			return nil, &OptionSetError{Index: i, Err: err}
+		}
+		results[i] = make([]string, len(p))
+		for j := range p {
+			results[i][j] = p[j].Phrase
+		}
+	}
+	return results, nil
+}
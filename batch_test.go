@@ -0,0 +1,63 @@
+package wordentropy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGenerateBatches(t *testing.T) {
+	g := load_test_generator(t)
+	results, err := g.GenerateBatches([]GenerateOptions{
+		{Count: 2, Length: 4},
+		{Count: 1, Length: 8, Separator: "-"},
+		{},
+	})
+	if err != nil {
+		t.Fatalf("Error generating batches: %v", err)
+	}
+	if len(results) != 3 || len(results[0]) != 2 || len(results[1]) != 1 || len(results[2]) != count_default {
+		t.Fatalf("Unexpected batch sizes: %q", results)
+	}
+	for _, p := range results[0] {
+		if n := len(strings.Fields(p)); n < 4 {
+			t.Errorf("Expected 4 words in %q, got %v", p, n)
+		}
+	}
+	if n := len(strings.Split(results[1][0], "-")); n < 8 { // multiword entries split further
+		t.Errorf("Expected 8 words in %q, got %v", results[1][0], n)
+	}
+	if c := g.Counters(); c.Phrases != uint64(3+count_default) || c.Errors != 0 {
+		t.Errorf("Unexpected counters %+v", c)
+	}
+
+	// An invalid set fails the whole call before anything is generated
+	g.ResetCounters()
+	cases := []struct {
+		reqs  []GenerateOptions
+		index int
+		err   error
+	}{
+		{[]GenerateOptions{{Count: 1}, {Count: count_max + 1}}, 1, ErrCountExceedsMax},
+		{[]GenerateOptions{{Capitalize: "shout"}, {Count: 1}}, 0, ErrUnknownCapitalize},
+		{[]GenerateOptions{{Count: 1}, {Count: 1}, {AllowedTypes: []string{"xyzzy"}}}, 2, ErrUnknownWordType},
+		{[]GenerateOptions{{Count: 1}, {Count: 1, MaxChars: 1, MaxRetries: 2}}, 1, ErrRetriesExhausted},
+	}
+	for _, c := range cases {
+		results, err := g.GenerateBatches(c.reqs)
+		var be *OptionSetError
+		if results != nil || !errors.As(err, &be) || be.Index != c.index || !errors.Is(err, c.err) {
+			t.Errorf("Expected batch %v to fail with %v, got %v, %q", c.index, c.err, err, results)
+		}
+	}
+	if c := g.Counters(); c.Phrases != 1 || c.Errors != 4 {
+		t.Errorf("Expected only the batch before the generation failure to be counted, got %+v", c)
+	}
+
+	if results, err := g.GenerateBatches(nil); err != nil || len(results) != 0 {
+		t.Errorf("Expected no results for no batches, got %q, %v", results, err)
+	}
+	if _, err := (&Generator{}).GenerateBatches([]GenerateOptions{{}}); !errors.Is(err, ErrEmptyWordlist) {
+		t.Errorf("Expected ErrEmptyWordlist, got %v", err)
+	}
+}
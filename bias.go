@@ -0,0 +1,72 @@
+package wordentropy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"unicode/utf8"
+)
+
+// Words of one pool grouped by length for ShortWordBias. Each word is drawn with likelihood
+// proportional to 2^(-ShortWordBias × (its length in runes - the shortest length in the
+// pool)), so at a bias of 1 every extra letter halves a word's chances, and words of one
+// length are equally likely.
+type length_buckets struct {
+	words      [][]string // words of each length, shortest first
+	cumulative []float64  // running total of the bucket weights
+	entropy    float64    // Shannon entropy of a draw in bits
+}
+
+func new_length_buckets(pool []string, bias float64) *length_buckets {
+	by_length := make(map[int][]string)
+	for _, w := range pool {
+		n := utf8.RuneCountInString(w)
+		by_length[n] = append(by_length[n], w)
+	}
+	lengths := make([]int, 0, len(by_length))
+	for n := range by_length {
+		lengths = append(lengths, n)
+	}
+	sort.Ints(lengths)
+
+	b := &length_buckets{}
+	weights := make([]float64, len(lengths)) // weight of one word of each length
+	total := 0.0
+	for i, n := range lengths {
+		weights[i] = math.Exp2(-bias * float64(n-lengths[0]))
+		total += weights[i] * float64(len(by_length[n]))
+		b.words = append(b.words, by_length[n])
+		b.cumulative = append(b.cumulative, total)
+	}
+	for i, n := range lengths {
+		p := weights[i] / total
+		b.entropy -= float64(len(by_length[n])) * p * math.Log2(p)
+	}
+	return b
+}
+
+// Biased length buckets of a pool of the call, shared with other calls with the same pools
+// and bias. The pool of a type is the same throughout a call.
+func (s *gen_state) buckets(word_type string, pool []string) *length_buckets {
+	if b, ok := s.biased[word_type]; ok {
+		return b
+	}
+	if s.biased == nil {
+		s.biased = make(map[string]*length_buckets)
+	}
+	key := fmt.Sprintf("%v/bias/%v/%v", s.pools_key(), s.o.ShortWordBias, word_type)
+	b := s.d.derived(key, func() interface{} {
+		return new_length_buckets(pool, s.o.ShortWordBias)
+	}).(*length_buckets)
+	s.biased[word_type] = b
+	return b
+}
+
+// Random word of a pool per ShortWordBias: a length bucket by weight, then a word of it
+func (s *gen_state) biased_choice(word_type string, pool []string) string {
+	b := s.buckets(word_type, pool)
+	total := b.cumulative[len(b.cumulative)-1]
+	r := float64(s.rng.int_n(1<<53)) / (1 << 53) * total
+	i := sort.Search(len(b.cumulative), func(i int) bool { return b.cumulative[i] > r })
+	return s.choice(b.words[min(i, len(b.words)-1)])
+}
@@ -0,0 +1,65 @@
+package wordentropy
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestShortWordBias(t *testing.T) {
+	m := uniform_word_map("otter")
+	m["snoun"] = nil
+	for n := 3; n <= 14; n++ {
+		m["snoun"] = append(m["snoun"], strings.Repeat("a", n))
+	}
+	g := generator_for(m)
+
+	previous := math.Inf(1)
+	for _, bias := range []float64{0, 0.25, 0.5, 1} {
+		o := GenerateOptions{Count: 99, Length: 4, AllowedTypes: []string{"snoun", "verb"}, ShortWordBias: bias}
+		total, words := 0, 0
+		for i := 0; i < 10; i++ {
+			p, _, err := g.GeneratePassphrasesDetailed(&o)
+			if err != nil {
+				t.Fatalf("Error generating passphrases: %v", err)
+			}
+			for _, phrase := range p {
+				for j, w := range phrase.Words {
+					if phrase.Types[j] == "snoun" {
+						total += utf8.RuneCountInString(w)
+						words++
+					}
+				}
+			}
+		}
+		average := float64(total) / float64(words)
+		if average >= previous {
+			t.Errorf("Average noun length %.2f at bias %v, expected less than %.2f", average, bias, previous)
+		}
+		previous = average
+	}
+
+	for _, bias := range []float64{-0.1, 1.5, math.NaN()} {
+		if _, err := g.GeneratePassphrases(&GenerateOptions{ShortWordBias: bias}); !errors.Is(err, ErrInvalidBias) {
+			t.Errorf("Expected ErrInvalidBias for %v, got %v", bias, err)
+		}
+	}
+}
+
+func TestLengthBucketsEntropy(t *testing.T) {
+	pool := []string{"ox", "cat", "dog", "mouse"}
+	if h := new_length_buckets(pool, 0).entropy; math.Abs(h-2) > 1e-9 {
+		t.Errorf("Expected 2 bits without bias, got %v", h)
+	}
+	// Weights 1, 1/2, 1/2 and 1/8 for lengths 2, 3, 3 and 5
+	p := []float64{8.0 / 17, 4.0 / 17, 4.0 / 17, 1.0 / 17}
+	want := 0.0
+	for _, q := range p {
+		want -= q * math.Log2(q)
+	}
+	if h := new_length_buckets(pool, 1).entropy; math.Abs(h-want) > 1e-9 {
+		t.Errorf("Expected %v bits at bias 1, got %v", want, h)
+	}
+}
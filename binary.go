@@ -0,0 +1,251 @@
+package wordentropy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/bkeroack/libwordentropy/wordlist"
+	"io"
+	"math"
+	"sort"
+)
+
+// Binary wordlist layout (integers little-endian):
+//
+//	magic      "WEBW"
+//	version    uint16
+//	types      uint16
+//	per type:  uint8 name length, name, uint32 word count
+//	per word:  uint16 length, UTF-8 bytes (grouped by type, in header order)
+const (
+	binary_magic   = "WEBW"
+	binary_version = 1
+)
+
+// Errors returned when loading a binary wordlist
+var (
+	ErrBinaryVersion = errors.New("Unsupported binary wordlist version")
+	ErrInvalidBinary = errors.New("Invalid binary wordlist")
+)
+
+// Convert a POS wordlist read from src to the binary format, loadable with
+// WordListOptions.Format "binary".
+func ConvertWordlist(src io.Reader, dst io.Writer) (err error) {
+	defer recover_internal(&err)
+	word_map, _, err := parse_wordmap(src, wordlist.Parser{})
+	if err != nil {
+		return err
+	}
+	return write_binary_wordmap(dst, word_map)
+}
+
+func write_binary_wordmap(dst io.Writer, word_map map[string][]string) error {
+	w := bufio.NewWriter(dst)
+	le := binary.LittleEndian
+	w.WriteString(binary_magic)
+	w.Write(le.AppendUint16(nil, binary_version))
+	w.Write(le.AppendUint16(nil, uint16(len(word_types))))
+	lists := make([][]string, len(word_types))
+	for i, t := range word_types {
+		lists[i] = dedup_words(append([]string{}, word_map[t]...))
+		sort.Strings(lists[i])
+		w.WriteByte(byte(len(t)))
+		w.WriteString(t)
+		w.Write(le.AppendUint32(nil, uint32(len(lists[i]))))
+	}
+	for _, words := range lists {
+		for _, word := range words {
+			if len(word) > math.MaxUint16 {
+				return fmt.Errorf("%w: word too long: %v bytes", ErrInvalidBinary, len(word))
+			}
+			w.Write(le.AppendUint16(nil, uint16(len(word))))
+			w.WriteString(word)
+		}
+	}
+	return w.Flush()
+}
+
+// Load a binary wordlist with a single read. Words are substrings of one copy of the file,
+// so the only per-type allocation is the slice holding them.
+func load_binary_wordmap(p string, max_bytes int64) (map[string][]string, error) {
+	buf, err := read_limited(p, max_bytes)
+	if err != nil {
+		return nil, err
+	}
+	word_map, err := parse_binary_wordmap(buf)
+	if err != nil {
+		return nil, &ParseError{Path: p, Reason: err.Error(), Err: err}
+	}
+	return word_map, nil
+}
+
+// Per-type word counts from a binary wordlist header, and the offset of the first word
+type binary_header struct {
+	types  []string
+	counts []uint32
+	words  int
+}
+
+func parse_binary_header(data []byte) (*binary_header, error) {
+	le := binary.LittleEndian
+	if len(data) < 8 || string(data[:4]) != binary_magic {
+		return nil, fmt.Errorf("%w: bad magic", ErrInvalidBinary)
+	}
+	if v := le.Uint16(data[4:6]); v != binary_version {
+		return nil, fmt.Errorf("%w: %v (expected %v)", ErrBinaryVersion, v, binary_version)
+	}
+	if int64(len(data)) > math.MaxUint32 {
+		return nil, fmt.Errorf("%w: file too large", ErrInvalidBinary)
+	}
+	n := int(le.Uint16(data[6:8]))
+	h := &binary_header{types: make([]string, n), counts: make([]uint32, n)}
+	off := 8
+	for i := 0; i < n; i++ {
+		if off >= len(data) {
+			return nil, fmt.Errorf("%w: truncated header", ErrInvalidBinary)
+		}
+		l := int(data[off])
+		off++
+		if off+l+4 > len(data) {
+			return nil, fmt.Errorf("%w: truncated header", ErrInvalidBinary)
+		}
+		h.types[i] = string(data[off : off+l])
+		off += l
+		if _, ok := grammar_rules[h.types[i]]; !ok {
+			return nil, fmt.Errorf("%w: %v", ErrUnknownWordType, h.types[i])
+		}
+		h.counts[i] = le.Uint32(data[off : off+4])
+		off += 4
+		if int64(h.counts[i])*2 > int64(len(data)-off) {
+			return nil, fmt.Errorf("%w: truncated word data", ErrInvalidBinary)
+		}
+	}
+	h.words = off
+	return h, nil
+}
+
+// Validate the words of a binary wordlist, calling fn with the type index, word index and
+// offset of each word's length prefix
+func walk_binary_words(data []byte, h *binary_header, fn func(t int, i int, off int)) error {
+	off := h.words
+	for t := range h.types {
+		for i := 0; i < int(h.counts[t]); i++ {
+			if off+2 > len(data) {
+				return fmt.Errorf("%w: truncated word data", ErrInvalidBinary)
+			}
+			l := int(binary.LittleEndian.Uint16(data[off : off+2]))
+			if l == 0 {
+				return fmt.Errorf("%w in word type %v", ErrEmptyWord, h.types[t])
+			}
+			if off+2+l > len(data) {
+				return fmt.Errorf("%w: truncated word data", ErrInvalidBinary)
+			}
+			fn(t, i, off)
+			off += 2 + l
+		}
+	}
+	if off != len(data) {
+		return fmt.Errorf("%w: trailing data", ErrInvalidBinary)
+	}
+	return nil
+}
+
+func parse_binary_wordmap(data []byte) (map[string][]string, error) {
+	h, err := parse_binary_header(data)
+	if err != nil {
+		return nil, err
+	}
+	all := string(data)
+	lists := make([][]string, len(h.types))
+	for t := range h.types {
+		lists[t] = make([]string, h.counts[t])
+	}
+	err = walk_binary_words(data, h, func(t int, i int, off int) {
+		l := int(binary.LittleEndian.Uint16(data[off : off+2]))
+		lists[t][i] = all[off+2 : off+2+l]
+	})
+	if err != nil {
+		return nil, err
+	}
+	word_map := make(map[string][]string, len(h.types))
+	for t, words := range lists {
+		word_map[h.types[t]] = words
+	}
+	return word_map, nil
+}
+
+// Binary wordlist kept as one byte slice. Words are only copied out when selected.
+type lazy_words struct {
+	data    []byte
+	offsets map[string][]uint32 // offset of each word's length prefix, by word type
+}
+
+// Build the per-type offset index for a binary wordlist without copying any words
+func index_binary(data []byte) (*lazy_words, error) {
+	h, err := parse_binary_header(data)
+	if err != nil {
+		return nil, err
+	}
+	lw := &lazy_words{data: data, offsets: make(map[string][]uint32, len(word_types))}
+	lists := make([][]uint32, len(h.types))
+	for t := range h.types {
+		lists[t] = make([]uint32, h.counts[t])
+	}
+	err = walk_binary_words(data, h, func(t int, i int, off int) {
+		lists[t][i] = uint32(off)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range word_types {
+		lw.offsets[t] = []uint32{}
+	}
+	for t, offsets := range lists {
+		// Files written by ConvertWordlist are already in canonical (sorted) order
+		less := func(i, j int) bool { return lw.compare(offsets[i], offsets[j]) < 0 }
+		if !sort.SliceIsSorted(offsets, less) {
+			sort.Slice(offsets, less)
+		}
+		lw.offsets[h.types[t]] = offsets
+	}
+	return lw, nil
+}
+
+// Compare the words at two offsets without copying them
+func (lw *lazy_words) compare(a uint32, b uint32) int {
+	return bytes.Compare(lw.bytes(a), lw.bytes(b))
+}
+
+// Bytes of the word whose length prefix is at off
+func (lw *lazy_words) bytes(off uint32) []byte {
+	l := uint32(binary.LittleEndian.Uint16(lw.data[off : off+2]))
+	return lw.data[off+2 : off+2+l]
+}
+
+func (lw *lazy_words) total() int {
+	n := 0
+	for _, offsets := range lw.offsets {
+		n += len(offsets)
+	}
+	return n
+}
+
+// Word i of a type
+func (lw *lazy_words) word(word_type string, i int) string {
+	return string(lw.bytes(lw.offsets[word_type][i]))
+}
+
+// Copy all words out into a word map
+func (lw *lazy_words) materialize() map[string][]string {
+	word_map := make(map[string][]string, len(lw.offsets))
+	for t, offsets := range lw.offsets {
+		words := make([]string, len(offsets))
+		for i := range offsets {
+			words[i] = lw.word(t, i)
+		}
+		word_map[t] = words
+	}
+	return word_map
+}
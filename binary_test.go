@@ -0,0 +1,267 @@
+package wordentropy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	mrand "math/rand/v2"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func convert_fixture(t testing.TB, path string) []byte {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Could not open fixture: %v", err)
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	if err := ConvertWordlist(f, &buf); err != nil {
+		t.Fatalf("Could not convert wordlist: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestBinaryWordlist(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "pos.bin")
+	if err := os.WriteFile(p, convert_fixture(t, "testdata/pos.txt"), 0644); err != nil {
+		t.Fatalf("Could not write binary wordlist: %v", err)
+	}
+
+	text, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos.txt"})
+	if err != nil {
+		t.Fatalf("Could not load text wordlist: %v", err)
+	}
+	bin, err := LoadGenerator(&WordListOptions{Wordlist: p, Format: "binary"})
+	if err != nil {
+		t.Fatalf("Could not load binary wordlist: %v", err)
+	}
+	if !reflect.DeepEqual(text.GetWordMap(), bin.GetWordMap()) {
+		t.Fatalf("Binary word map differs from text:\n%v\n%v", bin.GetWordMap(), text.GetWordMap())
+	}
+	if _, err := bin.GeneratePassphrases(nil); err != nil {
+		t.Fatalf("Error generating from binary wordlist: %v", err)
+	}
+}
+
+func TestBinaryWordlistErrors(t *testing.T) {
+	good := convert_fixture(t, "testdata/pos.txt")
+	version := append([]byte{}, good...)
+	version[4] = binary_version + 1
+	unknown := append([]byte{}, good...)
+	copy(unknown[9:], "xnoun")
+
+	cases := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"version mismatch", version, ErrBinaryVersion},
+		{"bad magic", append([]byte("XXXX"), good[4:]...), ErrInvalidBinary},
+		{"empty", nil, ErrInvalidBinary},
+		{"truncated header", good[:12], ErrInvalidBinary},
+		{"truncated words", good[:len(good)-1], ErrInvalidBinary},
+		{"trailing data", append(append([]byte{}, good...), 0), ErrInvalidBinary},
+		{"unknown type", unknown, ErrUnknownWordType},
+	}
+	for _, c := range cases {
+		p := filepath.Join(t.TempDir(), "pos.bin")
+		if err := os.WriteFile(p, c.data, 0644); err != nil {
+			t.Fatalf("Could not write binary wordlist: %v", err)
+		}
+		_, err := LoadGenerator(&WordListOptions{Wordlist: p, Format: "binary"})
+		if !errors.Is(err, c.err) {
+			t.Errorf("%v: expected %v, got %v", c.name, c.err, err)
+		}
+	}
+}
+
+func TestLazyBinaryWordlist(t *testing.T) {
+	data := convert_fixture(t, "testdata/pos.txt")
+	p := filepath.Join(t.TempDir(), "pos.bin")
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		t.Fatalf("Could not write binary wordlist: %v", err)
+	}
+	wo := WordListOptions{Wordlist: p, Format: "binary", Offensive: "testdata/offensive.txt"}
+	eager, err := LoadGenerator(&wo)
+	if err != nil {
+		t.Fatalf("Could not load binary wordlist: %v", err)
+	}
+	wo.Lazy = true
+	lazy, err := LoadGenerator(&wo)
+	if err != nil {
+		t.Fatalf("Could not lazily load binary wordlist: %v", err)
+	}
+	embedded := &Generator{}
+	if err := embedded.LoadBinaryWords(data, &WordListOptions{Offensive: "testdata/offensive.txt"}); err != nil {
+		t.Fatalf("Could not load binary wordlist from memory: %v", err)
+	}
+	if lazy.words().lazy == nil || embedded.words().lazy == nil {
+		t.Fatalf("Expected lazy word data")
+	}
+	// Derived index usage differs: a lazy list copies its words out for the offensive filter
+	eager_stats, lazy_stats := eager.Stats(), lazy.Stats()
+	eager_stats.DerivedIndexBytes, lazy_stats.DerivedIndexBytes = 0, 0
+	if !reflect.DeepEqual(eager_stats, lazy_stats) {
+		t.Fatalf("Stats differ: %+v vs %+v", eager_stats, lazy_stats)
+	}
+
+	for _, o := range []GenerateOptions{
+		{Count: 20, Length: 12},
+		{Count: 20, Length: 12, Prudish: true},
+		{Count: 20, Length: 6, MinWordLength: 4, Capitalize: "words"},
+	} {
+		var results [][]Passphrase
+		for _, g := range []*Generator{eager, lazy, embedded} {
+			g.rand = mrand.NewChaCha8([32]byte{1})
+			p, _, err := g.GeneratePassphrasesDetailed(&o)
+			if err != nil {
+				t.Fatalf("Error generating passphrases: %v", err)
+			}
+			results = append(results, p)
+		}
+		if !reflect.DeepEqual(results[0], results[1]) || !reflect.DeepEqual(results[0], results[2]) {
+			t.Errorf("%+v: lazy output differs from eager:\n%v\n%v\n%v", o, results[0], results[1], results[2])
+		}
+	}
+}
+
+func TestSkipExcluded(t *testing.T) {
+	for _, excluded := range [][]int{nil, {0}, {3}, {1, 3}, {0, 1, 2}, {2, 3, 4, 7}} {
+		kept := []int{}
+		for i := 0; i < 8; i++ {
+			if !slices.Contains(excluded, i) {
+				kept = append(kept, i)
+			}
+		}
+		for r, expected := range kept {
+			if i := skip_excluded(excluded, r); i != expected {
+				t.Errorf("%v: word %v: expected index %v, got %v", excluded, r, expected, i)
+			}
+		}
+	}
+}
+
+func TestLazyPrudishSampling(t *testing.T) {
+	dir := t.TempDir()
+	var pos bytes.Buffer
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&pos, "otter%v\tN\n", i)
+	}
+	offensive := filepath.Join(dir, "offensive.txt")
+	if err := os.WriteFile(offensive, []byte("otter1\notter4\t2\notter9\n"), 0644); err != nil {
+		t.Fatalf("Could not write offensive list: %v", err)
+	}
+	var data bytes.Buffer
+	if err := ConvertWordlist(&pos, &data); err != nil {
+		t.Fatalf("Could not convert wordlist: %v", err)
+	}
+	g := &Generator{}
+	if err := g.LoadBinaryWords(data.Bytes(), &WordListOptions{Offensive: offensive}); err != nil {
+		t.Fatalf("Could not load binary wordlist: %v", err)
+	}
+	g.rand = mrand.NewChaCha8([32]byte{2})
+
+	for _, c := range []struct {
+		level uint
+		kept  int
+	}{{0, 7}, {2, 9}} {
+		s, err := g.prepare(&GenerateOptions{Prudish: true, Prudish_level: c.level}, g.words(), nil)
+		if err != nil {
+			t.Fatalf("Error preparing generation: %v", err)
+		}
+		const draws = 50000
+		seen := make(map[string]int)
+		for i := 0; i < draws; i++ {
+			word, constraint := g.random_word("snoun", "", s)
+			if constraint != "" {
+				t.Fatalf("Unexpected constraint %v", constraint)
+			}
+			seen[word]++
+		}
+		if len(seen) != c.kept {
+			t.Fatalf("Level %v: expected %v distinct words, got %v", c.level, c.kept, seen)
+		}
+		for w, n := range seen {
+			if level := offensive_level(w, g.words().offensive); level >= max(c.level, 1) {
+				t.Fatalf("Level %v: drew offensive word %v", c.level, w)
+			}
+			if expected := float64(draws) / float64(c.kept); math.Abs(float64(n)-expected) > expected*0.05 {
+				t.Errorf("Level %v: %v drawn %v times, expected about %.0f", c.level, w, n, expected)
+			}
+		}
+	}
+	if _, ok := g.words().indexes.entries["words"]; ok {
+		t.Errorf("Prudish draws copied the lazy word list out")
+	}
+}
+
+// Prudish draws from the embedded wordlist: rejecting offensive words and redrawing (up to
+// 10 times), skipping them in a lazy word list, and drawing from a prefiltered copy
+func BenchmarkPrudishDraw(b *testing.B) {
+	wo := &WordListOptions{Offensive: "testdata/offensive.txt"}
+	o := &GenerateOptions{Prudish: true}
+	lazy := &Generator{}
+	if err := lazy.LoadBinaryWords(embedded_wordlist, wo); err != nil {
+		b.Fatalf("Could not load binary wordlist: %v", err)
+	}
+	eager := &Generator{}
+	if err := eager.load_provider(MapProvider(lazy.GetWordMap()), wo); err != nil {
+		b.Fatalf("Could not load word map: %v", err)
+	}
+
+	b.Run("retry", func(b *testing.B) {
+		s, _ := lazy.prepare(&GenerateOptions{}, lazy.words(), nil)
+		d := s.d
+		n := int64(len(d.lazy.offsets["snoun"]))
+		for i := 0; i < b.N; i++ {
+			for try := 0; try < 10; try++ {
+				if w := d.lazy.word("snoun", int(s.rng.int_n(n))); !is_offensive(w, d.offensive) {
+					break
+				}
+			}
+		}
+	})
+	for _, c := range []struct {
+		name string
+		g    *Generator
+	}{{"exclusion", lazy}, {"prefilter", eager}} {
+		b.Run(c.name, func(b *testing.B) {
+			s, _ := c.g.prepare(o, c.g.words(), nil)
+			c.g.random_word("snoun", "", s) // build the index outside the timing
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.g.random_word("snoun", "", s)
+			}
+		})
+	}
+}
+
+func benchmark_binary_loading(b *testing.B, lazy bool) {
+	data := embedded_wordlist
+	p := filepath.Join(b.TempDir(), "pos.bin")
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		b.Fatalf("Could not write binary wordlist: %v", err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g := &Generator{}
+		var err error
+		if lazy {
+			err = g.LoadBinaryWords(data, nil)
+		} else {
+			err = g.LoadWords(&WordListOptions{Wordlist: p, Format: "binary"})
+		}
+		if err != nil {
+			b.Fatalf("Error loading binary wordlist: %v", err)
+		}
+	}
+}
+
+func BenchmarkBinaryLoadingEager(b *testing.B) { benchmark_binary_loading(b, false) }
+func BenchmarkBinaryLoadingLazy(b *testing.B)  { benchmark_binary_loading(b, true) }
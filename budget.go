@@ -0,0 +1,179 @@
+package wordentropy
+
+import (
+	"container/list"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Approximate memory held by the indexes and pools derived from a word list snapshot, in
+// least recently used order so they can be evicted to stay within the Generator's budget
+type derived_usage struct {
+	budget    *atomic.Int64 // the Generator's budget in bytes (nil or 0 = unlimited)
+	bytes     int64         // total size of the counted items
+	order     list.List     // of *derived_item, most recently used first
+	evictions int64         // number of items evicted, for tests
+	sync.Mutex
+}
+
+// Accounting for one index or pool
+type derived_item struct {
+	size    int64
+	el      *list.Element // in derived_usage.order (nil if not counted)
+	dropped bool          // removed from its cache, so no longer counted
+	drop    func()        // remove it from its cache, so the next use rebuilds it
+}
+
+// Count a freshly built item, evicting others if that exceeds the budget
+func (u *derived_usage) add(it *derived_item, size int64, drop func()) {
+	u.Lock()
+	if it.dropped {
+		// Evicted from its cache while it was being built
+		u.Unlock()
+		return
+	}
+	it.size, it.drop = size, drop
+	it.el = u.order.PushFront(it)
+	u.bytes += size
+	victims := u.over_budget()
+	u.Unlock()
+	for _, v := range victims {
+		v.drop()
+	}
+}
+
+// Mark an item as the most recently used
+func (u *derived_usage) touch(it *derived_item) {
+	u.Lock()
+	if it.el != nil {
+		u.order.MoveToFront(it.el)
+	}
+	u.Unlock()
+}
+
+// Stop counting an item its cache has dropped
+func (u *derived_usage) forget(it *derived_item) {
+	u.Lock()
+	it.dropped = true
+	if it.el != nil {
+		u.order.Remove(it.el)
+		it.el = nil
+		u.bytes -= it.size
+	}
+	u.Unlock()
+}
+
+// Evict least recently used items until usage is within the budget
+func (u *derived_usage) enforce() {
+	u.Lock()
+	victims := u.over_budget()
+	u.Unlock()
+	for _, v := range victims {
+		v.drop()
+	}
+}
+
+// Remove the least recently used items from the count while usage exceeds the budget,
+// keeping the most recently used one, and return them to be dropped from their caches once
+// the lock is released
+func (u *derived_usage) over_budget() []*derived_item {
+	var budget int64
+	if u.budget != nil {
+		budget = u.budget.Load()
+	}
+	var victims []*derived_item
+	for budget > 0 && u.bytes > budget && u.order.Len() > 1 {
+		it := u.order.Remove(u.order.Back()).(*derived_item)
+		it.el, it.dropped = nil, true
+		u.bytes -= it.size
+		u.evictions++
+		victims = append(victims, it)
+	}
+	return victims
+}
+
+func (u *derived_usage) total() int64 {
+	u.Lock()
+	defer u.Unlock()
+	return u.bytes
+}
+
+// Approximate bytes held by a derived index or pool. Strings count only their headers, as
+// derived structures share the word list's bytes, unless copied is set.
+func approx_size(v interface{}, copied bool) int64 {
+	if v == nil {
+		return 0
+	}
+	rv := reflect.ValueOf(v)
+	return int64(rv.Type().Size()) + referenced_size(rv, copied)
+}
+
+// Bytes referenced by v, not counting v itself
+func referenced_size(v reflect.Value, copied bool) int64 {
+	switch v.Kind() {
+	case reflect.String:
+		if copied {
+			return int64(v.Len())
+		}
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			return int64(v.Elem().Type().Size()) + referenced_size(v.Elem(), copied)
+		}
+	case reflect.Slice:
+		n := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		if has_references(v.Type().Elem(), copied) {
+			for i := 0; i < v.Len(); i++ {
+				n += referenced_size(v.Index(i), copied)
+			}
+		}
+		return n
+	case reflect.Map:
+		const overhead = 48 // map header
+		const per_entry = 8 // hash bits and load factor slack
+		t := v.Type()
+		n := int64(overhead + v.Len()*(int(t.Key().Size()+t.Elem().Size())+per_entry))
+		it := v.MapRange()
+		for it.Next() {
+			n += referenced_size(it.Key(), copied) + referenced_size(it.Value(), copied)
+		}
+		return n
+	case reflect.Struct:
+		var n int64
+		for i := 0; i < v.NumField(); i++ {
+			n += referenced_size(v.Field(i), copied)
+		}
+		return n
+	}
+	return 0
+}
+
+// Whether values of type t can reference memory counted by referenced_size
+func has_references(t reflect.Type, copied bool) bool {
+	switch t.Kind() {
+	case reflect.String:
+		return copied
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if has_references(t.Field(i).Type, copied) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Cap the approximate memory used by structures derived from the word list (offensive word
+// prefilters, length indexes, word sets, pools filtered for particular options and the
+// like) at bytes, evicting the least recently used when it is exceeded. Evicted ones are
+// rebuilt transparently when next needed, so a small budget trades memory for time. 0 (the
+// default) removes the cap. The word list itself is not counted, and the most recently used
+// structure is kept even if it alone exceeds the budget. See Stats for current usage.
+func (g *Generator) SetDerivedIndexBudget(bytes int64) {
+	g.index_budget.Store(max(bytes, 0))
+	if d := g.data.Load(); d != nil {
+		d.usage.enforce()
+	}
+}
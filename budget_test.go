@@ -0,0 +1,104 @@
+package wordentropy
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDerivedIndexBudget(t *testing.T) {
+	g := load_test_generator(t)
+	d := g.words()
+	build := func() []interface{} {
+		return []interface{}{d.by_length(offense_filter{}), d.word_set(), d.shortest(at_level(1)), d.prudish(at_level(1))}
+	}
+
+	// Unlimited by default
+	want := build()
+	if n := len(d.indexes.entries); n != len(want) {
+		t.Fatalf("Expected %v indexes, got %v", len(want), n)
+	}
+	full := g.Stats().DerivedIndexBytes
+	if full <= 0 {
+		t.Fatalf("Expected positive derived index usage, got %v", full)
+	}
+
+	// Lowering the budget evicts down to the most recently used index
+	g.SetDerivedIndexBudget(1)
+	if n := len(d.indexes.entries); n != 1 || d.usage.evictions != int64(len(want)-1) {
+		t.Fatalf("Expected 1 index left after %v evictions, got %v after %v", len(want)-1, n, d.usage.evictions)
+	}
+	if _, ok := d.indexes.entries["prudish/1"]; !ok {
+		t.Fatalf("Expected the most recently used index to be kept")
+	}
+
+	// Evicted indexes are rebuilt on next use, evicting others, and are the same
+	builds := atomic.LoadInt64(&d.indexes.builds)
+	if got := build(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Rebuilt indexes differ from the originals")
+	}
+	// The offensive prefilter is rebuilt twice: for shortest, then for itself
+	if n := atomic.LoadInt64(&d.indexes.builds) - builds; n != int64(len(want)+1) {
+		t.Errorf("Expected %v rebuilds, got %v", len(want)+1, n)
+	}
+	if n := len(d.indexes.entries); n != 1 || d.usage.order.Len() != 1 {
+		t.Errorf("Expected 1 index within the budget, got %v", n)
+	}
+	if used := g.Stats().DerivedIndexBytes; used <= 0 || used >= full {
+		t.Errorf("Expected usage between 0 and %v, got %v", full, used)
+	}
+
+	// Generation works with pools evicted as soon as they are built
+	o := GenerateOptions{Count: 20, Prudish: true, ShortWordBias: 0.5, Agreement: true, ExtraDenyWords: []string{"otter"}, MaxWordLength: 8}
+	if _, err := g.GeneratePassphrases(&o); err != nil {
+		t.Fatalf("Error generating under a tiny budget: %v", err)
+	}
+	if n := len(d.indexes.entries) + len(d.derived_pools.entries); n != 1 || d.derived_pools.order.Len() != len(d.derived_pools.entries) {
+		t.Errorf("Expected 1 cached structure, got %v", n)
+	}
+
+	// A budget for everything keeps everything
+	g.SetDerivedIndexBudget(full * 10)
+	evictions := d.usage.evictions
+	build()
+	if _, err := g.GeneratePassphrases(&o); err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	if d.usage.evictions != evictions {
+		t.Errorf("Expected no evictions within the budget, got %v", d.usage.evictions-evictions)
+	}
+	var sum int64
+	for el := d.usage.order.Front(); el != nil; el = el.Next() {
+		sum += el.Value.(*derived_item).size
+	}
+	if used := g.Stats().DerivedIndexBytes; used != sum || used <= full {
+		t.Errorf("Expected usage %v to be the sum of %v and more than %v", used, sum, full)
+	}
+
+	// Reloading keeps the budget
+	if err := g.AddWords("snoun", "wombat"); err != nil {
+		t.Fatalf("Error adding words: %v", err)
+	}
+	if b := g.words().usage.budget.Load(); b != full*10 {
+		t.Errorf("Expected the budget to carry over, got %v", b)
+	}
+}
+
+func TestApproxSize(t *testing.T) {
+	cases := []struct {
+		v      interface{}
+		copied bool
+		size   int64
+	}{
+		{nil, false, 0},
+		{[]string{"otter", "badger"}, false, 24 + 2*16},
+		{[]string{"otter", "badger"}, true, 24 + 2*16 + 11},
+		{map[string]int{"otter": 5}, false, 8 + 48 + (16 + 8 + 8)},
+		{&length_buckets{words: [][]string{{"a"}}, cumulative: []float64{1}}, false, 8 + 56 + 24 + 16 + 8},
+	}
+	for _, c := range cases {
+		if n := approx_size(c.v, c.copied); n != c.size {
+			t.Errorf("%#v: expected %v bytes, got %v", c.v, c.size, n)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package wordentropy
+
+import (
+	"errors"
+)
+
+var ErrClosed = errors.New("Generator is closed")
+
+// Release the Generator's resources: stop watching word list files (see WatchInterval),
+// waiting for a reload in progress, and drop the word list with everything derived from
+// it. Later calls that can fail return ErrClosed, including loads; the others return what
+// they would with no word list loaded. Closing again does nothing. Without
+// WatchInterval a Generator holds nothing but memory, so closing it is optional.
+func (g *Generator) Close() error {
+	g.Lock()
+	g.closed.Store(true)
+	w := g.watcher
+	g.set_watcher(nil)
+	g.deferred.Store(nil)
+	g.data.Store(nil)
+	g.Unlock()
+	if w != nil {
+		<-w.done
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package wordentropy
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClose(t *testing.T) {
+	g := load_test_generator(t)
+	if err := g.Close(); err != nil {
+		t.Fatalf("Error closing: %v", err)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatalf("Error closing again: %v", err)
+	}
+
+	o := &GenerateOptions{Count: 1}
+	failing := map[string]func() error{
+		"AddWords":         func() error { return g.AddWords("snoun", "wombat") },
+		"EnumerateAll":     func() error { _, err := g.EnumerateAll(o, 10); return err },
+		"ExportWordMap":    func() error { return g.ExportWordMap(&bytes.Buffer{}, "json") },
+		"GenerateAvoiding": func() error { _, err := g.GenerateAvoiding("", o); return err },
+		"GenerateBatches":  func() error { _, err := g.GenerateBatches([]GenerateOptions{*o}); return err },
+		"GenerateHashedPassphrases": func() error {
+			_, err := g.GenerateHashedPassphrases(o, func(p string) (string, error) { return p, nil })
+			return err
+		},
+		"GeneratePassphrases":         func() error { _, err := g.GeneratePassphrases(o); return err },
+		"GeneratePassphrasesDetailed": func() error { _, _, err := g.GeneratePassphrasesDetailed(o); return err },
+		"GeneratePassphrasesTo":       func() error { return g.GeneratePassphrasesTo(&bytes.Buffer{}, o) },
+		"Keyspace":                    func() error { _, err := g.Keyspace(o); return err },
+		"KeyspaceUpperBound":          func() error { _, err := g.KeyspaceUpperBound(o); return err },
+		"KeyspaceUpperBoundBits":      func() error { _, err := g.KeyspaceUpperBoundBits(o); return err },
+		"MinEntropy":                  func() error { _, err := g.MinEntropy(o); return err },
+		"LengthDistribution":          func() error { _, _, _, _, err := g.LengthDistribution(o, 10); return err },
+		"LoadBinaryWords":             func() error { return g.LoadBinaryWords(embedded_wordlist, nil) },
+		"LoadEmbeddedWords":           func() error { return g.LoadEmbeddedWords(nil) },
+		"LoadWords":                   func() error { return g.LoadWords(&WordListOptions{Wordlist: "testdata/pos.txt"}) },
+		"NewPhraseReader":             func() error { _, err := NewPhraseReader(g, o); return err },
+		"PaddingEntropy":              func() error { _, err := g.PaddingEntropy(o); return err },
+		"ResolveOptions":              func() error { _, err := g.ResolveOptions(o); return err },
+		"SampleWords":                 func() error { _, err := g.SampleWords("snoun", 1); return err },
+		"SelfTest":                    func() error { return g.SelfTest(o) },
+		"StartTypeEntropy":            func() error { _, err := g.StartTypeEntropy(o); return err },
+	}
+	for name, call := range failing {
+		if err := call(); !errors.Is(err, ErrClosed) {
+			t.Errorf("%v: expected ErrClosed, got %v", name, err)
+		}
+	}
+
+	// The rest see no word list, and counters keep counting
+	g.SetDerivedIndexBudget(1)
+	empty := []struct {
+		name  string
+		check func() bool
+	}{
+		{"AnalyzeGrammar", func() bool { return reflect.DeepEqual(g.AnalyzeGrammar(), (&Generator{}).AnalyzeGrammar()) }},
+		{"Counters", func() bool { return g.Counters().Errors > 0 }}, // the failed calls above
+		{"ResetCounters", func() bool { return g.ResetCounters().Errors > 0 && g.Counters().Errors == 0 }},
+		{"GetWordMap", func() bool { return len(g.GetWordMap()) == 0 }},
+		{"PrudishImpact", func() bool { return len(g.PrudishImpact()) == 0 }},
+		{"Stats", func() bool { return len(g.Stats().Words) == 0 }},
+	}
+	checked := make(map[string]bool)
+	for _, c := range empty {
+		checked[c.name] = true
+		if !c.check() {
+			t.Errorf("%v: unexpected result after Close", c.name)
+		}
+	}
+
+	// Every exported method is covered
+	skip := map[string]bool{"Close": true, "SetDerivedIndexBudget": true, "Lock": true, "Unlock": true, "TryLock": true}
+	typ := reflect.TypeOf(g)
+	for i := 0; i < typ.NumMethod(); i++ {
+		name := typ.Method(i).Name
+		if _, ok := failing[name]; !ok && !checked[name] && !skip[name] {
+			t.Errorf("%v is not checked after Close", name)
+		}
+	}
+}
+
+func TestCloseGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		g := &Generator{}
+		if err := g.LoadWords(&WordListOptions{Wordlist: "testdata/pos.txt", WatchInterval: time.Millisecond}); err != nil {
+			t.Fatalf("Error loading wordlist: %v", err)
+		}
+		if _, err := g.GeneratePassphrases(nil); err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		g.Close()
+	}
+	// Close waits for the watchers, but allow the runtime a moment to reap them
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		buf := make([]byte, 1<<16)
+		stacks := string(buf[:runtime.Stack(buf, true)])
+		t.Fatalf("Expected at most %v goroutines after Close, got %v:\n%v", before, n, strings.TrimSpace(stacks))
+	}
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Returned by parse when -h or --help is given
+var errHelp = errors.New("help requested")
+
+// Command line flag bound to a variable. Flags are given as --long or -long, or -s for the
+// short form; values as --long=v, --long v, -s v or -sv. Booleans take no separate value
+// (--prude, --prude=false).
+type flag_def struct {
+	short string      // one-letter alias, "" if none
+	long  string      // full name
+	value interface{} // pointer to the bound variable: *uint, *float64, *bool, *string, *byte, *[]string, *map[string]uint, *map[string]string or *time.Duration; or a chars_value
+	def   string      // default value in flag syntax, "" for the zero value
+	usage string
+}
+
+// Flags listed together under a heading in the usage text
+type flag_group struct {
+	name  string
+	flags []flag_def
+}
+
+type flag_value interface {
+	Set(string) error
+}
+
+type uint_value struct{ p *uint }
+
+func (u uint_value) Set(v string) error {
+	n, err := strconv.ParseUint(v, 0, strconv.IntSize)
+	if err != nil {
+		return errors.New("expected a non-negative integer")
+	}
+	*u.p = uint(n)
+	return nil
+}
+
+type bool_value struct{ p *bool }
+
+func (b bool_value) Set(v string) error {
+	t, err := strconv.ParseBool(v)
+	if err != nil {
+		return errors.New("expected true or false")
+	}
+	*b.p = t
+	return nil
+}
+
+type string_value struct{ p *string }
+
+func (s string_value) Set(v string) error {
+	*s.p = v
+	return nil
+}
+
+type duration_value struct{ p *time.Duration }
+
+func (d duration_value) Set(v string) error {
+	t, err := time.ParseDuration(v)
+	if err != nil {
+		return errors.New("expected a duration such as 500ms or 2s")
+	}
+	*d.p = t
+	return nil
+}
+
+// Comma-separated list flag
+type list_value struct {
+	p *[]string
+}
+
+func (l list_value) String() string {
+	if l.p == nil {
+		return ""
+	}
+	return strings.Join(*l.p, ",")
+}
+
+func (l list_value) Set(v string) error {
+	*l.p = nil
+	if v != "" {
+		*l.p = strings.Split(v, ",")
+	}
+	return nil
+}
+
+type float_value struct{ p *float64 }
+
+func (f float_value) Set(v string) error {
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return errors.New("expected a number")
+	}
+	*f.p = n
+	return nil
+}
+
+// Flag holding a single ASCII character, given as is or as an escape such as \t ("" = 0)
+type byte_value struct{ p *byte }
+
+func (b byte_value) Set(v string) error {
+	if v == "" {
+		*b.p = 0
+		return nil
+	}
+	r, _, tail, err := strconv.UnquoteChar(v, '\'')
+	if err != nil || tail != "" || r >= 0x80 {
+		return errors.New("expected a single ASCII character or an escape such as \\t")
+	}
+	*b.p = byte(r)
+	return nil
+}
+
+// Flag listing single characters, e.g. "23456789"
+type chars_value struct {
+	p *[]string
+}
+
+func (c chars_value) Set(v string) error {
+	*c.p = nil
+	for _, r := range v {
+		*c.p = append(*c.p, string(r))
+	}
+	return nil
+}
+
+// Comma-separated type=weight flag
+type weights_value struct {
+	p *map[string]uint
+}
+
+func (w weights_value) String() string {
+	if w.p == nil || *w.p == nil {
+		return ""
+	}
+	pairs := []string{}
+	for t, n := range *w.p {
+		pairs = append(pairs, fmt.Sprintf("%v=%v", t, n))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (w weights_value) Set(v string) error {
+	*w.p = nil
+	if v == "" {
+		return nil
+	}
+	*w.p = make(map[string]uint)
+	for _, pair := range strings.Split(v, ",") {
+		t, n, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("expected type=weight, got %q", pair)
+		}
+		weight, err := strconv.ParseUint(n, 10, 32)
+		if err != nil {
+			return fmt.Errorf("bad weight for %v: %v", t, err)
+		}
+		(*w.p)[t] = uint(weight)
+	}
+	return nil
+}
+
+// Repeatable name=path flag, adding one entry each time it is given
+type paths_value struct {
+	p *map[string]string
+}
+
+func (n paths_value) Set(v string) error {
+	name, path, ok := strings.Cut(v, "=")
+	if !ok || name == "" || path == "" {
+		return fmt.Errorf("expected name=path, got %q", v)
+	}
+	if _, ok := (*n.p)[name]; ok {
+		return fmt.Errorf("%q given twice", name)
+	}
+	if *n.p == nil {
+		*n.p = make(map[string]string)
+	}
+	(*n.p)[name] = path
+	return nil
+}
+
+// Value setter for a bound variable, and the name of its type for the usage text ("" for
+// booleans)
+func bind(p interface{}) (flag_value, string) {
+	switch p := p.(type) {
+	case *uint:
+		return uint_value{p}, "uint"
+	case *float64:
+		return float_value{p}, "float"
+	case *bool:
+		return bool_value{p}, ""
+	case *string:
+		return string_value{p}, "string"
+	case *byte:
+		return byte_value{p}, "char"
+	case *[]string:
+		return list_value{p}, "list"
+	case *map[string]uint:
+		return weights_value{p}, "weights"
+	case *map[string]string:
+		return paths_value{p}, "name=path"
+	case *time.Duration:
+		return duration_value{p}, "duration"
+	case chars_value:
+		return p, "chars"
+	}
+	panic(fmt.Sprintf("unsupported flag type %T", p))
+}
+
+type bound_flag struct {
+	*flag_def
+	value flag_value
+	typ   string
+}
+
+func (f *bound_flag) is_bool() bool {
+	return f.typ == ""
+}
+
+type flag_set struct {
+	groups []flag_group
+	flags  []*bound_flag
+	long   map[string]*bound_flag
+	short  map[string]*bound_flag
+	set    map[string]bool // long names of the flags given on the command line
+}
+
+// Bind the flags in groups and set their defaults
+func new_args(groups []flag_group) *flag_set {
+	fs := &flag_set{
+		groups: groups,
+		long:   make(map[string]*bound_flag),
+		short:  make(map[string]*bound_flag),
+		set:    make(map[string]bool),
+	}
+	for i := range groups {
+		for j := range groups[i].flags {
+			d := &groups[i].flags[j]
+			v, typ := bind(d.value)
+			f := &bound_flag{d, v, typ}
+			if _, ok := fs.long[d.long]; ok || d.long == "help" {
+				panic("duplicate flag --" + d.long)
+			}
+			fs.long[d.long] = f
+			if d.short != "" {
+				if _, ok := fs.short[d.short]; ok || d.short == "h" || len(d.short) != 1 {
+					panic("bad short flag -" + d.short)
+				}
+				fs.short[d.short] = f
+			}
+			if d.def != "" {
+				if err := v.Set(d.def); err != nil {
+					panic(fmt.Sprintf("bad default for --%v: %v", d.long, err))
+				}
+			}
+			fs.flags = append(fs.flags, f)
+		}
+	}
+	return fs
+}
+
+func (fs *flag_set) lookup(name string) *bound_flag {
+	if f, ok := fs.long[name]; ok {
+		return f
+	}
+	return fs.short[name]
+}
+
+// Parse args into the bound variables. Positional arguments are not accepted.
+func (fs *flag_set) parse(args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			if i+1 < len(args) {
+				return fmt.Errorf("unexpected argument %q", args[i+1])
+			}
+			return nil
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			return fmt.Errorf("unexpected argument %q", arg)
+		}
+		dashes := "-"
+		if strings.HasPrefix(arg, "--") {
+			dashes = "--"
+		}
+		raw := arg[len(dashes):]
+		name, v, has_value := strings.Cut(raw, "=")
+		if name == "h" || name == "help" {
+			return errHelp
+		}
+		f := fs.lookup(name)
+		if f == nil && dashes == "-" && len(raw) > 1 {
+			if s := fs.short[raw[:1]]; s != nil && !s.is_bool() {
+				f, name, v, has_value = s, raw[:1], raw[1:], true
+			}
+		}
+		if f == nil {
+			return fs.unknown(dashes + name)
+		}
+		if !has_value {
+			if f.is_bool() {
+				v = "true"
+			} else {
+				if i+1 >= len(args) {
+					return fmt.Errorf("flag %v%v needs a value", dashes, name)
+				}
+				i++
+				v = args[i]
+			}
+		}
+		if err := f.value.Set(v); err != nil {
+			return fmt.Errorf("invalid value %q for flag %v%v: %v", v, dashes, name, err)
+		}
+		fs.set[f.long] = true
+	}
+	return nil
+}
+
+// Environment variable for a flag: --add_symbol is WE_ADD_SYMBOL
+func env_name(long string) string {
+	return "WE_" + strings.ToUpper(long)
+}
+
+// Set the flags not given on the command line from their environment variables, looked up
+// with lookup (os.LookupEnv), so flags win over the environment and the environment over
+// defaults. The flag named except (the one turning this off) is left alone.
+func (fs *flag_set) parse_env(lookup func(string) (string, bool), except string) error {
+	for _, f := range fs.flags {
+		if fs.set[f.long] || f.long == except {
+			continue
+		}
+		name := env_name(f.long)
+		v, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := f.value.Set(v); err != nil {
+			return fmt.Errorf("invalid value %q for $%v: %v", v, name, err)
+		}
+	}
+	return nil
+}
+
+// Error for an unknown flag, suggesting the closest long name if there is a plausible one
+func (fs *flag_set) unknown(arg string) error {
+	name := strings.ReplaceAll(strings.TrimLeft(arg, "-"), "-", "_")
+	best, best_d := "", 0
+	for _, f := range fs.flags {
+		d := edit_distance(name, f.long)
+		if best == "" || d < best_d {
+			best, best_d = f.long, d
+		}
+	}
+	if best != "" && (best_d <= 2 || best_d <= len(best)/3) {
+		return fmt.Errorf("unknown flag %v (did you mean --%v?)", arg, best)
+	}
+	return fmt.Errorf("unknown flag %v", arg)
+}
+
+// Levenshtein distance between a and b
+func edit_distance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// Write the usage text, one section per group
+func (fs *flag_set) usage(w io.Writer) {
+	left := func(f *bound_flag) string {
+		s := "      --" + f.long
+		if f.short != "" {
+			s = "  -" + f.short + ", --" + f.long
+		}
+		if !f.is_bool() {
+			s += " " + f.typ
+		}
+		return s
+	}
+	width := 0
+	for _, f := range fs.flags {
+		width = max(width, len(left(f)))
+	}
+
+	fmt.Fprintf(w, "Usage: we [flags]\n\nGenerate random pseudo-grammatical passphrases.\n")
+	for _, g := range fs.groups {
+		fmt.Fprintf(w, "\n%v:\n", g.name)
+		for i := range g.flags {
+			f := fs.long[g.flags[i].long]
+			text := f.usage
+			if f.def != "" {
+				def := f.def
+				if f.typ == "string" {
+					def = strconv.Quote(def)
+				}
+				text += " (default " + def + ")"
+			}
+			fmt.Fprintf(w, "%-*v  %v\n", width, left(f), text)
+		}
+	}
+	fmt.Fprintf(w, "\n%-*v  %v\n", width, "  -h, --help", "show this help")
+	fmt.Fprintf(w, "\nFlags take one or two dashes; values follow as --flag=value or --flag value.\n")
+	fmt.Fprintf(w, "Flags not given default to environment variables named after them, e.g. WE_ADD_SYMBOL=true\nfor --add_symbol (ignored with --no_env).\n")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Replaced in tests
+var sleep = time.Sleep
+
+// Wide (two-column) ranges, from the East Asian Width W and F classes
+var wide_ranges = [][2]rune{
+	{0x1100, 0x115F}, {0x2E80, 0x303E}, {0x3041, 0x33FF}, {0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF}, {0xA000, 0xA4CF}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF},
+	{0xFE30, 0xFE4F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x1F300, 0x1F64F},
+	{0x1F900, 0x1F9FF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+// Number of terminal columns a rune occupies
+func rune_width(r rune) int {
+	if r < 0x20 || (r >= 0x7F && r < 0xA0) || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	for _, w := range wide_ranges {
+		if r >= w[0] && r <= w[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+// Columns used by each terminal row a line occupies when wrapped at width. ANSI escape
+// sequences take no space; a wide rune that does not fit at the end of a row moves to the next.
+func line_rows(line string, width int) []int {
+	rows := []int{0}
+	escape := false
+	for _, r := range line {
+		if escape {
+			escape = !(r >= '@' && r <= '~' && r != '[')
+			continue
+		}
+		if r == '\x1b' {
+			escape = true
+			continue
+		}
+		w := rune_width(r)
+		if rows[len(rows)-1]+w > width {
+			rows = append(rows, 0)
+		}
+		rows[len(rows)-1] += w
+	}
+	return rows
+}
+
+// Escape sequence that erases text (which ends in a newline) just written to a terminal of
+// the given width: move back to its first row, overwrite every row with spaces, clear it,
+// then return to the first row so whatever is written next replaces it.
+func erase_sequence(text string, width int) string {
+	if width < 1 {
+		width = 1
+	}
+	var rows []int
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		rows = append(rows, line_rows(line, width)...)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\x1b[%dF", len(rows))
+	for _, cols := range rows {
+		sb.WriteString(strings.Repeat(" ", cols))
+		sb.WriteString("\r\x1b[2K\n")
+	}
+	fmt.Fprintf(&sb, "\x1b[%dF", len(rows))
+	return sb.String()
+}
+
+// Write text to a terminal of the given width, wait, then erase it and leave a notice
+func show_and_clear(w io.Writer, text string, seconds uint, width int) {
+	io.WriteString(w, text)
+	sleep(time.Duration(seconds) * time.Second)
+	io.WriteString(w, erase_sequence(text, width))
+	fmt.Fprintf(w, "(cleared after %v seconds)\n", seconds)
+}
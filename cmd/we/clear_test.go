@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLineRows(t *testing.T) {
+	cases := []struct {
+		line  string
+		width int
+		rows  []int
+	}{
+		{"", 80, []int{0}},
+		{"otter badger", 80, []int{12}},
+		{"otter badger", 6, []int{6, 6}},
+		{"otterbadger", 5, []int{5, 5, 1}},
+		{"日本語", 80, []int{6}},
+		{"日本語", 5, []int{4, 2}},     // the third rune does not fit in the last column
+		{"a日本", 4, []int{3, 2}},     // nor does the second
+		{"cafe\u0301", 4, []int{4}}, // combining accent takes no column
+		{"\x1b[30;107m▀▄█ \x1b[0m", 80, []int{4}},
+	}
+	for _, c := range cases {
+		if rows := line_rows(c.line, c.width); !reflect.DeepEqual(rows, c.rows) {
+			t.Errorf("%q at width %v: expected rows %v, got %v", c.line, c.width, c.rows, rows)
+		}
+	}
+}
+
+func TestEraseSequence(t *testing.T) {
+	if s := erase_sequence("ab\n", 80); s != "\x1b[1F  \r\x1b[2K\n\x1b[1F" {
+		t.Errorf("Unexpected sequence %q", s)
+	}
+	expected := "\x1b[3F" +
+		"    \r\x1b[2K\n" +
+		"  \r\x1b[2K\n" +
+		"\r\x1b[2K\n" +
+		"\x1b[3F"
+	if s := erase_sequence("日本語\n\n", 5); s != expected {
+		t.Errorf("Expected %q, got %q", expected, s)
+	}
+}
+
+func TestShowAndClear(t *testing.T) {
+	var slept time.Duration
+	sleep = func(d time.Duration) { slept = d }
+	defer func() { sleep = time.Sleep }()
+
+	var buf bytes.Buffer
+	show_and_clear(&buf, "otter badger\n", 3, 80)
+	if slept != 3*time.Second {
+		t.Errorf("Expected a 3s wait, got %v", slept)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "otter badger\n"+erase_sequence("otter badger\n", 80)) || !strings.HasSuffix(out, "cleared after 3 seconds)\n") {
+		t.Errorf("Unexpected output %q", out)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-wordlist_path", "../../testdata/pos.txt", "-show_seconds", "1"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("Expected exit code 2 on a non-terminal, got %v", code)
+	}
+	if stdout.Len() != 0 || !strings.Contains(stderr.String(), "not a terminal") {
+		t.Errorf("Unexpected output: %q, %q", stdout.String(), stderr.String())
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"github.com/bkeroack/libwordentropy"
+	"github.com/bkeroack/libwordentropy/wordlist"
+	"io"
+	"text/tabwriter"
+)
+
+// Print the changes between two word lists, with every added and removed word if verbose,
+// then a table by word type. Returns the largest loss of entropy per word of any type in
+// bits (0 if none lost any) and the type that lost it.
+func print_diff(w io.Writer, d wordentropy.Diff, verbose bool) (float64, string) {
+	if verbose {
+		for _, t := range wordlist.Types() {
+			for _, word := range d.Types[t].Added {
+				fmt.Fprintf(w, "+ %v %v\n", t, word)
+			}
+			for _, word := range d.Types[t].Removed {
+				fmt.Fprintf(w, "- %v %v\n", t, word)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	loss, lost := 0.0, ""
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "TYPE\tADDED\tREMOVED\tCOUNT\tBITS/WORD\n")
+	for _, t := range wordlist.Types() {
+		td, ok := d.Types[t]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%+d\t%+.3f\n", t, len(td.Added), len(td.Removed), td.CountDelta, td.EntropyDelta)
+		if -td.EntropyDelta > loss {
+			loss, lost = -td.EntropyDelta, t
+		}
+	}
+	tw.Flush()
+	return loss, lost
+}
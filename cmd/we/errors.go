@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/bkeroack/libwordentropy"
+	"io"
+	"log"
+	"strings"
+	"unicode"
+)
+
+// Errors from checks made by the command itself rather than the library
+var (
+	errWordlist  = errors.New("wordlist error")
+	errOffensive = errors.New("offensive wordlist error")
+	errWeak      = errors.New("passphrases too weak")
+)
+
+// Codes for --format json of errors raised by the command. Errors wrapping a library
+// sentinel are reported by the sentinel's name in snake case instead, e.g.
+// ErrCountExceedsMax as "count_exceeds_max".
+var error_codes = []struct {
+	err  error
+	code string
+}{
+	{errWordlist, "wordlist"},
+	{errOffensive, "offensive_wordlist"},
+	{errWeak, "min_entropy"},
+}
+
+// Code for err in JSON error output; fallback names the step that failed for errors of
+// no known class
+func error_code(err error, fallback string) string {
+	for _, e := range error_codes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	if name := wordentropy.ErrorCode(err); name != "ErrInternal" {
+		return snake_case(strings.TrimPrefix(name, "Err"))
+	}
+	return fallback
+}
+
+// "CountExceedsMax" -> "count_exceeds_max"
+func snake_case(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Report err on stderr: as the message alone for text output, or for --format json as an
+// object with the message and its code
+func report(logger *log.Logger, stderr io.Writer, json_errors bool, err error, fallback string) {
+	if !json_errors {
+		logger.Printf("%v\n", err)
+		return
+	}
+	json.NewEncoder(stderr).Encode(wordentropy.ErrorResponse{Error: err.Error(), Code: error_code(err, fallback)})
+}
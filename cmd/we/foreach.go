@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/bkeroack/libwordentropy"
+	"io"
+	"os"
+	"strings"
+)
+
+// Identifiers for --for_each, one per line; blank lines are skipped
+func read_identifiers(path string, format string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ids := []string{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		id := strings.TrimSpace(scanner.Text())
+		if id == "" {
+			continue
+		}
+		if format == "text" && strings.Contains(id, "\t") {
+			return nil, fmt.Errorf("%v:%v: identifier contains a tab (use --format csv)", path, n)
+		}
+		ids = append(ids, id)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%v: no identifiers", path)
+	}
+	return ids, nil
+}
+
+// Generate n distinct passphrases, in batches of up to the library's maximum count. Fails if
+// MaxRetries batches in a row (default 100) add no new passphrase.
+func unique_passphrases(g *wordentropy.Generator, o wordentropy.GenerateOptions, n int) ([]string, error) {
+	retries := o.MaxRetries
+	if retries == 0 {
+		retries = 100
+	}
+	seen := make(map[string]bool, n)
+	phrases := make([]string, 0, n)
+	for stale := uint(0); len(phrases) < n; {
+		o.Count = uint(min(n-len(phrases), 99))
+		p, err := g.GeneratePassphrases(&o)
+		if err != nil {
+			return nil, err
+		}
+		added := false
+		for _, phrase := range p {
+			if !seen[phrase] {
+				seen[phrase] = true
+				phrases = append(phrases, phrase)
+				added = true
+			}
+		}
+		if added {
+			stale = 0
+		} else if stale++; stale >= retries {
+			return nil, errors.New("could not generate enough distinct passphrases: the options allow too few")
+		}
+	}
+	return phrases, nil
+}
+
+// Write one "identifier<TAB>passphrase" line per identifier, or CSV records for format "csv"
+func write_for_each(w io.Writer, format string, ids []string, phrases []string) error {
+	if format == "csv" {
+		cw := csv.NewWriter(w)
+		for i := range ids {
+			cw.Write([]string{ids[i], phrases[i]})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	for i := range ids {
+		if _, err := fmt.Fprintf(w, "%v\t%v\n", ids[i], phrases[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
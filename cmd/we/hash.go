@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/bkeroack/libwordentropy"
+	"io"
+	"sort"
+)
+
+// Built-in algorithms for --hash. Unsalted and fast, so only for phrases with plenty of
+// entropy; services storing passwords should call GenerateHashedPassphrases with a KDF.
+var hash_funcs = map[string]wordentropy.HashFunc{
+	"sha256": func(phrase string) (string, error) {
+		sum := sha256.Sum256([]byte(phrase))
+		return hex.EncodeToString(sum[:]), nil
+	},
+}
+
+// Names of the --hash algorithms, sorted
+func hash_names() []string {
+	names := make([]string, 0, len(hash_funcs))
+	for name := range hash_funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Write one "hash<TAB>passphrase" line per passphrase
+func write_hashed(w io.Writer, hashed []wordentropy.HashedPassphrase) error {
+	for _, h := range hashed {
+		if _, err := fmt.Fprintf(w, "%v\t%v\n", h.Hash, h.Plaintext); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/bkeroack/libwordentropy/wordlist"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+)
+
+// Word types with fewer words than this are reported by --lint
+const lint_min_words = 10
+
+// Problem found by --lint. Errors are lines the wordlist parser skips; warnings are words
+// that load but may make poor passphrases.
+type finding struct {
+	severity string // "error" or "warning"
+	check    string
+	detail   string
+}
+
+// Checks in summary table order
+var lint_checks = []struct {
+	check    string
+	severity string
+}{
+	{"malformed", "error"},
+	{"unknown_tag", "error"},
+	{"empty_word", "error"},
+	{"duplicate", "warning"},
+	{"punctuation", "warning"},
+	{"non_ascii", "warning"},
+	{"short_type", "warning"},
+	{"offensive", "warning"},
+}
+
+// Check a POS wordlist for common problems, also reporting words in the offensive list if
+// one is given
+func lint(path string, offensive_path string) ([]finding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p := wordlist.Parser{Path: path}
+	word_map, rep, err := p.ParsePOS(f)
+	if err != nil {
+		return nil, err
+	}
+	var offensive map[string]bool
+	if offensive_path != "" {
+		if offensive, err = read_offensive(offensive_path); err != nil {
+			return nil, err
+		}
+	}
+
+	var findings []finding
+	add := func(check string, format string, a ...interface{}) {
+		for _, c := range lint_checks {
+			if c.check == check {
+				findings = append(findings, finding{c.severity, check, fmt.Sprintf(format, a...)})
+			}
+		}
+	}
+	for _, pe := range rep.Malformed {
+		switch {
+		case errors.Is(pe, wordlist.ErrUnknownWordType):
+			// counted by tag below
+		case errors.Is(pe, wordlist.ErrEmptyWord):
+			add("empty_word", "%v", pe)
+		default:
+			add("malformed", "%v", pe)
+		}
+	}
+	tags := make([]string, 0, len(rep.UnknownTags))
+	for tag := range rep.UnknownTags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		add("unknown_tag", "%q on %v lines", tag, rep.UnknownTags[tag])
+	}
+	for _, t := range wordlist.Types() {
+		seen := make(map[string]int)
+		for _, w := range word_map[t] {
+			if seen[w]++; seen[w] == 2 {
+				add("duplicate", "%v %q", t, w)
+			}
+			if strings.IndexFunc(w, is_odd_punct) >= 0 {
+				add("punctuation", "%v %q", t, w)
+			}
+			if strings.IndexFunc(w, func(r rune) bool { return r > unicode.MaxASCII }) >= 0 {
+				add("non_ascii", "%v %q", t, w)
+			}
+			if is_listed(w, offensive) {
+				add("offensive", "%v %q", t, w)
+			}
+		}
+		if n := len(word_map[t]); n < lint_min_words {
+			add("short_type", "%v has %v words", t, n)
+		}
+	}
+	return findings, nil
+}
+
+// Punctuation other than the apostrophes and hyphens of ordinary words
+func is_odd_punct(r rune) bool {
+	return unicode.IsPunct(r) && r != '\'' && r != '-'
+}
+
+// Whether a word, or any component of a multiword entry, is in the offensive list
+func is_listed(word string, offensive map[string]bool) bool {
+	word = strings.ToLower(word)
+	if offensive[word] {
+		return true
+	}
+	for _, w := range strings.Fields(word) {
+		if offensive[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// Words of an offensive list, lowercased, without severity levels
+func read_offensive(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	offensive := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		word, _, _ := strings.Cut(scanner.Text(), "\t")
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			offensive[word] = true
+		}
+	}
+	return offensive, scanner.Err()
+}
+
+// Print each finding, then a table of the number of findings per check. Returns the number
+// of errors.
+func print_lint(w io.Writer, findings []finding) int {
+	counts := make(map[string]int)
+	errs := 0
+	for _, f := range findings {
+		fmt.Fprintf(w, "%v: %v: %v\n", f.severity, f.check, f.detail)
+		counts[f.check]++
+		if f.severity == "error" {
+			errs++
+		}
+	}
+	if len(findings) > 0 {
+		fmt.Fprintln(w)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "CHECK\tSEVERITY\tFINDINGS\n")
+	for _, c := range lint_checks {
+		fmt.Fprintf(tw, "%v\t%v\t%v\n", c.check, c.severity, counts[c.check])
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "%v errors, %v warnings\n", errs, len(findings)-errs)
+	return errs
+}
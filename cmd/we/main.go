@@ -0,0 +1,611 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bkeroack/libwordentropy"
+	_ "github.com/bkeroack/libwordentropy/embedded"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+type config struct {
+	options             wordentropy.GenerateOptions
+	wordlist_path       string
+	builtin             string
+	wordlists           map[string]string
+	profile             string
+	offensive_path      string
+	common_phrases_path string
+	verb_exceptions     []string
+	verbose             bool
+	export              string
+	convert             string
+	hint                bool
+	spellout            bool
+	qr                  bool
+	qr_only             bool
+	qr_force            bool
+	show_seconds        uint
+	strict              bool
+	reject_nonprintable bool
+	schema              bool
+	histogram           uint
+	examples            bool
+	selftest            bool
+	format              string
+	for_each            string
+	lint                string
+	hash                string
+	no_env              bool
+	diff                string
+	diff_max_loss       float64
+	min_entropy_bits    float64
+}
+
+// Command line flags, bound to c. Every exported GenerateOptions field needs a flag.
+func flag_table(c *config) []flag_group {
+	o := &c.options
+	return []flag_group{
+		{"Passphrases", []flag_def{
+			{"n", "count", &o.Count, "1", "number of passphrases to generate"}, // CLI defaults, smaller than the library's
+			{"l", "length", &o.Length, "4", "number of words per passphrase"},
+			{"", "fragment_length", &o.Fragments.TargetFragmentWords, "", "number of words per fragment after its joining word (0 = library default)"},
+			{"", "fragment_jitter", &o.Fragments.Jitter, "", "vary each fragment's length at random by up to this many words either way"},
+			{"", "join_type", &o.Fragments.JoinType, "", "word type joining fragments, or \"none\" to join them directly (empty = --joint_types)"},
+			{"", "prude", &o.Prudish, "", "filter offensive words"},
+			{"", "prude_level", &o.Prudish_level, "", "only filter offensive words of at least this severity (0 = all; implies --prude)"},
+			{"", "filter_categories", &o.FilterCategories, "", "comma-separated offensive list categories to filter, e.g. \"slurs,profanity\" (empty = all; implies --prude)"},
+			{"", "no_spaces", &o.No_spaces, "", "no spaces between words"},
+			{"", "add_number", &o.Add_digit, "", "add random digit to passphrase (password requirement workaround)"},
+			{"", "add_symbol", &o.Add_symbol, "", "add random symbol to passphrase (password requirement workaround)"},
+			{"", "max_symbol_length", &o.MaxSymbolLength, "", "maximum characters per symbol in --symbols (0 = library default)"},
+			{"", "digit_set", chars_value{&o.Digits}, "", "characters to use with --add_number, e.g. \"23456789\" (empty = 0-9)"},
+			{"", "symbols", &o.Symbols, "", "comma-separated symbols to use with --add_symbol (empty = library default)"},
+			{"", "separator", &o.Separator, "", "separator between words (empty = single space)"},
+			{"", "lower", &o.Lowercase, "", "lowercase all words"},
+			{"", "capitalize", &o.Capitalize, "", "capitalize the first letter of \"words\" or the \"sentence\""},
+			{"", "max_chars", &o.MaxChars, "", "maximum characters per passphrase (0 = unlimited)"},
+			{"", "max_bytes", &o.MaxBytes, "", "maximum UTF-8 bytes per passphrase (0 = unlimited)"},
+			{"", "max_syllables", &o.MaxSyllables, "", "maximum estimated syllables per passphrase read aloud, counting the digit and symbol (0 = unlimited)"},
+			{"", "max_retries", &o.MaxRetries, "", "regeneration attempts per passphrase for --max_chars, --max_bytes and --max_syllables (0 = library default)"},
+			{"", "timeout", &o.Timeout, "", "stop generating after this long (0 = no limit)"},
+			{"", "allow_partial", &o.AllowPartial, "", "if generation fails partway, print the passphrases generated so far before the error (exit code 1)"},
+			{"", "min_word_length", &o.MinWordLength, "", "only use words of at least this many characters"},
+			{"", "max_word_length", &o.MaxWordLength, "", "only use words of at most this many characters (0 = unlimited)"},
+			{"", "unambiguous_concat", &o.UnambiguousConcat, "", "with --no_spaces, only output passphrases that split back into words in exactly one way"},
+			{"", "agreement", &o.Agreement, "", "make verbs agree in number with the noun before them (\"otters run\", guessed from a trailing \"s\")"},
+			{"", "short_word_bias", &o.ShortWordBias, "", "prefer shorter words, from 0 (uniform) to 1 (each extra letter halves a word's chances)"},
+			{"", "no_adjacent_same_type", &o.NoAdjacentSameType, "", "never put two words of the same type next to each other"},
+			{"", "insecure_fast_random", &o.InsecureFastRandom, "", "INSECURE: use a fast non-cryptographic generator (test data only, never for credentials)"},
+			{"", "allowed_types", &o.AllowedTypes, "", "comma-separated word types to use, e.g. \"snoun,verb\" (empty = all)"},
+			{"", "start_type_weights", &o.StartTypeWeights, "", "comma-separated type=weight pairs for the word type starting each fragment, e.g. \"sarticle=4,conjunction=0\" (unlisted types weigh 1)"},
+			{"", "joint_types", &o.JointTypes, "", "comma-separated type=weight pairs for the word type joining fragments, e.g. \"conjunction=7,preposition=3\" (empty = conjunction)"},
+			{"", "no_joints", &o.NoJoints, "", "join fragments directly, without a word between them"},
+			{"", "vary_joints", &o.VaryJoints, "", "use every joining word once across the passphrases before repeating any"},
+			{"", "avoid_common_phrases", &o.AvoidCommonPhrases, "", "regenerate passphrases containing a phrase from --common_phrases_path"},
+			{"", "deny_words", &o.ExtraDenyWords, "", "comma-separated words never to use, e.g. your own name (case-insensitive)"},
+			{"", "min_entropy_bits", &c.min_entropy_bits, "", "print nothing and exit with code 3 if the options may give passphrases with fewer than this many bits of min-entropy"},
+			{"", "best_of", &o.BestOf, "", "generate this many candidates per passphrase and print the one with the most entropy (0 = 1)"},
+		}},
+		{"Output", []flag_def{
+			{"", "format", &c.format, "text", "output format: \"text\", \"json\" for passphrases and errors as JSON objects, or \"csv\" with --for_each"},
+			{"", "for_each", &c.for_each, "", "generate a distinct passphrase for each identifier (e.g. username) in this file, one per line, writing \"identifier<TAB>passphrase\" lines"},
+			{"", "timings", &o.CollectTimings, "", "log each passphrase's generation time, and the part spent waiting for randomness, to stderr"},
+			{"", "print0", &o.NulDelimiter, "", "end each passphrase with a NUL byte instead of a newline, for xargs -0"},
+			{"", "delimiter", &o.Delimiter, "", "control character to end each passphrase with, e.g. \\t for a tab (empty = newline)"},
+			{"", "trailing_delimiter", &o.TrailingDelimiter, "true", "end the last passphrase with the delimiter too (on by default here, unlike the library's TrailingDelimiter)"},
+			{"", "hash", &c.hash, "", "write \"hash<TAB>passphrase\" lines, hashing each passphrase with this algorithm (\"sha256\", hex-encoded)"},
+			{"", "hint", &c.hint, "", "print the part-of-speech skeleton under each passphrase as a memory aid"},
+			{"", "spellout", &c.spellout, "", "print each passphrase spelled out for reading aloud beneath it"},
+			{"", "qr", &c.qr, "", "print each passphrase as a QR code beneath it (terminal only)"},
+			{"", "qr_only", &c.qr_only, "", "print each passphrase as a QR code only, without the plain text"},
+			{"", "qr_force", &c.qr_force, "", "write QR codes even if stdout is not a terminal"},
+			{"", "show_seconds", &c.show_seconds, "", "print the passphrases, then erase them from the terminal after this many seconds (0 = keep)"},
+			{"", "examples", &c.examples, "", "print a passphrase in each preset style, labeled with the preset's name, and exit"},
+			{"", "length_histogram", &c.histogram, "", "print the distribution of passphrase lengths over this many sampled passphrases and exit"},
+			{"", "verbose", &c.verbose, "", "verbose output"},
+			{"", "no_env", &c.no_env, "", "ignore WE_* environment variables"},
+		}},
+		{"Wordlists", []flag_def{
+			{"", "wordlist_path", &c.wordlist_path, "", "path to POS wordlist (empty = " + default_wordlist + " from a standard location if found, else the wordlist built into the program)"},
+			{"", "builtin", &c.builtin, "", "use a wordlist built into the program instead of a file: \"full\" or \"common\" (curated common 3-8 letter words)"},
+			{"", "wordlist", &c.wordlists, "", "define a wordlist profile for --profile, e.g. \"tech=jargon.txt\"; repeatable, and a path of \"builtin:common\" names a built-in wordlist"},
+			{"", "profile", &c.profile, "", "use the --wordlist profile of this name (needed if there are several)"},
+			{"", "strict_wordlist", &c.strict, "", "fail on the first malformed wordlist line instead of skipping it"},
+			{"", "reject_nonprintable", &c.reject_nonprintable, "", "fail on wordlist words with control, zero-width or other unprintable characters instead of stripping them"},
+			{"", "offensive_path", &c.offensive_path, "", "path to offensive wordlist (required with --prude)"},
+			{"", "common_phrases_path", &c.common_phrases_path, "", "path to common phrase list, one phrase per line (required with --avoid_common_phrases)"},
+			{"", "verb_exceptions", &c.verb_exceptions, "", "comma-separated verbs whose number --agreement guesses wrong, e.g. \"bus\""},
+			{"", "export", &c.export, "", "write the usable word list to stdout in the given format (csv or json) and exit"},
+			{"", "convert", &c.convert, "", "convert the POS wordlist to the binary format at this path and exit"},
+			{"", "selftest", &c.selftest, "", "check passphrases generated with these options for common word list problems and exit"},
+			{"", "diff", &c.diff, "", "compare the POS wordlist at this path with the one in use, print the changes by word type and exit (1 if a type loses more than --diff_max_loss bits per word)"},
+			{"", "diff_max_loss", &c.diff_max_loss, "", "bits of entropy per word a word type may lose in --diff"},
+			{"", "lint", &c.lint, "", "check the POS wordlist at this path for problems, print a summary and exit (1 if any errors are found)"},
+			{"", "schema", &c.schema, "", "print the JSON Schema of the HTTP handler's responses and exit"},
+		}},
+	}
+}
+
+// Parse args, writing the usage text to stdout for --help (returning errHelp). The config
+// is returned even on error, with the flags parsed so far, so errors can be reported in
+// the requested format.
+func parse_flags(args []string, stdout io.Writer) (*config, error) {
+	c := config{}
+	fs := new_args(flag_table(&c))
+	if err := fs.parse(args); err != nil {
+		if err == errHelp {
+			fs.usage(stdout)
+		}
+		return &c, err
+	}
+	if !c.no_env {
+		if err := fs.parse_env(os.LookupEnv, "no_env"); err != nil {
+			return &c, err
+		}
+	}
+
+	if c.options.Count < 1 {
+		return &c, fmt.Errorf("invalid count: %v", c.options.Count)
+	}
+	if c.options.Count > 99 {
+		return &c, fmt.Errorf("invalid count: %v: %w", c.options.Count, wordentropy.ErrCountExceedsMax)
+	}
+	if c.options.Length < 1 {
+		return &c, fmt.Errorf("invalid length: %v", c.options.Length)
+	}
+	if c.options.Length > 99 {
+		return &c, fmt.Errorf("invalid length: %v: %w", c.options.Length, wordentropy.ErrLengthExceedsMax)
+	}
+	if err := wordentropy.CheckSymbols(c.options.Symbols, c.options.MaxSymbolLength); err != nil {
+		return &c, fmt.Errorf("invalid --symbols: %w", err)
+	}
+	switch c.format {
+	case "text", "json", "csv":
+	default:
+		return &c, fmt.Errorf("invalid --format %q: expected text, json or csv", c.format)
+	}
+	if c.format == "csv" && c.for_each == "" {
+		return &c, fmt.Errorf("--format csv needs --for_each")
+	}
+	if c.format == "json" && c.for_each != "" {
+		return &c, fmt.Errorf("--for_each cannot be combined with --format json")
+	}
+	if (c.format != "text" || c.for_each != "") && (c.hint || c.spellout || c.qr || c.qr_only || c.show_seconds > 0) {
+		return &c, fmt.Errorf("--hint, --spellout, --qr, --qr_only and --show_seconds only work with plain text output")
+	}
+	if c.options.NulDelimiter || c.options.Delimiter != 0 {
+		if c.format != "text" || c.for_each != "" || c.hint || c.spellout || c.qr || c.qr_only || c.show_seconds > 0 {
+			return &c, fmt.Errorf("--print0 and --delimiter only work with plain text passphrases")
+		}
+	}
+	if c.options.NulDelimiter {
+		if c.options.Delimiter != 0 {
+			return &c, fmt.Errorf("--print0 cannot be combined with --delimiter")
+		}
+		// A passphrase containing the delimiter would be split in two
+		if strings.Contains(c.options.Separator, "\x00") || strings.Contains(strings.Join(c.options.Symbols, ""), "\x00") || strings.Contains(strings.Join(c.options.Digits, ""), "\x00") {
+			return &c, fmt.Errorf("--print0 cannot be combined with a NUL in --separator, --symbols or --digit_set")
+		}
+	}
+	if c.hash != "" {
+		if hash_funcs[c.hash] == nil {
+			return &c, fmt.Errorf("invalid --hash %q: expected %v", c.hash, strings.Join(hash_names(), " or "))
+		}
+		if c.format != "text" || c.for_each != "" || c.options.Delimiter != 0 || c.options.NulDelimiter || c.hint || c.spellout || c.qr || c.qr_only || c.show_seconds > 0 {
+			return &c, fmt.Errorf("--hash only works with plain text passphrases")
+		}
+	}
+	if c.examples && c.format != "text" {
+		return &c, fmt.Errorf("--examples only works with plain text output")
+	}
+	if c.schema {
+		return &c, nil // no wordlist needed
+	}
+	if c.lint != "" {
+		if c.format != "text" {
+			return &c, fmt.Errorf("--lint only works with plain text output")
+		}
+		return &c, nil // checks its own wordlist
+	}
+	if len(c.wordlists) > 0 || c.profile != "" {
+		if err := c.choose_profile(); err != nil {
+			return &c, err
+		}
+	}
+	if c.builtin != "" && c.wordlist_path != "" {
+		return &c, fmt.Errorf("--builtin cannot be used with --wordlist_path")
+	}
+	if c.wordlist_path == "" && c.builtin == "" {
+		c.wordlist_path = find_default_wordlist()
+	}
+	if c.wordlist_path != "" {
+		if _, err := os.Stat(c.wordlist_path); err != nil {
+			return &c, fmt.Errorf("%w: %v", errWordlist, err)
+		}
+	} else if c.convert != "" {
+		return &c, fmt.Errorf("--convert needs --wordlist_path")
+	}
+	if c.diff != "" {
+		if c.format != "text" {
+			return &c, fmt.Errorf("--diff only works with plain text output")
+		}
+		if _, err := os.Stat(c.diff); err != nil {
+			return &c, fmt.Errorf("%w: %v", errWordlist, err)
+		}
+	}
+	if c.qr_only {
+		c.qr = true
+	}
+	if c.options.Prudish || c.options.Prudish_level > 0 || len(c.options.FilterCategories) > 0 {
+		if c.offensive_path == "" {
+			return &c, fmt.Errorf("%w: --prude needs --offensive_path", errOffensive)
+		}
+	}
+	if c.options.AvoidCommonPhrases && c.common_phrases_path == "" {
+		return &c, fmt.Errorf("%w: --avoid_common_phrases needs --common_phrases_path", errWordlist)
+	}
+	return &c, nil
+}
+
+// Set the wordlist to that of the --wordlist profile named by --profile, or of the only one
+func (c *config) choose_profile() error {
+	if c.wordlist_path != "" || c.builtin != "" {
+		return fmt.Errorf("--wordlist profiles cannot be used with --wordlist_path or --builtin")
+	}
+	names := make([]string, 0, len(c.wordlists))
+	for name := range c.wordlists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	switch {
+	case len(names) == 0:
+		return fmt.Errorf("--profile needs --wordlist name=path")
+	case c.profile == "" && len(names) > 1:
+		return fmt.Errorf("--profile needed to choose a --wordlist profile: %v", strings.Join(names, ", "))
+	case c.profile == "":
+		c.profile = names[0]
+	}
+	path, ok := c.wordlists[c.profile]
+	if !ok {
+		return fmt.Errorf("%w: %q (--wordlist profiles: %v)", wordentropy.ErrUnknownProfile, c.profile, strings.Join(names, ", "))
+	}
+	if builtin, ok := strings.CutPrefix(path, "builtin:"); ok {
+		c.builtin = builtin
+	} else {
+		c.wordlist_path = path
+	}
+	return nil
+}
+
+// Run the command with the given arguments, returning the process exit code
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	logger := log.New(stderr, "", log.LstdFlags)
+
+	c, err := parse_flags(args, stdout)
+	// Report err for the step that failed (its code in JSON output unless err has a known
+	// class) and return the exit code
+	fail := func(code int, step string, err error) int {
+		report(logger, stderr, c.format == "json", err, step)
+		return code
+	}
+	if err != nil {
+		if err == errHelp {
+			return 0
+		}
+		return fail(2, "usage", err)
+	}
+
+	if c.schema {
+		stdout.Write(wordentropy.ResponseSchema())
+		return 0
+	}
+
+	if c.lint != "" {
+		findings, err := lint(c.lint, c.offensive_path)
+		if err != nil {
+			return fail(1, "lint", fmt.Errorf("%w: %v", errWordlist, err))
+		}
+		if print_lint(stdout, findings) > 0 {
+			return 1
+		}
+		return 0
+	}
+
+	if c.qr && !c.qr_force && !is_terminal(stdout) {
+		return fail(2, "usage", errors.New("refusing to write QR codes: stdout is not a terminal (use -qr_force)"))
+	}
+
+	if c.show_seconds > 0 && !is_terminal(stdout) {
+		return fail(2, "usage", errors.New("refusing to use -show_seconds: stdout is not a terminal"))
+	}
+
+	msg := func(m string) {
+		if c.verbose {
+			logger.Print(m)
+		}
+	}
+
+	if c.convert != "" {
+		if err := convert(c.wordlist_path, c.convert); err != nil {
+			return fail(1, "convert", fmt.Errorf("error converting wordlist: %w", err))
+		}
+		return 0
+	}
+
+	msg("loading word list...\n")
+	wo := wordentropy.WordListOptions{
+		Wordlist:           c.wordlist_path,
+		Builtin:            c.builtin,
+		Strict:             c.strict,
+		RejectNonPrintable: c.reject_nonprintable,
+		VerbExceptions:     c.verb_exceptions,
+	}
+	if c.options.Prudish || c.options.Prudish_level > 0 || len(c.options.FilterCategories) > 0 {
+		wo.Offensive = c.offensive_path
+		// A missing file fails like any other load failure (exit code 1), not as a usage error
+		if _, err := os.Stat(c.offensive_path); err != nil {
+			return fail(1, "wordlist", fmt.Errorf("%w: %v", errOffensive, err))
+		}
+	}
+	if c.options.AvoidCommonPhrases {
+		wo.CommonPhrases = c.common_phrases_path
+	}
+	g := &wordentropy.Generator{}
+	if c.wordlist_path == "" {
+		err = g.LoadEmbeddedWords(&wo)
+	} else {
+		err = g.LoadWords(&wo)
+	}
+	if err != nil {
+		return fail(1, "wordlist", fmt.Errorf("error loading wordlist: %w", err))
+	}
+
+	if c.diff != "" {
+		old, err := wordentropy.LoadGenerator(&wordentropy.WordListOptions{Wordlist: c.diff, Strict: c.strict})
+		if err != nil {
+			return fail(1, "diff", fmt.Errorf("error loading wordlist to compare: %w", err))
+		}
+		loss, lost := print_diff(stdout, wordentropy.DiffWordMaps(old.GetWordMap(), g.GetWordMap()), c.verbose)
+		if loss > c.diff_max_loss {
+			return fail(1, "diff", fmt.Errorf("%v lost %.3f bits of entropy per word, more than --diff_max_loss %v", lost, loss, c.diff_max_loss))
+		}
+		return 0
+	}
+
+	if c.export != "" {
+		if err := g.ExportWordMap(stdout, c.export); err != nil {
+			return fail(1, "export", fmt.Errorf("error exporting wordlist: %w", err))
+		}
+		return 0
+	}
+
+	o := c.options
+
+	msg(fmt.Sprintf("options: %v\n", o))
+
+	if c.selftest {
+		if err := g.SelfTest(&o); err != nil {
+			return fail(1, "selftest", fmt.Errorf("self-test failed:\n%w", err))
+		}
+		fmt.Fprintf(stdout, "self-test passed\n")
+		return 0
+	}
+
+	if c.examples {
+		if err := print_examples(stdout, g, &o); err != nil {
+			return fail(1, "generate", fmt.Errorf("error generating examples: %w", err))
+		}
+		return 0
+	}
+
+	if c.min_entropy_bits > 0 {
+		bits, err := g.MinEntropy(&o)
+		if err != nil {
+			return fail(1, "entropy", fmt.Errorf("error estimating entropy: %w", err))
+		}
+		msg(fmt.Sprintf("at least %.1f bits of min-entropy per passphrase\n", bits))
+		if bits < c.min_entropy_bits {
+			return fail(3, "entropy", fmt.Errorf("%w: %.1f bits of min-entropy per passphrase, less than --min_entropy_bits %v", errWeak, bits, c.min_entropy_bits))
+		}
+	}
+
+	if c.for_each != "" {
+		ids, err := read_identifiers(c.for_each, c.format)
+		if err != nil {
+			return fail(1, "for_each", fmt.Errorf("error reading identifiers: %w", err))
+		}
+		phrases, err := unique_passphrases(g, o, len(ids))
+		if err != nil {
+			return fail(1, "generate", fmt.Errorf("error generating passphrases: %w", err))
+		}
+		if err := write_for_each(stdout, c.format, ids, phrases); err != nil {
+			return fail(1, "output", fmt.Errorf("error writing passphrases: %w", err))
+		}
+		return 0
+	}
+
+	if c.histogram > 0 {
+		if err := print_histogram(stdout, g, &o, c.histogram); err != nil {
+			return fail(1, "histogram", fmt.Errorf("error sampling passphrase lengths: %w", err))
+		}
+		return 0
+	}
+
+	if c.hash != "" {
+		hashed, err := g.GenerateHashedPassphrases(&o, hash_funcs[c.hash])
+		var partial *wordentropy.BatchError
+		if err != nil && !errors.As(err, &partial) {
+			return fail(1, "generate", fmt.Errorf("error generating passphrases: %w", err))
+		}
+		if err := write_hashed(stdout, hashed); err != nil {
+			return fail(1, "output", fmt.Errorf("error writing passphrases: %w", err))
+		}
+		if partial != nil {
+			return fail(1, "generate", fmt.Errorf("error generating passphrases: %w", err))
+		}
+		return 0
+	}
+
+	p, warnings, err := g.GeneratePassphrasesDetailed(&o)
+	for _, w := range warnings {
+		logger.Printf("WARNING: %v\n", w)
+	}
+	if o.CollectTimings {
+		for i := range p {
+			logger.Printf("passphrase %v: generated in %v, %v of it waiting for randomness\n", i+1, p[i].GenDuration, p[i].RandWait)
+		}
+	}
+	// With --allow_partial, the passphrases generated before a failure are printed first
+	var partial *wordentropy.BatchError
+	if err != nil && !errors.As(err, &partial) {
+		return fail(1, "generate", fmt.Errorf("error generating passphrases: %w", err))
+	}
+	done := func() int {
+		if partial != nil {
+			return fail(1, "generate", fmt.Errorf("error generating passphrases: %w", err))
+		}
+		return 0
+	}
+
+	if c.format == "json" {
+		json.NewEncoder(stdout).Encode(wordentropy.Response{Passphrases: phrases(p)})
+		return done()
+	}
+
+	msg("passphrases:\n")
+	out := stdout
+	var shown bytes.Buffer
+	if c.show_seconds > 0 {
+		out = &shown
+	}
+	if c.hint || c.spellout || c.qr || c.qr_only {
+		for i := range p {
+			if !c.qr_only {
+				if c.hint {
+					fmt.Fprintf(out, "%v\n", wordentropy.FormatHint(p[i]))
+				} else {
+					fmt.Fprintf(out, "%v\n", p[i].Phrase)
+				}
+				if c.spellout {
+					fmt.Fprintf(out, "%v\n", wordentropy.Spellout(p[i].Phrase))
+				}
+			}
+			if c.qr {
+				code, err := qr_for(p[i].Phrase)
+				if err != nil {
+					return fail(1, "qr", fmt.Errorf("error encoding QR code: %w", err))
+				}
+				fmt.Fprintf(out, "%v\n", code)
+			}
+		}
+	} else if err := wordentropy.WritePassphrases(out, phrases(p), &o); err != nil {
+		return fail(1, "output", fmt.Errorf("error writing passphrases: %w", err))
+	}
+	if c.show_seconds > 0 {
+		width := terminal_width(stdout.(*os.File))
+		if width == 0 {
+			width = 80
+		}
+		show_and_clear(stdout, shown.String(), c.show_seconds, width)
+	}
+	return done()
+}
+
+// Final passphrase strings of p
+func phrases(p []wordentropy.Passphrase) []string {
+	s := make([]string, len(p))
+	for i := range p {
+		s[i] = p[i].Phrase
+	}
+	return s
+}
+
+// Print the sampled distribution of passphrase lengths as a bar chart
+func print_histogram(w io.Writer, g *wordentropy.Generator, o *wordentropy.GenerateOptions, samples uint) error {
+	histogram, p50, p95, max, err := g.LengthDistribution(o, samples)
+	if err != nil {
+		return err
+	}
+	lengths := []int{}
+	peak := uint(0)
+	for n, count := range histogram {
+		lengths = append(lengths, n)
+		if count > peak {
+			peak = count
+		}
+	}
+	sort.Ints(lengths)
+	fmt.Fprintf(w, "length  samples\n")
+	for n := lengths[0]; n <= max; n++ {
+		count := histogram[n]
+		bar := strings.Repeat("#", int((count*50+peak-1)/peak))
+		fmt.Fprintf(w, "%v\n", strings.TrimRight(fmt.Sprintf("%6v  %7v  %v", n, count, bar), " "))
+	}
+	fmt.Fprintf(w, "p50 %v, p95 %v, max %v characters (%v samples)\n", p50, p95, max, samples)
+	return nil
+}
+
+// Print a passphrase in each preset style, labeled with the preset's name. The length,
+// offensive word filter and randomness source are taken from o.
+func print_examples(w io.Writer, g *wordentropy.Generator, o *wordentropy.GenerateOptions) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range wordentropy.PresetNames() {
+		p, err := wordentropy.DescribePreset(name)
+		if err != nil {
+			return err
+		}
+		po := p.Options
+		po.Count = 1
+		po.Length = o.Length
+		po.Prudish = o.Prudish
+		po.Prudish_level = o.Prudish_level
+		po.FilterCategories = o.FilterCategories
+		po.InsecureFastRandom = o.InsecureFastRandom
+		phrases, err := g.GeneratePassphrases(&po)
+		if err != nil {
+			return fmt.Errorf("%v: %w", name, err)
+		}
+		fmt.Fprintf(tw, "%v\t%v\n", name, phrases[0])
+	}
+	return tw.Flush()
+}
+
+// Whether w is a terminal (character device)
+func is_terminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Write the binary form of the POS wordlist at src to dst
+func convert(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if err := wordentropy.ConvertWordlist(in, out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,832 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/bkeroack/libwordentropy"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunPrudeOffensivePath(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+
+	cases := []struct {
+		name      string
+		prude     bool
+		offensive string
+		code      int
+	}{
+		{"not prude, file exists", false, "../../testdata/offensive.txt", 0},
+		{"not prude, file missing", false, missing, 0},
+		{"prude, file exists", true, "../../testdata/offensive.txt", 0},
+		{"prude, file missing", true, missing, 1},
+		{"prude, no path", true, "", 2},
+	}
+
+	for _, c := range cases {
+		var stdout, stderr bytes.Buffer
+		args := []string{
+			"-count", "20",
+			"-length", "12",
+			"-wordlist_path", "../../testdata/pos.txt",
+			"-offensive_path", c.offensive,
+		}
+		if c.prude {
+			args = append(args, "-prude")
+		}
+		code := run(args, &stdout, &stderr)
+		if code != c.code {
+			t.Fatalf("%v: expected exit code %v, got %v (stderr: %v)", c.name, c.code, code, stderr.String())
+		}
+		if code != 0 {
+			if !strings.Contains(stderr.String(), "offensive wordlist error") {
+				t.Errorf("%v: expected offensive wordlist error, got: %v", c.name, stderr.String())
+			}
+			continue
+		}
+		if stderr.Len() != 0 {
+			t.Errorf("%v: unexpected stderr output: %v", c.name, stderr.String())
+		}
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		if len(lines) != 20 {
+			t.Errorf("%v: expected 20 passphrases, got %v", c.name, len(lines))
+		}
+		if c.prude && strings.Contains(strings.ToLower(stdout.String()), "damn") {
+			t.Errorf("%v: offensive word in output: %v", c.name, stdout.String())
+		}
+	}
+}
+
+func TestRunExport(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{
+		"-wordlist_path", "../../testdata/pos.txt",
+		"-offensive_path", "../../testdata/offensive.txt",
+		"-prude",
+		"-export", "json",
+	}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	m := map[string][]string{}
+	if err := json.Unmarshal(stdout.Bytes(), &m); err != nil {
+		t.Fatalf("Could not decode export: %v", err)
+	}
+	if len(m["snoun"]) != 2 {
+		t.Fatalf("Expected offensive snoun to be filtered from export, got %v", m["snoun"])
+	}
+
+	stdout.Reset()
+	if code := run([]string{"-wordlist_path", "../../testdata/pos.txt", "-export", "xml"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("Expected exit code 1 for unknown format, got %v", code)
+	}
+}
+
+func TestRunConvert(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "pos.bin")
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-wordlist_path", "../../testdata/pos.txt", "-convert", out}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	g, err := wordentropy.LoadGenerator(&wordentropy.WordListOptions{Wordlist: out, Format: "binary"})
+	if err != nil {
+		t.Fatalf("Could not load converted wordlist: %v", err)
+	}
+	if _, err := g.GeneratePassphrases(nil); err != nil {
+		t.Fatalf("Error generating from converted wordlist: %v", err)
+	}
+}
+
+func TestFlagParity(t *testing.T) {
+	c := config{}
+	bound := make(map[interface{}]bool)
+	for _, g := range flag_table(&c) {
+		for _, f := range g.flags {
+			if c, ok := f.value.(chars_value); ok {
+				bound[c.p] = true
+				continue
+			}
+			bound[f.value] = true
+		}
+	}
+	deprecated := map[string]bool{"Magic_fragment_length": true} // set through its replacement
+	var check func(v reflect.Value, name string)
+	check = func(v reflect.Value, name string) {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() || field.Type.Kind() == reflect.Func || deprecated[field.Name] {
+				continue // functions such as Scorer can't be given on the command line
+			}
+			if field.Type.Kind() == reflect.Struct {
+				check(v.Field(i), name+"."+field.Name)
+			} else if !bound[v.Field(i).Addr().Interface()] {
+				t.Errorf("%v.%v has no flag", name, field.Name)
+			}
+		}
+	}
+	check(reflect.ValueOf(&c.options).Elem(), "GenerateOptions")
+}
+
+func TestFlagAliases(t *testing.T) {
+	for _, args := range [][]string{
+		{"-count", "7", "-length", "3"},
+		{"--count", "7", "--length", "3"},
+		{"--count=7", "--length=3"},
+		{"-n", "7", "-l", "3"},
+		{"-n7", "-l3"},
+		{"-n=7", "--l", "3"},
+	} {
+		c, err := parse_flags(append(args, "--wordlist_path", "../../testdata/pos.txt"), io.Discard)
+		if err != nil {
+			t.Errorf("%q: %v", args, err)
+			continue
+		}
+		if c.options.Count != 7 || c.options.Length != 3 {
+			t.Errorf("%q: expected count 7 and length 3, got %v and %v", args, c.options.Count, c.options.Length)
+		}
+	}
+
+	c, err := parse_flags([]string{"--prude", "--no_spaces=false", "-lower", "--wordlist_path", "../../testdata/pos.txt", "--offensive_path", "../../testdata/offensive.txt"}, io.Discard)
+	if err != nil || !c.options.Prudish || c.options.No_spaces || !c.options.Lowercase {
+		t.Errorf("Unexpected boolean flags: %+v, %v", c, err)
+	}
+}
+
+func TestFlagErrors(t *testing.T) {
+	cases := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--nospaces"}, "unknown flag --nospaces (did you mean --no_spaces?)"},
+		{[]string{"--no-spaces"}, "unknown flag --no-spaces (did you mean --no_spaces?)"},
+		{[]string{"-cuont", "3"}, "unknown flag -cuont (did you mean --count?)"},
+		{[]string{"--start-type-weights", "a=1"}, "did you mean --start_type_weights?"},
+		{[]string{"--xyzzy"}, "unknown flag --xyzzy"},
+		{[]string{"-x"}, "unknown flag -x"},
+		{[]string{"--count"}, "flag --count needs a value"},
+		{[]string{"-n", "many"}, `invalid value "many" for flag -n`},
+		{[]string{"--prude=maybe"}, `invalid value "maybe" for flag --prude`},
+		{[]string{"extra"}, `unexpected argument "extra"`},
+		{[]string{"--", "extra"}, `unexpected argument "extra"`},
+	}
+	for _, c := range cases {
+		_, err := parse_flags(c.args, io.Discard)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%q: expected error containing %q, got %v", c.args, c.err, err)
+		}
+	}
+	if _, err := parse_flags([]string{"--xyzzy"}, io.Discard); strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("Unexpected suggestion: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--nospaces"}, &stdout, &stderr); code != 2 || !strings.Contains(stderr.String(), "did you mean --no_spaces?") {
+		t.Errorf("Expected exit code 2 with a suggestion, got %v: %v", code, stderr.String())
+	}
+}
+
+func TestFlagEnv(t *testing.T) {
+	t.Setenv("WE_COUNT", "7")
+	t.Setenv("WE_LENGTH", "3")
+	t.Setenv("WE_ADD_SYMBOL", "true")
+	t.Setenv("WE_SYMBOLS", "!,@")
+	wordlist := []string{"--wordlist_path", "../../testdata/pos.txt"}
+
+	// Flag > environment > default
+	c, err := parse_flags(append(wordlist, "-l", "5"), io.Discard)
+	if err != nil {
+		t.Fatalf("Error parsing flags: %v", err)
+	}
+	o := c.options
+	if o.Count != 7 || o.Length != 5 || !o.Add_symbol || !reflect.DeepEqual(o.Symbols, []string{"!", "@"}) || o.No_spaces {
+		t.Errorf("Unexpected options from the environment: %+v", o)
+	}
+
+	c, err = parse_flags(append(wordlist, "-no_env"), io.Discard)
+	if err != nil || c.options.Count != 1 || c.options.Length != 4 || c.options.Add_symbol {
+		t.Errorf("Expected defaults with -no_env, got %+v, %v", c.options, err)
+	}
+
+	t.Setenv("WE_LENGTH", "three")
+	if _, err := parse_flags(wordlist, io.Discard); err == nil || !strings.Contains(err.Error(), `invalid value "three" for $WE_LENGTH`) {
+		t.Errorf("Expected an error naming $WE_LENGTH, got %v", err)
+	}
+	if _, err := parse_flags(append(wordlist, "-l", "2"), io.Discard); err != nil {
+		t.Errorf("Expected the flag to override a bad environment variable, got %v", err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run(wordlist, &stdout, &stderr); code != 2 || !strings.Contains(stderr.String(), "$WE_LENGTH") {
+		t.Errorf("Expected exit code 2 naming $WE_LENGTH, got %v: %v", code, stderr.String())
+	}
+}
+
+func TestHelp(t *testing.T) {
+	for _, arg := range []string{"-h", "--help", "-help"} {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"-n", "3", arg}, &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: expected exit code 0, got %v", arg, code)
+		}
+		help := stdout.String()
+		if stderr.Len() != 0 {
+			t.Errorf("%v: unexpected stderr output: %v", arg, stderr.String())
+		}
+		for _, s := range []string{
+			"Usage: we [flags]",
+			"\nPassphrases:\n",
+			"\nOutput:\n",
+			"\nWordlists:\n",
+			"  -n, --count uint ",
+			"  -l, --length uint ",
+			"      --prude  ",
+			"number of passphrases to generate (default 1)",
+			"path to POS wordlist (empty = part-of-speech.txt from a standard location if found, else the wordlist built into the program)",
+			"  -h, --help ",
+		} {
+			if !strings.Contains(help, s) {
+				t.Errorf("%v: expected %q in help output:\n%v", arg, s, help)
+			}
+		}
+		c := config{}
+		for _, g := range flag_table(&c) {
+			for _, f := range g.flags {
+				if !strings.Contains(help, "--"+f.long) {
+					t.Errorf("%v: --%v missing from help output", arg, f.long)
+				}
+			}
+		}
+		// Headings come in table order and every flag's usage is aligned
+		if strings.Index(help, "Passphrases:") > strings.Index(help, "Output:") {
+			t.Errorf("%v: groups out of order", arg)
+		}
+		col := -1
+		for _, line := range strings.Split(help, "\n") {
+			start := len(line) - len(strings.TrimLeft(line, " "))
+			if start == len(line) || line[start] != '-' {
+				continue
+			}
+			i := start + strings.Index(line[start:], "  ")
+			i += len(line[i:]) - len(strings.TrimLeft(line[i:], " "))
+			if col == -1 {
+				col = i
+			} else if i != col {
+				t.Errorf("%v: misaligned usage line %q", arg, line)
+			}
+		}
+	}
+}
+
+func TestOptionFlags(t *testing.T) {
+	c, err := parse_flags([]string{
+		"-wordlist_path", "../../testdata/pos.txt",
+		"-symbols", "!,@,#",
+		"-separator", "-",
+		"-capitalize", "words",
+		"-timeout", "2s",
+		"-max_word_length", "8",
+		"-digit_set", "2345",
+		"-start_type_weights", "sarticle=4,conjunction=0",
+	}, io.Discard)
+	if err != nil {
+		t.Fatalf("Error parsing flags: %v", err)
+	}
+	o := c.options
+	if !reflect.DeepEqual(o.Symbols, []string{"!", "@", "#"}) || o.Separator != "-" || o.Capitalize != "words" ||
+		o.Timeout != 2*time.Second || o.MaxWordLength != 8 || !reflect.DeepEqual(o.Digits, []string{"2", "3", "4", "5"}) || o.Count != 1 || o.Length != 4 ||
+		!reflect.DeepEqual(o.StartTypeWeights, map[string]uint{"sarticle": 4, "conjunction": 0}) {
+		t.Fatalf("Unexpected options: %+v", o)
+	}
+	if _, err := parse_flags([]string{"-start_type_weights", "sarticle"}, io.Discard); err == nil {
+		t.Errorf("Expected an error for a weight without a value")
+	}
+	_, err = parse_flags([]string{"-symbols", "!,a b", "-wordlist_path", "../../testdata/pos.txt"}, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), `Invalid symbol 1 ("a b"): contains whitespace`) {
+		t.Errorf("Expected the library's symbol error, got %v", err)
+	}
+}
+
+func TestRunHint(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-wordlist_path", "../../testdata/pos.txt", "-count", "3", "-hint", "-add_number"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("Expected a hint line under each of 3 passphrases, got %q", lines)
+	}
+	for i := 1; i < len(lines); i += 2 {
+		if !strings.HasPrefix(lines[i], "[") || !strings.HasSuffix(lines[i], "[digit]") {
+			t.Errorf("Unexpected hint line: %q", lines[i])
+		}
+	}
+}
+
+func TestRunSpellout(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-wordlist_path", "../../testdata/pos.txt", "-count", "2", "-separator", "-", "-spellout"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected a spellout line under each of 2 passphrases, got %q", lines)
+	}
+	for i := 0; i < len(lines); i += 2 {
+		if lines[i+1] != wordentropy.Spellout(lines[i]) || !strings.Contains(lines[i+1], "dash") {
+			t.Errorf("Unexpected spellout %q for %q", lines[i+1], lines[i])
+		}
+	}
+}
+
+func TestRunStrictWordlist(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"-wordlist_path", "../../testdata/corrupt.txt", "-length", "2"}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0 without -strict_wordlist, got %v (stderr: %v)", code, stderr.String())
+	}
+	stderr.Reset()
+	if code := run(append(args, "-strict_wordlist"), &stdout, &stderr); code != 1 {
+		t.Fatalf("Expected exit code 1 with -strict_wordlist, got %v", code)
+	}
+	if !strings.Contains(stderr.String(), "corrupt.txt:2:") {
+		t.Errorf("Expected the malformed line in the error, got %v", stderr.String())
+	}
+}
+
+func TestRunSchema(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-schema", "-wordlist_path", "missing.txt"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	if !bytes.Equal(stdout.Bytes(), wordentropy.ResponseSchema()) || !json.Valid(stdout.Bytes()) {
+		t.Errorf("Unexpected schema output: %v", stdout.String())
+	}
+}
+
+func TestRunLengthHistogram(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--wordlist_path", "../../testdata/pos.txt", "--length_histogram", "500", "-l", "3", "--add_number"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) < 3 || lines[0] != "length  samples" || !strings.HasPrefix(lines[len(lines)-1], "p50 ") || !strings.HasSuffix(lines[len(lines)-1], "(500 samples)") {
+		t.Fatalf("Unexpected histogram output:\n%v", stdout.String())
+	}
+	total := 0
+	for _, line := range lines[1 : len(lines)-1] {
+		var n, count int
+		if _, err := fmt.Sscan(line, &n, &count); err != nil {
+			t.Fatalf("Bad histogram line %q: %v", line, err)
+		}
+		total += count
+	}
+	if total != 500 {
+		t.Errorf("Expected 500 samples, got %v", total)
+	}
+}
+
+func TestRunSelfTest(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--wordlist_path", "../../testdata/pos.txt", "--selftest", "--add_number"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	if stdout.String() != "self-test passed\n" {
+		t.Errorf("Unexpected output %q", stdout.String())
+	}
+}
+
+func TestRunExamples(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--wordlist_path", "../../testdata/pos.txt", "--examples", "-l", "3"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("Unexpected stderr output: %v", stderr.String())
+	}
+	names := wordentropy.PresetNames()
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if len(lines) != len(names) {
+		t.Fatalf("Expected %v lines, got %q", len(names), stdout.String())
+	}
+	for i, line := range lines {
+		if fields := strings.Fields(line); len(fields) < 2 || fields[0] != names[i] {
+			t.Errorf("Expected a passphrase labeled %v, got %q", names[i], line)
+		}
+	}
+
+	if code := run([]string{"--wordlist_path", "../../testdata/pos.txt", "--examples", "--format", "json"}, &stdout, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2 for --examples with --format json, got %v", code)
+	}
+}
+
+func TestRunEmbeddedWordlist(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-count", "3"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	if lines := strings.Split(strings.TrimSpace(stdout.String()), "\n"); len(lines) != 3 {
+		t.Errorf("Expected 3 passphrases, got %v", stdout.String())
+	}
+	if code := run([]string{"-convert", filepath.Join(t.TempDir(), "out.bin")}, &stdout, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2 for --convert without --wordlist_path, got %v", code)
+	}
+}
+
+func TestRunBuiltin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--builtin", "common", "-count", "3"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	for _, w := range strings.Fields(stdout.String()) {
+		if len(w) < 3 || len(w) > 8 || strings.ToLower(w) != w {
+			t.Errorf("Unexpected word from the common wordlist: %q", w)
+		}
+	}
+	if code := run([]string{"--builtin", "common", "--wordlist_path", "../../testdata/pos.txt"}, &stdout, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2 for --builtin with --wordlist_path, got %v", code)
+	}
+	if code := run([]string{"--builtin", "tiny"}, &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for an unknown --builtin, got %v", code)
+	}
+}
+
+func TestFindDefaultWordlist(t *testing.T) {
+	exe_dir := t.TempDir()
+	config_dir := t.TempDir()
+	dirs := search_dirs(exe_dir, config_dir)
+	if p := find_file(default_wordlist, dirs); p != "" {
+		t.Fatalf("Expected no wordlist in empty directories, got %v", p)
+	}
+
+	write := func(path string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("otter\tN\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A directory of that name is not a wordlist
+	if err := os.MkdirAll(filepath.Join(exe_dir, default_wordlist), 0755); err != nil {
+		t.Fatal(err)
+	}
+	in_config := filepath.Join(config_dir, "wordentropy", default_wordlist)
+	write(in_config)
+	if p := find_file(default_wordlist, dirs); p != in_config {
+		t.Errorf("Expected %v, got %v", in_config, p)
+	}
+	in_data := filepath.Join(exe_dir, "data", default_wordlist)
+	write(in_data)
+	if p := find_file(default_wordlist, dirs); p != in_data {
+		t.Errorf("Expected the executable's data directory to come first (%v), got %v", in_data, p)
+	}
+
+	if dirs := search_dirs("", config_dir); len(dirs) != 1 || dirs[0] != filepath.Join(config_dir, "wordentropy") {
+		t.Errorf("Expected only the config directory without an executable directory, got %v", dirs)
+	}
+	if dirs := search_dirs("", ""); len(dirs) != 0 {
+		t.Errorf("Expected no directories, got %v", dirs)
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--format", "json", "--wordlist_path", "../../testdata/pos.txt", "-n", "3"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	var r wordentropy.Response
+	if err := json.Unmarshal(stdout.Bytes(), &r); err != nil || len(r.Passphrases) != 3 {
+		t.Fatalf("Expected 3 passphrases as JSON, got %q (%v)", stdout.String(), err)
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	cases := []struct {
+		args []string
+		exit int
+		code string
+	}{
+		{[]string{"--xyzzy"}, 2, "usage"},
+		{[]string{"-n", "100"}, 2, "count_exceeds_max"},
+		{[]string{"-l", "0"}, 2, "usage"},
+		{[]string{"--symbols", "a b"}, 2, "invalid_symbol"},
+		{[]string{"--hint"}, 2, "usage"},
+		{[]string{"--wordlist_path", missing}, 2, "wordlist"},
+		{[]string{"--prude", "--offensive_path", missing}, 1, "offensive_wordlist"},
+		{[]string{"--wordlist_path", "../../testdata/corrupt.txt", "--strict_wordlist"}, 1, "wordlist"},
+		{[]string{"--wordlist_path", "../../testdata/unprintable.txt", "--reject_nonprintable"}, 1, "unprintable_word"},
+		{[]string{"--capitalize", "shout"}, 1, "unknown_capitalize"},
+		{[]string{"--max_chars", "1", "--max_retries", "1"}, 1, "retries_exhausted"},
+		{[]string{"--max_bytes", "2", "--add_symbol", "--symbols", "!!"}, 1, "max_bytes_too_small"},
+	}
+	for _, c := range cases {
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"--format", "json", "--wordlist_path", "../../testdata/pos.txt"}, c.args...)
+		if code := run(args, &stdout, &stderr); code != c.exit {
+			t.Errorf("%v: expected exit code %v, got %v", c.args, c.exit, code)
+		}
+		var e map[string]string
+		if err := json.Unmarshal(stderr.Bytes(), &e); err != nil || len(e) != 2 || e["error"] == "" {
+			t.Errorf("%v: expected a JSON error object, got %q (%v)", c.args, stderr.String(), err)
+			continue
+		}
+		if e["code"] != c.code {
+			t.Errorf("%v: expected code %q, got %q (%v)", c.args, c.code, e["code"], e["error"])
+		}
+	}
+}
+
+func TestRunForEach(t *testing.T) {
+	ids := []string{"carol, jr"}
+	for i := 0; i < 40; i++ {
+		ids = append(ids, fmt.Sprintf("user%v", i))
+	}
+	path := filepath.Join(t.TempDir(), "users.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(ids, "\n")+"\n\n"), 0644); err != nil {
+		t.Fatalf("Could not write identifiers: %v", err)
+	}
+
+	for _, format := range []string{"text", "csv"} {
+		var stdout, stderr bytes.Buffer
+		args := []string{"--wordlist_path", "../../testdata/pos.txt", "--for_each", path, "--format", format, "-l", "3"}
+		if code := run(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: expected exit code 0, got %v (stderr: %v)", format, code, stderr.String())
+		}
+		r := csv.NewReader(&stdout)
+		if format == "text" {
+			r.Comma = '\t'
+		}
+		rows, err := r.ReadAll()
+		if err != nil || len(rows) != len(ids) {
+			t.Fatalf("%v: expected %v rows, got %v (%v)", format, len(ids), len(rows), err)
+		}
+		seen := make(map[string]bool)
+		for i, row := range rows {
+			if row[0] != ids[i] || len(strings.Fields(row[1])) < 3 || seen[row[1]] {
+				t.Fatalf("%v: bad or duplicate row %q", format, row)
+			}
+			seen[row[1]] = true
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := os.WriteFile(path, []byte("bad\tname\n"), 0644); err != nil {
+		t.Fatalf("Could not write identifiers: %v", err)
+	}
+	if code := run([]string{"--wordlist_path", "../../testdata/pos.txt", "--for_each", path}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "contains a tab") {
+		t.Errorf("Expected an error for an identifier with a tab, got %v: %v", code, stderr.String())
+	}
+}
+
+func TestRunLint(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"-lint", "../../testdata/messy.txt", "-offensive_path", "../../testdata/offensive.txt"}
+	if code := run(args, &stdout, &stderr); code != 1 {
+		t.Fatalf("Expected exit code 1 for a wordlist with errors, got %v (stderr: %v)", code, stderr.String())
+	}
+	for _, want := range []string{
+		"error: malformed: ../../testdata/messy.txt:8:",
+		"error: unknown_tag: \"Q\" on 2 lines",
+		"error: empty_word: ../../testdata/messy.txt:11:",
+		"warning: duplicate: snoun \"cat\"",
+		"warning: punctuation: snoun \"rock&roll\"",
+		"warning: non_ascii: snoun \"café\"",
+		"warning: offensive: adjective \"damn\"",
+		"warning: short_type: verb has 1 words",
+		"3 errors, 15 warnings",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected %q in lint output:\n%v", want, stdout.String())
+		}
+	}
+	if strings.Contains(stdout.String(), "punctuation: snoun \"cat\"") {
+		t.Errorf("Unexpected punctuation finding:\n%v", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run([]string{"-lint", "../../testdata/pos.txt"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0 for a wordlist with only warnings, got %v:\n%v", code, stdout.String())
+	}
+	if code := run([]string{"-lint", "../../testdata/pos.txt", "-format", "json"}, &stdout, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2 for --lint with --format json, got %v", code)
+	}
+}
+
+func TestRunAllowPartial(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"--wordlist_path", "../../testdata/pos.txt", "-n", "5", "--max_chars", "1", "--max_retries", "2", "--allow_partial", "--format", "json"}
+	if code := run(args, &stdout, &stderr); code != 1 {
+		t.Fatalf("Expected exit code 1, got %v (stderr: %v)", code, stderr.String())
+	}
+	var r wordentropy.Response
+	if err := json.Unmarshal(stdout.Bytes(), &r); err != nil || r.Passphrases == nil || len(r.Passphrases) != 0 {
+		t.Errorf("Expected an empty list of passphrases, got %q (%v)", stdout.String(), err)
+	}
+	if !strings.Contains(stderr.String(), `"code":"retries_exhausted"`) || !strings.Contains(stderr.String(), "passphrase 0") {
+		t.Errorf("Expected the partial error on stderr, got %v", stderr.String())
+	}
+}
+
+func TestRunMinEntropyBits(t *testing.T) {
+	// testdata/pos.txt has a keyspace of about 14 bits with 4 words, but a min-entropy of
+	// only about 8
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--wordlist_path", "../../testdata/pos.txt", "-n", "3", "--min_entropy_bits", "6"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	if lines := strings.Split(strings.TrimSpace(stdout.String()), "\n"); len(lines) != 3 {
+		t.Errorf("Expected 3 passphrases, got %q", stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"--wordlist_path", "../../testdata/pos.txt", "-n", "3", "--min_entropy_bits", "10", "--format", "json"}, &stdout, &stderr); code != 3 {
+		t.Fatalf("Expected exit code 3, got %v (stderr: %v)", code, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no output, got %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), `"code":"min_entropy"`) || !strings.Contains(stderr.String(), "--min_entropy_bits 10") {
+		t.Errorf("Unexpected error output %v", stderr.String())
+	}
+}
+
+func TestRunHash(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--wordlist_path", "../../testdata/pos.txt", "-n", "3", "--hash", "sha256"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %q", stdout.String())
+	}
+	for _, l := range lines {
+		hash, phrase, ok := strings.Cut(l, "\t")
+		if !ok || phrase == "" || hash != fmt.Sprintf("%x", sha256.Sum256([]byte(phrase))) {
+			t.Errorf("Expected \"sha256<TAB>passphrase\", got %q", l)
+		}
+	}
+
+	for _, args := range [][]string{{"--hash", "md5"}, {"--hash", "sha256", "--print0"}, {"--hash", "sha256", "--format", "json"}} {
+		stderr.Reset()
+		if code := run(append(args, "--wordlist_path", "../../testdata/pos.txt"), &stdout, &stderr); code != 2 {
+			t.Errorf("%q: expected exit code 2, got %v", args, code)
+		}
+	}
+}
+
+func TestRunPrint0(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"-wordlist_path", "../../testdata/pos.txt", "-n", "5", "-print0"}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	if !bytes.HasSuffix(stdout.Bytes(), []byte{0}) || bytes.Contains(stdout.Bytes(), []byte("\n")) {
+		t.Fatalf("Expected NUL-terminated passphrases without newlines, got %q", stdout.String())
+	}
+	records := bytes.Split(bytes.TrimSuffix(stdout.Bytes(), []byte{0}), []byte{0})
+	if len(records) != 5 {
+		t.Fatalf("Expected 5 passphrases, got %q", records)
+	}
+	for _, r := range records {
+		if len(strings.Fields(string(r))) != 4 {
+			t.Errorf("Expected a 4-word passphrase, got %q", r)
+		}
+	}
+
+	for _, bad := range [][]string{
+		{"-separator", "\x00"},
+		{"-format", "json"},
+		{"-hint"},
+		{"-delimiter", "\t"},
+	} {
+		if code := run(append(args, bad...), &stdout, &stderr); code != 2 {
+			t.Errorf("Expected exit code 2 for -print0 with %q, got %v", bad, code)
+		}
+	}
+	if !strings.Contains(stderr.String(), "--print0 cannot be combined with a NUL") {
+		t.Errorf("Expected the separator conflict in the errors, got %v", stderr.String())
+	}
+}
+
+func TestRunDelimiter(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"-wordlist_path", "../../testdata/pos.txt", "-n", "3", "-delimiter", "\t"}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	if s := stdout.String(); strings.Count(s, "\t") != 3 || !strings.HasSuffix(s, "\t") {
+		t.Errorf("Expected 3 tab-terminated passphrases, got %q", s)
+	}
+	stdout.Reset()
+	if code := run(append(args, "-trailing_delimiter=false"), &stdout, &stderr); code != 0 || strings.Count(stdout.String(), "\t") != 2 {
+		t.Errorf("Expected 3 tab-separated passphrases, got %v: %q", code, stdout.String())
+	}
+	stdout.Reset()
+	escaped := []string{"-wordlist_path", "../../testdata/pos.txt", "-n", "3", "-delimiter", `\t`}
+	if code := run(escaped, &stdout, &stderr); code != 0 || strings.Count(stdout.String(), "\t") != 3 {
+		t.Errorf("Expected 3 tab-terminated passphrases with an escaped tab, got %v: %q", code, stdout.String())
+	}
+	escaped[len(escaped)-1] = "\t\t"
+	if code := run(escaped, &stdout, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2 for two delimiter characters, got %v", code)
+	}
+	if code := run(append(args, "-delimiter", ","), &stdout, &stderr); code != 1 {
+		t.Errorf("Expected exit code 1 for a printable delimiter, got %v", code)
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"-diff", "../../testdata/diff_old.txt", "-wordlist_path", "../../testdata/diff_new.txt"}
+	if code := run(append(args, "-verbose"), &stdout, &stderr); code != 1 {
+		t.Fatalf("Expected exit code 1 for fewer verbs, got %v (stderr: %v)", code, stderr.String())
+	}
+	for _, want := range []string{"+ snoun heron\n", "+ snoun owl\n", "- snoun fox\n", "- verb sings\n", "+ adjective brave\n"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("Expected %q in diff output:\n%v", want, stdout.String())
+		}
+	}
+	verb := false
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		verb = verb || strings.Join(strings.Fields(line), " ") == "verb 0 1 -1 -1.000"
+	}
+	if !verb {
+		t.Errorf("Expected a verb row with one word removed:\n%v", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "verb lost 1.000 bits of entropy per word") {
+		t.Errorf("Expected the verb loss in the error, got %v", stderr.String())
+	}
+
+	stdout.Reset()
+	if code := run(append(args, "-diff_max_loss", "1"), &stdout, &stderr); code != 0 {
+		t.Errorf("Expected exit code 0 within --diff_max_loss, got %v", code)
+	}
+	if strings.Contains(stdout.String(), "+ snoun") {
+		t.Errorf("Unexpected word list without -verbose:\n%v", stdout.String())
+	}
+}
+
+func TestRunProfile(t *testing.T) {
+	cases := []struct {
+		args     []string
+		wordlist string
+		builtin  string
+		err      string
+	}{
+		{[]string{"--wordlist", "test=../../testdata/pos.txt"}, "../../testdata/pos.txt", "", ""},
+		{[]string{"--wordlist", "test=x.txt", "--wordlist", "common=builtin:common", "--profile", "common"}, "", "common", ""},
+		{[]string{"--wordlist", "a=x.txt", "--wordlist", "b=y.txt"}, "", "", "--profile needed to choose a --wordlist profile: a, b"},
+		{[]string{"--wordlist", "a=x.txt", "--profile", "c"}, "", "", `unknown profile: "c"`},
+		{[]string{"--profile", "a"}, "", "", "--profile needs --wordlist"},
+		{[]string{"--wordlist", "a=x.txt", "--wordlist_path", "y.txt"}, "", "", "cannot be used with --wordlist_path"},
+		{[]string{"--wordlist", "a=x.txt", "--wordlist", "a=y.txt"}, "", "", `"a" given twice`},
+		{[]string{"--wordlist", "x.txt"}, "", "", `expected name=path, got "x.txt"`},
+		{[]string{"--wordlist", "=x.txt"}, "", "", "expected name=path"},
+	}
+	for _, c := range cases {
+		conf, err := parse_flags(c.args, io.Discard)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("%q: expected error containing %q, got %v", c.args, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", c.args, err)
+		} else if conf.wordlist_path != c.wordlist || conf.builtin != c.builtin {
+			t.Errorf("%q: expected wordlist %q, builtin %q, got %q, %q", c.args, c.wordlist, c.builtin, conf.wordlist_path, conf.builtin)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"--wordlist", "test=../../testdata/pos.txt", "--wordlist", "common=builtin:common", "--profile", "test", "-count", "3"}
+	if code := run(args, &stdout, &stderr); code != 0 || strings.Count(stdout.String(), "\n") != 3 {
+		t.Fatalf("Expected exit code 0 and 3 passphrases, got %v: %v%v", code, stdout.String(), stderr.String())
+	}
+	stderr.Reset()
+	if code := run([]string{"--wordlist", "test=../../testdata/pos.txt", "--profile", "other"}, &stdout, &stderr); code != 2 || !strings.Contains(stderr.String(), "unknown profile") {
+		t.Errorf("Expected exit code 2 for an unknown profile, got %v: %v", code, stderr.String())
+	}
+}
+
+func TestRunTimings(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--wordlist_path", "../../testdata/pos.txt", "-count", "2", "--timings"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	for _, expected := range []string{"passphrase 1: generated in ", "passphrase 2: generated in "} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("Expected %q in stderr:\n%v", expected, stderr.String())
+		}
+	}
+	if strings.Contains(stdout.String(), "generated in") {
+		t.Errorf("Expected timings on stderr only, got %v", stdout.String())
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Wordlist looked for in the standard locations when --wordlist_path is not given
+const default_wordlist = "part-of-speech.txt"
+
+// Standard locations of default_wordlist, in search order: next to the executable, in a
+// data directory next to it, and in the wordentropy directory of the user's configuration
+// directory (%APPDATA%\wordentropy on Windows, ~/.config/wordentropy on Linux). Empty base
+// directories are skipped.
+func search_dirs(exe_dir string, config_dir string) []string {
+	var dirs []string
+	if exe_dir != "" {
+		dirs = append(dirs, exe_dir, filepath.Join(exe_dir, "data"))
+	}
+	if config_dir != "" {
+		dirs = append(dirs, filepath.Join(config_dir, "wordentropy"))
+	}
+	return dirs
+}
+
+// Path of the first regular file called name in dirs, or "" if there is none
+func find_file(name string, dirs []string) string {
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		if fi, err := os.Stat(path); err == nil && fi.Mode().IsRegular() {
+			return path
+		}
+	}
+	return ""
+}
+
+// default_wordlist in the standard locations of this executable, or "" to use the built-in
+// wordlist. Locations that cannot be determined are skipped.
+func find_default_wordlist() string {
+	var exe_dir string
+	if exe, err := os.Executable(); err == nil {
+		if exe, err = filepath.EvalSymlinks(exe); err == nil {
+			exe_dir = filepath.Dir(exe)
+		}
+	}
+	config_dir, _ := os.UserConfigDir()
+	return find_file(default_wordlist, search_dirs(exe_dir, config_dir))
+}
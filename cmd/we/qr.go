@@ -0,0 +1,607 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Minimal QR code encoder: byte mode, error correction level M, versions 1-40.
+
+var errQRTooLong = errors.New("text too long for a QR code")
+
+// Error correction codewords per block and number of blocks for level M, by version
+var qr_ecc_per_block = [41]int{-1,
+	10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26,
+	26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28}
+var qr_ecc_blocks = [41]int{-1,
+	1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16,
+	17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49}
+
+const (
+	qr_format_level_m = 0 // format bits for error correction level M
+	qr_quiet_zone     = 4
+)
+
+type qr_code struct {
+	version  int
+	size     int
+	mask     int
+	modules  [][]bool // [y][x], true = dark
+	function [][]bool // [y][x], true for finder, timing, alignment, format and version modules
+}
+
+// Encode text as a QR code, using the smallest version that fits
+func qr_encode(text string) (*qr_code, error) {
+	data := []byte(text)
+	version := 0
+	for v := 1; v <= 40; v++ {
+		if qr_data_bits(v, len(data)) <= qr_data_codewords(v)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("%w: %v bytes", errQRTooLong, len(data))
+	}
+
+	codewords := qr_add_ecc(version, qr_data_codewords_for(version, data))
+	best := (*qr_code)(nil)
+	best_penalty := 0
+	for mask := 0; mask < 8; mask++ {
+		q := new_qr_code(version)
+		q.draw_function_patterns()
+		q.draw_codewords(codewords)
+		q.apply_mask(mask)
+		q.draw_format_bits(mask)
+		if p := q.penalty(); best == nil || p < best_penalty {
+			best, best_penalty = q, p
+		}
+	}
+	return best, nil
+}
+
+// Bits needed to encode n bytes in byte mode
+func qr_data_bits(version int, n int) int {
+	return 4 + qr_count_bits(version) + 8*n
+}
+
+func qr_count_bits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// Number of modules available for data and error correction
+func qr_raw_data_modules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		result -= (25*align-10)*align - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+func qr_data_codewords(version int) int {
+	return qr_raw_data_modules(version)/8 - qr_ecc_per_block[version]*qr_ecc_blocks[version]
+}
+
+// Data codewords: mode, count, bytes, terminator and padding
+func qr_data_codewords_for(version int, data []byte) []byte {
+	bits := []bool{}
+	put := func(v int, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, (v>>i)&1 == 1)
+		}
+	}
+	put(0x4, 4) // byte mode
+	put(len(data), qr_count_bits(version))
+	for _, b := range data {
+		put(int(b), 8)
+	}
+	capacity := qr_data_codewords(version) * 8
+	for i := 0; i < 4 && len(bits) < capacity; i++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+	out := make([]byte, 0, capacity/8)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << (7 - j)
+			}
+		}
+		out = append(out, b)
+	}
+	for pad := byte(0xEC); len(out) < capacity/8; pad ^= 0xEC ^ 0x11 {
+		out = append(out, pad)
+	}
+	return out
+}
+
+// Lengths of the data part of each block
+func qr_block_lengths(version int) []int {
+	blocks := qr_ecc_blocks[version]
+	raw := qr_raw_data_modules(version) / 8
+	short := blocks - raw%blocks
+	lengths := make([]int, blocks)
+	for i := range lengths {
+		lengths[i] = raw/blocks - qr_ecc_per_block[version]
+		if i >= short {
+			lengths[i]++
+		}
+	}
+	return lengths
+}
+
+// Split data into blocks, append error correction to each and interleave
+func qr_add_ecc(version int, data []byte) []byte {
+	ecc_len := qr_ecc_per_block[version]
+	divisor := rs_divisor(ecc_len)
+	var blocks, eccs [][]byte
+	for _, n := range qr_block_lengths(version) {
+		blocks = append(blocks, data[:n])
+		eccs = append(eccs, rs_remainder(data[:n], divisor))
+		data = data[n:]
+	}
+	out := []byte{}
+	for i := 0; i <= len(blocks[len(blocks)-1]); i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+			}
+		}
+	}
+	for i := 0; i < ecc_len; i++ {
+		for _, e := range eccs {
+			out = append(out, e[i])
+		}
+	}
+	return out
+}
+
+func new_qr_code(version int) *qr_code {
+	size := version*4 + 17
+	q := &qr_code{version: version, size: size}
+	q.modules = make([][]bool, size)
+	q.function = make([][]bool, size)
+	for y := range q.modules {
+		q.modules[y] = make([]bool, size)
+		q.function[y] = make([]bool, size)
+	}
+	return q
+}
+
+func (q *qr_code) set_function(x int, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+func (q *qr_code) draw_function_patterns() {
+	for i := 0; i < q.size; i++ {
+		q.set_function(6, i, i%2 == 0)
+		q.set_function(i, 6, i%2 == 0)
+	}
+	q.draw_finder(3, 3)
+	q.draw_finder(q.size-4, 3)
+	q.draw_finder(3, q.size-4)
+
+	align := qr_alignment_positions(q.version)
+	for i, x := range align {
+		for j, y := range align {
+			last := len(align) - 1
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue // finder corners
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set_function(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	q.draw_format_bits(0) // reserve the format areas; redrawn after masking
+	if q.version >= 7 {
+		rem := q.version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := q.version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>i)&1 == 1
+			a, b := q.size-11+i%3, i/3
+			q.set_function(a, b, dark)
+			q.set_function(b, a, dark)
+		}
+	}
+}
+
+func (q *qr_code) draw_finder(cx int, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x >= 0 && x < q.size && y >= 0 && y < q.size {
+				d := max(abs(dx), abs(dy))
+				q.set_function(x, y, d != 2 && d != 4)
+			}
+		}
+	}
+}
+
+func qr_alignment_positions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := 26
+	if version != 32 {
+		step = (version*4 + n*2 + 1) / (n*2 - 2) * 2
+	}
+	positions := make([]int, n)
+	positions[0] = 6
+	for i, pos := n-1, version*4+10; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// 15-bit format information for level M and a mask
+func qr_format_bits(mask int) int {
+	data := qr_format_level_m<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+func (q *qr_code) draw_format_bits(mask int) {
+	bits := qr_format_bits(mask)
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+	for i := 0; i <= 5; i++ {
+		q.set_function(8, i, bit(i))
+	}
+	q.set_function(8, 7, bit(6))
+	q.set_function(8, 8, bit(7))
+	q.set_function(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set_function(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		q.set_function(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set_function(8, q.size-15+i, bit(i))
+	}
+	q.set_function(8, q.size-8, true) // dark module
+}
+
+// Visit data modules in placement order: two-column strips from the right, zigzagging
+func (q *qr_code) data_modules(fn func(x int, y int)) {
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert
+				}
+				if !q.function[y][x] {
+					fn(x, y)
+				}
+			}
+		}
+	}
+}
+
+func (q *qr_code) draw_codewords(codewords []byte) {
+	i := 0
+	q.data_modules(func(x int, y int) {
+		if i < len(codewords)*8 {
+			q.modules[y][x] = (codewords[i>>3]>>(7-i&7))&1 == 1
+			i++
+		}
+	})
+}
+
+func qr_mask(mask int, x int, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// Apply a data mask (applying it twice undoes it)
+func (q *qr_code) apply_mask(mask int) {
+	q.mask = mask
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if !q.function[y][x] && qr_mask(mask, x, y) {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// Penalty score used to choose the mask: long runs, 2x2 blocks, finder-like patterns and
+// dark/light imbalance
+func (q *qr_code) penalty() int {
+	p := 0
+	finder := []bool{true, false, true, true, true, false, true, false, false, false, false}
+	line := func(get func(i int) bool) {
+		run := 1
+		for i := 1; i <= q.size; i++ {
+			if i < q.size && get(i) == get(i-1) {
+				run++
+				continue
+			}
+			if run >= 5 {
+				p += run - 2
+			}
+			run = 1
+		}
+		for i := 0; i+len(finder) <= q.size; i++ {
+			forward, backward := true, true
+			for j, f := range finder {
+				forward = forward && get(i+j) == f
+				backward = backward && get(i+len(finder)-1-j) == f
+			}
+			if forward {
+				p += 40
+			}
+			if backward {
+				p += 40
+			}
+		}
+	}
+	dark := 0
+	for k := 0; k < q.size; k++ {
+		line(func(i int) bool { return q.modules[k][i] })
+		line(func(i int) bool { return q.modules[i][k] })
+		for i := 0; i < q.size; i++ {
+			if q.modules[k][i] {
+				dark++
+			}
+			if k+1 < q.size && i+1 < q.size {
+				c := q.modules[k][i]
+				if q.modules[k][i+1] == c && q.modules[k+1][i] == c && q.modules[k+1][i+1] == c {
+					p += 3
+				}
+			}
+		}
+	}
+	percent := dark * 100 / (q.size * q.size)
+	p += abs(percent-50) / 5 * 10
+	return p
+}
+
+// Read the text back from the matrix, checking the error correction codewords of every
+// block. Used to verify an encoded code before showing it.
+func (q *qr_code) decode() (string, error) {
+	bits := 0
+	get := func(x int, y int) int {
+		if q.modules[y][x] {
+			return 1
+		}
+		return 0
+	}
+	for i := 0; i <= 5; i++ {
+		bits |= get(8, i) << i
+	}
+	bits |= get(8, 7)<<6 | get(8, 8)<<7 | get(7, 8)<<8
+	for i := 9; i < 15; i++ {
+		bits |= get(14-i, 8) << i
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if qr_format_bits(m) == bits {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		return "", fmt.Errorf("unrecognized format bits %015b", bits)
+	}
+
+	r := new_qr_code(q.version)
+	r.draw_function_patterns()
+	for y := range r.modules {
+		for x := range r.modules[y] {
+			if !r.function[y][x] {
+				r.modules[y][x] = q.modules[y][x]
+			}
+		}
+	}
+	r.apply_mask(mask)
+	raw := make([]byte, qr_raw_data_modules(q.version)/8)
+	i := 0
+	r.data_modules(func(x int, y int) {
+		if i < len(raw)*8 {
+			if r.modules[y][x] {
+				raw[i>>3] |= 1 << (7 - i&7)
+			}
+			i++
+		}
+	})
+
+	// Deinterleave and verify each block
+	lengths := qr_block_lengths(q.version)
+	ecc_len := qr_ecc_per_block[q.version]
+	blocks := make([][]byte, len(lengths))
+	pos := 0
+	for k := 0; k <= lengths[len(lengths)-1]; k++ {
+		for b, n := range lengths {
+			if k < n {
+				blocks[b] = append(blocks[b], raw[pos])
+				pos++
+			}
+		}
+	}
+	for k := 0; k < ecc_len; k++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], raw[pos])
+			pos++
+		}
+	}
+	data := []byte{}
+	for b, block := range blocks {
+		if !rs_check(block, ecc_len) {
+			return "", fmt.Errorf("error correction check failed in block %v", b)
+		}
+		data = append(data, block[:lengths[b]]...)
+	}
+
+	// Byte mode segment
+	bit := 0
+	read := func(n int) int {
+		v := 0
+		for j := 0; j < n; j++ {
+			v = v<<1 | int(data[bit>>3]>>(7-bit&7)&1)
+			bit++
+		}
+		return v
+	}
+	if m := read(4); m != 0x4 {
+		return "", fmt.Errorf("unsupported mode %v", m)
+	}
+	n := read(qr_count_bits(q.version))
+	if qr_data_bits(q.version, n) > len(data)*8 {
+		return "", fmt.Errorf("byte count %v exceeds data", n)
+	}
+	out := make([]byte, n)
+	for j := range out {
+		out[j] = byte(read(8))
+	}
+	return string(out), nil
+}
+
+// Render the code with Unicode half blocks, two module rows per line, dark on light
+func (q *qr_code) render() string {
+	dark := func(x int, y int) bool {
+		return x >= 0 && y >= 0 && x < q.size && y < q.size && q.modules[y][x]
+	}
+	var sb strings.Builder
+	for y := -qr_quiet_zone; y < q.size+qr_quiet_zone; y += 2 {
+		sb.WriteString("\x1b[30;107m")
+		for x := -qr_quiet_zone; x < q.size+qr_quiet_zone; x++ {
+			top, bottom := dark(x, y), dark(x, y+1)
+			switch {
+			case top && bottom:
+				sb.WriteRune('█')
+			case top:
+				sb.WriteRune('▀')
+			case bottom:
+				sb.WriteRune('▄')
+			default:
+				sb.WriteRune(' ')
+			}
+		}
+		sb.WriteString("\x1b[0m\n")
+	}
+	return sb.String()
+}
+
+// Reed-Solomon over GF(256) with the QR polynomial x^8 + x^4 + x^3 + x^2 + 1
+
+func gf_mul(x byte, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// Generator polynomial of the given degree, highest coefficient first (leading 1 omitted)
+func rs_divisor(degree int) []byte {
+	divisor := make([]byte, degree)
+	divisor[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range divisor {
+			divisor[j] = gf_mul(divisor[j], root)
+			if j+1 < degree {
+				divisor[j] ^= divisor[j+1]
+			}
+		}
+		root = gf_mul(root, 0x02)
+	}
+	return divisor
+}
+
+func rs_remainder(data []byte, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gf_mul(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// Check that a block (data followed by ecc_len error correction codewords) has all-zero
+// syndromes
+func rs_check(block []byte, ecc_len int) bool {
+	root := byte(1)
+	for i := 0; i < ecc_len; i++ {
+		s := byte(0)
+		for _, b := range block {
+			s = gf_mul(s, root) ^ b
+		}
+		if s != 0 {
+			return false
+		}
+		root = gf_mul(root, 0x02)
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Rendered QR code for a phrase, verified by decoding it back first
+func qr_for(phrase string) (string, error) {
+	q, err := qr_encode(phrase)
+	if err != nil {
+		return "", err
+	}
+	text, err := q.decode()
+	if err != nil {
+		return "", err
+	}
+	if text != phrase {
+		return "", fmt.Errorf("QR code decodes to %q", text)
+	}
+	return q.render(), nil
+}
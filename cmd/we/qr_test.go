@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQRReedSolomon(t *testing.T) {
+	// Version 1-M example for "01234567" from the QR specification
+	data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	expected := []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+	if ecc := rs_remainder(data, rs_divisor(10)); !bytes.Equal(ecc, expected) {
+		t.Fatalf("Expected ECC % X, got % X", expected, ecc)
+	}
+	if !rs_check(append(data, expected...), 10) {
+		t.Fatalf("Expected valid block to pass the check")
+	}
+	data[3] ^= 0x01
+	if rs_check(append(data, expected...), 10) {
+		t.Fatalf("Expected corrupted block to fail the check")
+	}
+
+	if f := qr_format_bits(0); fmt.Sprintf("%015b", f) != "101010000010010" {
+		t.Errorf("Unexpected format bits for M, mask 0: %015b", f)
+	}
+}
+
+func TestQRRoundTrip(t *testing.T) {
+	versions := map[int]bool{}
+	for _, n := range []int{0, 1, 14, 15, 40, 100, 180, 300, 700, 2331} {
+		text := strings.Repeat("otter-9!", n/8+1)[:n]
+		q, err := qr_encode(text)
+		if err != nil {
+			t.Fatalf("%v bytes: %v", n, err)
+		}
+		versions[q.version] = true
+		if q.size != q.version*4+17 {
+			t.Errorf("%v bytes: unexpected size %v for version %v", n, q.size, q.version)
+		}
+		decoded, err := q.decode()
+		if err != nil {
+			t.Fatalf("%v bytes (version %v, mask %v): %v", n, q.version, q.mask, err)
+		}
+		if decoded != text {
+			t.Fatalf("%v bytes: decoded %q", n, decoded)
+		}
+	}
+	if !versions[1] || !versions[40] {
+		t.Errorf("Expected versions 1 and 40 to be covered, got %v", versions)
+	}
+
+	if _, err := qr_encode(strings.Repeat("x", 2332)); err == nil {
+		t.Errorf("Expected error for text beyond version 40 capacity")
+	}
+
+	q, _ := qr_encode("correct horse battery staple")
+	q.data_modules(func(x int, y int) {
+		if x == q.size-1 && y == q.size-1 {
+			q.modules[y][x] = !q.modules[y][x]
+		}
+	})
+	if _, err := q.decode(); err == nil {
+		t.Errorf("Expected a flipped data module to fail verification")
+	}
+}
+
+// Parse a rendered code back into a matrix
+func parse_rendered(t *testing.T, s string) *qr_code {
+	var rows [][]bool
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		line = strings.TrimSuffix(strings.TrimPrefix(line, "\x1b[30;107m"), "\x1b[0m")
+		var top, bottom []bool
+		for _, r := range line {
+			top = append(top, r == '█' || r == '▀')
+			bottom = append(bottom, r == '█' || r == '▄')
+		}
+		rows = append(rows, top, bottom)
+	}
+	size := len(rows[0]) - 2*qr_quiet_zone
+	q := new_qr_code((size - 17) / 4)
+	for y := range q.modules {
+		copy(q.modules[y], rows[y+qr_quiet_zone][qr_quiet_zone:])
+	}
+	return q
+}
+
+// Split CLI output into plain text lines and the decoded text of each QR code
+func split_qr_output(t *testing.T, out string) (lines []string, decoded []string) {
+	block := ""
+	flush := func() {
+		if block == "" {
+			return
+		}
+		d, err := parse_rendered(t, block).decode()
+		if err != nil {
+			t.Fatalf("Could not decode rendered QR code: %v", err)
+		}
+		decoded = append(decoded, d)
+		block = ""
+	}
+	for _, line := range strings.SplitAfter(out, "\n") {
+		if strings.HasPrefix(line, "\x1b[") {
+			block += line
+			continue
+		}
+		flush()
+		if line = strings.TrimSuffix(line, "\n"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	flush()
+	return lines, decoded
+}
+
+func TestRunQR(t *testing.T) {
+	args := []string{"-wordlist_path", "../../testdata/pos.txt", "-count", "2", "-length", "6"}
+	var stdout, stderr bytes.Buffer
+	if code := run(append(args, "-qr"), &stdout, &stderr); code != 2 {
+		t.Fatalf("Expected exit code 2 for -qr on a non-terminal, got %v", code)
+	}
+	if stdout.Len() != 0 || !strings.Contains(stderr.String(), "not a terminal") {
+		t.Fatalf("Unexpected output: %q, %q", stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	if code := run(append(args, "-qr", "-qr_force"), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	lines, decoded := split_qr_output(t, stdout.String())
+	if len(lines) != 2 || !reflect.DeepEqual(lines, decoded) {
+		t.Errorf("QR codes decode to %q, expected %q", decoded, lines)
+	}
+
+	stdout.Reset()
+	if code := run(append(args, "-qr_only", "-qr_force"), &stdout, &stderr); code != 0 {
+		t.Fatalf("Expected exit code 0, got %v (stderr: %v)", code, stderr.String())
+	}
+	lines, decoded = split_qr_output(t, stdout.String())
+	if len(lines) != 0 || len(decoded) != 2 {
+		t.Errorf("Expected 2 QR codes and no plain text with -qr_only, got %q and %q", lines, decoded)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package main
+
+import "os"
+
+// Width of the terminal f is attached to, in columns (0 if unknown)
+func terminal_width(f *os.File) int {
+	return 0
+}
@@ -0,0 +1,25 @@
+//go:build js && wasm
+
+// Command wewasm is the WebAssembly module for browsers. It loads the embedded wordlist,
+// registers wordentropy.generate(optionsJSON) (see package wasmapi) and keeps running:
+//
+//	GOOS=js GOARCH=wasm go build -o wordentropy.wasm ./cmd/wewasm
+package main
+
+import (
+	"fmt"
+	"github.com/bkeroack/libwordentropy"
+	_ "github.com/bkeroack/libwordentropy/embedded"
+	"github.com/bkeroack/libwordentropy/wasmapi"
+	"os"
+)
+
+func main() {
+	g := &wordentropy.Generator{}
+	if err := g.LoadEmbeddedWords(nil); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading the embedded wordlist: %v\n", err)
+		os.Exit(1)
+	}
+	wasmapi.Register(g)
+	select {}
+}
@@ -0,0 +1,46 @@
+package wordentropy
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"strings"
+	"unicode"
+)
+
+// Whether a and b are the same passphrase, compared in constant time so that the time taken
+// does not reveal how much of a stored phrase a guess got right. The time taken depends only
+// on the length of the longer phrase.
+//
+// With normalize, both phrases are first normalized as follows, and the results compared:
+//   - every run of separators (Unicode white space, '-' and '_') becomes a single space;
+//   - separators at the start and end are removed;
+//   - letters are lowercased (Unicode simple case mapping, as strings.ToLower).
+//
+// So "  Correct-Horse__battery STAPLE " equals "correct horse battery staple", but
+// "correcthorse" does not equal "correct horse", and digits, symbols and punctuation other
+// than the separators above must match exactly. Normalization itself is not constant time.
+// Without normalize, the phrases must be byte-for-byte equal.
+func EqualPhrases(a string, b string, normalize bool) bool {
+	if normalize {
+		a, b = normalize_phrase(a), normalize_phrase(b)
+	}
+	n := max(len(a), len(b))
+	return subtle.ConstantTimeCompare(padded(a, n), padded(b, n)) == 1
+}
+
+// Phrase in the form EqualPhrases compares with normalize
+func normalize_phrase(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return unicode.IsSpace(r) || r == '-' || r == '_'
+	}), " ")
+}
+
+// s after its length, zero-padded to n bytes, so that phrases of different lengths are
+// compared over buffers of the same length (subtle.ConstantTimeCompare returns at once on a
+// length mismatch) and still differ
+func padded(s string, n int) []byte {
+	buf := make([]byte, 8+n)
+	binary.BigEndian.PutUint64(buf, uint64(len(s)))
+	copy(buf[8:], s)
+	return buf
+}
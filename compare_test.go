@@ -0,0 +1,45 @@
+package wordentropy
+
+import (
+	"testing"
+)
+
+func TestEqualPhrases(t *testing.T) {
+	cases := []struct {
+		a, b      string
+		normalize bool
+		equal     bool
+	}{
+		{"correct horse battery staple", "correct horse battery staple", false, true},
+		{"correct horse battery staple", "Correct horse battery staple", false, false},
+		{"correct horse battery staple", "correct horse battery stapl", false, false},
+		{"correct horse", "correct horse ", false, false},
+		{"", "", false, true},
+		{"", "a", false, false},
+		{"a\x00", "a", false, false},
+		{"  Correct-Horse__battery\tSTAPLE\n", "correct horse battery staple", true, true},
+		{"correct - horse", "correct horse", true, true},
+		{"ÉCOLE noire", "école noire", true, true},
+		{"correcthorse", "correct horse", true, false},
+		{"correct horse!", "correct horse", true, false},
+		{"correct.horse", "correct horse", true, false},
+		{"correct horse 7", "correct horse 8", true, false},
+		{" - ", "", true, true},
+	}
+	for _, c := range cases {
+		if eq := EqualPhrases(c.a, c.b, c.normalize); eq != c.equal {
+			t.Errorf("EqualPhrases(%q, %q, %v): expected %v, got %v", c.a, c.b, c.normalize, c.equal, eq)
+		}
+		if eq := EqualPhrases(c.b, c.a, c.normalize); eq != c.equal {
+			t.Errorf("EqualPhrases(%q, %q, %v): expected %v, got %v", c.b, c.a, c.normalize, c.equal, eq)
+		}
+	}
+
+	// Phrases of different lengths are compared over buffers of the same length, so the
+	// comparison does not return early
+	a, b := "correct horse battery staple", "a"
+	n := max(len(a), len(b))
+	if pa, pb := padded(a, n), padded(b, n); len(pa) != len(pb) || string(pa) == string(pb) {
+		t.Errorf("Expected distinct buffers of the same length, got %q and %q", pa, pb)
+	}
+}
@@ -0,0 +1,150 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Exercise the Generator contract: generation and queries run concurrently with reloads and
+// AddWords. Run with -race.
+func TestConcurrentUse(t *testing.T) {
+	g, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos.txt"})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	const workers = 4
+	const rounds = 50
+	errs := make(chan error, 8*workers*rounds)
+	var wg sync.WaitGroup
+	run := func(f func(i int) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					errs <- fmt.Errorf("panic: %v", r)
+				}
+			}()
+			for i := 0; i < rounds; i++ {
+				if err := f(i); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+
+	for w := 0; w < workers; w++ {
+		run(func(int) error {
+			p, err := g.GeneratePassphrases(&GenerateOptions{Count: 10, Length: 4})
+			if err != nil {
+				return err
+			}
+			if len(p) != 10 {
+				return fmt.Errorf("expected 10 passphrases, got %v", len(p))
+			}
+			for _, phrase := range p {
+				if len(strings.Fields(phrase)) != 4 {
+					return fmt.Errorf("expected 4 words, got %q", phrase)
+				}
+			}
+			return nil
+		})
+		run(func(int) error {
+			m := g.GetWordMap()
+			if len(m["snoun"]) < 2 {
+				return fmt.Errorf("expected nouns, got %v", m["snoun"])
+			}
+			m["snoun"][0] = "" // a copy: must not reach the Generator
+			m["verb"] = nil
+			return nil
+		})
+		run(func(int) error {
+			st := g.Stats()
+			if st.Words["snoun"] < 2 || st.Words["verb"] < 2 {
+				return fmt.Errorf("unexpected counts %v", st.Words)
+			}
+			return nil
+		})
+	}
+	run(func(i int) error {
+		if i%10 == 0 {
+			return g.LoadWords(&WordListOptions{Wordlist: "testdata/pos.txt"})
+		}
+		return g.AddWords("snoun", fmt.Sprintf("otter%v", i))
+	})
+	run(func(i int) error {
+		return g.AddWords("verb", fmt.Sprintf("swims%v", i))
+	})
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for _, words := range g.GetWordMap() {
+		for _, w := range words {
+			if w == "" {
+				t.Fatalf("Empty word in the word list")
+			}
+		}
+	}
+	if p, err := g.GeneratePassphrases(&GenerateOptions{Count: 1}); err != nil || p[0] == "" {
+		t.Fatalf("Expected a passphrase after the changes, got %v, %v", p, err)
+	}
+}
+
+func TestAddWords(t *testing.T) {
+	g := generator_for(map[string][]string{"snoun": []string{"otter"}, "verb": []string{"runs"}})
+	if err := g.AddWords("snoun", "badger", "otter"); err != nil {
+		t.Fatalf("Error adding words: %v", err)
+	}
+	if words := g.GetWordMap()["snoun"]; len(words) != 2 || words[0] != "badger" {
+		t.Errorf("Expected badger added once, got %v", words)
+	}
+	if err := g.AddWords("noun", "otter"); !errors.Is(err, ErrUnknownWordType) {
+		t.Errorf("Expected ErrUnknownWordType, got %v", err)
+	}
+	if err := g.AddWords("snoun", ""); !errors.Is(err, ErrEmptyWord) {
+		t.Errorf("Expected ErrEmptyWord, got %v", err)
+	}
+	if err := (&Generator{}).AddWords("snoun", "otter"); !errors.Is(err, ErrWordlistNotLoaded) {
+		t.Errorf("Expected ErrWordlistNotLoaded, got %v", err)
+	}
+
+	// Adding words to a pruned type brings it back into the grammar
+	g, err := LoadGenerator(&WordListOptions{
+		Wordlist:        write_temp(t, "pos.txt", "otter\tN\nruns\tV\n"),
+		PruneEmptyTypes: true,
+	})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	if err := g.AddWords("adverb", "slowly"); err != nil {
+		t.Fatalf("Error adding words: %v", err)
+	}
+	for _, typ := range g.Stats().PrunedTypes {
+		if typ == "adverb" {
+			t.Errorf("Expected adverb back in the grammar, pruned %v", g.Stats().PrunedTypes)
+		}
+	}
+}
+
+func TestDeferredLoadReplaced(t *testing.T) {
+	g := &Generator{}
+	if err := g.LoadWords(&WordListOptions{Wordlist: "testdata/pos.txt", Deferred: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.LoadEmbeddedWords(nil); err != nil {
+		t.Fatal(err)
+	}
+	embedded := &Generator{}
+	if err := embedded.LoadEmbeddedWords(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := g.Stats().Words["snoun"], embedded.Stats().Words["snoun"]; got != expected {
+		t.Errorf("Expected the embedded word list to replace the deferred one: %v nouns, got %v", expected, got)
+	}
+}
@@ -0,0 +1,35 @@
+package wordentropy
+
+import (
+	"fmt"
+)
+
+// Constraints reported by ConstraintError
+const (
+	ConstraintUnknownWordType    = "UnknownWordType"    // word type missing from the word list
+	ConstraintEmptyWordType      = "EmptyWordType"      // word type with no words
+	ConstraintPrudish            = "Prudish"            // every word of the type is offensive
+	ConstraintWordLength         = "WordLength"         // no word of the type within MinWordLength/MaxWordLength
+	ConstraintAvoided            = "Avoided"            // every word of the type is in the previous phrase (GenerateAvoiding)
+	ConstraintNoAdjacentSameType = "NoAdjacentSameType" // only the previous type may follow
+	ConstraintGrammar            = "Grammar"            // the grammar allows no follower
+	ConstraintAgreement          = "Agreement"          // no verb agrees in number with the noun before it (Agreement)
+)
+
+// Error returned when no word can be placed in a passphrase, even after backtracking.
+// Unwraps to ErrNoCandidates (ErrNoAllowedType for NoAdjacentSameType).
+type ConstraintError struct {
+	Constraint string // one of the Constraint constants
+	WordType   string // word type whose pool was empty, or that nothing could follow
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("%v: %v (word type %v)", e.Unwrap(), e.Constraint, e.WordType)
+}
+
+func (e *ConstraintError) Unwrap() error {
+	if e.Constraint == ConstraintNoAdjacentSameType {
+		return ErrNoAllowedType
+	}
+	return ErrNoCandidates
+}
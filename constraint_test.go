@@ -0,0 +1,123 @@
+package wordentropy
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Grammar where pnoun leads only to adverb, which has no usable words: drawing pnoun
+// mid-fragment forces a backtrack
+func dead_end_generator(adverbs []string, offensive map[string]uint, snoun_followers []string) *Generator {
+	word_map := uniform_word_map("otter")
+	if adverbs == nil {
+		delete(word_map, "adverb")
+	} else {
+		word_map["adverb"] = adverbs
+	}
+	g := &Generator{}
+	g.data.Store(&word_data{
+		word_map:  word_map,
+		offensive: offensive,
+		grammar: map[string][]string{
+			"adjective": {"snoun", "pnoun"},
+			"snoun":     snoun_followers,
+			"pnoun":     {"adverb"},
+			"adverb":    {"verb"},
+			"verb":      {"adjective"},
+		},
+		types: []string{"adjective"},
+	})
+	return g
+}
+
+func TestBacktracking(t *testing.T) {
+	cases := []struct {
+		name       string
+		adverbs    []string
+		offensive  map[string]uint
+		o          GenerateOptions
+		constraint string
+	}{
+		{"unknown type", nil, nil, GenerateOptions{}, ConstraintUnknownWordType},
+		{"empty type", []string{}, nil, GenerateOptions{}, ConstraintEmptyWordType},
+		{"prudish", []string{"damn"}, map[string]uint{"damn": 1}, GenerateOptions{Prudish: true}, ConstraintPrudish},
+		{"word length", []string{"interminably"}, nil, GenerateOptions{MaxWordLength: 6}, ConstraintWordLength},
+	}
+	for _, c := range cases {
+		o := c.o
+		o.Count, o.Length, o.Magic_fragment_length = 20, 4, 4
+
+		g := dead_end_generator(c.adverbs, c.offensive, []string{"verb"})
+		for i := 0; i < 10; i++ {
+			p, _, err := g.GeneratePassphrasesDetailed(&o)
+			if err != nil {
+				t.Fatalf("%v: backtracking should recover, got %v", c.name, err)
+			}
+			for _, pp := range p {
+				for _, typ := range pp.Types {
+					if typ == "pnoun" || typ == "adverb" {
+						t.Fatalf("%v: dead end type %v in %v", c.name, typ, pp.Types)
+					}
+				}
+			}
+		}
+
+		// snoun now also leads only to adverb, so there is no way forward
+		g = dead_end_generator(c.adverbs, c.offensive, []string{"adverb"})
+		_, err := g.GeneratePassphrases(&o)
+		var ce *ConstraintError
+		if !errors.As(err, &ce) || !errors.Is(err, ErrNoCandidates) {
+			t.Fatalf("%v: expected ConstraintError, got %v", c.name, err)
+		}
+		if ce.Constraint != c.constraint || ce.WordType != "adverb" {
+			t.Errorf("%v: expected %v on adverb, got %+v", c.name, c.constraint, ce)
+		}
+	}
+}
+
+func TestBacktrackingNoAdjacentSameType(t *testing.T) {
+	// adjective may be followed by itself or snoun, and snoun only by adjective or itself
+	g := &Generator{}
+	g.data.Store(&word_data{
+		word_map: uniform_word_map("otter"),
+		grammar: map[string][]string{
+			"adjective": {"adjective", "snoun"},
+			"snoun":     {"snoun", "adjective"},
+		},
+		types: []string{"adjective"},
+	})
+	o := GenerateOptions{Count: 20, Length: 6, Magic_fragment_length: 6, NoAdjacentSameType: true}
+	p, _, err := g.GeneratePassphrasesDetailed(&o)
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	for _, pp := range p {
+		for j := 1; j < len(pp.Types); j++ {
+			if pp.Types[j] == pp.Types[j-1] {
+				t.Fatalf("Adjacent words of type %v: %v", pp.Types[j], pp.Types)
+			}
+		}
+	}
+
+	o.AllowedTypes = []string{"adjective"}
+	_, err = g.GeneratePassphrases(&o)
+	var ce *ConstraintError
+	if !errors.As(err, &ce) || ce.Constraint != ConstraintNoAdjacentSameType || !errors.Is(err, ErrNoAllowedType) {
+		t.Fatalf("Expected NoAdjacentSameType ConstraintError, got %v", err)
+	}
+}
+
+func TestBacktrackingDeadline(t *testing.T) {
+	// No way forward, and enough retries to backtrack for a long time
+	g := dead_end_generator([]string{}, nil, []string{"adverb"})
+	o := GenerateOptions{Length: 4, Magic_fragment_length: 4, MaxRetries: 1 << 30}
+	s, err := g.prepare(&o, g.words(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.deadline = time.Now().Add(-time.Second)
+	if _, _, err := g.generate_fragment(s, nil, 4, "", 4); err != ErrDeadlineExceeded {
+		t.Errorf("Expected ErrDeadlineExceeded while backtracking, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package wordentropy
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Generation totals since the Generator was created or ResetCounters was last called
+type Counters struct {
+	Phrases  uint64        // passphrases generated
+	Words    uint64        // words drawn, including words discarded by regeneration or backtracking
+	Retries  uint64        // passphrase regenerations and backtracking redraws
+	Warnings uint64        // warnings emitted (counting repeats)
+	Errors   uint64        // generation calls that returned an error
+	Time     time.Duration // wall time spent in generation calls
+}
+
+type generator_counters struct {
+	phrases, words, retries, warnings, errors, time atomic.Uint64
+}
+
+// Add the totals of one generation call. s is nil if the call failed before generating.
+func (c *generator_counters) record(s *gen_state, phrases int, err error, start time.Time) {
+	c.phrases.Add(uint64(phrases))
+	if s != nil {
+		c.words.Add(s.drawn)
+		c.retries.Add(s.retries)
+		for _, w := range s.warnings {
+			c.warnings.Add(uint64(w.Count))
+		}
+	}
+	if err != nil {
+		c.errors.Add(1)
+	}
+	c.time.Add(uint64(time.Since(start)))
+}
+
+// Current generation totals. Safe to call while generation is running; each field is read
+// atomically, so a call that finishes concurrently may be partly included.
+func (g *Generator) Counters() Counters {
+	c := &g.counters
+	return Counters{
+		Phrases:  c.phrases.Load(),
+		Words:    c.words.Load(),
+		Retries:  c.retries.Load(),
+		Warnings: c.warnings.Load(),
+		Errors:   c.errors.Load(),
+		Time:     time.Duration(c.time.Load()),
+	}
+}
+
+// Reset the generation totals to zero, returning their values just before the reset
+func (g *Generator) ResetCounters() Counters {
+	c := &g.counters
+	return Counters{
+		Phrases:  c.phrases.Swap(0),
+		Words:    c.words.Swap(0),
+		Retries:  c.retries.Swap(0),
+		Warnings: c.warnings.Swap(0),
+		Errors:   c.errors.Swap(0),
+		Time:     time.Duration(c.time.Swap(0)),
+	}
+}
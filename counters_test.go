@@ -0,0 +1,90 @@
+package wordentropy
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCounters(t *testing.T) {
+	g := load_test_generator(t)
+	p, _, err := g.GeneratePassphrasesDetailed(&GenerateOptions{Count: 5, Length: 6})
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	entries := 0
+	for _, pp := range p {
+		entries += len(pp.Entries)
+	}
+	c := g.Counters()
+	if c.Phrases != 5 || c.Words < uint64(entries) || c.Errors != 0 || c.Time <= 0 {
+		t.Fatalf("Unexpected counters %+v", c)
+	}
+
+	if _, err := g.GeneratePassphrases(&GenerateOptions{Count: 1, MaxChars: 1, MaxRetries: 3}); err != ErrRetriesExhausted {
+		t.Fatalf("Expected ErrRetriesExhausted, got %v", err)
+	}
+	g.GeneratePassphrases(&GenerateOptions{Count: count_max + 1})
+	c2 := g.Counters()
+	if c2.Phrases != 5 || c2.Errors != 2 || c2.Retries < c.Retries+3 || c2.Words <= c.Words {
+		t.Fatalf("Unexpected counters after errors %+v (before %+v)", c2, c)
+	}
+
+	if reset := g.ResetCounters(); reset != c2 {
+		t.Errorf("Expected ResetCounters to return %+v, got %+v", c2, reset)
+	}
+	if c := g.Counters(); c != (Counters{}) {
+		t.Errorf("Expected zero counters after reset, got %+v", c)
+	}
+
+	w := uniform_word_map("otter")
+	w["adverb"] = []string{}
+	wg := generator_for(w)
+	_, warnings, _ := wg.GeneratePassphrasesDetailed(&GenerateOptions{Count: count_max, Length: 20})
+	n := uint64(0)
+	for _, w := range warnings {
+		n += uint64(w.Count)
+	}
+	if n == 0 || wg.Counters().Warnings != n {
+		t.Errorf("Expected %v warnings to be counted, got %+v", n, wg.Counters())
+	}
+}
+
+func TestCountersConcurrent(t *testing.T) {
+	g := load_test_generator(t)
+	const workers, calls = 8, 50
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < calls; j++ {
+				g.GeneratePassphrases(&GenerateOptions{Count: 2, Length: 4})
+			}
+		}()
+	}
+
+	var total Counters
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		case <-time.After(time.Millisecond):
+		}
+		if c := g.Counters(); c.Phrases > workers*calls*2 {
+			t.Errorf("Snapshot exceeds the phrases generated: %+v", c)
+		}
+		r := g.ResetCounters()
+		total.Phrases += r.Phrases
+		total.Words += r.Words
+	}
+	// Multiword entries fill several of the 4 words per passphrase with one draw
+	if total.Phrases != workers*calls*2 || total.Words < workers*calls*2 {
+		t.Fatalf("Expected resets to add up to all generation, got %+v", total)
+	}
+}
@@ -0,0 +1,56 @@
+package wordentropy
+
+import (
+	"sort"
+)
+
+// Changes to the words of one type between two word maps
+type TypeDiff struct {
+	Added        []string // words only in the new map, sorted
+	Removed      []string // words only in the old map, sorted
+	CountDelta   int      // change in the number of words
+	EntropyDelta float64  // change in bits of entropy per word of the type
+}
+
+// Changes between two word maps, by word type
+type Diff struct {
+	Types map[string]TypeDiff // every type in either map, including unchanged ones
+}
+
+// Compare an old word map a with a new one b, e.g. as returned by GetWordMap before rolling
+// out a new word list. A type missing from one map counts as having no words there.
+// Duplicate words count once in Added and Removed but every time in CountDelta.
+func DiffWordMaps(a map[string][]string, b map[string][]string) Diff {
+	d := Diff{Types: make(map[string]TypeDiff)}
+	for _, m := range []map[string][]string{a, b} {
+		for t := range m {
+			if _, ok := d.Types[t]; ok {
+				continue
+			}
+			d.Types[t] = TypeDiff{
+				Added:        missing_from(b[t], a[t]),
+				Removed:      missing_from(a[t], b[t]),
+				CountDelta:   len(b[t]) - len(a[t]),
+				EntropyDelta: choice_entropy(len(b[t])) - choice_entropy(len(a[t])),
+			}
+		}
+	}
+	return d
+}
+
+// Sorted distinct words of l not in other (nil if none)
+func missing_from(l []string, other []string) []string {
+	in_other := make(map[string]bool, len(other))
+	for _, w := range other {
+		in_other[w] = true
+	}
+	var missing []string
+	for _, w := range l {
+		if !in_other[w] {
+			missing = append(missing, w)
+			in_other[w] = true // once only
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,41 @@
+package wordentropy
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestDiffWordMaps(t *testing.T) {
+	load := func(path string) map[string][]string {
+		g, err := LoadGenerator(&WordListOptions{Wordlist: path})
+		if err != nil {
+			t.Fatalf("Could not load %v: %v", path, err)
+		}
+		return g.GetWordMap()
+	}
+	d := DiffWordMaps(load("testdata/diff_old.txt"), load("testdata/diff_new.txt"))
+	want := map[string]TypeDiff{
+		"snoun":     {Added: []string{"heron", "owl"}, Removed: []string{"fox"}, CountDelta: 1, EntropyDelta: 2 - math.Log2(3)},
+		"verb":      {Removed: []string{"sings"}, CountDelta: -1, EntropyDelta: -1},
+		"adjective": {Added: []string{"brave"}, CountDelta: 1},
+	}
+	for ty, td := range d.Types {
+		w := want[ty]
+		if !reflect.DeepEqual(td.Added, w.Added) || !reflect.DeepEqual(td.Removed, w.Removed) || td.CountDelta != w.CountDelta || math.Abs(td.EntropyDelta-w.EntropyDelta) > 1e-9 {
+			t.Errorf("%v: expected %+v, got %+v", ty, w, td)
+		}
+	}
+	if len(d.Types) != len(word_types) {
+		t.Errorf("Expected every word type, got %v", d.Types)
+	}
+
+	// Types missing from either map, and duplicates
+	d = DiffWordMaps(map[string][]string{"verb": {"runs", "runs"}}, map[string][]string{"snoun": {"otter"}})
+	if v := d.Types["verb"]; !reflect.DeepEqual(v.Removed, []string{"runs"}) || v.Added != nil || v.CountDelta != -2 || v.EntropyDelta != -1 {
+		t.Errorf("Unexpected verb diff: %+v", v)
+	}
+	if n := d.Types["snoun"]; !reflect.DeepEqual(n.Added, []string{"otter"}) || n.CountDelta != 1 || n.EntropyDelta != 0 {
+		t.Errorf("Unexpected snoun diff: %+v", n)
+	}
+}
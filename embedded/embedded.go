@@ -0,0 +1,32 @@
+// Package embedded builds the package's wordlists into the program and registers them with
+// wordentropy.RegisterBuiltin, for Quick, LoadEmbeddedWords and WordListOptions.Builtin.
+// Import it for its side effect:
+//
+//	import _ "github.com/bkeroack/libwordentropy/embedded"
+//
+// The lists add about 3.7MB to the program, so only programs that use them import it.
+package embedded
+
+import (
+	_ "embed"
+	"github.com/bkeroack/libwordentropy"
+)
+
+//go:generate go run ../cmd/we -wordlist_path ../data/part-of-speech.txt -convert part-of-speech.bin
+
+//go:generate go run ../cmd/we -wordlist_path ../data/common.txt -convert common.bin
+
+// Full list derived from the Moby part-of-speech database, used by Quick
+//
+//go:embed part-of-speech.bin
+var full []byte
+
+// Curated list of common, easily spelled words of 3 to 8 letters (see data/common.txt)
+//
+//go:embed common.bin
+var common []byte
+
+func init() {
+	wordentropy.RegisterBuiltin("full", full)
+	wordentropy.RegisterBuiltin("common", common)
+}
@@ -0,0 +1,18 @@
+package embedded
+
+import (
+	"github.com/bkeroack/libwordentropy"
+	"testing"
+)
+
+func TestRegistered(t *testing.T) {
+	for _, name := range []string{"full", "common"} {
+		g := &wordentropy.Generator{}
+		if err := g.LoadWords(&wordentropy.WordListOptions{Builtin: name}); err != nil {
+			t.Fatalf("Error loading %v wordlist: %v", name, err)
+		}
+	}
+	if p, err := wordentropy.Quick(2); err != nil || len(p) != 2 {
+		t.Fatalf("Expected 2 passphrases from Quick, got %v (%v)", p, err)
+	}
+}
@@ -0,0 +1,106 @@
+package wordentropy
+
+import (
+	"math"
+)
+
+// Bits of entropy in a uniform choice among n options (0 if there are none)
+func choice_entropy(n int) float64 {
+	if n < 1 {
+		return 0
+	}
+	return math.Log2(float64(n))
+}
+
+// Bits of entropy in a choice where each option is picked with likelihood proportional to
+// its weight (Shannon entropy; choice_entropy(n) for n equal weights)
+func weighted_entropy(weights []uint) float64 {
+	total := 0.0
+	for _, w := range weights {
+		total += float64(w)
+	}
+	h := 0.0
+	for _, w := range weights {
+		if w > 0 {
+			p := float64(w) / total
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
+
+// Get the bits of entropy in the choice of the word type that starts each fragment, given
+// the AllowedTypes and StartTypeWeights in the options
+func (g *Generator) StartTypeEntropy(o *GenerateOptions) (_ float64, err error) {
+	defer recover_internal(&err)
+	options, err := g.check_options(o)
+	if err != nil {
+		return 0, err
+	}
+	d := g.words()
+	_, start, err := restrict_to_allowed(d.rules(), d.start_types(), &options)
+	if err != nil {
+		return 0, err
+	}
+	start, weights, err := weight_start_types(start, &options)
+	if err != nil {
+		return 0, err
+	}
+	if weights == nil {
+		return choice_entropy(len(start)), nil
+	}
+	w := make([]uint, len(start))
+	for i, t := range start {
+		w[i] = weights[t]
+	}
+	return weighted_entropy(w), nil
+}
+
+// Get the bits of entropy added to each passphrase by the digit and symbol requested with
+// Add_digit and Add_symbol
+func (g *Generator) PaddingEntropy(o *GenerateOptions) (_ float64, err error) {
+	defer recover_internal(&err)
+	options, err := g.check_options(o)
+	if err != nil {
+		return 0, err
+	}
+	bits := 0.0
+	if options.Add_digit {
+		bits += choice_entropy(len(options.Digits))
+	}
+	if options.Add_symbol {
+		bits += choice_entropy(len(options.Symbols))
+	}
+	return bits, nil
+}
+
+// Effect of the offensive filter on one word type
+type PrudishStats struct {
+	Total        uint    // words of the type in the word list
+	Filtered     uint    // words removed by the offensive filter
+	EntropyDelta float64 // change in bits of entropy per word of the type (negative or zero)
+}
+
+// Get the effect of the offensive filter on each word type, as applied with Prudish. Returns
+// nil if no offensive list was loaded.
+func (g *Generator) PrudishImpact() map[string]PrudishStats {
+	d := g.words()
+	if d.offensive == nil {
+		return nil
+	}
+	pools := d.prudish(at_level(1)).pools
+	impact := make(map[string]PrudishStats, len(pools))
+	for _, t := range word_types {
+		total, ok := d.count(t)
+		if !ok {
+			continue
+		}
+		remaining := len(pools[t])
+		impact[t] = PrudishStats{
+			Total:        uint(total),
+			Filtered:     uint(total - remaining),
+			EntropyDelta: choice_entropy(remaining) - choice_entropy(total),
+		}
+	}
+	return impact
+}
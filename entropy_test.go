@@ -0,0 +1,27 @@
+package wordentropy
+
+import (
+	"testing"
+)
+
+func TestPrudishImpact(t *testing.T) {
+	g, err := LoadGenerator(&WordListOptions{
+		Wordlist:  write_temp(t, "pos.txt", "otter\tN\nsings\tV\nruns\tV\ncurses\tV\nswears\tV\n"),
+		Offensive: write_temp(t, "offensive.txt", "curses\nswears\n"),
+	})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	impact := g.PrudishImpact()
+	verb := impact["verb"]
+	if verb.Total != 4 || verb.Filtered != 2 || verb.EntropyDelta != -1 {
+		t.Fatalf("Expected 4 verbs with 2 filtered and a 1 bit drop, got %+v", verb)
+	}
+	if noun := impact["snoun"]; noun.Total != 1 || noun.Filtered != 0 || noun.EntropyDelta != 0 {
+		t.Fatalf("Expected unfiltered snoun, got %+v", noun)
+	}
+
+	if impact := generator_for(uniform_word_map("otter")).PrudishImpact(); impact != nil {
+		t.Fatalf("Expected nil impact without an offensive list, got %v", impact)
+	}
+}
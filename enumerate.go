@@ -0,0 +1,137 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+var ErrKeyspaceTooLarge = errors.New("Keyspace exceeds the enumeration limit")
+
+// List every passphrase the options can produce, sorted, e.g. to validate the entropy
+// figures on a tiny word list. The walk visits the same decision points as generation (the
+// word type and word at each position, the joint type at each seam, then the digit and
+// symbol) in a fixed order; passphrases that MaxChars, MaxBytes, MaxSyllables,
+// AvoidCommonPhrases or UnambiguousConcat would reject, or whose verbs disagree with
+// Agreement, are left out.
+// Returns ErrKeyspaceTooLarge if KeyspaceUpperBound for the options exceeds limit. Count, Timeout,
+// BestOf and Scorer in the options are ignored.
+func (g *Generator) EnumerateAll(o *GenerateOptions, limit int) (_ []string, err error) {
+	defer recover_internal(&err)
+	var options GenerateOptions
+	if o != nil {
+		options = *o
+	}
+	options.Count, options.Timeout, options.BestOf, options.Scorer = 1, 0, 1, nil
+	s, err := g.prepare(&options, g.words(), nil)
+	if err != nil {
+		return nil, err
+	}
+	k := new_keyspace(s)
+	if size := k.size(); size.Cmp(big.NewInt(int64(limit))) > 0 {
+		return nil, fmt.Errorf("%w: %v passphrases, limit %v", ErrKeyspaceTooLarge, size, limit)
+	}
+
+	digits, symbols := []string{""}, []string{""}
+	if s.o.Add_digit {
+		digits = s.o.Digits
+	}
+	if s.o.Add_symbol {
+		symbols = s.o.Symbols
+	}
+	seen := make(map[string]bool)
+	k.begin("", 0, raw_passphrase{fragments: []int{0}}, func(r raw_passphrase) {
+		p := split_entries(r, s.o.Length)
+		if s.o.AvoidCommonPhrases && s.d.common.contains(p.Words) || s.ambiguous(p) || !s.agrees(p) {
+			return
+		}
+		phrase := join_words(transform_case(p.Words, s.o), separator(s.o))
+		for _, d := range digits {
+			for _, sym := range symbols {
+				p.Phrase, p.Digit, p.Symbol = phrase+d+sym, d, sym
+				p.Syllables = syllables(p)
+				if check_constraints(p, s.o) {
+					seen[p.Phrase] = true
+				}
+			}
+		}
+	})
+	all := make([]string, 0, len(seen))
+	for phrase := range seen {
+		all = append(all, phrase)
+	}
+	sort.Strings(all)
+	return all, nil
+}
+
+// Walk every fragment length from the start of a fragment, following fragment in keyspace
+func (k *keyspace) begin(before string, words int, r raw_passphrase, emit func(raw_passphrase)) {
+	for _, f := range k.fragments {
+		k.walk(f, 0, before, words, r, emit)
+	}
+}
+
+// Call emit with every distinct raw passphrase from the position on, following the same
+// cases as count. A passphrase is emitted once it has Length words.
+func (k *keyspace) walk(fragment int, position int, before string, words int, r raw_passphrase, emit func(raw_passphrase)) {
+	// Draw each entry of a type in turn, then go on with then, or emit if the passphrase is
+	// complete
+	draw := func(t string, r raw_passphrase, words int, then func(r raw_passphrase, words int)) {
+		for _, w := range k.words(t) {
+			n := len(r.fragments) - 1
+			next := raw_passphrase{
+				entries:   append(r.entries, w),
+				types:     append(r.types, t),
+				fragments: append(r.fragments[:n:n], r.fragments[n]+1),
+			}
+			if words := min(words+entry_words(w), k.length); words < k.length {
+				then(next, words)
+			} else {
+				emit(next)
+			}
+		}
+	}
+	// Start the next fragment
+	seam := func(r raw_passphrase) raw_passphrase {
+		r.fragments = append(r.fragments[:len(r.fragments):len(r.fragments)], 0)
+		return r
+	}
+	nast := k.s.o.NoAdjacentSameType
+	candidates := k.s.start
+	if position > 0 {
+		candidates = k.s.rules[before]
+	}
+	if nast {
+		candidates = exclude_types(candidates, before)
+	}
+
+	if position < fragment-1 {
+		for _, t := range candidates {
+			draw(t, r, words, func(r raw_passphrase, words int) {
+				k.walk(fragment, position+1, t, words, r, emit)
+			})
+		}
+		return
+	}
+	for _, t := range candidates {
+		joints, direct := k.seams(t)
+		if len(joints) == 0 && !direct {
+			continue
+		}
+		draw(t, r, words, func(r raw_passphrase, words int) {
+			if k.length-words == 1 {
+				k.begin(t, words, seam(r), emit)
+				return
+			}
+			for _, j := range joints {
+				draw(j, seam(r), words, func(r raw_passphrase, words int) {
+					k.begin(j, words, r, emit)
+				})
+			}
+			if direct {
+				k.begin(t, words, seam(r), emit)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package wordentropy
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEnumerateAll(t *testing.T) {
+	g := generator_for(map[string][]string{
+		"snoun":  []string{"otter", "badger"},
+		"verb":   []string{"runs"},
+		"adverb": []string{"slowly"},
+	})
+	o := GenerateOptions{Length: 2, AllowedTypes: []string{"snoun", "verb", "adverb"}}
+	all, err := g.EnumerateAll(&o, 100)
+	if err != nil {
+		t.Fatalf("Error enumerating: %v", err)
+	}
+	// snoun -> adverb, verb; verb -> snoun; adverb -> verb
+	expected := []string{
+		"badger runs",
+		"badger slowly",
+		"otter runs",
+		"otter slowly",
+		"runs badger",
+		"runs otter",
+		"slowly runs",
+	}
+	if !reflect.DeepEqual(all, expected) {
+		t.Errorf("Expected %v, got %v", expected, all)
+	}
+	if n, err := g.KeyspaceUpperBound(&o); err != nil || n.Int64() != int64(len(expected)) {
+		t.Errorf("Expected a keyspace of %v, got %v, %v", len(expected), n, err)
+	}
+
+	o.Add_digit, o.Digits, o.No_spaces, o.Capitalize, o.MaxChars = true, []string{"1", "2"}, true, "words", 10
+	all, err = g.EnumerateAll(&o, 100)
+	if err != nil {
+		t.Fatalf("Error enumerating: %v", err)
+	}
+	expected = []string{"OtterRuns1", "OtterRuns2", "RunsOtter1", "RunsOtter2"}
+	if !reflect.DeepEqual(all, expected) {
+		t.Errorf("Expected %v, got %v", expected, all)
+	}
+
+	if _, err := g.EnumerateAll(&o, 13); !errors.Is(err, ErrKeyspaceTooLarge) {
+		t.Errorf("Expected ErrKeyspaceTooLarge, got %v", err)
+	}
+}
+
+func TestEnumerateAllKeyspace(t *testing.T) {
+	g := generator_for(map[string][]string{
+		"snoun":       []string{"otter", "badger"},
+		"verb":        []string{"runs", "swims"},
+		"adverb":      []string{"slowly"},
+		"pronoun":     []string{"she", "they"},
+		"conjunction": []string{"and", "or"},
+	})
+	allowed := []string{"snoun", "verb", "adverb", "pronoun", "conjunction"}
+	for _, o := range []GenerateOptions{
+		{Length: 3},
+		{Length: 4, Magic_fragment_length: 2},
+		{Length: 4, Magic_fragment_length: 2, NoAdjacentSameType: true},
+		{Length: 4, Magic_fragment_length: 2, MinWordLength: 4},
+		{Length: 3, Magic_fragment_length: 1, JointTypes: map[string]uint{"conjunction": 1, "pronoun": 1}},
+		{Length: 4, Fragments: FragmentPolicy{TargetFragmentWords: 2, JoinType: "pronoun"}, MinWordLength: 4},
+	} {
+		o.AllowedTypes = allowed
+		all, err := g.EnumerateAll(&o, 10000)
+		if err != nil {
+			t.Fatalf("Error enumerating: %v", err)
+		}
+		// Every word is distinct and of one type, so distinct strings match the keyspace
+		if n, _ := g.KeyspaceUpperBound(&o); n.Int64() != int64(len(all)) {
+			t.Errorf("%+v: keyspace %v, but %v passphrases enumerated", o, n, len(all))
+		}
+	}
+
+	// With Jitter, passphrases that can be assembled from fragments of different lengths are
+	// counted once for each
+	o := GenerateOptions{Length: 3, Fragments: FragmentPolicy{TargetFragmentWords: 2, Jitter: 1}, AllowedTypes: allowed}
+	all, err := g.EnumerateAll(&o, 100000)
+	if err != nil {
+		t.Fatalf("Error enumerating: %v", err)
+	}
+	if n, _ := g.KeyspaceUpperBound(&o); n.Int64() < int64(len(all)) {
+		t.Errorf("Keyspace %v with Jitter is less than the %v passphrases enumerated", n, len(all))
+	}
+	o.Fragments.Jitter = 0
+	if fixed, _ := g.EnumerateAll(&o, 100000); len(fixed) >= len(all) {
+		t.Errorf("Expected Jitter to add to the %v passphrases without it, got %v", len(fixed), len(all))
+	}
+}
@@ -0,0 +1,66 @@
+package wordentropy
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrUnknownFormat = errors.New("Unknown format")
+
+// Words that generation can draw from: the offensive prefilter is applied if an offensive
+// list was loaded, and word types pruned from the grammar are left out.
+func (g *Generator) effective_word_map() map[string][]string {
+	d := g.words()
+	word_map := d.pools(at_level(1))
+	pruned := make(map[string]bool, len(d.pruned))
+	for _, t := range d.pruned {
+		pruned[t] = true
+	}
+	effective := make(map[string][]string, len(word_map))
+	for t, words := range word_map {
+		if !pruned[t] {
+			effective[t] = words
+		}
+	}
+	return effective
+}
+
+// Write the words generation can draw from to w. Format is "csv" (word,type rows) or
+// "json" (object of word type to words, loadable with WordListOptions.Format "json"). If
+// the generator was loaded with an offensive list, offensive words are left out of the
+// export.
+func (g *Generator) ExportWordMap(w io.Writer, format string) (err error) {
+	defer recover_internal(&err)
+	if g.closed.Load() {
+		return ErrClosed
+	}
+	word_map := g.effective_word_map()
+	switch format {
+	case "json":
+		for t, words := range word_map {
+			if len(words) == 0 {
+				delete(word_map, t)
+			}
+		}
+		return json.NewEncoder(w).Encode(word_map)
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"word", "type"}); err != nil {
+			return err
+		}
+		for _, t := range word_types {
+			for _, word := range word_map[t] {
+				if err := cw.Write([]string{word, t}); err != nil {
+					return err
+				}
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("%w: %v", ErrUnknownFormat, format)
+	}
+}
@@ -0,0 +1,83 @@
+package wordentropy
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExportWordMapRoundTrip(t *testing.T) {
+	for _, offensive := range []string{"", "testdata/offensive.txt"} {
+		g, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos.txt", Offensive: offensive})
+		if err != nil {
+			t.Fatalf("Could not load wordlist: %v", err)
+		}
+		expected := g.Stats().Words
+		for wt, n := range g.Stats().OffensiveFiltered {
+			expected[wt] -= n
+		}
+
+		var buf bytes.Buffer
+		if err := g.ExportWordMap(&buf, "json"); err != nil {
+			t.Fatalf("Error exporting JSON: %v", err)
+		}
+		m := MapProvider{}
+		if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+			t.Fatalf("Error decoding JSON export: %v", err)
+		}
+		g2, err := NewGeneratorFromProvider(m)
+		if err != nil {
+			t.Fatalf("Error reloading JSON export: %v", err)
+		}
+		if words := g2.Stats().Words; !reflect.DeepEqual(words, expected) {
+			t.Fatalf("JSON round trip (offensive: %q): expected %v, got %v", offensive, expected, words)
+		}
+
+		buf.Reset()
+		if err := g.ExportWordMap(&buf, "csv"); err != nil {
+			t.Fatalf("Error exporting CSV: %v", err)
+		}
+		rows, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("Error decoding CSV export: %v", err)
+		}
+		if !reflect.DeepEqual(rows[0], []string{"word", "type"}) {
+			t.Fatalf("Unexpected CSV header: %v", rows[0])
+		}
+		m = MapProvider{}
+		for _, row := range rows[1:] {
+			m[row[1]] = append(m[row[1]], row[0])
+		}
+		g2, err = NewGeneratorFromProvider(m)
+		if err != nil {
+			t.Fatalf("Error reloading CSV export: %v", err)
+		}
+		if words := g2.Stats().Words; !reflect.DeepEqual(words, expected) {
+			t.Fatalf("CSV round trip (offensive: %q): expected %v, got %v", offensive, expected, words)
+		}
+	}
+}
+
+func TestExportWordMapPruned(t *testing.T) {
+	g, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos3.txt", PruneEmptyTypes: true})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := g.ExportWordMap(&buf, "json"); err != nil {
+		t.Fatalf("Error exporting JSON: %v", err)
+	}
+	m := map[string][]string{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("Error decoding JSON export: %v", err)
+	}
+	if len(m) != 3 {
+		t.Fatalf("Expected only the 3 unpruned types, got %v", m)
+	}
+	if err := g.ExportWordMap(&buf, "xml"); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build js
+
+package wordentropy
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// Browsers have no file system, so js builds load words only from memory: a built-in
+// wordlist, LoadBinaryWords or a WordProvider
+func open_file(p string) (io.ReadCloser, int64, error) {
+	return nil, -1, &os.PathError{Op: "open", Path: p, Err: ErrNoFiles}
+}
+
+func same_file(a, b string) bool {
+	return false
+}
+
+func stat_file(p string) (time.Time, int64, error) {
+	return time.Time{}, 0, &os.PathError{Op: "stat", Path: p, Err: ErrNoFiles}
+}
@@ -0,0 +1,41 @@
+//go:build !js
+
+package wordentropy
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// Open a file, returning its size if it is a regular file (-1 otherwise)
+func open_file(p string) (io.ReadCloser, int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, -1, err
+	}
+	if fi, err := f.Stat(); err == nil && fi.Mode().IsRegular() {
+		return f, fi.Size(), nil
+	}
+	return f, -1, nil
+}
+
+// Whether paths a and b name the same file, after following symbolic links (false if
+// either cannot be found)
+func same_file(a, b string) bool {
+	fa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	fb, err := os.Stat(b)
+	return err == nil && os.SameFile(fa, fb)
+}
+
+// Modification time and size of a file, to notice when it changes
+func stat_file(p string) (time.Time, int64, error) {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return fi.ModTime(), fi.Size(), nil
+}
@@ -0,0 +1,62 @@
+package wordentropy
+
+import (
+	"strings"
+)
+
+// How a passphrase of Length words is assembled from fragments: autonomous runs of words
+// that follow the grammar, joined by a word of a joining type (a conjunction by default) or
+// directly. A passphrase is built as follows:
+//
+//  1. Start a fragment: draw its length, TargetFragmentWords varied uniformly at random by
+//     up to Jitter words either way, and the joining type for the seam after it.
+//  2. Unless it is the first fragment, draw a word of the joining type chosen with the
+//     previous fragment. The fragments are joined directly instead if only one word of the
+//     passphrase is left to fill or no word of the type can be drawn.
+//  3. Draw the fragment's words by the grammar rules, stopping as soon as the passphrase has
+//     Length words.
+//  4. Repeat from 1 until the passphrase has Length words.
+//
+// So every fragment but the last has exactly its drawn length after its joining word, the
+// last is cut short to fit, and a joining word never ends a passphrase. Words are counted
+// with multiword entries split; one that overruns Length is cut to fit, and an entry
+// without words counts as one.
+type FragmentPolicy struct {
+	TargetFragmentWords uint   // Words per fragment after its joining word (default 4, maximum 99)
+	JoinType            string // Word type joining fragments, or "none" to join them directly (default: see JointTypes and NoJoints, which it cannot be combined with)
+	Jitter              uint   // Vary each fragment's length at random by up to this many words either way (must be less than TargetFragmentWords)
+}
+
+// Words an entry counts for towards Length
+func entry_words(entry string) int {
+	return max(len(strings.Fields(entry)), 1)
+}
+
+// Lengths a fragment may be drawn with, each equally likely
+func fragment_lengths(f FragmentPolicy) []int {
+	lengths := make([]int, 0, 2*f.Jitter+1)
+	for n := f.TargetFragmentWords - f.Jitter; n <= f.TargetFragmentWords+f.Jitter; n++ {
+		lengths = append(lengths, int(n))
+	}
+	return lengths
+}
+
+// Random length for the next fragment
+func (s *gen_state) fragment_length() int {
+	f := s.o.Fragments
+	if f.Jitter == 0 {
+		return int(f.TargetFragmentWords)
+	}
+	return int(f.TargetFragmentWords-f.Jitter) + int(s.rng.int_n(int64(2*f.Jitter+1)))
+}
+
+// Random joint type for the seam after the next fragment ("" to join directly)
+func (s *gen_state) seam() string {
+	switch len(s.joints) {
+	case 0:
+		return ""
+	case 1:
+		return s.joints[0]
+	}
+	return s.weighted_choice(s.joints, s.jweights)
+}
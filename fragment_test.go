@@ -0,0 +1,229 @@
+package wordentropy
+
+import (
+	"errors"
+	mrand "math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+var fragment_word_map = map[string][]string{
+	"snoun":       []string{"otter", "badger", "sea lion"},
+	"verb":        []string{"runs", "swims"},
+	"adverb":      []string{"slowly"},
+	"pronoun":     []string{"she", "they"},
+	"conjunction": []string{"and", "or"},
+}
+
+func TestFragmentPolicyGolden(t *testing.T) {
+	cases := []struct {
+		o        GenerateOptions
+		phrases  []string
+		fragment [][][2]int
+	}{
+		{
+			GenerateOptions{Count: 3, Length: 9, Fragments: FragmentPolicy{TargetFragmentWords: 3, Jitter: 1}},
+			[]string{"or runs otter swims or they runs or swims", "and badger slowly swims and runs sea lion and", "slowly runs and or and swims and or runs"},
+			[][][2]int{{{0, 4}, {4, 9}}, {{0, 4}, {4, 8}, {8, 9}}, {{0, 3}, {3, 6}, {6, 9}}},
+		},
+	}
+	for _, c := range cases {
+		g := generator_for(fragment_word_map)
+		g.rand = mrand.NewChaCha8([32]byte{5})
+		p, _, err := g.GeneratePassphrasesDetailed(&c.o)
+		if err != nil {
+			t.Fatalf("%+v: %v", c.o.Fragments, err)
+		}
+		for i := range p {
+			if p[i].Phrase != c.phrases[i] || !reflect.DeepEqual(p[i].FragmentSpans, c.fragment[i]) {
+				t.Errorf("%+v: expected %q %v, got %q %v", c.o.Fragments, c.phrases[i], c.fragment[i], p[i].Phrase, p[i].FragmentSpans)
+			}
+		}
+	}
+
+	// Magic_fragment_length maps onto TargetFragmentWords
+	generate := func(o GenerateOptions) []string {
+		g := generator_for(fragment_word_map)
+		g.rand = mrand.NewChaCha8([32]byte{6})
+		p, err := g.GeneratePassphrases(&o)
+		if err != nil {
+			t.Fatalf("%+v: %v", o, err)
+		}
+		return p
+	}
+	legacy := generate(GenerateOptions{Count: 5, Length: 10, Magic_fragment_length: 3})
+	policy := generate(GenerateOptions{Count: 5, Length: 10, Fragments: FragmentPolicy{TargetFragmentWords: 3}})
+	if !reflect.DeepEqual(legacy, policy) {
+		t.Errorf("Expected Magic_fragment_length 3 to match TargetFragmentWords 3, got %q and %q", legacy, policy)
+	}
+}
+
+func TestFragmentPolicyExactLength(t *testing.T) {
+	g := load_test_generator(t)
+	join_types := []string{"", "none", "conjunction", "preposition"}
+	bool_opt := func() bool { return test_rng.int_n(2) == 1 }
+	for i := 0; i < 1000; i++ {
+		target := uint(test_rng.int_n(8)) + 1
+		o := GenerateOptions{
+			Count:  uint(test_rng.int_n(3)) + 1,
+			Length: uint(test_rng.int_n(30)) + 1,
+			Fragments: FragmentPolicy{
+				TargetFragmentWords: target,
+				JoinType:            join_types[test_rng.int_n(int64(len(join_types)))],
+				Jitter:              uint(test_rng.int_n(int64(target))),
+			},
+			NoAdjacentSameType: bool_opt(),
+			Agreement:          bool_opt(),
+			No_spaces:          bool_opt(),
+			Add_digit:          bool_opt(),
+			ShortWordBias:      float64(test_rng.int_n(2)),
+		}
+		p, _, err := g.GeneratePassphrasesDetailed(&o)
+		if err != nil {
+			t.Fatalf("Error generating passphrases (options: %+v): %v", o, err)
+		}
+		for _, pp := range p {
+			if uint(len(pp.Words)) != o.Length || len(pp.Types) != len(pp.Words) {
+				t.Fatalf("Expected %v words, got %q (options: %+v)", o.Length, pp.Words, o)
+			}
+			spans := pp.FragmentSpans
+			if spans[0][0] != 0 || spans[len(spans)-1][1] != len(pp.Words) {
+				t.Fatalf("Fragments %v do not cover %q (options: %+v)", spans, pp.Words, o)
+			}
+			for j := 1; j < len(spans); j++ {
+				if spans[j][0] != spans[j-1][1] {
+					t.Fatalf("Fragments %v are not contiguous (options: %+v)", spans, o)
+				}
+			}
+		}
+	}
+}
+
+func TestFragmentPolicyLengths(t *testing.T) {
+	// With single-word entries and a joining word at every seam (but a last one-word
+	// fragment), every fragment but the last has its drawn length after the joining word
+	g := generator_for(fragment_word_map)
+	o := GenerateOptions{
+		Count:              count_max,
+		Length:             40,
+		Fragments:          FragmentPolicy{TargetFragmentWords: 4, Jitter: 2, JoinType: "pronoun"},
+		AllowedTypes:       []string{"verb", "adverb", "pronoun", "conjunction"},
+		NoAdjacentSameType: true,
+	}
+	p, _, err := g.GeneratePassphrasesDetailed(&o)
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	seen := make(map[int]bool)
+	for _, pp := range p {
+		spans := pp.FragmentSpans
+		for j, span := range spans[:len(spans)-1] {
+			n := span[1] - span[0]
+			if j > 0 {
+				n-- // joining word
+				if pp.Types[span[0]] != "pronoun" {
+					t.Fatalf("Expected fragment %v of %q to start with a pronoun", span, pp.Words)
+				}
+			}
+			if n < 2 || n > 6 {
+				t.Fatalf("Fragment %v of %q has %v words, expected 2 to 6", span, pp.Words, n)
+			}
+			seen[n] = true
+		}
+	}
+	if len(seen) != 5 {
+		t.Errorf("Expected fragments of every length from 2 to 6, got %v", seen)
+	}
+}
+
+func TestFragmentPolicyErrors(t *testing.T) {
+	g := generator_for(fragment_word_map)
+	cases := []struct {
+		f   FragmentPolicy
+		o   GenerateOptions
+		err error
+	}{
+		{FragmentPolicy{TargetFragmentWords: fragment_max + 1}, GenerateOptions{}, ErrFragmentExceedsMax},
+		{FragmentPolicy{TargetFragmentWords: 3, Jitter: 3}, GenerateOptions{}, ErrInvalidFragments},
+		{FragmentPolicy{Jitter: 4}, GenerateOptions{}, ErrInvalidFragments},
+		{FragmentPolicy{TargetFragmentWords: 3}, GenerateOptions{Magic_fragment_length: 2}, ErrInvalidFragments},
+		{FragmentPolicy{JoinType: "xyzzy"}, GenerateOptions{}, ErrUnknownWordType},
+		{FragmentPolicy{JoinType: "none"}, GenerateOptions{NoJoints: true}, ErrInvalidFragments},
+		{FragmentPolicy{JoinType: "pronoun"}, GenerateOptions{JointTypes: map[string]uint{"pronoun": 1}}, ErrInvalidFragments},
+		{FragmentPolicy{JoinType: "interjection"}, GenerateOptions{}, ErrTooFewWords},
+		{FragmentPolicy{JoinType: "pronoun"}, GenerateOptions{AllowedTypes: []string{"snoun", "verb"}}, ErrGrammarUnusable},
+	}
+	for _, c := range cases {
+		c.o.Fragments = c.f
+		if _, err := g.GeneratePassphrases(&c.o); !errors.Is(err, c.err) {
+			t.Errorf("%+v: expected %v, got %v", c.f, c.err, err)
+		}
+	}
+
+	// Resolved options can be passed back in
+	o, err := g.ResolveOptions(&GenerateOptions{Magic_fragment_length: 3, Fragments: FragmentPolicy{JoinType: "none"}})
+	if err != nil || o.Fragments.TargetFragmentWords != 3 || o.Magic_fragment_length != 3 {
+		t.Fatalf("Unexpected resolved options %+v, %v", o.Fragments, err)
+	}
+	if _, err := g.GeneratePassphrases(&o); err != nil {
+		t.Errorf("Error generating with resolved options: %v", err)
+	}
+}
+
+// testdata/joints.txt has 5 conjunctions
+func TestVaryJoints(t *testing.T) {
+	g, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/joints.txt"})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	if n := g.Stats().Conjunctions; n != 5 {
+		t.Fatalf("Expected 5 conjunctions, got %v", n)
+	}
+	lazy := &Generator{}
+	if err := lazy.LoadBinaryWords(convert_fixture(t, "testdata/joints.txt"), nil); err != nil {
+		t.Fatalf("Could not load binary wordlist: %v", err)
+	}
+
+	// One-word fragments that never start with a conjunction, so each fragment after the
+	// first starts with its joint
+	o := GenerateOptions{Count: 10, Length: 3, Fragments: FragmentPolicy{TargetFragmentWords: 1}, StartTypeWeights: map[string]uint{"conjunction": 0}}
+	joints := func(g *Generator, o GenerateOptions) []string {
+		p, _, err := g.GeneratePassphrasesDetailed(&o)
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		joints := []string{}
+		for _, pp := range p {
+			for _, span := range pp.FragmentSpans[1:] {
+				if pp.Types[span[0]] != "conjunction" {
+					t.Fatalf("Expected a joint at %v in %q, got %v", span[0], pp.Phrase, pp.Types)
+				}
+				joints = append(joints, pp.Words[span[0]])
+			}
+		}
+		return joints
+	}
+	distinct := func(words []string) bool {
+		seen := make(map[string]bool)
+		for _, w := range words {
+			seen[w] = true
+		}
+		return len(seen) == len(words)
+	}
+
+	repeated := false
+	for i := 0; i < 20; i++ {
+		for _, g := range []*Generator{g, lazy} {
+			o := o
+			o.VaryJoints = true
+			j := joints(g, o)
+			if len(j) != 10 || !distinct(j[:5]) || !distinct(j[5:]) {
+				t.Fatalf("Expected each conjunction once in the first 5 joints and the next 5, got %q", j)
+			}
+		}
+		repeated = repeated || !distinct(joints(g, o)[:5])
+	}
+	if !repeated {
+		t.Errorf("Expected joints to repeat without VaryJoints")
+	}
+}
@@ -0,0 +1,93 @@
+//go:build fulldata
+
+// Tests against the text wordlists in data/, which are not needed to build the package and
+// are not always present: run with go test -tags fulldata
+
+package wordentropy
+
+import (
+	"bufio"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFullWordlist(t *testing.T) {
+	var ops GenerateOptions
+
+	g, err := LoadGenerator(&WordListOptions{
+		Wordlist:  "data/part-of-speech.txt",
+		Offensive: "data/offensive.txt",
+	})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		ops.Length = uint(test_rng.int_n(int64(20)))
+		ops.Count = uint(test_rng.int_n(int64(20)))
+		_, err := g.GeneratePassphrases(&ops)
+		if err != nil {
+			t.Fatalf("Error generating passphrases (i: %v): %v", i, err)
+		}
+	}
+}
+
+// The embedded wordlists are the text ones converted
+func TestEmbeddedWordlists(t *testing.T) {
+	for name, path := range map[string]string{"full": "data/part-of-speech.txt", "common": "data/common.txt"} {
+		source, embedded := &Generator{}, &Generator{}
+		if err := source.LoadWords(&WordListOptions{Wordlist: path}); err != nil {
+			t.Fatalf("Error loading %v: %v", path, err)
+		}
+		if err := embedded.LoadEmbeddedWords(&WordListOptions{Builtin: name}); err != nil {
+			t.Fatalf("Error loading %v wordlist: %v", name, err)
+		}
+		if !reflect.DeepEqual(source.GetWordMap(), embedded.GetWordMap()) {
+			t.Errorf("Embedded %v wordlist is out of date: run go generate", name)
+		}
+	}
+}
+
+// testdata/pos_tags.txt, used by the classification tests, has every tag in the full list
+func TestPOSTagsFixture(t *testing.T) {
+	tags := func(path string) map[string]bool {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Could not open %v: %v", path, err)
+		}
+		defer f.Close()
+		tags := make(map[string]bool)
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if _, tag, ok := strings.Cut(scanner.Text(), "\t"); ok {
+				tags[tag] = true
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			t.Fatalf("Error reading %v: %v", path, err)
+		}
+		return tags
+	}
+	if full, fixture := tags("data/part-of-speech.txt"), tags("testdata/pos_tags.txt"); !reflect.DeepEqual(full, fixture) {
+		for tag := range full {
+			if !fixture[tag] {
+				t.Errorf("Tag %q missing from testdata/pos_tags.txt", tag)
+			}
+		}
+	}
+}
+
+func BenchmarkWordlistLoading(b *testing.B) {
+	wo := WordListOptions{
+		Wordlist:  "data/part-of-speech.txt",
+		Offensive: "data/offensive.txt",
+	}
+	for i := 0; i < b.N; i++ {
+		_, err := LoadGenerator(&wo)
+		if err != nil {
+			b.Fatalf("Error loading wordlist: %v\n", err)
+		}
+	}
+}
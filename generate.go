@@ -3,21 +3,32 @@ package wordentropy
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"github.com/bkeroack/libwordentropy/wordlist"
+	"io"
 	"log"
-	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 const (
-	count_max        = 99
-	count_default    = 4
-	length_max       = 99
-	length_default   = 5
-	fragment_max     = 99
-	fragment_default = 4
+	count_max             = 99
+	count_default         = 4
+	retries_default       = 100
+	length_max            = 99
+	length_default        = 5
+	fragment_max          = 99
+	fragment_default      = 4
+	symbol_length_default = 4
 )
 
 var grammar_rules = map[string][]string{ // word_type -> "can be followed by..."
@@ -34,310 +45,1310 @@ var grammar_rules = map[string][]string{ // word_type -> "can be followed by..."
 	"interjection": []string{"snoun", "pnoun", "preposition", "adjective", "conjunction", "sarticle", "particle"},
 }
 
+// Errors returned by LoadWords and passphrase generation
+var (
+	ErrWordlistRequired   = errors.New("Wordlist path is required")
+	ErrEmptyWordlist      = errors.New("Empty wordlist, call LoadWords() first")
+	ErrWordlistNotLoaded  = fmt.Errorf("%w: no wordlist has been loaded", ErrEmptyWordlist)
+	ErrCountExceedsMax    = errors.New("Count exceeds max")
+	ErrLengthExceedsMax   = errors.New("Length exceeds max")
+	ErrFragmentExceedsMax = errors.New("Fragment length exceeds max")
+	ErrInvalidFragments   = errors.New("Invalid fragment policy")
+	ErrUnknownCapitalize  = errors.New("Unknown Capitalize mode")
+	ErrRetriesExhausted   = errors.New("Could not generate a passphrase satisfying constraints within MaxRetries")
+	ErrDeadlineExceeded   = errors.New("Timeout expired before all passphrases were generated")
+	ErrInvalidWordLength  = errors.New("MinWordLength exceeds MaxWordLength")
+	ErrNoCandidates       = errors.New("No words satisfy the constraints")
+	ErrNoAllowedType      = fmt.Errorf("%w: no word type can follow without repeating the previous type", ErrNoCandidates)
+	ErrTooFewWords        = errors.New("Too few words for word type")
+	ErrInvalidBias        = errors.New("ShortWordBias must be between 0 and 1")
+	ErrInvalidDigits      = errors.New("Digits must be single characters")
+	ErrMaxBytesTooSmall   = errors.New("MaxBytes is too small for the shortest word and padding")
+	ErrWordlistTooLarge   = errors.New("Wordlist file is larger than MaxFileBytes")
+	ErrNoFiles            = errors.New("Wordlist files cannot be loaded in js/wasm builds")
+)
+
+// Error from generation with AllowPartial that failed partway, carrying the passphrases
+// generated before the failure. Its message does not include them.
+type BatchError struct {
+	Passphrases []string // passphrases generated before the failure, in order
+	Index       int      // 0-based position of the passphrase that failed (= len(Passphrases))
+	Err         error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("passphrase %v: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+var default_digits = []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
 var default_symbols = []string{"!", "@", "#", "$", "%", "^", "&", "*", "(", ")", "-", "+", "_", "="}
-var word_types = []string{"snoun", "pnoun", "verb", "adjective", "adverb", "preposition", "pronoun", "conjunction", "sarticle", "particle", "interjection"}
+var word_types = wordlist.Types()
 
 // Options for loading word list. Wordlist is required, Offensive is optional.
-// Wordlist must be formatted according to http://wordlist.aspell.net/pos-readme
-// Offensive list must be ASCII/UTF8, one word per line
+// Wordlist must be formatted according to http://wordlist.aspell.net/pos-readme, or with
+// Format "json" an object mapping word types to non-empty arrays of words.
+// Offensive list must be ASCII/UTF8, one word per line, optionally followed by a tab and a
+// severity level (default 1), then optionally a tab and comma-separated categories (e.g.
+// "slurs" or "profanity,innuendo"; the severity may be left empty). A word listed on
+// several lines takes the highest severity and every category.
 type WordListOptions struct {
-	Wordlist  string // path to POS wordlist (required)
-	Offensive string // "offensive" wordlist for optional filtering
+	Wordlist           string           // path to POS wordlist (required unless Builtin is set)
+	Builtin            string           // load a word list registered with RegisterBuiltin instead of Wordlist; the embedded package registers "full" (the one used by Quick) and "common" (a smaller curated list of common 3 to 8 letter words)
+	Offensive          string           // "offensive" wordlist for optional filtering
+	CommonPhrases      string           // common phrases for AvoidCommonPhrases, one per line
+	PruneEmptyTypes    bool             // remove word types with no words from the grammar instead of generating warnings
+	Format             string           // wordlist format: "pos" (default), "json" (as written by ExportWordMap) or "binary" (as written by ConvertWordlist)
+	IgnoreUnknownTypes bool             // skip unknown word types in JSON wordlists instead of failing
+	ExcludeProperNouns bool             // drop capitalized nouns (e.g. "Pennsylvania") from POS wordlists; all-caps acronyms are kept
+	Lazy               bool             // keep a "binary" wordlist in memory as is and copy words out only when selected
+	Strict             bool             // fail with a ParseError on the first malformed POS wordlist line instead of logging and skipping it
+	MinWordsPerType    uint             // fail with ErrTooFewWords if a word type in the grammar has fewer words (0 = no minimum)
+	Deferred           bool             // read the wordlist on first use instead of in LoadWords; load errors are returned by every later generation call
+	VerbExceptions     []string         // verbs whose number the Agreement heuristic gets wrong: listed verbs ending in "s" are plural (e.g. "bus"), others singular
+	MaxFileBytes       int64            // fail with ErrWordlistTooLarge on wordlist, offensive or common phrase files larger than this (0 = 64 MiB, negative = no limit)
+	Classification     []wordlist.Class // how POS wordlist tags map to word types, first matching row first (nil = wordlist.Classification)
+	RejectNonPrintable bool             // fail with ErrUnprintableWord on words with control, zero-width or other unprintable characters instead of stripping them
+	WatchInterval      time.Duration    // check the wordlist, offensive and common phrase files this often and reload them when one changes (0 = never); stop with Close
+	OnReload           func(err error)  // with WatchInterval, called after each reload with its error (the previous word list stays in use), or that of a file that could not be read
 }
 
 // Load wordlist from disk and return a pointer to a Generator object.
-func LoadGenerator(o *WordListOptions) (*Generator, error) {
+func LoadGenerator(o *WordListOptions) (_ *Generator, err error) {
+	defer recover_internal(&err)
 	g := Generator{}
-	err := g.LoadWords(o)
+	err = g.LoadWords(o)
 	if err != nil {
 		return nil, err
 	}
 	return &g, nil
 }
 
-// Top-level Generator object
+// Top-level Generator object.
+//
+// All methods are safe for concurrent use. Generation and queries such as GetWordMap,
+// Stats and KeyspaceUpperBound use the word list that was current when the call started, and never
+// see a partial update. LoadWords, LoadBinaryWords, LoadEmbeddedWords and AddWords run one
+// at a time and replace the word list at once; of concurrent ones, the last to finish wins.
+// Maps and slices returned are copies. The only exclusivity required is on data handed to
+// the Generator: a slice given to LoadBinaryWords must not be modified afterwards. The
+// embedded Mutex serializes word list changes and must not be held by callers. Close
+// releases a Generator that is no longer needed.
 type Generator struct {
-	word_map   map[string][]string
-	offensive  map[string]uint
-	options    *GenerateOptions
-	sync.Mutex // Used only for loading/parsing word list
+	data         atomic.Pointer[word_data]
+	deferred     atomic.Pointer[deferred_load] // load waiting for first use (nil if none)
+	options      *GenerateOptions
+	rand         io.Reader // source for every random choice: word types, words, digits and symbols (nil = crypto/rand)
+	counters     generator_counters
+	index_budget atomic.Int64 // SetDerivedIndexBudget (0 = unlimited)
+	closed       atomic.Bool  // set by Close
+	watcher      *watcher     // LoadWords with WatchInterval (nil if none); guarded by the Mutex
+	sync.Mutex                // Used only for loading/parsing word list
 }
 
 // Options for passphrase generation. All fields have sane defaults, none are required.
 type GenerateOptions struct {
-	Count                 uint     // Number of passphrases to generate
-	Length                uint     // Length in words of each passphrase
-	Magic_fragment_length uint     // Number of words per fragment
-	Prudish               bool     // Filter out words in "offensive" wordlist
-	No_spaces             bool     // Do not add spaces between words
-	Add_digit             bool     // Add a random digit to the end of each passphrase
-	Add_symbol            bool     // Add a random symbol to the end of each passphrase
-	Symbols               []string // Slice of valid symbols to use with the Add_symbol option
-}
-
-func (g *Generator) random_word(word_type string, o *GenerateOptions) string {
-	grw := func(words []string) (string, bool) {
-		word := random_choice(words)
-		_, ok := g.offensive[word]
-		return word, ok
-	}
-
-	if words, ok := g.word_map[word_type]; ok {
-		word, off := grw(words)
-		if o.Prudish && off {
-			log.Printf("Got offensive word: %v\n", word)
-			i := 0
-			for i = 0; off && i < 10; i++ {
-				word, off = grw(words)
-				if off {
-					log.Printf("Got offensive word (retry): %v\n", word)
+	Count                 uint            // Number of passphrases to generate
+	Length                uint            // Length in words of each passphrase
+	Magic_fragment_length uint            // Deprecated: use Fragments.TargetFragmentWords, which this sets if it is 0
+	Fragments             FragmentPolicy  // How passphrases are assembled from fragments
+	Prudish               bool            // Filter out words in "offensive" wordlist
+	Prudish_level         uint            // Only filter offensive words of at least this severity (0 = all); setting it implies Prudish
+	FilterCategories      []string        // Only filter offensive words in these categories of the offensive list (default all, including uncategorized words); setting it implies Prudish
+	No_spaces             bool            // Do not add spaces between words
+	Add_digit             bool            // Add a random digit to the end of each passphrase
+	Add_symbol            bool            // Add a random symbol to the end of each passphrase
+	Symbols               []string        // Slice of valid symbols to use with the Add_symbol option
+	Digits                []string        // Single-character digits to use with the Add_digit option (default 0-9)
+	MaxSymbolLength       uint            // Maximum characters per entry in Symbols (default 4)
+	Separator             string          // Separator between words (default is a single space; ignored with No_spaces); ErrInvalidParameter if a usable word starts or ends with one of its characters or contains it twice in a row
+	Lowercase             bool            // Lowercase all words (applied before Capitalize)
+	Capitalize            string          // Capitalize the first letter of "words" (every word) or "sentence" (first word only)
+	MaxChars              uint            // Maximum characters per passphrase including digit/symbol (0 = unlimited); longer passphrases are regenerated
+	MaxBytes              uint            // Maximum UTF-8 bytes per passphrase including digit/symbol (0 = unlimited); longer passphrases are regenerated
+	MaxSyllables          uint            // Maximum estimated syllables per passphrase read aloud, including digit/symbol (0 = unlimited; see Syllables); longer passphrases are regenerated
+	MaxRetries            uint            // Regeneration attempts per passphrase for constraints such as MaxChars, MaxBytes, MaxSyllables, AvoidCommonPhrases and UnambiguousConcat (default 100)
+	Timeout               time.Duration   // Stop generating after this long and return ErrDeadlineExceeded (0 = no limit)
+	MinWordLength         uint            // Only use words of at least this many characters
+	MaxWordLength         uint            // Only use words of at most this many characters (0 = unlimited)
+	NoAdjacentSameType    bool            // Never put two words of the same type next to each other, including around conjunctions
+	InsecureFastRandom    bool            // INSECURE: select words with a fast non-cryptographic generator; never use for credentials
+	AllowedTypes          []string        // Only use these word types (default all); without "conjunction", fragments are joined directly
+	StartTypeWeights      map[string]uint // Relative likelihood of each word type starting a fragment (missing types weigh 1, 0 excludes)
+	JointTypes            map[string]uint // Relative likelihood of each word type joining fragments (default conjunction only; unlisted types are not used; see also Fragments.JoinType)
+	NoJoints              bool            // Join fragments directly, without a word between them
+	VaryJoints            bool            // Use every joining word once across the passphrases of the call before repeating any (see Stats.Conjunctions)
+	AvoidCommonPhrases    bool            // Regenerate passphrases containing a phrase from the CommonPhrases list (case-insensitive; no effect if none was loaded)
+	ExtraDenyWords        []string        // Never use these words in this call, on top of Prudish (case-insensitive; a multiword entry is denied if any component word is listed)
+	UnambiguousConcat     bool            // With No_spaces, regenerate passphrases whose words can be split out of the concatenation in more than one way
+	Agreement             bool            // Make verbs agree in number with the noun before them ("otters run", "otter runs"); verb forms are guessed, see VerbExceptions
+	ShortWordBias         float64         // Prefer shorter words, from 0 (uniform) to 1 (each extra letter halves a word's likelihood); longer words stay possible
+	AllowPartial          bool            // If generation fails partway, return the passphrases generated so far with a *BatchError instead of none
+	BestOf                uint            // Generate this many candidates for each passphrase and return the highest scoring (default 1)
+	Scorer                Scorer          // Score of a BestOf candidate, higher is better (default: bits of entropy in its word choices)
+	CollectTimings        bool            // Measure the time taken by each passphrase, and spent waiting for the randomness source, in Passphrase.GenDuration and RandWait
+	Delimiter             byte            // Control character written after each passphrase by GeneratePassphrasesTo, WritePassphrases and PhraseReader (default '\n'; 0 also means the default, see NulDelimiter)
+	NulDelimiter          bool            // Write NUL after each passphrase instead of the Delimiter, for xargs -0
+	TrailingDelimiter     bool            // Write the delimiter after the last passphrase too
+}
+
+// Quality score of a generated passphrase, higher is better (see GenerateOptions.BestOf)
+type Scorer func(p Passphrase) float64
+
+// Passphrase along with the words and word types it was assembled from
+type Passphrase struct {
+	Phrase        string        // final passphrase
+	Words         []string      // individual words in order (multiword entries are split), as in the word list: without case transforms, separators or padding
+	Types         []string      // word type of each entry in Words
+	Entries       []int         // number of words in Words taken from each word list entry, in order
+	Insecure      bool          // generated with InsecureFastRandom: not suitable as a credential
+	FragmentSpans [][2]int      // word index range [start, end) of each fragment in Words; a joining word (see JointTypes) belongs to the fragment it introduces
+	Digit         string        // digit appended by Add_digit ("" if none)
+	Symbol        string        // symbol appended by Add_symbol ("" if none)
+	Syllables     int           // estimated syllables when read aloud: of Words, plus the digit and symbol as Spellout names them (see Syllables)
+	GenDuration   time.Duration // with CollectTimings, wall time spent generating the passphrase, including regenerations and RandWait
+	RandWait      time.Duration // with CollectTimings, time spent in reads from the randomness source (always 0 with InsecureFastRandom)
+}
+
+// The underlying words joined with single spaces, regardless of the case, separator and
+// padding options used for Phrase
+func (p Passphrase) Raw() string {
+	return strings.Join(p.Words, " ")
+}
+
+// Per-call generation state
+type gen_state struct {
+	o        *GenerateOptions
+	d        *word_data // word list snapshot for the call
+	warnings []Warning
+	deadline time.Time // zero if no Timeout
+	rng      int_source
+	rules    map[string][]string        // grammar rules for the call (restricted by AllowedTypes)
+	start    []string                   // word types a fragment may start with
+	weights  map[string]uint            // StartTypeWeights (nil = uniform)
+	joints   []string                   // word types that may join fragments (none = joined directly)
+	jweights map[string]uint            // JointTypes (nil = uniform)
+	avoid    map[string]uint            // words excluded by GenerateAvoiding and ExtraDenyWords, lowercased (nil = none)
+	avoided  map[string][]string        // pools with the avoided words removed, by word type
+	biased   map[string]*length_buckets // pools grouped by length for ShortWordBias, by word type
+	agreed   map[string][]string        // verb pools agreeing in number for Agreement, by word type and number
+	unused   map[string][]string        // joining words not yet used in the call for VaryJoints, by word type and number
+	key      string                     // pools_key ("" until first used)
+	drawn    uint64                     // words drawn, for Counters
+	retries  uint64                     // regenerations and backtracking redraws, for Counters
+	waited   *time.Duration             // time spent reading randomness, with CollectTimings (nil otherwise)
+}
+
+// Draw a random word of a type, agreeing with number ("singular" or "plural", "" for any;
+// see Agreement). If no word of the type satisfies the options, returns the constraint that
+// emptied the pool instead.
+func (g *Generator) random_word(word_type string, number string, s *gen_state) (string, string) {
+	words, n, constraint := s.pool(word_type, true)
+	if constraint != "" {
+		return "", constraint
+	}
+	key := word_type
+	if number != "" {
+		if words, key = s.agreeing(word_type, number, words); len(words) == 0 {
+			s.warn(WarnNoAgreeingVerbs, word_type)
+			return "", ConstraintAgreement
+		}
+	}
+	if words == nil {
+		s.drawn++
+		i := int(s.rng.int_n(int64(n)))
+		if f := s.d.filter(s.o); f.level > 0 {
+			i = skip_excluded(s.d.excluded(f)[word_type], i)
+		}
+		return s.d.lazy.word(word_type, i), ""
+	}
+
+	var word string
+	if s.o.ShortWordBias > 0 {
+		word = s.biased_choice(key, words)
+	} else {
+		word = s.choice(words)
+	}
+	s.drawn++
+	if word == "" {
+		s.warn(WarnEmptyWord, word_type)
+	}
+	return word, ""
+}
+
+// Draw a joining word for VaryJoints: a random word of a type, agreeing with number, that
+// has not joined fragments yet in the call, starting over once every word has. ShortWordBias
+// does not apply.
+func (g *Generator) vary_joint(word_type string, number string, s *gen_state) (string, string) {
+	words, n, constraint := s.pool(word_type, true)
+	if constraint != "" {
+		return "", constraint
+	}
+	key := word_type
+	if number != "" {
+		if words, key = s.agreeing(word_type, number, words); len(words) == 0 {
+			s.warn(WarnNoAgreeingVerbs, word_type)
+			return "", ConstraintAgreement
+		}
+	}
+	if s.unused == nil {
+		s.unused = make(map[string][]string)
+	}
+	unused := s.unused[key]
+	if len(unused) == 0 {
+		if words == nil {
+			// Lazy word list: copy out the words the draws would otherwise skip between
+			words = make([]string, n)
+			f := s.d.filter(s.o)
+			for i := range words {
+				j := i
+				if f.level > 0 {
+					j = skip_excluded(s.d.excluded(f)[word_type], i)
 				}
+				words[i] = s.d.lazy.word(word_type, j)
 			}
-			if i >= 10 {
-				log.Printf("Gave up trying to get non-offensive word!")
-				word = ""
+		}
+		unused = append([]string{}, words...)
+	}
+	i := int(s.rng.int_n(int64(len(unused))))
+	word := unused[i]
+	unused[i] = unused[len(unused)-1]
+	s.unused[key] = unused[:len(unused)-1]
+	s.drawn++
+	if word == "" {
+		s.warn(WarnEmptyWord, word_type)
+	}
+	return word, ""
+}
+
+// Words of a type that satisfy the options and their number, or the constraint that
+// emptied the pool (warning about it if warn is set). Words is nil when drawing directly
+// from a lazy word list, skipping any offensive words.
+func (s *gen_state) pool(word_type string, warn bool) ([]string, int, string) {
+	fail := func(w WarningType, constraint string) ([]string, int, string) {
+		if warn {
+			s.warn(w, word_type)
+		}
+		return nil, 0, constraint
+	}
+	n, ok := s.d.count(word_type)
+	if !ok {
+		return fail(WarnUnknownWordType, ConstraintUnknownWordType)
+	}
+	if n == 0 {
+		return fail(WarnEmptyWordType, ConstraintEmptyWordType)
+	}
+	filter := s.d.filter(s.o)
+	if s.d.lazy != nil && s.avoid == nil && s.o.MinWordLength == 0 && s.o.MaxWordLength == 0 && s.o.ShortWordBias == 0 && !s.o.Agreement {
+		// Offensive words are skipped when drawing rather than filtered out
+		if filter.level > 0 {
+			n -= len(s.d.excluded(filter)[word_type])
+			if n == 0 {
+				return fail(WarnPrudishExhausted, ConstraintPrudish)
 			}
 		}
-		return word
-	} else {
-		log.Printf("WARNING: random_word couldn't find word_type in word_map: %v\n", word_type)
-		return "()"
+		return nil, n, ""
+	}
+
+	words := s.d.pools(filter)[word_type]
+	if len(words) == 0 {
+		return fail(WarnPrudishExhausted, ConstraintPrudish)
 	}
+	if s.o.MinWordLength > 0 || s.o.MaxWordLength > 0 {
+		words = s.d.by_length(filter).within(word_type, s.o.MinWordLength, s.o.MaxWordLength)
+		if len(words) == 0 {
+			return fail(WarnNoMatchingWords, ConstraintWordLength)
+		}
+	}
+	if s.avoid != nil {
+		words = s.avoiding(word_type, words)
+		if len(words) == 0 {
+			return fail(WarnAvoidedExhausted, ConstraintAvoided)
+		}
+	}
+	return words, len(words), ""
 }
 
-// A fragment is an autonomous run of words constructed using grammar rules
-func (g *Generator) generate_fragment(o *GenerateOptions) []string {
-	fragment_length := o.Magic_fragment_length
+// Canonical key of the options the call's pools depend on: the offensive word filter,
+// the word length limits and the avoided words. Hashed, as there may be many avoided words.
+func (s *gen_state) pools_key() string {
+	if s.key != "" {
+		return s.key
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%v\x00%v\x00%v", s.d.filter(s.o), s.o.MinWordLength, s.o.MaxWordLength)
+	avoid := make([]string, 0, len(s.avoid))
+	for w := range s.avoid {
+		avoid = append(avoid, w)
+	}
+	sort.Strings(avoid)
+	for _, w := range avoid {
+		fmt.Fprintf(h, "\x00%v\x00%v", w, s.avoid[w])
+	}
+	s.key = hex.EncodeToString(h.Sum(nil))
+	return s.key
+}
+
+// Pool of a type without the avoided words, shared with other calls avoiding the same words
+func (s *gen_state) avoiding(word_type string, words []string) []string {
+	if pool, ok := s.avoided[word_type]; ok {
+		return pool
+	}
+	if s.avoided == nil {
+		s.avoided = make(map[string][]string)
+	}
+	pool := s.d.derived(s.pools_key()+"/avoid/"+word_type, func() interface{} {
+		pool := []string{}
+		for _, w := range words {
+			if !is_offensive(w, s.avoid) {
+				pool = append(pool, w)
+			}
+		}
+		return pool
+	}).([]string)
+	s.avoided[word_type] = pool
+	return pool
+}
+
+// A fragment is an autonomous run of words constructed using grammar rules. drawn is the
+// word types of the passphrase so far, for Agreement; its last (prev) and next are the word
+// types adjacent to the fragment ("" if none), used by NoAdjacentSameType.
+// The fragment has fragment_length entries, or fewer if they reach room words first (see
+// entry_words), in which case next is not adjacent after all.
+//
+// If no word can be placed at some position, the previous word and its type are redrawn,
+// up to MaxRetries times per fragment; after that a ConstraintError is returned, or
+// ErrDeadlineExceeded if the Timeout expires first.
+func (g *Generator) generate_fragment(s *gen_state, drawn []string, fragment_length int, next string, room int) ([]string, []string, error) {
+	prev := ""
+	if len(drawn) > 0 {
+		prev = drawn[len(drawn)-1]
+	}
 	fragment_slice := make([]string, fragment_length)
-	prev_type_index := random_range(int64(len(word_types) - 1))       // Random initial word type
-	fragment_slice[0] = g.random_word(word_types[prev_type_index], o) // Random initial word
-	this_word_type := ""
-	for i := uint(1); i < fragment_length; i++ {
-		// Get random allowed word type by type of the previous word
-		next_word_type_count := int32(len(grammar_rules[word_types[prev_type_index]]) - 1)
-		if next_word_type_count > 0 { //rand.Int31n cannot take zero as a param
-			this_word_type = grammar_rules[word_types[prev_type_index]][random_range(int64(next_word_type_count))]
-		} else {
-			this_word_type = grammar_rules[word_types[prev_type_index]][0]
+	type_slice := make([]string, fragment_length)
+	filled := make([]int, fragment_length+1)         // words in the entries before each position
+	dead := make([]map[string]bool, fragment_length) // types that failed at each position
+	var failure *ConstraintError
+	backtracks := uint(0)
+
+	for i := 0; i < fragment_length; {
+		before := prev
+		candidates := s.start // Random initial word type
+		weights := s.weights
+		if i > 0 {
+			// Allowed word types by type of the previous word
+			before = type_slice[i-1]
+			candidates = s.rules[before]
+			weights = nil
 		}
-		fragment_slice[i] = g.random_word(this_word_type, o) //Random word of the allowed random type
-		for j, v := range word_types {                       // Update previous word type with current word type for next iteration
-			if v == this_word_type {
-				prev_type_index = int64(j)
+		if s.o.NoAdjacentSameType {
+			if i == fragment_length-1 {
+				candidates = exclude_types(candidates, before, next)
+			} else {
+				candidates = exclude_types(candidates, before)
+			}
+			if len(candidates) == 0 {
+				failure = &ConstraintError{Constraint: ConstraintNoAdjacentSameType, WordType: before}
+			}
+		} else if len(candidates) == 0 {
+			failure = &ConstraintError{Constraint: ConstraintGrammar, WordType: before}
+		}
+		for t := range dead[i] {
+			candidates = exclude_types(candidates, t)
+		}
+
+		placed := false
+		for len(candidates) > 0 {
+			this_word_type := s.weighted_choice(candidates, weights)
+			number := s.agreement(this_word_type, append(drawn[:len(drawn):len(drawn)], type_slice[:i]...))
+			word, constraint := g.random_word(this_word_type, number, s) //Random word of the allowed random type
+			if constraint == "" {
+				fragment_slice[i] = word
+				type_slice[i] = this_word_type
+				placed = true
+				break
+			}
+			failure = &ConstraintError{Constraint: constraint, WordType: this_word_type}
+			if dead[i] == nil {
+				dead[i] = make(map[string]bool)
+			}
+			dead[i][this_word_type] = true
+			candidates = exclude_types(candidates, this_word_type)
+		}
+		if placed {
+			filled[i+1] = filled[i] + entry_words(fragment_slice[i])
+			i++
+			if filled[i] >= room {
+				return fragment_slice[:i], type_slice[:i], nil
+			}
+			if i < fragment_length {
+				dead[i] = nil
 			}
+			continue
+		}
+
+		// Backtrack: redraw the previous word and type
+		if i == 0 || backtracks >= s.o.MaxRetries {
+			return nil, nil, failure
+		}
+		if s.expired() {
+			return nil, nil, ErrDeadlineExceeded
+		}
+		backtracks++
+		s.retries++
+		dead[i] = nil
+		i--
+		if dead[i] == nil {
+			dead[i] = make(map[string]bool)
 		}
+		dead[i][type_slice[i]] = true
 	}
-	return fragment_slice
+	return fragment_slice, type_slice, nil
 }
 
-func (g *Generator) generate_passphrase(o *GenerateOptions) []string {
-	iterations := o.Length / o.Magic_fragment_length
-	phrase_slice := make([]string, 1)
+// Word types in candidates other than the excluded ones
+func exclude_types(candidates []string, exclude ...string) []string {
+	out := make([]string, 0, len(candidates))
+	for _, t := range candidates {
+		keep := true
+		for _, e := range exclude {
+			if t == e {
+				keep = false
+			}
+		}
+		if keep {
+			out = append(out, t)
+		}
+	}
+	return out
+}
 
-	phrase_slice = append(phrase_slice, g.generate_fragment(o)...)
-	if iterations >= 1 {
-		for i := uint(1); i <= iterations; i++ {
-			phrase_slice = append(phrase_slice, g.random_word("conjunction", o))
-			phrase_slice = append(phrase_slice, g.generate_fragment(o)...)
+// Word list entries drawn for a passphrase, before post-processing
+type raw_passphrase struct {
+	entries   []string
+	types     []string // word type of each entry
+	fragments []int    // number of entries in each fragment, including the word joining it to the previous one
+}
+
+// Generate fragments as documented on FragmentPolicy, joined by a word of one of the joint
+// types (conjunctions by default) or directly
+func (g *Generator) generate_passphrase(s *gen_state) (raw_passphrase, error) {
+	var r raw_passphrase
+	length := int(s.o.Length)
+	words := 0
+	joint := "" // joint type for the seam before the fragment
+	for words < length {
+		fragment_length := s.fragment_length()
+		next := s.seam()
+		start := len(r.entries)
+		if start > 0 && joint != "" && length-words > 1 {
+			draw := g.random_word
+			if s.o.VaryJoints {
+				draw = g.vary_joint
+			}
+			if word, constraint := draw(joint, s.agreement(joint, r.types), s); constraint == "" {
+				r.entries = append(r.entries, word)
+				r.types = append(r.types, joint)
+				words += entry_words(word)
+			}
 		}
+		if words < length {
+			fw, ft, err := g.generate_fragment(s, r.types, fragment_length, next, length-words)
+			if err != nil {
+				return raw_passphrase{}, err
+			}
+			r.entries = append(r.entries, fw...)
+			r.types = append(r.types, ft...)
+			for _, w := range fw {
+				words += entry_words(w)
+			}
+		}
+		r.fragments = append(r.fragments, len(r.entries)-start)
+		joint = next
 	}
-	return phrase_slice
+	return r, nil
 }
 
-// Load and parse word list into memory.
-func (g *Generator) LoadWords(o *WordListOptions) error {
-	var err error
+// Load and parse word list into memory. With Deferred, only the options are checked and
+// the word list is loaded by the first call that needs it.
+func (g *Generator) LoadWords(o *WordListOptions) (err error) {
+	defer recover_internal(&err)
+	switch {
+	case o.Wordlist == "" && o.Builtin == "":
+		return ErrWordlistRequired
+	case o.Wordlist != "" && o.Builtin != "":
+		return fmt.Errorf("%w: builtin: cannot be used with a wordlist path", ErrInvalidParameter)
+	case o.Builtin != "":
+		if _, err := builtin_wordlist(o.Builtin); err != nil {
+			return err
+		}
+	}
+	if err := check_paths(o); err != nil {
+		return err
+	}
+	if g.closed.Load() {
+		return ErrClosed
+	}
+	var w *watcher
+	if o.WatchInterval > 0 {
+		w = new_watcher(o) // before loading, so changes made meanwhile are not missed
+	}
+	if o.Deferred {
+		switch o.Format {
+		case "", "pos", "json", "binary":
+		default:
+			return fmt.Errorf("%w: %v", ErrUnknownFormat, o.Format)
+		}
+		l := &deferred_load{o: *o}
+		l.o.Deferred = false
+		g.Lock()
+		g.deferred.Store(l)
+		g.set_watcher(w)
+		g.Unlock()
+		return nil
+	}
+	g.Lock()
+	g.deferred.Store(nil)
+	g.Unlock()
+	if err := g.load_words(o); err != nil {
+		return err
+	}
+	if w != nil {
+		g.Lock()
+		g.set_watcher(w)
+		g.Unlock()
+	}
+	return nil
+}
 
+func (g *Generator) load_words(o *WordListOptions) error {
+	if o.Builtin != "" {
+		data, err := builtin_wordlist(o.Builtin)
+		if err != nil {
+			return err
+		}
+		return g.load_binary(data, "", o)
+	}
+	switch o.Format {
+	case "", "pos":
+		return g.load_provider(&POSFileProvider{Path: o.Wordlist, ExcludeProperNouns: o.ExcludeProperNouns, Strict: o.Strict, MaxFileBytes: o.MaxFileBytes, Classification: o.Classification}, o)
+	case "json":
+		word_map, err := load_json_wordmap(o.Wordlist, o.IgnoreUnknownTypes, o.MaxFileBytes)
+		if err != nil {
+			return err
+		}
+		return g.load_provider(MapProvider(word_map), o)
+	case "binary":
+		if o.Lazy {
+			data, err := read_limited(o.Wordlist, o.MaxFileBytes)
+			if err != nil {
+				return err
+			}
+			return g.load_binary(data, o.Wordlist, o)
+		}
+		word_map, err := load_binary_wordmap(o.Wordlist, o.MaxFileBytes)
+		if err != nil {
+			return err
+		}
+		return g.load_provider(MapProvider(word_map), o)
+	default:
+		return fmt.Errorf("%w: %v", ErrUnknownFormat, o.Format)
+	}
+}
+
+// Snapshot words from a provider, apply word list options and publish the result
+func (g *Generator) load_provider(p WordProvider, o *WordListOptions) error {
 	g.Lock()
 	defer g.Unlock()
 
-	if o.Wordlist != "" {
-		g.word_map, err = load_wordmap(o.Wordlist)
+	word_map, err := snapshot_provider(p)
+	if err != nil {
+		return err
+	}
+	d := &word_data{word_map: word_map}
+	if pp, ok := p.(*POSFileProvider); ok {
+		d.proper_excluded = pp.proper_excluded
+	}
+	return g.publish(d, o)
+}
+
+// Add words of a type to the word list, e.g. site-specific vocabulary. The words are added
+// to a copy of the current list, which then replaces it as a reload would; words already
+// listed are skipped. The offensive and common phrase lists and VerbExceptions stay in effect, and with
+// PruneEmptyTypes the grammar is pruned again.
+func (g *Generator) AddWords(word_type string, words ...string) (err error) {
+	defer recover_internal(&err)
+	if _, ok := grammar_rules[word_type]; !ok {
+		return fmt.Errorf("%w: %v", ErrUnknownWordType, word_type)
+	}
+	g.words() // run any deferred load first, as it takes the lock
+	g.Lock()
+	defer g.Unlock()
+
+	if g.closed.Load() {
+		return ErrClosed
+	}
+	d := g.words()
+	if l := g.deferred.Load(); l != nil && l.err != nil {
+		return l.err
+	}
+	if !d.loaded() {
+		return ErrWordlistNotLoaded
+	}
+	word_map := make(MapProvider, len(word_types))
+	for t, l := range d.all() {
+		word_map[t] = l
+	}
+	word_map[word_type] = append(append([]string{}, word_map[word_type]...), words...)
+	snapshot, err := snapshot_provider(word_map)
+	if err != nil {
+		return err
+	}
+	nd := &word_data{
+		word_map:           snapshot,
+		offensive:          d.offensive,
+		categories:         d.categories,
+		common:             d.common,
+		verb_exceptions:    d.verb_exceptions,
+		proper_excluded:    d.proper_excluded,
+		reject_unprintable: d.reject_unprintable,
+		warnings:           d.warnings,
+	}
+	if err := sanitize_words(nd, nd.reject_unprintable); err != nil {
+		return err
+	}
+	if d.grammar != nil {
+		nd.grammar, nd.types, nd.pruned, err = prune_grammar(grammar_rules, nd)
 		if err != nil {
 			return err
 		}
-	} else {
-		return errors.New("Wordlist path is required")
 	}
+	nd.usage.budget = &g.index_budget
+	g.data.Store(nd)
+	return nil
+}
+
+// Keep a binary wordlist in memory as is, copying words out only when they are selected.
+// The data must not be modified afterwards.
+func (g *Generator) LoadBinaryWords(data []byte, o *WordListOptions) (err error) {
+	defer recover_internal(&err)
+	if o == nil {
+		o = &WordListOptions{}
+	}
+	return g.load_binary(data, "", o)
+}
+
+func (g *Generator) load_binary(data []byte, path string, o *WordListOptions) error {
+	g.Lock()
+	defer g.Unlock()
+
+	lw, err := index_binary(data)
+	if err != nil {
+		if path != "" {
+			return &ParseError{Path: path, Reason: err.Error(), Err: err}
+		}
+		return err
+	}
+	if lw.total() == 0 {
+		return ErrEmptyWordlist
+	}
+	return g.publish(&word_data{lazy: lw}, o)
+}
 
+// Apply word list options to a new snapshot and make it current. Called with g locked.
+func (g *Generator) publish(d *word_data, o *WordListOptions) error {
+	if g.closed.Load() {
+		return ErrClosed
+	}
+	var err error
+	d.reject_unprintable = o.RejectNonPrintable
+	if err := sanitize_words(d, o.RejectNonPrintable); err != nil {
+		return err
+	}
 	if o.Offensive != "" {
-		g.offensive, err = load_offensive_words(o.Offensive)
+		d.offensive, d.categories, err = load_offensive_words(o.Offensive, o.MaxFileBytes)
+		if err != nil {
+			return err
+		}
+	}
+	if len(o.VerbExceptions) > 0 {
+		d.verb_exceptions = make(map[string]bool, len(o.VerbExceptions))
+		for _, v := range o.VerbExceptions {
+			d.verb_exceptions[strings.ToLower(strings.TrimSpace(v))] = true
+		}
+	}
+	if o.CommonPhrases != "" {
+		d.common, err = load_common_phrases(o.CommonPhrases, o.MaxFileBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.PruneEmptyTypes {
+		d.grammar, d.types, d.pruned, err = prune_grammar(grammar_rules, d)
 		if err != nil {
 			return err
 		}
 	}
 
+	if o.MinWordsPerType > 0 {
+		if err := check_min_words(d, o.MinWordsPerType); err != nil {
+			return err
+		}
+	}
+
+	g.deferred.Store(nil) // a deferred load would replace this one
+	g.set_watcher(nil)    // nor should a reload of the files watched before
+	d.usage.budget = &g.index_budget
+	d.warnings = sanity_warnings(d)
+	g.data.Store(d)
 	return nil
 }
 
-func (g *Generator) check_options(o *GenerateOptions) error {
-	if o == nil {
-		o = &GenerateOptions{}
+// Check that every word type left in the grammar has at least min words, listing those
+// that do not
+func check_min_words(d *word_data, min uint) error {
+	var deficient []string
+	for _, t := range word_types {
+		if _, ok := d.rules()[t]; !ok {
+			continue
+		}
+		if n, _ := d.count(t); uint(n) < min {
+			deficient = append(deficient, fmt.Sprintf("%v (%v)", t, n))
+		}
+	}
+	if deficient != nil {
+		return fmt.Errorf("%w: minimum %v: %v", ErrTooFewWords, min, strings.Join(deficient, ", "))
 	}
-	if len(g.word_map) == 0 {
-		return fmt.Errorf("Empty wordlist, call LoadWords() first")
+	return nil
+}
+
+// Validate options and return a copy with defaults filled in. The caller's struct is
+// never modified.
+func (g *Generator) check_options(options *GenerateOptions) (GenerateOptions, error) {
+	var o GenerateOptions
+	if options != nil {
+		o = *options
+	}
+	if g.closed.Load() {
+		return o, ErrClosed
+	}
+	d := g.words()
+	if l := g.deferred.Load(); l != nil && l.err != nil {
+		return o, l.err
+	}
+	if !d.loaded() {
+		return o, ErrWordlistNotLoaded
+	}
+	if o.Prudish_level > 0 || len(o.FilterCategories) > 0 {
+		o.Prudish = true
+	}
+	if len(o.FilterCategories) > 0 {
+		categories, err := d.filter_categories(o.FilterCategories)
+		if err != nil {
+			return o, err
+		}
+		o.FilterCategories = categories
 	}
 	if o.Count > count_max {
-		return fmt.Errorf("Count exceeds max: %v", count_max)
+		return o, fmt.Errorf("%w: %v", ErrCountExceedsMax, count_max)
 	}
 	if o.Count == 0 {
 		o.Count = count_default
 	}
 	if o.Length > length_max {
-		return fmt.Errorf("Length exceeds max: %v", length_max)
+		return o, fmt.Errorf("%w: %v", ErrLengthExceedsMax, length_max)
 	}
 	if o.Length == 0 {
 		o.Length = length_default
 	}
-	if o.Magic_fragment_length > fragment_max {
-		return fmt.Errorf("Fragment length exceeds max: %v", fragment_max)
+	f := &o.Fragments
+	if f.TargetFragmentWords == 0 {
+		f.TargetFragmentWords = o.Magic_fragment_length
+	} else if o.Magic_fragment_length != 0 && o.Magic_fragment_length != f.TargetFragmentWords {
+		return o, fmt.Errorf("%w: Magic_fragment_length %v conflicts with TargetFragmentWords %v", ErrInvalidFragments, o.Magic_fragment_length, f.TargetFragmentWords)
 	}
-	if o.Magic_fragment_length == 0 {
-		o.Magic_fragment_length = fragment_default
+	if f.TargetFragmentWords > fragment_max {
+		return o, fmt.Errorf("%w: %v", ErrFragmentExceedsMax, fragment_max)
+	}
+	if f.TargetFragmentWords == 0 {
+		f.TargetFragmentWords = fragment_default
+	}
+	o.Magic_fragment_length = f.TargetFragmentWords
+	if f.Jitter >= f.TargetFragmentWords {
+		return o, fmt.Errorf("%w: Jitter %v must be less than TargetFragmentWords %v", ErrInvalidFragments, f.Jitter, f.TargetFragmentWords)
+	}
+	if f.JoinType != "" {
+		if _, ok := grammar_rules[f.JoinType]; !ok && f.JoinType != "none" {
+			return o, fmt.Errorf("%w: %v", ErrUnknownWordType, f.JoinType)
+		}
+		if len(o.JointTypes) > 0 || o.NoJoints {
+			return o, fmt.Errorf("%w: JoinType cannot be combined with JointTypes or NoJoints", ErrInvalidFragments)
+		}
 	}
 	if len(o.Symbols) == 0 {
 		o.Symbols = default_symbols
 	}
-	return nil
+	if o.MaxSymbolLength == 0 {
+		o.MaxSymbolLength = symbol_length_default
+	}
+	if err := CheckSymbols(o.Symbols, o.MaxSymbolLength); err != nil {
+		return o, err
+	}
+	if len(o.Digits) == 0 {
+		o.Digits = default_digits
+	}
+	for _, d := range o.Digits {
+		if utf8.RuneCountInString(d) != 1 || !is_printable(d) {
+			return o, fmt.Errorf("%w: %q", ErrInvalidDigits, d)
+		}
+	}
+	if o.Separator == "" {
+		o.Separator = " "
+	}
+	if !is_printable(o.Separator) {
+		return o, fmt.Errorf("%w: Separator %q contains unprintable characters", ErrInvalidParameter, o.Separator)
+	}
+	delimiter, err := check_delimiter(&o)
+	if err != nil {
+		return o, err
+	}
+	if !o.NulDelimiter {
+		o.Delimiter = delimiter
+	}
+	if o.MaxRetries == 0 {
+		o.MaxRetries = retries_default
+	}
+	if o.MaxWordLength > 0 && o.MinWordLength > o.MaxWordLength {
+		return o, ErrInvalidWordLength
+	}
+	if !(o.ShortWordBias >= 0 && o.ShortWordBias <= 1) {
+		return o, fmt.Errorf("%w: %v", ErrInvalidBias, o.ShortWordBias)
+	}
+	for _, t := range o.AllowedTypes {
+		if _, ok := grammar_rules[t]; !ok {
+			return o, fmt.Errorf("%w: %v", ErrUnknownWordType, t)
+		}
+	}
+	for t := range o.StartTypeWeights {
+		if _, ok := grammar_rules[t]; !ok {
+			return o, fmt.Errorf("%w: %v", ErrUnknownWordType, t)
+		}
+	}
+	for t := range o.JointTypes {
+		if _, ok := grammar_rules[t]; !ok {
+			return o, fmt.Errorf("%w: %v", ErrUnknownWordType, t)
+		}
+	}
+	switch o.Capitalize {
+	case "", "words", "sentence":
+	default:
+		return o, fmt.Errorf("%w: %v", ErrUnknownCapitalize, o.Capitalize)
+	}
+	return o, nil
 }
 
-// Generate and return passphrases according to options provided.
-func (g *Generator) GeneratePassphrases(options *GenerateOptions) ([]string, error) {
-	// Generate count passphrase slices
-	// Merge each passphrase slice into a single string
-	// Split string by spaces (individual random "words" can actually be multiword phrases)
-	// Truncate slice to length words
-	// Merge truncated slice back into string
-	// Return slice of strings (final random passphrases)
+// Return the options that would be used for a generation call, with defaults filled in,
+// without generating anything.
+func (g *Generator) ResolveOptions(o *GenerateOptions) (_ GenerateOptions, err error) {
+	defer recover_internal(&err)
+	return g.check_options(o)
+}
 
-	err := g.check_options(options)
-	if err != nil {
+// Generate and return passphrases according to options provided. If Timeout expires after
+// some passphrases were generated, they are returned along with ErrDeadlineExceeded. With
+// AllowPartial, any failure partway returns the passphrases so far with a *BatchError.
+func (g *Generator) GeneratePassphrases(options *GenerateOptions) (_ []string, err error) {
+	defer recover_internal(&err)
+	p, warnings, err := g.GeneratePassphrasesDetailed(options)
+	for _, w := range warnings {
+		log.Printf("WARNING: %v\n", w)
+	}
+	if p == nil {
 		return nil, err
 	}
-	passphrases := make([]string, options.Count)
+	return phrases(p), err
+}
 
-	var sep string
-	if options.No_spaces {
-		sep = ""
-	} else {
-		sep = " "
+// Final passphrase strings of p
+func phrases(p []Passphrase) []string {
+	passphrases := make([]string, len(p))
+	for i := range p {
+		passphrases[i] = p[i].Phrase
 	}
-	for i := uint(0); i < options.Count; i++ {
-		ps := g.generate_passphrase(options)
-		pj := strings.Join(ps, " ")
-		ps = strings.Split(pj, " ")
-		ps = ps[:options.Length+1]
-		pp := strings.TrimSpace(strings.Join(ps, sep))
-		if options.Add_digit {
-			pp += random_digit()
-		}
-		if options.Add_symbol {
-			pp += random_choice(options.Symbols)
+	return passphrases
+}
+
+// Generate passphrases according to options provided, returning per-word detail and any
+// non-fatal anomalies encountered along the way. If Timeout expires after some passphrases
+// were generated, they are returned along with ErrDeadlineExceeded, and with AllowPartial
+// after any failure along with a *BatchError.
+func (g *Generator) GeneratePassphrasesDetailed(o *GenerateOptions) ([]Passphrase, []Warning, error) {
+	return g.generate(o, nil)
+}
+
+// Generate passphrases without words in the avoid set (nil = no exclusions)
+func (g *Generator) generate(o *GenerateOptions, avoid map[string]uint) (passphrases []Passphrase, _ []Warning, err error) {
+	var s *gen_state
+	defer func(start time.Time) {
+		if g != nil {
+			g.counters.record(s, len(passphrases), err, start)
 		}
-		passphrases[i] = pp
+	}(time.Now())
+	defer recover_internal(&err)
+	s, err = g.prepare(o, g.words(), avoid)
+	if err != nil {
+		return nil, nil, err
 	}
-	return passphrases, nil
+	return g.run(s)
 }
 
-func load_offensive_words(p string) (map[string]uint, error) {
-	offensive := make(map[string]uint)
-
-	f, err := os.Open(p)
+// Validate options and set up generation state from word data d without generating anything
+func (g *Generator) prepare(o *GenerateOptions, d *word_data, avoid map[string]uint) (*gen_state, error) {
+	options, err := g.check_options(o)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	s := &gen_state{o: &options, d: d, rng: g.source(&options), avoid: with_denied(avoid, options.ExtraDenyWords)}
+	if options.CollectTimings {
+		s.collect_timings()
+	}
+	s.rules, s.start, err = restrict_to_allowed(s.d.rules(), s.d.start_types(), &options)
+	if err != nil {
+		return nil, err
+	}
+	s.start, s.weights, err = weight_start_types(s.start, &options)
+	if err != nil {
+		return nil, err
+	}
+	s.joints, s.jweights, err = joint_types(s.rules, &options)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.check_separator(); err != nil {
+		return nil, err
+	}
+	if options.MaxBytes > 0 {
+		if min := s.min_bytes(); min > options.MaxBytes {
+			return nil, fmt.Errorf("%w: need at least %v bytes", ErrMaxBytesTooSmall, min)
+		}
+	}
+	if len(options.JointTypes) > 0 || options.Fragments.JoinType != "" {
+		field := "JointTypes"
+		if options.Fragments.JoinType != "" {
+			field = "JoinType"
+		}
+		for _, t := range s.joints {
+			if _, _, constraint := s.pool(t, false); constraint != "" {
+				return nil, fmt.Errorf("%w: %v %v has no usable words (%v)", ErrTooFewWords, field, t, constraint)
+			}
+		}
+	}
+	return s, nil
+}
 
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		l := scanner.Text()
-		offensive[strings.TrimSpace(l)] = 1
+// Generate the passphrases for prepared state s. The Timeout starts counting here.
+func (g *Generator) run(s *gen_state) ([]Passphrase, []Warning, error) {
+	if s.o.Timeout > 0 {
+		s.deadline = time.Now().Add(s.o.Timeout)
 	}
-	return offensive, nil
+	passphrases := make([]Passphrase, 0, s.o.Count)
+
+	for i := uint(0); i < s.o.Count; i++ {
+		var start time.Time
+		var before time.Duration
+		if s.waited != nil {
+			start, before = time.Now(), *s.waited
+		}
+		p, err := g.generate_one(s)
+		if err != nil {
+			if s.o.AllowPartial {
+				return passphrases, s.warnings, &BatchError{Passphrases: phrases(passphrases), Index: int(i), Err: err}
+			}
+			if err == ErrDeadlineExceeded && len(passphrases) > 0 {
+				return passphrases, s.warnings, err
+			}
+			return nil, s.warnings, err
+		}
+		if s.waited != nil {
+			p.GenDuration, p.RandWait = time.Since(start), *s.waited-before
+		}
+		passphrases = append(passphrases, p)
+	}
+	return passphrases, s.warnings, nil
 }
 
-//Load word list into a mapping of word type to words of that type
-func load_wordmap(p string) (map[string][]string, error) {
+// Generate a single passphrase: the highest scoring of BestOf candidates, each regenerated
+// until it satisfies the constraints in the options. Regenerations for all candidates count
+// against MaxRetries; if retries or time run out after a candidate was found, the best so
+// far is returned.
+func (g *Generator) generate_one(s *gen_state) (Passphrase, error) {
+	best_of := max(s.o.BestOf, 1)
+	var best Passphrase
+	best_score := 0.0
+	found, retries := uint(0), uint(0)
+	for found < best_of {
+		if s.expired() {
+			if found > 0 {
+				break
+			}
+			return Passphrase{}, ErrDeadlineExceeded
+		}
+		r, err := g.generate_passphrase(s)
+		if err == ErrDeadlineExceeded && found > 0 {
+			break
+		}
+		if err != nil {
+			return Passphrase{}, err
+		}
+		p, ok := post_process(r, s.o, s.rng)
+		if ok && s.o.AvoidCommonPhrases && s.d.common.contains(p.Words) {
+			ok = false
+		}
+		if ok && s.ambiguous(p) {
+			ok = false
+		}
+		if !ok {
+			if retries == s.o.MaxRetries {
+				if found > 0 {
+					break
+				}
+				return Passphrase{}, ErrRetriesExhausted
+			}
+			retries++
+			s.retries++
+			continue
+		}
+		found++
+		if best_of == 1 {
+			return p, nil
+		}
+		if score := s.score(p); found == 1 || score > best_score {
+			best, best_score = p, score
+		}
+	}
+	return best, nil
+}
+
+// Whether the Timeout of the call has expired
+func (s *gen_state) expired() bool {
+	return !s.deadline.IsZero() && time.Now().After(s.deadline)
+}
+
+// Score of a BestOf candidate
+func (s *gen_state) score(p Passphrase) float64 {
+	if s.o.Scorer != nil {
+		return s.o.Scorer(p)
+	}
+	return s.measured_entropy(p)
+}
 
-	word_map := map[string][]string{
-		"snoun":        []string{},
-		"pnoun":        []string{},
-		"verb":         []string{},
-		"adjective":    []string{},
-		"adverb":       []string{},
-		"preposition":  []string{},
-		"pronoun":      []string{},
-		"conjunction":  []string{},
-		"sarticle":     []string{},
-		"particle":     []string{},
-		"interjection": []string{},
+// Bits of entropy in the word choices of a passphrase: the sum over its entries of the
+// choice among the words of the entry's type that satisfy the options (the Shannon entropy
+// of the biased choice with ShortWordBias)
+func (s *gen_state) measured_entropy(p Passphrase) float64 {
+	bits := 0.0
+	i := 0
+	for _, n := range p.Entries {
+		words, size, _ := s.pool(p.Types[i], false)
+		key := p.Types[i]
+		if number := s.agreement(p.Types[i], p.Types[:i]); number != "" {
+			words, key = s.agreeing(p.Types[i], number, words)
+			size = len(words)
+		}
+		if s.o.ShortWordBias > 0 && words != nil {
+			bits += s.buckets(key, words).entropy
+		} else {
+			bits += choice_entropy(size)
+		}
+		i += n
 	}
+	return bits
+}
+
+// Load an offensive wordlist: one word per line, optionally followed by a tab and a
+// severity level (default 1)
+func load_offensive_words(p string, max_bytes int64) (map[string]uint, map[string][]string, error) {
+	offensive := make(map[string]uint)
+	var categories map[string][]string
 
-	file, err := os.Open(p)
+	f, err := open_limited(p, max_bytes)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	defer file.Close()
+	defer f.Close()
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(f)
+	n := 0
 	for scanner.Scan() {
-		word_type := ""
-		plural := false
-		line := scanner.Text()
-		line_array := strings.Split(line, "\t")
-		if len(line_array) != 2 {
-			log.Printf("Bad string array length: %v, string: %v", len(line_array), line)
+		n++
+		fields := strings.Split(scanner.Text(), "\t")
+		word := strings.ToLower(strings.TrimSpace(fields[0]))
+		if word == "" {
 			continue
 		}
-		word := line_array[0]
-		pos_tag := line_array[1]
-		if strings.Contains(pos_tag, "N") || strings.Contains(pos_tag, "D") || strings.Contains(pos_tag, "I") {
-			if strings.Contains(pos_tag, "P") {
-				plural = true
+		columns := fields[1:]
+		if len(columns) > 2 {
+			return nil, nil, &ParseError{Path: p, Line: n, Reason: "too many columns"}
+		}
+		severity := uint64(1)
+		if len(columns) == 1 || len(columns) == 2 && strings.TrimSpace(columns[0]) != "" {
+			level := columns[0]
+			severity, err = strconv.ParseUint(strings.TrimSpace(level), 10, 32)
+			if err != nil || severity == 0 {
+				return nil, nil, &ParseError{Path: p, Line: n, Reason: fmt.Sprintf("bad severity level %v", wordlist.Excerpt(level))}
 			}
 		}
-		if strings.Contains(pos_tag, "D") || strings.Contains(pos_tag, "I") {
-			if plural {
-				word_type = "particle"
-			} else {
-				word_type = "sarticle"
+		offensive[word] = max(offensive[word], uint(severity))
+		if len(columns) < 2 {
+			continue
+		}
+		for _, c := range strings.Split(columns[1], ",") {
+			c = strings.ToLower(strings.TrimSpace(c))
+			if !is_category(c) {
+				return nil, nil, &ParseError{Path: p, Line: n, Reason: fmt.Sprintf("bad category %v", wordlist.Excerpt(columns[1]))}
 			}
-		} else if strings.Contains(pos_tag, "N") || strings.Contains(pos_tag, "h") || strings.Contains(pos_tag, "o") {
-			if plural {
-				word_type = "pnoun"
-			} else {
-				word_type = "snoun"
+			if categories == nil {
+				categories = make(map[string][]string)
+			}
+			listed := categories[word]
+			if i := sort.SearchStrings(listed, c); i == len(listed) || listed[i] != c {
+				listed = append(listed, c)
+				sort.Strings(listed)
+				categories[word] = listed
 			}
-		} else if strings.Contains(pos_tag, "V") || strings.Contains(pos_tag, "t") || strings.Contains(pos_tag, "i") {
-			word_type = "verb"
-		} else if strings.Contains(pos_tag, "A") {
-			word_type = "adjective"
-		} else if strings.Contains(pos_tag, "v") {
-			word_type = "adverb"
-		} else if strings.Contains(pos_tag, "C") {
-			word_type = "conjunction"
-		} else if strings.Contains(pos_tag, "p") || strings.Contains(pos_tag, "P") {
-			word_type = "preposition"
-		} else if strings.Contains(pos_tag, "r") {
-			word_type = "pronoun"
-		} else if strings.Contains(pos_tag, "!") {
-			word_type = "interjection"
-		} else {
-			log.Printf("Unknown word type! word: %v; pos: %v\n", word, pos_tag)
-			continue
 		}
-		if len(word) > 0 {
-			word_map[word_type] = append(word_map[word_type], word)
-		} else {
-			log.Printf("WARNING: got zero length word: line: %v (interpreted type: %v)", line, word_type)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, &ParseError{Path: p, Line: n + 1, Reason: err.Error(), Err: err}
+	}
+	return offensive, categories, nil
+}
+
+// Whether c is a valid offensive word category name: letters, digits, underscores and
+// hyphens, starting with a letter
+func is_category(c string) bool {
+	for i, r := range c {
+		if !(unicode.IsLetter(r) || i > 0 && (unicode.IsDigit(r) || r == '_' || r == '-')) {
+			return false
+		}
+	}
+	return c != ""
+}
+
+// Check a word map entry against the offensive set. Comparison is case-insensitive and
+// multiword entries are offensive if the whole entry or any component word is listed.
+func is_offensive(word string, offensive map[string]uint) bool {
+	return offensive_level(word, offensive) > 0
+}
+
+// Highest severity of a word map entry or its component words in the offensive set
+// (0 if none are listed)
+func offensive_level(word string, offensive map[string]uint) uint {
+	word = strings.ToLower(word)
+	level := offensive[word]
+	for _, c := range strings.FieldsFunc(word, func(r rune) bool { return r == ' ' || r == '-' }) {
+		level = max(level, offensive[c])
+	}
+	return level
+}
+
+// Build a copy of the word map with the entries offends reports removed, along with the
+// number of entries removed from each word type
+func prefilter_offensive(word_map map[string][]string, offends func(string) bool) (map[string][]string, map[string]uint) {
+	prudish_map := make(map[string][]string, len(word_map))
+	filtered := make(map[string]uint, len(word_map))
+	for word_type, words := range word_map {
+		clean := make([]string, 0, len(words))
+		for _, w := range words {
+			if !offends(w) {
+				clean = append(clean, w)
+			}
 		}
+		prudish_map[word_type] = clean
+		filtered[word_type] = uint(len(words) - len(clean))
+	}
+	return prudish_map, filtered
+}
 
+// Load the POS word list at p.Path into a mapping of word type to words of that type.
+// Returns the number of proper nouns dropped.
+func load_wordmap(p wordlist.Parser, max_bytes int64) (map[string][]string, uint, error) {
+	file, err := open_limited(p.Path, max_bytes)
+	if err != nil {
+		return nil, 0, err
 	}
+	defer file.Close()
+	return parse_wordmap(file, p)
+}
 
-	return word_map, nil
+// Parse a POS wordlist into a map of word type to words. Malformed lines are logged and
+// skipped, or returned as an error if p is strict.
+func parse_wordmap(r io.Reader, p wordlist.Parser) (map[string][]string, uint, error) {
+	word_map, rep, err := p.ParsePOS(r)
+	for _, pe := range rep.Malformed {
+		log.Print(pe)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return word_map, rep.ProperNouns, nil
 }
 
-// Get parsed wordlist as map of word type to words of that type
+// Get parsed wordlist as map of word type to words of that type. The map is a copy, so
+// changing it does not affect the Generator.
 func (g *Generator) GetWordMap() map[string][]string {
-	return g.word_map
+	all := g.words().all()
+	word_map := make(map[string][]string, len(all))
+	for t, words := range all {
+		word_map[t] = append([]string{}, words...)
+	}
+	return word_map
+}
+
+// Word list statistics
+type Stats struct {
+	Words             map[string]uint    // number of words of each type
+	OffensiveFiltered map[string]uint    // words of each type removed by the offensive prefilter (nil if no offensive list)
+	PrunedTypes       []string           // word types removed from the grammar by PruneEmptyTypes
+	ProperNouns       uint               // proper nouns dropped by ExcludeProperNouns
+	DerivedIndexBytes int64              // approximate memory held by indexes and pools derived from the word list (see SetDerivedIndexBudget)
+	EntropyPerWord    map[string]float64 // bits of entropy in the choice of a word of each type, without filtering options
+	Conjunctions      uint               // conjunctions available to join fragments by default; a call repeats them after this many joints with VaryJoints, usually sooner without
+	LoadWarnings      []string           // signs of a misconfigured word list found when loading it, also logged: fewer than 100 words, or mostly offensive ones
+}
+
+// Get statistics for the loaded word list
+func (g *Generator) Stats() Stats {
+	d := g.words()
+	st := Stats{
+		Words:          make(map[string]uint, len(word_types)),
+		EntropyPerWord: make(map[string]float64, len(word_types)),
+		ProperNouns:    d.proper_excluded,
+	}
+	for _, word_type := range word_types {
+		if n, ok := d.count(word_type); ok {
+			st.Words[word_type] = uint(n)
+			st.EntropyPerWord[word_type] = choice_entropy(n)
+		}
+	}
+	st.Conjunctions = st.Words["conjunction"]
+	if d.pruned != nil {
+		st.PrunedTypes = append([]string{}, d.pruned...)
+	}
+	if d.warnings != nil {
+		st.LoadWarnings = append([]string{}, d.warnings...)
+	}
+	if d.offensive != nil {
+		filtered := d.prudish(at_level(1)).filtered
+		st.OffensiveFiltered = make(map[string]uint, len(filtered))
+		for word_type, n := range filtered {
+			st.OffensiveFiltered[word_type] = n
+		}
+	}
+	st.DerivedIndexBytes = d.usage.total()
+	return st
 }
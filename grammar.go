@@ -0,0 +1,250 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrGrammarUnusable = errors.New("No usable word types left after pruning grammar")
+
+// Remove word types with no words from the grammar. Followers referencing removed types are
+// dropped, and types left without any followers are removed in turn until the grammar is
+// stable. Returns the pruned rules, the remaining types and the removed types (both in
+// word_types order).
+func prune_grammar(rules map[string][]string, d *word_data) (map[string][]string, []string, []string, error) {
+	removed := make(map[string]bool)
+	for _, t := range word_types {
+		if n, _ := d.count(t); n == 0 {
+			removed[t] = true
+		}
+	}
+	return restrict_grammar(rules, removed)
+}
+
+// Restrict the grammar to the word types allowed by the options (rules unchanged if no
+// AllowedTypes are set). Returns the rules and the types a fragment may start with.
+func restrict_to_allowed(rules map[string][]string, types []string, o *GenerateOptions) (map[string][]string, []string, error) {
+	if len(o.AllowedTypes) == 0 {
+		return rules, types, nil
+	}
+	removed := make(map[string]bool)
+	for _, t := range word_types {
+		removed[t] = true
+	}
+	for _, t := range o.AllowedTypes {
+		delete(removed, t)
+	}
+	rules, types, _, err := restrict_grammar(rules, removed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: AllowedTypes %v", err, o.AllowedTypes)
+	}
+	return rules, types, nil
+}
+
+// Apply StartTypeWeights to the start types: types with weight 0 are dropped and the rest
+// get their weight (1 if not listed). Returns nil weights if none are set.
+func weight_start_types(types []string, o *GenerateOptions) ([]string, map[string]uint, error) {
+	if len(o.StartTypeWeights) == 0 {
+		return types, nil, nil
+	}
+	weights := make(map[string]uint, len(types))
+	start := []string{}
+	for _, t := range types {
+		w, ok := o.StartTypeWeights[t]
+		if !ok {
+			w = 1
+		}
+		if w > 0 {
+			weights[t] = w
+			start = append(start, t)
+		}
+	}
+	if len(start) == 0 {
+		return nil, nil, fmt.Errorf("%w: StartTypeWeights leaves no start type", ErrGrammarUnusable)
+	}
+	return start, weights, nil
+}
+
+// Word types that may join fragments and their JointTypes weights (nil = uniform): by
+// default "conjunction" if it is still in the grammar, none with NoJoints or JoinType
+// "none", only JoinType if set. Listed types must be in the grammar; those with weight 0 are
+// dropped.
+func joint_types(rules map[string][]string, o *GenerateOptions) ([]string, map[string]uint, error) {
+	switch t := o.Fragments.JoinType; {
+	case o.NoJoints || t == "none":
+		return nil, nil, nil
+	case t != "":
+		if _, ok := rules[t]; !ok {
+			return nil, nil, fmt.Errorf("%w: JoinType %v is not in the grammar", ErrGrammarUnusable, t)
+		}
+		return []string{t}, nil, nil
+	}
+	if len(o.JointTypes) == 0 {
+		if _, ok := rules["conjunction"]; ok {
+			return []string{"conjunction"}, nil, nil
+		}
+		return nil, nil, nil
+	}
+	joints := []string{}
+	for _, t := range word_types {
+		w := o.JointTypes[t]
+		if w == 0 {
+			continue
+		}
+		if _, ok := rules[t]; !ok {
+			return nil, nil, fmt.Errorf("%w: JointTypes %v is not in the grammar", ErrGrammarUnusable, t)
+		}
+		joints = append(joints, t)
+	}
+	if len(joints) == 0 {
+		return nil, nil, fmt.Errorf("%w: JointTypes leaves no joint type", ErrGrammarUnusable)
+	}
+	return joints, o.JointTypes, nil
+}
+
+// Remove the given word types from the grammar, along with types left without followers
+func restrict_grammar(rules map[string][]string, removed map[string]bool) (map[string][]string, []string, []string, error) {
+	pruned := make(map[string][]string)
+	for changed := true; changed; {
+		changed = false
+		for _, t := range word_types {
+			if removed[t] {
+				continue
+			}
+			followers := []string{}
+			for _, f := range rules[t] {
+				if !removed[f] {
+					followers = append(followers, f)
+				}
+			}
+			if len(followers) == 0 {
+				removed[t] = true
+				changed = true
+				continue
+			}
+			pruned[t] = followers
+		}
+	}
+
+	types := []string{}
+	removed_types := []string{}
+	for _, t := range word_types {
+		if removed[t] {
+			delete(pruned, t)
+			removed_types = append(removed_types, t)
+		} else {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return nil, nil, removed_types, ErrGrammarUnusable
+	}
+	return pruned, types, removed_types, nil
+}
+
+// Structural problems in a grammar
+type GrammarAnalysis struct {
+	Unreachable       []string // word types no fragment can reach from a start type
+	Sinks             []string // word types with no followers, where a fragment is stranded
+	StrandLength      int      // shortest fragment, in words, that ends at a sink (0 = no sink is reachable)
+	MaxFragmentLength int      // longest fragment the grammar allows (0 = unbounded)
+}
+
+// Analyze the grammar in effect for the loaded word list (after pruning)
+func (g *Generator) AnalyzeGrammar() GrammarAnalysis {
+	d := g.words()
+	return analyze_grammar(d.rules(), d.start_types())
+}
+
+func analyze_grammar(rules map[string][]string, start []string) GrammarAnalysis {
+	var a GrammarAnalysis
+
+	// Every type mentioned, in word_types order followed by any others sorted
+	seen := make(map[string]bool)
+	for t, followers := range rules {
+		seen[t] = true
+		for _, f := range followers {
+			seen[f] = true
+		}
+	}
+	for _, t := range start {
+		seen[t] = true
+	}
+	types := []string{}
+	for _, t := range word_types {
+		if seen[t] {
+			types = append(types, t)
+			delete(seen, t)
+		}
+	}
+	others := []string{}
+	for t := range seen {
+		others = append(others, t)
+	}
+	sort.Strings(others)
+	types = append(types, others...)
+
+	// Shortest fragment reaching each type
+	dist := make(map[string]int)
+	queue := []string{}
+	for _, t := range start {
+		if _, ok := dist[t]; !ok {
+			dist[t] = 1
+			queue = append(queue, t)
+		}
+	}
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		for _, f := range rules[t] {
+			if _, ok := dist[f]; !ok {
+				dist[f] = dist[t] + 1
+				queue = append(queue, f)
+			}
+		}
+	}
+
+	for _, t := range types {
+		n, reachable := dist[t]
+		if !reachable {
+			a.Unreachable = append(a.Unreachable, t)
+		}
+		if len(rules[t]) == 0 {
+			a.Sinks = append(a.Sinks, t)
+			if reachable && (a.StrandLength == 0 || n < a.StrandLength) {
+				a.StrandLength = n
+			}
+		}
+	}
+
+	// Longest path over reachable types; a reachable cycle makes it unbounded
+	longest := make(map[string]int)
+	visiting := make(map[string]bool)
+	cycle := false
+	var walk func(t string) int
+	walk = func(t string) int {
+		if n, ok := longest[t]; ok {
+			return n
+		}
+		if visiting[t] {
+			cycle = true
+			return 0
+		}
+		visiting[t] = true
+		n := 1
+		for _, f := range rules[t] {
+			n = max(n, 1+walk(f))
+		}
+		visiting[t] = false
+		longest[t] = n
+		return n
+	}
+	for _, t := range start {
+		a.MaxFragmentLength = max(a.MaxFragmentLength, walk(t))
+	}
+	if cycle {
+		a.MaxFragmentLength = 0
+	}
+	return a
+}
@@ -0,0 +1,374 @@
+package wordentropy
+
+import (
+	"errors"
+	"math"
+	mrand "math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func follows(rules map[string][]string, prev, next string) bool {
+	for _, f := range rules[prev] {
+		if f == next {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPruneEmptyTypes(t *testing.T) {
+	g, err := LoadGenerator(&WordListOptions{
+		Wordlist:        "testdata/pos3.txt",
+		PruneEmptyTypes: true,
+	})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+
+	expected := []string{"pnoun", "adverb", "preposition", "pronoun", "conjunction", "sarticle", "particle", "interjection"}
+	if pruned := g.Stats().PrunedTypes; !reflect.DeepEqual(pruned, expected) {
+		t.Fatalf("Expected pruned types %v, got %v", expected, pruned)
+	}
+	expected_rules := map[string][]string{
+		"snoun":     []string{"verb"},
+		"verb":      []string{"snoun", "adjective"},
+		"adjective": []string{"snoun"},
+	}
+	if !reflect.DeepEqual(g.words().rules(), expected_rules) {
+		t.Fatalf("Expected rules %v, got %v", expected_rules, g.words().rules())
+	}
+
+	for _, length := range []uint{1, 4, 12} {
+		p, warnings, err := g.GeneratePassphrasesDetailed(&GenerateOptions{Count: 50, Length: length})
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		if len(warnings) != 0 {
+			t.Fatalf("Unexpected warnings: %v", warnings)
+		}
+		for _, pp := range p {
+			if uint(len(pp.Words)) != length {
+				t.Fatalf("Expected %v words, got %v: %v", length, len(pp.Words), pp.Phrase)
+			}
+			for i, wt := range pp.Types {
+				if _, ok := expected_rules[wt]; !ok {
+					t.Fatalf("Pruned word type %v in passphrase %v", wt, pp.Phrase)
+				}
+				// fragments are joined directly, so only check pairs within a fragment
+				if i > 0 && uint(i)%fragment_default != 0 && !follows(expected_rules, pp.Types[i-1], wt) {
+					t.Fatalf("%v cannot follow %v in passphrase %v", wt, pp.Types[i-1], pp.Phrase)
+				}
+			}
+		}
+	}
+}
+
+func TestPruneEmptyTypesDisabled(t *testing.T) {
+	g, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos3.txt"})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	if pruned := g.Stats().PrunedTypes; pruned != nil {
+		t.Fatalf("Expected no pruned types, got %v", pruned)
+	}
+	_, warnings, err := g.GeneratePassphrasesDetailed(&GenerateOptions{Count: 50, Length: 12})
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	if !has_warning(warnings, WarnEmptyWordType) {
+		t.Fatalf("Expected empty word type warnings, got %v", warnings)
+	}
+}
+
+func TestPruneEmptyTypesUnusable(t *testing.T) {
+	p := write_temp(t, "adverbs.txt", "quietly\tv\nslowly\tv\n")
+	_, err := LoadGenerator(&WordListOptions{Wordlist: p, PruneEmptyTypes: true})
+	if err != ErrGrammarUnusable {
+		t.Fatalf("Expected ErrGrammarUnusable, got %v", err)
+	}
+}
+
+// The last start type and the last follower of a type can be drawn (they once could not),
+// and single-entry lists do not panic
+func TestLastTypeChosen(t *testing.T) {
+	g := load_test_generator(t)
+	s, err := g.prepare(&GenerateOptions{Length: 2, Magic_fragment_length: 2}, g.words(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.rng = last_source{}
+	_, types, err := g.generate_fragment(s, nil, 2, "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := s.start[len(s.start)-1]; types[0] != first {
+		t.Errorf("Expected the last start type %v, got %v", first, types[0])
+	}
+	if followers := s.rules[types[0]]; types[1] != followers[len(followers)-1] {
+		t.Errorf("Expected the last follower of %v, %v, got %v", types[0], followers[len(followers)-1], types[1])
+	}
+
+	g = dead_end_generator([]string{"quickly"}, nil, []string{"verb"})
+	s, err = g.prepare(&GenerateOptions{Length: 3, Magic_fragment_length: 3}, g.words(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.rng = last_source{}
+	words, types, err := g.generate_fragment(s, nil, 3, "", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"adjective", "pnoun", "adverb"}; !reflect.DeepEqual(types, expected) {
+		t.Errorf("Expected types %v, got %v (%v)", expected, types, words)
+	}
+}
+
+func TestNoAdjacentSameType(t *testing.T) {
+	g := &Generator{}
+	g.data.Store(&word_data{
+		word_map: uniform_word_map("otter"),
+		grammar: map[string][]string{
+			"snoun":       {"snoun", "verb", "conjunction"},
+			"verb":        {"verb", "snoun", "conjunction"},
+			"conjunction": {"snoun", "verb"},
+		},
+		types: []string{"snoun", "verb", "conjunction"},
+	})
+
+	o := GenerateOptions{Count: 20, Length: 12, Magic_fragment_length: 3}
+	repeats := false
+	for i := 0; i < 10; i++ {
+		p, _, err := g.GeneratePassphrasesDetailed(&o)
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		for _, pp := range p {
+			for j := 1; j < len(pp.Types); j++ {
+				repeats = repeats || pp.Types[j] == pp.Types[j-1]
+			}
+		}
+	}
+	if !repeats {
+		t.Fatalf("Sanity check failed: self-loop grammar never repeated a type")
+	}
+
+	o.NoAdjacentSameType = true
+	for i := 0; i < 50; i++ {
+		p, _, err := g.GeneratePassphrasesDetailed(&o)
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		for _, pp := range p {
+			for j := 1; j < len(pp.Types); j++ {
+				if pp.Types[j] == pp.Types[j-1] {
+					t.Fatalf("Adjacent words of type %v: %v", pp.Types[j], pp.Types)
+				}
+			}
+		}
+	}
+
+	// verb can only be followed by itself
+	g.data.Store(&word_data{
+		word_map: uniform_word_map("otter"),
+		grammar:  map[string][]string{"verb": {"verb"}},
+		types:    []string{"verb"},
+	})
+	if _, err := g.GeneratePassphrases(&o); !errors.Is(err, ErrNoAllowedType) {
+		t.Fatalf("Expected ErrNoAllowedType, got %v", err)
+	}
+}
+
+func TestAllowedTypes(t *testing.T) {
+	g := load_test_generator(t)
+	o := GenerateOptions{Count: 20, Length: 10, AllowedTypes: []string{"snoun", "verb"}, Prudish: true, MinWordLength: 3}
+	for i := 0; i < 10; i++ {
+		p, _, err := g.GeneratePassphrasesDetailed(&o)
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		for _, pp := range p {
+			if len(pp.Words) != 10 {
+				t.Fatalf("Expected 10 words, got %v", pp.Words)
+			}
+			for _, typ := range pp.Types {
+				if typ != "snoun" && typ != "verb" {
+					t.Fatalf("Unexpected word type %v in %v", typ, pp.Types)
+				}
+			}
+		}
+	}
+
+	o.AllowedTypes = []string{"adjective", "adverb"}
+	if _, err := g.GeneratePassphrases(&o); !errors.Is(err, ErrGrammarUnusable) {
+		t.Fatalf("Expected ErrGrammarUnusable for disconnected types, got %v", err)
+	}
+	o.AllowedTypes = []string{"snoun", "noun"}
+	if _, err := g.GeneratePassphrases(&o); !errors.Is(err, ErrUnknownWordType) {
+		t.Fatalf("Expected ErrUnknownWordType, got %v", err)
+	}
+}
+
+func TestAnalyzeGrammar(t *testing.T) {
+	cases := []struct {
+		name     string
+		rules    map[string][]string
+		start    []string
+		expected GrammarAnalysis
+	}{
+		{"unreachable", map[string][]string{"snoun": {"verb"}, "verb": {"snoun"}, "adjective": {"snoun"}}, []string{"snoun"},
+			GrammarAnalysis{Unreachable: []string{"adjective"}}},
+		{"sink", map[string][]string{"snoun": {"verb"}, "verb": {"adverb"}, "adverb": {}}, []string{"snoun"},
+			GrammarAnalysis{Sinks: []string{"adverb"}, StrandLength: 3, MaxFragmentLength: 3}},
+		{"follower without rules", map[string][]string{"snoun": {"verb", "adverb"}, "verb": {"snoun"}}, []string{"snoun"},
+			GrammarAnalysis{Sinks: []string{"adverb"}, StrandLength: 2}},
+		{"unreachable sink", map[string][]string{"snoun": {"snoun"}, "adverb": nil}, []string{"snoun"},
+			GrammarAnalysis{Unreachable: []string{"adverb"}, Sinks: []string{"adverb"}}},
+		{"shortest strand", map[string][]string{"verb": {"adverb", "snoun"}, "snoun": {"pnoun"}, "adverb": {"snoun"}}, []string{"verb", "adverb"},
+			GrammarAnalysis{Sinks: []string{"pnoun"}, StrandLength: 3, MaxFragmentLength: 4}},
+		{"custom types", map[string][]string{"zeta": {}, "alpha": {"zeta"}, "snoun": {"alpha"}}, []string{"alpha"},
+			GrammarAnalysis{Unreachable: []string{"snoun"}, Sinks: []string{"zeta"}, StrandLength: 2, MaxFragmentLength: 2}},
+	}
+	for _, c := range cases {
+		if a := analyze_grammar(c.rules, c.start); !reflect.DeepEqual(a, c.expected) {
+			t.Errorf("%v: expected %+v, got %+v", c.name, c.expected, a)
+		}
+	}
+
+	if a := load_test_generator(t).AnalyzeGrammar(); !reflect.DeepEqual(a, GrammarAnalysis{}) {
+		t.Errorf("Expected no problems with the default grammar, got %+v", a)
+	}
+}
+
+func TestStartTypeWeights(t *testing.T) {
+	g := generator_for(uniform_word_map("otter"))
+	g.rand = mrand.NewChaCha8([32]byte{3})
+	weights := map[string]uint{"sarticle": 4, "adjective": 3, "snoun": 3}
+	for _, t := range word_types {
+		if _, ok := weights[t]; !ok {
+			weights[t] = 0
+		}
+	}
+	o := GenerateOptions{Count: count_max, Length: 4, StartTypeWeights: weights}
+	seen := make(map[string]int)
+	n := 0
+	for i := 0; i < 30; i++ {
+		p, _, err := g.GeneratePassphrasesDetailed(&o)
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		for _, pp := range p {
+			seen[pp.Types[0]]++
+			n++
+		}
+	}
+	for typ, w := range weights {
+		expected := float64(w) / 10
+		if observed := float64(seen[typ]) / float64(n); math.Abs(observed-expected) > 0.03 {
+			t.Errorf("%v: expected frequency %.2f, got %.3f", typ, expected, observed)
+		}
+	}
+
+	h, err := g.StartTypeEntropy(&o)
+	if expected := -(0.4*math.Log2(0.4) + 0.6*math.Log2(0.3)); err != nil || math.Abs(h-expected) > 1e-9 {
+		t.Errorf("Expected start entropy %v, got %v (%v)", expected, h, err)
+	}
+	if h, _ := g.StartTypeEntropy(&GenerateOptions{}); math.Abs(h-math.Log2(float64(len(word_types)))) > 1e-9 {
+		t.Errorf("Expected uniform start entropy, got %v", h)
+	}
+	// Unlisted types weigh 1
+	if h, _ := g.StartTypeEntropy(&GenerateOptions{StartTypeWeights: map[string]uint{"conjunction": 0}}); math.Abs(h-math.Log2(float64(len(word_types)-1))) > 1e-9 {
+		t.Errorf("Expected uniform entropy over the remaining types, got %v", h)
+	}
+
+	if _, err := g.GeneratePassphrases(&GenerateOptions{StartTypeWeights: map[string]uint{"verbs": 1}}); !errors.Is(err, ErrUnknownWordType) {
+		t.Errorf("Expected ErrUnknownWordType, got %v", err)
+	}
+	o = GenerateOptions{StartTypeWeights: map[string]uint{"snoun": 0, "verb": 0}, AllowedTypes: []string{"snoun", "verb"}}
+	if _, err := g.GeneratePassphrases(&o); !errors.Is(err, ErrGrammarUnusable) {
+		t.Errorf("Expected ErrGrammarUnusable with every start type weighted 0, got %v", err)
+	}
+}
+
+func TestJointTypes(t *testing.T) {
+	g := load_test_generator(t)
+	o := GenerateOptions{Count: 20, Length: 12, JointTypes: map[string]uint{"preposition": 1}}
+	p, _, err := g.GeneratePassphrasesDetailed(&o)
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	for _, pp := range p {
+		if len(pp.FragmentSpans) < 2 {
+			t.Fatalf("Expected several fragments, got %v", pp.FragmentSpans)
+		}
+		for _, span := range pp.FragmentSpans[1:] {
+			if span[1]-span[0] == 1 && span[1] == len(pp.Words) {
+				continue // the last word is joined directly rather than by a preposition alone
+			}
+			if typ := pp.Types[span[0]]; typ != "preposition" {
+				t.Fatalf("Expected a preposition at the seam, got %v in %v (%v)", typ, pp.Words, pp.Types)
+			}
+		}
+	}
+
+	// Fragments abut without joints
+	g = generator_for(uniform_word_map("otter"))
+	p, _, err = g.GeneratePassphrasesDetailed(&GenerateOptions{Count: 10, Length: 8, NoJoints: true})
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	for _, pp := range p {
+		if len(pp.Words) != 8 {
+			t.Fatalf("Expected 8 words without joints, got %v", pp.Words)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		o        GenerateOptions
+		expected error
+	}{
+		{"unknown", GenerateOptions{JointTypes: map[string]uint{"noun": 1}}, ErrUnknownWordType},
+		{"disallowed", GenerateOptions{AllowedTypes: []string{"snoun", "verb"}, JointTypes: map[string]uint{"preposition": 1}}, ErrGrammarUnusable},
+		{"all zero", GenerateOptions{JointTypes: map[string]uint{"preposition": 0}}, ErrGrammarUnusable},
+		{"too long", GenerateOptions{MinWordLength: 6, JointTypes: map[string]uint{"preposition": 1}}, ErrTooFewWords},
+	}
+	for _, c := range cases {
+		if _, err := g.GeneratePassphrases(&c.o); !errors.Is(err, c.expected) {
+			t.Errorf("%v: expected %v, got %v", c.name, c.expected, err)
+		}
+	}
+}
+
+func TestShortLength(t *testing.T) {
+	g := load_test_generator(t)
+	for length := uint(1); length <= 3; length++ {
+		p, _, err := g.GeneratePassphrasesDetailed(&GenerateOptions{Count: count_max, Length: length})
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		for _, pp := range p {
+			if uint(len(pp.Words)) != length || len(pp.FragmentSpans) != 1 {
+				t.Fatalf("Expected a single fragment of %v words, got %v (%v)", length, pp.Words, pp.FragmentSpans)
+			}
+			// Every word follows the previous one by the grammar
+			prev := ""
+			for start, n := 0, 0; n < len(pp.Entries); start, n = start+pp.Entries[n], n+1 {
+				typ := pp.Types[start]
+				if prev != "" && !follows(grammar_rules, prev, typ) {
+					t.Fatalf("%v cannot follow %v in %v (%v)", typ, prev, pp.Words, pp.Types)
+				}
+				prev = typ
+			}
+		}
+	}
+
+	// No words are drawn only to be discarded
+	g = generator_for(uniform_word_map("otter"))
+	if _, err := g.GeneratePassphrases(&GenerateOptions{Count: 10, Length: 2}); err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	if c := g.Counters(); c.Words != 20 {
+		t.Errorf("Expected 20 words drawn, got %v", c.Words)
+	}
+}
@@ -0,0 +1,167 @@
+//go:build grpcapi
+
+// Package grpcapi serves passphrase generation over gRPC. It is only built with the
+// grpcapi build tag so that users of the core package do not inherit the gRPC
+// dependency. The generated stubs are checked in; go generate rebuilds them from
+// wordentropy.proto:
+//
+//	go build -tags grpcapi ./grpcapi
+//	go generate -tags grpcapi ./grpcapi
+package grpcapi
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative wordentropy.proto
+//go:generate go run tag.go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bkeroack/libwordentropy"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Options for the gRPC server. All fields are optional. Requests can set every option,
+// unlike the HTTP handler's, so MaxRetries and MaxTimeout bound the work the costlier ones
+// (max_syllables, unambiguous_concat and the like) can cause.
+type Options struct {
+	MaxWork    uint          // maximum count × length (× best_of) per request (0 = unlimited), as HandlerOptions.MaxWork
+	MaxRetries uint          // highest max_retries a request may ask for (0 = none: requests get the library default)
+	MaxTimeout time.Duration // longest a request may generate for, whatever its timeout_ms (0 = unlimited)
+}
+
+// WordentropyServer implementation wrapping a Generator
+type Server struct {
+	UnimplementedWordentropyServer
+	g *wordentropy.Generator
+	o Options
+}
+
+// Return a server generating passphrases with g
+func NewServer(g *wordentropy.Generator, o *Options) *Server {
+	s := &Server{g: g}
+	if o != nil {
+		s.o = *o
+	}
+	return s
+}
+
+// Generate passphrases. Errors carry the same codes as the HTTP handler's error bodies
+// ("ErrWorkLimitExceeded: ...") with InvalidArgument, Internal for ErrInternal or
+// Unavailable for ErrClosed. With allow_partial, passphrases generated before a failure are
+// returned with the error in the response instead. The entropy fields of the response are
+// only filled in if the request asks for them.
+func (s *Server) Generate(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
+	o := generate_options(req)
+	if err := s.check_limits(o); err != nil {
+		return nil, status_error(err)
+	}
+	if s.o.MaxTimeout > 0 && (o.Timeout == 0 || o.Timeout > s.o.MaxTimeout) {
+		o.Timeout = s.o.MaxTimeout
+	}
+	if d, ok := ctx.Deadline(); ok && (o.Timeout == 0 || time.Until(d) < o.Timeout) {
+		o.Timeout = time.Until(d)
+	}
+	p, err := s.g.GeneratePassphrases(o)
+	var partial *wordentropy.BatchError
+	if err != nil && (!errors.As(err, &partial) || len(p) == 0 || errors.Is(err, wordentropy.ErrInternal)) {
+		return nil, status_error(err)
+	}
+	resp := &GenerateResponse{Passphrases: p}
+	if req.Entropy {
+		var bits_err error
+		if resp.StartTypeEntropyBits, bits_err = s.g.StartTypeEntropy(o); bits_err != nil {
+			return nil, status_error(bits_err)
+		}
+		if resp.MinEntropyBits, bits_err = s.g.MinEntropy(o); bits_err != nil {
+			return nil, status_error(bits_err)
+		}
+	}
+	if partial != nil {
+		resp.Error = fmt.Sprintf("%v: %v", wordentropy.ErrorCode(err), err)
+	}
+	return resp, nil
+}
+
+// Check a request's options against the server's limits
+func (s *Server) check_limits(o *wordentropy.GenerateOptions) error {
+	if err := wordentropy.CheckWork(o, s.o.MaxWork); err != nil {
+		return err
+	}
+	if o.MaxRetries > s.o.MaxRetries {
+		return fmt.Errorf("%w: max_retries: %v exceeds the server's limit of %v", wordentropy.ErrInvalidParameter, o.MaxRetries, s.o.MaxRetries)
+	}
+	return nil
+}
+
+func status_error(err error) error {
+	c := codes.InvalidArgument
+	switch {
+	case errors.Is(err, wordentropy.ErrInternal):
+		c = codes.Internal
+	case errors.Is(err, wordentropy.ErrClosed):
+		c = codes.Unavailable
+	}
+	return status.Error(c, fmt.Sprintf("%v: %v", wordentropy.ErrorCode(err), err))
+}
+
+// Longest timeout_ms that fits in a time.Duration
+const max_timeout_ms = uint64(math.MaxInt64 / int64(time.Millisecond))
+
+func generate_options(req *GenerateRequest) *wordentropy.GenerateOptions {
+	o := &wordentropy.GenerateOptions{
+		Count:  uint(req.Count),
+		Length: uint(req.Length),
+		Fragments: wordentropy.FragmentPolicy{
+			TargetFragmentWords: uint(req.FragmentLength),
+			JoinType:            req.JoinType,
+			Jitter:              uint(req.FragmentJitter),
+		},
+		Prudish:            req.Prudish,
+		Prudish_level:      uint(req.PrudishLevel),
+		FilterCategories:   req.FilterCategories,
+		No_spaces:          req.NoSpaces,
+		Add_digit:          req.AddDigit,
+		Add_symbol:         req.AddSymbol,
+		Symbols:            req.Symbols,
+		Digits:             req.Digits,
+		MaxSymbolLength:    uint(req.MaxSymbolLength),
+		Separator:          req.Separator,
+		Lowercase:          req.Lowercase,
+		Capitalize:         req.Capitalize,
+		MaxChars:           uint(req.MaxChars),
+		MaxBytes:           uint(req.MaxBytes),
+		MaxSyllables:       uint(req.MaxSyllables),
+		MaxRetries:         uint(req.MaxRetries),
+		Timeout:            time.Duration(min(req.TimeoutMs, max_timeout_ms)) * time.Millisecond,
+		MinWordLength:      uint(req.MinWordLength),
+		MaxWordLength:      uint(req.MaxWordLength),
+		NoAdjacentSameType: req.NoAdjacentSameType,
+		AllowedTypes:       req.AllowedTypes,
+		NoJoints:           req.NoJoints,
+		VaryJoints:         req.VaryJoints,
+		AvoidCommonPhrases: req.AvoidCommonPhrases,
+		ExtraDenyWords:     req.ExtraDenyWords,
+		ShortWordBias:      req.ShortWordBias,
+		UnambiguousConcat:  req.UnambiguousConcat,
+		Agreement:          req.Agreement,
+		AllowPartial:       req.AllowPartial,
+		BestOf:             uint(req.BestOf),
+	}
+	if len(req.StartTypeWeights) > 0 {
+		o.StartTypeWeights = make(map[string]uint, len(req.StartTypeWeights))
+		for t, w := range req.StartTypeWeights {
+			o.StartTypeWeights[t] = uint(w)
+		}
+	}
+	if len(req.JointTypes) > 0 {
+		o.JointTypes = make(map[string]uint, len(req.JointTypes))
+		for t, w := range req.JointTypes {
+			o.JointTypes[t] = uint(w)
+		}
+	}
+	return o
+}
@@ -0,0 +1,115 @@
+//go:build grpcapi
+
+package grpcapi
+
+import (
+	"context"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bkeroack/libwordentropy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Start a server on an in-memory listener and return a client connected to it
+func dial_test_server(t *testing.T, o *Options) WordentropyClient {
+	g, err := wordentropy.LoadGenerator(&wordentropy.WordListOptions{
+		Wordlist:  "../testdata/pos.txt",
+		Offensive: "../testdata/offensive.txt",
+	})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	RegisterWordentropyServer(srv, NewServer(g, o))
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Could not dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewWordentropyClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	c := dial_test_server(t, &Options{MaxWork: 50})
+	ctx := context.Background()
+
+	resp, err := c.Generate(ctx, &GenerateRequest{Count: 3, Length: 4, Separator: "+"})
+	if err != nil {
+		t.Fatalf("Error generating: %v", err)
+	}
+	if len(resp.Passphrases) != 3 {
+		t.Fatalf("Expected 3 passphrases, got %v", resp.Passphrases)
+	}
+	for _, p := range resp.Passphrases {
+		if n := len(strings.Split(p, "+")); n != 4 {
+			t.Errorf("Expected 4 words in %q, got %v", p, n)
+		}
+	}
+	if resp.StartTypeEntropyBits != 0 || resp.MinEntropyBits != 0 {
+		t.Errorf("Expected no entropy unless asked for, got %v and %v", resp.StartTypeEntropyBits, resp.MinEntropyBits)
+	}
+	resp, err = c.Generate(ctx, &GenerateRequest{Count: 1, Length: 4, Entropy: true})
+	if err != nil {
+		t.Fatalf("Error generating: %v", err)
+	}
+	if resp.StartTypeEntropyBits <= 0 {
+		t.Errorf("Expected positive start type entropy, got %v", resp.StartTypeEntropyBits)
+	}
+	if resp.MinEntropyBits <= 0 || resp.MinEntropyBits < resp.StartTypeEntropyBits {
+		t.Errorf("Expected a min-entropy above the start type entropy, got %v", resp.MinEntropyBits)
+	}
+
+	// A timeout too long for a time.Duration is clamped rather than wrapped around
+	if o := generate_options(&GenerateRequest{TimeoutMs: math.MaxUint64}); o.Timeout <= 0 {
+		t.Errorf("Expected a positive timeout, got %v", o.Timeout)
+	}
+	if _, err := c.Generate(ctx, &GenerateRequest{Count: 1, TimeoutMs: math.MaxUint64}); err != nil {
+		t.Errorf("Error generating with the longest timeout: %v", err)
+	}
+
+	cases := []struct {
+		req  *GenerateRequest
+		code string
+	}{
+		{&GenerateRequest{Count: 10, Length: 10}, "ErrWorkLimitExceeded"},
+		{&GenerateRequest{Length: 20}, "ErrWorkLimitExceeded"}, // default count counts towards work
+		{&GenerateRequest{Count: 1, AllowedTypes: []string{"xyzzy"}}, "ErrUnknownWordType"},
+		{&GenerateRequest{Count: 1, MaxRetries: 1000}, "ErrInvalidParameter"},
+	}
+	for _, tc := range cases {
+		_, err := c.Generate(ctx, tc.req)
+		st, _ := status.FromError(err)
+		if st.Code() != codes.InvalidArgument || !strings.HasPrefix(st.Message(), tc.code+": ") {
+			t.Errorf("%v: expected InvalidArgument %v, got %v", tc.req, tc.code, err)
+		}
+	}
+}
+
+func TestServerLimits(t *testing.T) {
+	c := dial_test_server(t, &Options{MaxRetries: 100, MaxTimeout: time.Nanosecond})
+	ctx := context.Background()
+
+	_, err := c.Generate(ctx, &GenerateRequest{Count: 1, MaxRetries: 101})
+	if st, _ := status.FromError(err); st.Code() != codes.InvalidArgument || !strings.HasPrefix(st.Message(), "ErrInvalidParameter: ") {
+		t.Errorf("Expected InvalidArgument ErrInvalidParameter past the retry limit, got %v", err)
+	}
+	// Within the retry limit, but the server's timeout cuts the longer one asked for short
+	_, err = c.Generate(ctx, &GenerateRequest{Count: 1, MaxRetries: 100, TimeoutMs: 60000})
+	if st, _ := status.FromError(err); !strings.HasPrefix(st.Message(), "ErrDeadlineExceeded: ") {
+		t.Errorf("Expected ErrDeadlineExceeded from MaxTimeout, got %v", err)
+	}
+}
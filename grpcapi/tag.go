@@ -0,0 +1,34 @@
+//go:build ignore
+
+// Prepend the grpcapi build constraint to the generated *.pb.go files, so that a plain go
+// build ./... skips them along with the rest of the package. Run by go generate after
+// protoc.
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const constraint = "//go:build grpcapi\n\n"
+
+func main() {
+	names, err := filepath.Glob("*.pb.go")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, name := range names {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if bytes.HasPrefix(b, []byte(constraint)) {
+			continue
+		}
+		if err := os.WriteFile(name, append([]byte(constraint), b...), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
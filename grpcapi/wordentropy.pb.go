@@ -0,0 +1,555 @@
+//go:build grpcapi
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: wordentropy.proto
+
+package grpcapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Mirrors GenerateOptions; unset fields take the library defaults. InsecureFastRandom is
+// deliberately not exposed.
+type GenerateRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Count              uint32                 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Length             uint32                 `protobuf:"varint,2,opt,name=length,proto3" json:"length,omitempty"`
+	FragmentLength     uint32                 `protobuf:"varint,3,opt,name=fragment_length,json=fragmentLength,proto3" json:"fragment_length,omitempty"`
+	Prudish            bool                   `protobuf:"varint,4,opt,name=prudish,proto3" json:"prudish,omitempty"`
+	NoSpaces           bool                   `protobuf:"varint,5,opt,name=no_spaces,json=noSpaces,proto3" json:"no_spaces,omitempty"`
+	AddDigit           bool                   `protobuf:"varint,6,opt,name=add_digit,json=addDigit,proto3" json:"add_digit,omitempty"`
+	AddSymbol          bool                   `protobuf:"varint,7,opt,name=add_symbol,json=addSymbol,proto3" json:"add_symbol,omitempty"`
+	Symbols            []string               `protobuf:"bytes,8,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	Separator          string                 `protobuf:"bytes,9,opt,name=separator,proto3" json:"separator,omitempty"`
+	Lowercase          bool                   `protobuf:"varint,10,opt,name=lowercase,proto3" json:"lowercase,omitempty"`
+	Capitalize         string                 `protobuf:"bytes,11,opt,name=capitalize,proto3" json:"capitalize,omitempty"`
+	MaxChars           uint32                 `protobuf:"varint,12,opt,name=max_chars,json=maxChars,proto3" json:"max_chars,omitempty"`
+	MaxRetries         uint32                 `protobuf:"varint,13,opt,name=max_retries,json=maxRetries,proto3" json:"max_retries,omitempty"`
+	TimeoutMs          uint64                 `protobuf:"varint,14,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
+	MinWordLength      uint32                 `protobuf:"varint,15,opt,name=min_word_length,json=minWordLength,proto3" json:"min_word_length,omitempty"`
+	MaxWordLength      uint32                 `protobuf:"varint,16,opt,name=max_word_length,json=maxWordLength,proto3" json:"max_word_length,omitempty"`
+	NoAdjacentSameType bool                   `protobuf:"varint,17,opt,name=no_adjacent_same_type,json=noAdjacentSameType,proto3" json:"no_adjacent_same_type,omitempty"`
+	AllowedTypes       []string               `protobuf:"bytes,18,rep,name=allowed_types,json=allowedTypes,proto3" json:"allowed_types,omitempty"`
+	StartTypeWeights   map[string]uint32      `protobuf:"bytes,19,rep,name=start_type_weights,json=startTypeWeights,proto3" json:"start_type_weights,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	PrudishLevel       uint32                 `protobuf:"varint,20,opt,name=prudish_level,json=prudishLevel,proto3" json:"prudish_level,omitempty"`
+	Digits             []string               `protobuf:"bytes,21,rep,name=digits,proto3" json:"digits,omitempty"`
+	MaxSymbolLength    uint32                 `protobuf:"varint,22,opt,name=max_symbol_length,json=maxSymbolLength,proto3" json:"max_symbol_length,omitempty"`
+	BestOf             uint32                 `protobuf:"varint,23,opt,name=best_of,json=bestOf,proto3" json:"best_of,omitempty"`
+	JointTypes         map[string]uint32      `protobuf:"bytes,24,rep,name=joint_types,json=jointTypes,proto3" json:"joint_types,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	NoJoints           bool                   `protobuf:"varint,25,opt,name=no_joints,json=noJoints,proto3" json:"no_joints,omitempty"`
+	MaxBytes           uint32                 `protobuf:"varint,26,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`
+	AvoidCommonPhrases bool                   `protobuf:"varint,27,opt,name=avoid_common_phrases,json=avoidCommonPhrases,proto3" json:"avoid_common_phrases,omitempty"`
+	ExtraDenyWords     []string               `protobuf:"bytes,28,rep,name=extra_deny_words,json=extraDenyWords,proto3" json:"extra_deny_words,omitempty"`
+	ShortWordBias      float64                `protobuf:"fixed64,29,opt,name=short_word_bias,json=shortWordBias,proto3" json:"short_word_bias,omitempty"`
+	UnambiguousConcat  bool                   `protobuf:"varint,30,opt,name=unambiguous_concat,json=unambiguousConcat,proto3" json:"unambiguous_concat,omitempty"`
+	Agreement          bool                   `protobuf:"varint,31,opt,name=agreement,proto3" json:"agreement,omitempty"`
+	AllowPartial       bool                   `protobuf:"varint,32,opt,name=allow_partial,json=allowPartial,proto3" json:"allow_partial,omitempty"`
+	FragmentJitter     uint32                 `protobuf:"varint,33,opt,name=fragment_jitter,json=fragmentJitter,proto3" json:"fragment_jitter,omitempty"`
+	JoinType           string                 `protobuf:"bytes,34,opt,name=join_type,json=joinType,proto3" json:"join_type,omitempty"`
+	FilterCategories   []string               `protobuf:"bytes,35,rep,name=filter_categories,json=filterCategories,proto3" json:"filter_categories,omitempty"`
+	VaryJoints         bool                   `protobuf:"varint,36,opt,name=vary_joints,json=varyJoints,proto3" json:"vary_joints,omitempty"`
+	MaxSyllables       uint32                 `protobuf:"varint,37,opt,name=max_syllables,json=maxSyllables,proto3" json:"max_syllables,omitempty"`
+	Entropy            bool                   `protobuf:"varint,38,opt,name=entropy,proto3" json:"entropy,omitempty"` // fill in the response's start_type_entropy_bits and min_entropy_bits
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *GenerateRequest) Reset() {
+	*x = GenerateRequest{}
+	mi := &file_wordentropy_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateRequest) ProtoMessage() {}
+
+func (x *GenerateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_wordentropy_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateRequest.ProtoReflect.Descriptor instead.
+func (*GenerateRequest) Descriptor() ([]byte, []int) {
+	return file_wordentropy_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GenerateRequest) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetLength() uint32 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetFragmentLength() uint32 {
+	if x != nil {
+		return x.FragmentLength
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetPrudish() bool {
+	if x != nil {
+		return x.Prudish
+	}
+	return false
+}
+
+func (x *GenerateRequest) GetNoSpaces() bool {
+	if x != nil {
+		return x.NoSpaces
+	}
+	return false
+}
+
+func (x *GenerateRequest) GetAddDigit() bool {
+	if x != nil {
+		return x.AddDigit
+	}
+	return false
+}
+
+func (x *GenerateRequest) GetAddSymbol() bool {
+	if x != nil {
+		return x.AddSymbol
+	}
+	return false
+}
+
+func (x *GenerateRequest) GetSymbols() []string {
+	if x != nil {
+		return x.Symbols
+	}
+	return nil
+}
+
+func (x *GenerateRequest) GetSeparator() string {
+	if x != nil {
+		return x.Separator
+	}
+	return ""
+}
+
+func (x *GenerateRequest) GetLowercase() bool {
+	if x != nil {
+		return x.Lowercase
+	}
+	return false
+}
+
+func (x *GenerateRequest) GetCapitalize() string {
+	if x != nil {
+		return x.Capitalize
+	}
+	return ""
+}
+
+func (x *GenerateRequest) GetMaxChars() uint32 {
+	if x != nil {
+		return x.MaxChars
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetMaxRetries() uint32 {
+	if x != nil {
+		return x.MaxRetries
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetTimeoutMs() uint64 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetMinWordLength() uint32 {
+	if x != nil {
+		return x.MinWordLength
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetMaxWordLength() uint32 {
+	if x != nil {
+		return x.MaxWordLength
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetNoAdjacentSameType() bool {
+	if x != nil {
+		return x.NoAdjacentSameType
+	}
+	return false
+}
+
+func (x *GenerateRequest) GetAllowedTypes() []string {
+	if x != nil {
+		return x.AllowedTypes
+	}
+	return nil
+}
+
+func (x *GenerateRequest) GetStartTypeWeights() map[string]uint32 {
+	if x != nil {
+		return x.StartTypeWeights
+	}
+	return nil
+}
+
+func (x *GenerateRequest) GetPrudishLevel() uint32 {
+	if x != nil {
+		return x.PrudishLevel
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetDigits() []string {
+	if x != nil {
+		return x.Digits
+	}
+	return nil
+}
+
+func (x *GenerateRequest) GetMaxSymbolLength() uint32 {
+	if x != nil {
+		return x.MaxSymbolLength
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetBestOf() uint32 {
+	if x != nil {
+		return x.BestOf
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetJointTypes() map[string]uint32 {
+	if x != nil {
+		return x.JointTypes
+	}
+	return nil
+}
+
+func (x *GenerateRequest) GetNoJoints() bool {
+	if x != nil {
+		return x.NoJoints
+	}
+	return false
+}
+
+func (x *GenerateRequest) GetMaxBytes() uint32 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetAvoidCommonPhrases() bool {
+	if x != nil {
+		return x.AvoidCommonPhrases
+	}
+	return false
+}
+
+func (x *GenerateRequest) GetExtraDenyWords() []string {
+	if x != nil {
+		return x.ExtraDenyWords
+	}
+	return nil
+}
+
+func (x *GenerateRequest) GetShortWordBias() float64 {
+	if x != nil {
+		return x.ShortWordBias
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetUnambiguousConcat() bool {
+	if x != nil {
+		return x.UnambiguousConcat
+	}
+	return false
+}
+
+func (x *GenerateRequest) GetAgreement() bool {
+	if x != nil {
+		return x.Agreement
+	}
+	return false
+}
+
+func (x *GenerateRequest) GetAllowPartial() bool {
+	if x != nil {
+		return x.AllowPartial
+	}
+	return false
+}
+
+func (x *GenerateRequest) GetFragmentJitter() uint32 {
+	if x != nil {
+		return x.FragmentJitter
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetJoinType() string {
+	if x != nil {
+		return x.JoinType
+	}
+	return ""
+}
+
+func (x *GenerateRequest) GetFilterCategories() []string {
+	if x != nil {
+		return x.FilterCategories
+	}
+	return nil
+}
+
+func (x *GenerateRequest) GetVaryJoints() bool {
+	if x != nil {
+		return x.VaryJoints
+	}
+	return false
+}
+
+func (x *GenerateRequest) GetMaxSyllables() uint32 {
+	if x != nil {
+		return x.MaxSyllables
+	}
+	return 0
+}
+
+func (x *GenerateRequest) GetEntropy() bool {
+	if x != nil {
+		return x.Entropy
+	}
+	return false
+}
+
+type GenerateResponse struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Passphrases          []string               `protobuf:"bytes,1,rep,name=passphrases,proto3" json:"passphrases,omitempty"`
+	StartTypeEntropyBits float64                `protobuf:"fixed64,2,opt,name=start_type_entropy_bits,json=startTypeEntropyBits,proto3" json:"start_type_entropy_bits,omitempty"` // with entropy, bits of entropy in each fragment's start type choice
+	Error                string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`                                                                 // with allow_partial, why fewer passphrases were returned than requested ("ErrRetriesExhausted: ...")
+	MinEntropyBits       float64                `protobuf:"fixed64,4,opt,name=min_entropy_bits,json=minEntropyBits,proto3" json:"min_entropy_bits,omitempty"`                     // with entropy, lower bound on the bits of entropy of each passphrase (see Generator.MinEntropy)
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *GenerateResponse) Reset() {
+	*x = GenerateResponse{}
+	mi := &file_wordentropy_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateResponse) ProtoMessage() {}
+
+func (x *GenerateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_wordentropy_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateResponse.ProtoReflect.Descriptor instead.
+func (*GenerateResponse) Descriptor() ([]byte, []int) {
+	return file_wordentropy_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GenerateResponse) GetPassphrases() []string {
+	if x != nil {
+		return x.Passphrases
+	}
+	return nil
+}
+
+func (x *GenerateResponse) GetStartTypeEntropyBits() float64 {
+	if x != nil {
+		return x.StartTypeEntropyBits
+	}
+	return 0
+}
+
+func (x *GenerateResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *GenerateResponse) GetMinEntropyBits() float64 {
+	if x != nil {
+		return x.MinEntropyBits
+	}
+	return 0
+}
+
+var File_wordentropy_proto protoreflect.FileDescriptor
+
+const file_wordentropy_proto_rawDesc = "" +
+	"\n" +
+	"\x11wordentropy.proto\x12\vwordentropy\"\x90\f\n" +
+	"\x0fGenerateRequest\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\rR\x05count\x12\x16\n" +
+	"\x06length\x18\x02 \x01(\rR\x06length\x12'\n" +
+	"\x0ffragment_length\x18\x03 \x01(\rR\x0efragmentLength\x12\x18\n" +
+	"\aprudish\x18\x04 \x01(\bR\aprudish\x12\x1b\n" +
+	"\tno_spaces\x18\x05 \x01(\bR\bnoSpaces\x12\x1b\n" +
+	"\tadd_digit\x18\x06 \x01(\bR\baddDigit\x12\x1d\n" +
+	"\n" +
+	"add_symbol\x18\a \x01(\bR\taddSymbol\x12\x18\n" +
+	"\asymbols\x18\b \x03(\tR\asymbols\x12\x1c\n" +
+	"\tseparator\x18\t \x01(\tR\tseparator\x12\x1c\n" +
+	"\tlowercase\x18\n" +
+	" \x01(\bR\tlowercase\x12\x1e\n" +
+	"\n" +
+	"capitalize\x18\v \x01(\tR\n" +
+	"capitalize\x12\x1b\n" +
+	"\tmax_chars\x18\f \x01(\rR\bmaxChars\x12\x1f\n" +
+	"\vmax_retries\x18\r \x01(\rR\n" +
+	"maxRetries\x12\x1d\n" +
+	"\n" +
+	"timeout_ms\x18\x0e \x01(\x04R\ttimeoutMs\x12&\n" +
+	"\x0fmin_word_length\x18\x0f \x01(\rR\rminWordLength\x12&\n" +
+	"\x0fmax_word_length\x18\x10 \x01(\rR\rmaxWordLength\x121\n" +
+	"\x15no_adjacent_same_type\x18\x11 \x01(\bR\x12noAdjacentSameType\x12#\n" +
+	"\rallowed_types\x18\x12 \x03(\tR\fallowedTypes\x12`\n" +
+	"\x12start_type_weights\x18\x13 \x03(\v22.wordentropy.GenerateRequest.StartTypeWeightsEntryR\x10startTypeWeights\x12#\n" +
+	"\rprudish_level\x18\x14 \x01(\rR\fprudishLevel\x12\x16\n" +
+	"\x06digits\x18\x15 \x03(\tR\x06digits\x12*\n" +
+	"\x11max_symbol_length\x18\x16 \x01(\rR\x0fmaxSymbolLength\x12\x17\n" +
+	"\abest_of\x18\x17 \x01(\rR\x06bestOf\x12M\n" +
+	"\vjoint_types\x18\x18 \x03(\v2,.wordentropy.GenerateRequest.JointTypesEntryR\n" +
+	"jointTypes\x12\x1b\n" +
+	"\tno_joints\x18\x19 \x01(\bR\bnoJoints\x12\x1b\n" +
+	"\tmax_bytes\x18\x1a \x01(\rR\bmaxBytes\x120\n" +
+	"\x14avoid_common_phrases\x18\x1b \x01(\bR\x12avoidCommonPhrases\x12(\n" +
+	"\x10extra_deny_words\x18\x1c \x03(\tR\x0eextraDenyWords\x12&\n" +
+	"\x0fshort_word_bias\x18\x1d \x01(\x01R\rshortWordBias\x12-\n" +
+	"\x12unambiguous_concat\x18\x1e \x01(\bR\x11unambiguousConcat\x12\x1c\n" +
+	"\tagreement\x18\x1f \x01(\bR\tagreement\x12#\n" +
+	"\rallow_partial\x18  \x01(\bR\fallowPartial\x12'\n" +
+	"\x0ffragment_jitter\x18! \x01(\rR\x0efragmentJitter\x12\x1b\n" +
+	"\tjoin_type\x18\" \x01(\tR\bjoinType\x12+\n" +
+	"\x11filter_categories\x18# \x03(\tR\x10filterCategories\x12\x1f\n" +
+	"\vvary_joints\x18$ \x01(\bR\n" +
+	"varyJoints\x12#\n" +
+	"\rmax_syllables\x18% \x01(\rR\fmaxSyllables\x12\x18\n" +
+	"\aentropy\x18& \x01(\bR\aentropy\x1aC\n" +
+	"\x15StartTypeWeightsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\x1a=\n" +
+	"\x0fJointTypesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\"\xab\x01\n" +
+	"\x10GenerateResponse\x12 \n" +
+	"\vpassphrases\x18\x01 \x03(\tR\vpassphrases\x125\n" +
+	"\x17start_type_entropy_bits\x18\x02 \x01(\x01R\x14startTypeEntropyBits\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12(\n" +
+	"\x10min_entropy_bits\x18\x04 \x01(\x01R\x0eminEntropyBits2V\n" +
+	"\vWordentropy\x12G\n" +
+	"\bGenerate\x12\x1c.wordentropy.GenerateRequest\x1a\x1d.wordentropy.GenerateResponseB,Z*github.com/bkeroack/libwordentropy/grpcapib\x06proto3"
+
+var (
+	file_wordentropy_proto_rawDescOnce sync.Once
+	file_wordentropy_proto_rawDescData []byte
+)
+
+func file_wordentropy_proto_rawDescGZIP() []byte {
+	file_wordentropy_proto_rawDescOnce.Do(func() {
+		file_wordentropy_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_wordentropy_proto_rawDesc), len(file_wordentropy_proto_rawDesc)))
+	})
+	return file_wordentropy_proto_rawDescData
+}
+
+var file_wordentropy_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_wordentropy_proto_goTypes = []any{
+	(*GenerateRequest)(nil),  // 0: wordentropy.GenerateRequest
+	(*GenerateResponse)(nil), // 1: wordentropy.GenerateResponse
+	nil,                      // 2: wordentropy.GenerateRequest.StartTypeWeightsEntry
+	nil,                      // 3: wordentropy.GenerateRequest.JointTypesEntry
+}
+var file_wordentropy_proto_depIdxs = []int32{
+	2, // 0: wordentropy.GenerateRequest.start_type_weights:type_name -> wordentropy.GenerateRequest.StartTypeWeightsEntry
+	3, // 1: wordentropy.GenerateRequest.joint_types:type_name -> wordentropy.GenerateRequest.JointTypesEntry
+	0, // 2: wordentropy.Wordentropy.Generate:input_type -> wordentropy.GenerateRequest
+	1, // 3: wordentropy.Wordentropy.Generate:output_type -> wordentropy.GenerateResponse
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_wordentropy_proto_init() }
+func file_wordentropy_proto_init() {
+	if File_wordentropy_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_wordentropy_proto_rawDesc), len(file_wordentropy_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_wordentropy_proto_goTypes,
+		DependencyIndexes: file_wordentropy_proto_depIdxs,
+		MessageInfos:      file_wordentropy_proto_msgTypes,
+	}.Build()
+	File_wordentropy_proto = out.File
+	file_wordentropy_proto_goTypes = nil
+	file_wordentropy_proto_depIdxs = nil
+}
@@ -0,0 +1,337 @@
+package wordentropy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	pad_bucket_default = 512
+)
+
+// Errors returned by the HTTP handler
+var (
+	ErrInvalidParameter  = errors.New("invalid parameter")
+	ErrRateLimited       = errors.New("rate limit exceeded")
+	ErrWorkLimitExceeded = errors.New("requested count × length exceeds work limit")
+)
+
+// Sentinel errors reported by name in JSON error bodies
+var error_names = []struct {
+	err  error
+	name string
+}{
+	{ErrInvalidParameter, "ErrInvalidParameter"},
+	{ErrRateLimited, "ErrRateLimited"},
+	{ErrWorkLimitExceeded, "ErrWorkLimitExceeded"},
+	{ErrUnknownProfile, "ErrUnknownProfile"},
+	{ErrWordlistNotLoaded, "ErrWordlistNotLoaded"},
+	{ErrEmptyWordlist, "ErrEmptyWordlist"},
+	{ErrWordlistTooLarge, "ErrWordlistTooLarge"},
+	{ErrSameFile, "ErrSameFile"},
+	{ErrNoFiles, "ErrNoFiles"},
+	{ErrUnprintableWord, "ErrUnprintableWord"},
+	{ErrClosed, "ErrClosed"},
+	{ErrCountExceedsMax, "ErrCountExceedsMax"},
+	{ErrLengthExceedsMax, "ErrLengthExceedsMax"},
+	{ErrFragmentExceedsMax, "ErrFragmentExceedsMax"},
+	{ErrInvalidFragments, "ErrInvalidFragments"},
+	{ErrNoCandidates, "ErrNoCandidates"},
+	{ErrUnknownCapitalize, "ErrUnknownCapitalize"},
+	{ErrRetriesExhausted, "ErrRetriesExhausted"},
+	{ErrDeadlineExceeded, "ErrDeadlineExceeded"},
+	{ErrInvalidWordLength, "ErrInvalidWordLength"},
+	{ErrInvalidBias, "ErrInvalidBias"},
+	{ErrInvalidDigits, "ErrInvalidDigits"},
+	{ErrMaxBytesTooSmall, "ErrMaxBytesTooSmall"},
+	{ErrInvalidSymbol, "ErrInvalidSymbol"},
+	{ErrUnknownWordType, "ErrUnknownWordType"},
+	{ErrGrammarUnusable, "ErrGrammarUnusable"},
+	{ErrRandomness, "ErrRandomness"},
+	{ErrInternal, "ErrInternal"},
+}
+
+// Name of the sentinel error an error wraps, as reported in error bodies (ErrInternal if
+// it wraps none of them). Used by other transports to report errors the same way.
+func ErrorCode(err error) string {
+	for _, e := range error_names {
+		if errors.Is(err, e.err) {
+			return e.name
+		}
+	}
+	return "ErrInternal"
+}
+
+// Options for the HTTP handler. All fields are optional.
+type HandlerOptions struct {
+	PadResponses bool              // pad JSON responses to a multiple of PadBucket bytes to hide passphrase length
+	PadBucket    uint              // padding bucket size in bytes (default 512)
+	RateLimit    float64           // requests per second allowed per client IP (0 = unlimited)
+	RateBurst    uint              // requests a client IP may burst above RateLimit (default 1)
+	MaxClients   int               // client IPs tracked by the rate limiter before evicting the least recent (default 10000)
+	MaxWork      uint              // maximum count × length (× BestOf) per request (0 = unlimited)
+	AccessLog    func(RequestInfo) // called after each request with non-secret request metadata
+}
+
+// Request metadata passed to the access log hook. It deliberately has no fields that can
+// carry generated content: only parsed options, the outcome and timing.
+type RequestInfo struct {
+	Method         string
+	RemoteIP       string
+	Profile        string // profile requested from a NewSetHandler ("" otherwise)
+	Count          uint
+	Length         uint
+	FragmentLength uint
+	Prudish        bool
+	NoSpaces       bool
+	AddDigit       bool
+	AddSymbol      bool
+	Status         int
+	ErrorCode      string // sentinel error name if the request failed
+	Latency        time.Duration
+}
+
+// JSON response body returned by the HTTP handler
+type Response struct {
+	Passphrases []string `json:"passphrases"`
+	Error       string   `json:"error,omitempty"`   // with allow_partial, why fewer passphrases were returned than requested (status 207)
+	Code        string   `json:"code,omitempty"`    // name of the sentinel error with Error
+	Padding     string   `json:"padding,omitempty"` // whitespace used to round the body up to the padding bucket
+}
+
+// JSON error body returned by the HTTP handler
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"` // name of the sentinel error, e.g. "ErrRateLimited"
+}
+
+type handler struct {
+	profile Profile       // what NewHandler serves
+	set     *GeneratorSet // profiles NewSetHandler serves (nil for NewHandler)
+	o       HandlerOptions
+	limiter *rate_limiter
+}
+
+// Return an http.Handler that generates passphrases as JSON. Generation options are read
+// from query parameters: count, length, fragment_length, prudish, no_spaces, add_digit,
+// add_symbol and allow_partial. With allow_partial, a request that fails after some
+// passphrases were generated gets them with status 207 (Multi-Status) and the error. After
+// Generator.Close, requests get status 503 (Service Unavailable).
+func NewHandler(g *Generator, o *HandlerOptions) http.Handler {
+	return new_handler(&handler{profile: Profile{Generator: g}}, o)
+}
+
+// Return an http.Handler like NewHandler's that serves the profiles in s, chosen by the
+// profile query parameter and taking their Defaults and MaxWork. A request for a profile
+// that is not registered gets status 404 (Not Found); one without a profile, status 400.
+// Profiles may be registered while serving.
+func NewSetHandler(s *GeneratorSet, o *HandlerOptions) http.Handler {
+	return new_handler(&handler{set: s}, o)
+}
+
+// Apply the handler options o (nil for the defaults) to h
+func new_handler(h *handler, o *HandlerOptions) http.Handler {
+	if o != nil {
+		h.o = *o
+	}
+	if h.o.PadBucket == 0 {
+		h.o.PadBucket = pad_bucket_default
+	}
+	if h.o.RateLimit > 0 {
+		h.limiter = new_rate_limiter(h.o.RateLimit, h.o.RateBurst, h.o.MaxClients)
+	}
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	info := RequestInfo{
+		Method:   r.Method,
+		RemoteIP: client_ip(r),
+	}
+	info.Status, info.ErrorCode = h.serve(w, r, &info)
+	info.Latency = time.Since(start)
+	if h.o.AccessLog != nil {
+		h.o.AccessLog(info)
+	}
+}
+
+// Handle a request, returning the status and error code written
+func (h *handler) serve(w http.ResponseWriter, r *http.Request, info *RequestInfo) (int, string) {
+	if r.Method != http.MethodGet {
+		h.write_json(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed", Code: "ErrMethodNotAllowed"})
+		return http.StatusMethodNotAllowed, "ErrMethodNotAllowed"
+	}
+	if h.limiter != nil && !h.limiter.allow(info.RemoteIP) {
+		return h.write_error(w, http.StatusTooManyRequests, ErrRateLimited)
+	}
+	p := h.profile
+	if h.set != nil {
+		info.Profile = r.URL.Query().Get("profile")
+		if info.Profile == "" {
+			return h.write_error(w, http.StatusBadRequest, fmt.Errorf("%w: profile: required", ErrInvalidParameter))
+		}
+		var err error
+		if p, err = h.set.Profile(info.Profile); err != nil {
+			return h.write_error(w, http.StatusNotFound, err)
+		}
+	}
+	o, err := parse_query_options(r, p.Defaults)
+	if err != nil {
+		return h.write_error(w, http.StatusBadRequest, err)
+	}
+	info.Count = o.Count
+	info.Length = o.Length
+	info.FragmentLength = o.Fragments.TargetFragmentWords
+	info.Prudish = o.Prudish
+	info.NoSpaces = o.No_spaces
+	info.AddDigit = o.Add_digit
+	info.AddSymbol = o.Add_symbol
+	max_work := h.o.MaxWork
+	if p.MaxWork > 0 {
+		max_work = p.MaxWork
+	}
+	if err := CheckWork(o, max_work); err != nil {
+		return h.write_error(w, http.StatusBadRequest, err)
+	}
+	phrases, err := p.Generator.GeneratePassphrases(o)
+	if errors.Is(err, ErrInternal) || errors.Is(err, ErrRandomness) {
+		return h.write_error(w, http.StatusInternalServerError, err)
+	}
+	if errors.Is(err, ErrClosed) {
+		return h.write_error(w, http.StatusServiceUnavailable, err)
+	}
+	var partial *BatchError
+	if errors.As(err, &partial) && len(phrases) > 0 {
+		code := ErrorCode(err)
+		h.write_json(w, http.StatusMultiStatus, Response{Passphrases: phrases, Error: err.Error(), Code: code})
+		return http.StatusMultiStatus, code
+	}
+	if err != nil {
+		return h.write_error(w, http.StatusBadRequest, err)
+	}
+	h.write_json(w, http.StatusOK, Response{Passphrases: phrases})
+	return http.StatusOK, ""
+}
+
+// Rate limiting key for a request (RemoteAddr without the port)
+func client_ip(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Check a request against a work limit (see HandlerOptions.MaxWork; 0 = unlimited),
+// returning ErrWorkLimitExceeded if it asks for more
+func CheckWork(o *GenerateOptions, max_work uint) error {
+	if max_work > 0 && request_work(o) > max_work {
+		return fmt.Errorf("%w: %v", ErrWorkLimitExceeded, max_work)
+	}
+	return nil
+}
+
+// Work requested as count × length, times BestOf candidates, with defaults applied to
+// unset fields
+func request_work(o *GenerateOptions) uint {
+	count, length := o.Count, o.Length
+	if count == 0 {
+		count = count_default
+	}
+	if length == 0 {
+		length = length_default
+	}
+	return count * length * max(o.BestOf, 1)
+}
+
+// Options from the query parameters of a request, starting from defaults (nil for none)
+func parse_query_options(r *http.Request, defaults *GenerateOptions) (*GenerateOptions, error) {
+	q := r.URL.Query()
+	o := GenerateOptions{}
+	if defaults != nil {
+		o = *defaults
+	}
+
+	uints := []struct {
+		name string
+		dst  *uint
+	}{
+		{"count", &o.Count},
+		{"length", &o.Length},
+		{"fragment_length", &o.Fragments.TargetFragmentWords},
+	}
+	for _, u := range uints {
+		if v := q.Get(u.name); v != "" {
+			n, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v: %v", ErrInvalidParameter, u.name, v)
+			}
+			*u.dst = uint(n)
+		}
+	}
+
+	bools := []struct {
+		name string
+		dst  *bool
+	}{
+		{"prudish", &o.Prudish},
+		{"no_spaces", &o.No_spaces},
+		{"add_digit", &o.Add_digit},
+		{"add_symbol", &o.Add_symbol},
+		{"allow_partial", &o.AllowPartial},
+	}
+	for _, b := range bools {
+		if v := q.Get(b.name); v != "" {
+			t, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v: %v", ErrInvalidParameter, b.name, v)
+			}
+			*b.dst = t
+		}
+	}
+	return &o, nil
+}
+
+func (h *handler) write_error(w http.ResponseWriter, status int, err error) (int, string) {
+	code := ErrorCode(err)
+	h.write_json(w, status, ErrorResponse{Error: err.Error(), Code: code})
+	return status, code
+}
+
+func (h *handler) write_json(w http.ResponseWriter, status int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if resp, ok := v.(Response); ok && h.o.PadResponses {
+		body, err = pad_response(resp, body, h.o.PadBucket)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// Re-encode a response with a padding field sized so the body length is a multiple of bucket
+func pad_response(resp Response, body []byte, bucket uint) ([]byte, error) {
+	overhead := len(`,"padding":""`)
+	min_size := len(body) + overhead + 1 // always emit at least one byte of padding
+	target := (min_size + int(bucket) - 1) / int(bucket) * int(bucket)
+	pad := make([]byte, target-len(body)-overhead)
+	for i := range pad {
+		pad[i] = ' '
+	}
+	resp.Padding = string(pad)
+	return json.Marshal(resp)
+}
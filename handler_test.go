@@ -0,0 +1,235 @@
+package wordentropy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func serve(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	h := NewHandler(load_test_generator(t), nil)
+
+	rec := serve(h, "/?count=3&length=6&add_digit=true")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %v: %v", rec.Code, rec.Body.String())
+	}
+	var resp Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Could not decode response: %v", err)
+	}
+	if len(resp.Passphrases) != 3 {
+		t.Fatalf("Expected 3 passphrases, got %v", len(resp.Passphrases))
+	}
+	if resp.Padding != "" {
+		t.Fatalf("Unexpected padding with padding disabled")
+	}
+
+	for _, target := range []string{"/?count=abc", "/?length=1000", "/?prudish=maybe"} {
+		rec := serve(h, target)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%v: expected 400, got %v", target, rec.Code)
+		}
+		var e ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil || e.Error == "" {
+			t.Errorf("%v: expected JSON error body, got %v", target, rec.Body.String())
+		}
+	}
+}
+
+func TestHandlerPadding(t *testing.T) {
+	g := load_test_generator(t)
+	for _, bucket := range []uint{0, 128, 512} {
+		h := NewHandler(g, &HandlerOptions{PadResponses: true, PadBucket: bucket})
+		if bucket == 0 {
+			bucket = pad_bucket_default
+		}
+		sizes := make(map[int]bool)
+		for length := 1; length <= 40; length++ {
+			rec := serve(h, fmt.Sprintf("/?count=2&length=%v&add_symbol=true", length))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %v: %v", rec.Code, rec.Body.String())
+			}
+			n := rec.Body.Len()
+			if n%int(bucket) != 0 {
+				t.Errorf("Body length %v is not a multiple of %v", n, bucket)
+			}
+			if cl := rec.Header().Get("Content-Length"); cl != strconv.Itoa(n) {
+				t.Errorf("Content-Length %v does not match body length %v", cl, n)
+			}
+			var resp Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Could not decode padded response: %v", err)
+			}
+			sizes[n] = true
+		}
+		if bucket == 512 && len(sizes) != 1 {
+			t.Errorf("Expected a single bucketed size for short responses, got %v", sizes)
+		}
+	}
+}
+
+func serve_from(h http.Handler, target string, addr string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", target, nil)
+	r.RemoteAddr = addr
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func error_code(t *testing.T, rec *httptest.ResponseRecorder) string {
+	var e ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil {
+		t.Fatalf("Could not decode error body: %v", err)
+	}
+	return e.Code
+}
+
+func TestHandlerRateLimit(t *testing.T) {
+	h := NewHandler(load_test_generator(t), &HandlerOptions{RateLimit: 1, RateBurst: 2, MaxClients: 2}).(*handler)
+	now := time.Unix(1000, 0)
+	h.limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if rec := serve_from(h, "/", "10.0.0.1:1234"); rec.Code != http.StatusOK {
+			t.Fatalf("Request %v within burst: expected 200, got %v", i, rec.Code)
+		}
+	}
+	rec := serve_from(h, "/", "10.0.0.1:5678")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 after burst, got %v", rec.Code)
+	}
+	if code := error_code(t, rec); code != "ErrRateLimited" {
+		t.Fatalf("Expected ErrRateLimited, got %v", code)
+	}
+	if rec := serve_from(h, "/", "10.0.0.2:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("Other client should not be limited, got %v", rec.Code)
+	}
+
+	now = now.Add(time.Second)
+	if rec := serve_from(h, "/", "10.0.0.1:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected token refill after 1s, got %v", rec.Code)
+	}
+	if rec := serve_from(h, "/", "10.0.0.1:1234"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 after refilled token was used, got %v", rec.Code)
+	}
+
+	// Two new clients evict 10.0.0.1, which then starts again with a full bucket
+	serve_from(h, "/", "10.0.0.3:1234")
+	serve_from(h, "/", "10.0.0.4:1234")
+	if n := h.limiter.len(); n != 2 {
+		t.Fatalf("Expected limiter to track 2 clients, got %v", n)
+	}
+	if rec := serve_from(h, "/", "10.0.0.1:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("Evicted client should start with a full bucket, got %v", rec.Code)
+	}
+}
+
+func TestHandlerMaxWork(t *testing.T) {
+	h := NewHandler(load_test_generator(t), &HandlerOptions{MaxWork: 50})
+
+	rec := serve(h, "/?count=10&length=10")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %v", rec.Code)
+	}
+	if code := error_code(t, rec); code != "ErrWorkLimitExceeded" {
+		t.Fatalf("Expected ErrWorkLimitExceeded, got %v", code)
+	}
+	if rec := serve(h, "/?count=5&length=10"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 within budget, got %v", rec.Code)
+	}
+	if rec := serve(h, "/?length=20"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected default count to count towards work, got %v", rec.Code)
+	}
+	if code := error_code(t, serve(h, "/?count=x")); code != "ErrInvalidParameter" {
+		t.Fatalf("Expected ErrInvalidParameter, got %v", code)
+	}
+}
+
+func TestErrorCodeAndCheckWork(t *testing.T) {
+	cases := []struct {
+		err  error
+		code string
+	}{
+		{fmt.Errorf("%w: xyzzy", ErrUnknownWordType), "ErrUnknownWordType"},
+		{ErrDeadlineExceeded, "ErrDeadlineExceeded"},
+		{fmt.Errorf("%w: 5", ErrWorkLimitExceeded), "ErrWorkLimitExceeded"},
+		{fmt.Errorf("unexpected"), "ErrInternal"},
+	}
+	for _, c := range cases {
+		if code := ErrorCode(c.err); code != c.code {
+			t.Errorf("%v: expected %v, got %v", c.err, c.code, code)
+		}
+	}
+
+	if err := CheckWork(&GenerateOptions{Count: 10, Length: 10}, 0); err != nil {
+		t.Errorf("Expected no limit with 0, got %v", err)
+	}
+	if err := CheckWork(&GenerateOptions{Count: 5, Length: 10}, 50); err != nil {
+		t.Errorf("Expected work within limit, got %v", err)
+	}
+	if err := CheckWork(&GenerateOptions{Count: 5, Length: 10, BestOf: 2}, 50); !errors.Is(err, ErrWorkLimitExceeded) {
+		t.Errorf("Expected BestOf candidates to count towards work, got %v", err)
+	}
+	if err := CheckWork(&GenerateOptions{Length: 20}, 50); !errors.Is(err, ErrWorkLimitExceeded) {
+		t.Errorf("Expected ErrWorkLimitExceeded with default count, got %v", err)
+	}
+}
+
+func TestHandlerAccessLogNeverLeaksPhrases(t *testing.T) {
+	const sentinel = "zqxsentinel"
+	var infos []RequestInfo
+	h := NewHandler(generator_for(uniform_word_map(sentinel)), &HandlerOptions{
+		MaxWork:   100,
+		AccessLog: func(ri RequestInfo) { infos = append(infos, ri) },
+	})
+
+	targets := []string{
+		"/?count=3&length=6&prudish=true",
+		"/?count=50&length=50",
+		"/?count=abc",
+		"/?add_symbol=true&add_digit=true&no_spaces=true",
+	}
+	var bodies string
+	for _, target := range targets {
+		rec := serve(h, target)
+		bodies += rec.Body.String()
+		if rec.Code != http.StatusOK {
+			if strings.Contains(rec.Body.String(), sentinel) {
+				t.Errorf("%v: error body contains generated content: %v", target, rec.Body.String())
+			}
+		}
+	}
+	if !strings.Contains(bodies, sentinel) {
+		t.Fatalf("Sanity check failed: no generated content in any response")
+	}
+
+	if len(infos) != len(targets) {
+		t.Fatalf("Expected %v access log entries, got %v", len(targets), len(infos))
+	}
+	for _, ri := range infos {
+		if s := fmt.Sprintf("%+v", ri); strings.Contains(s, sentinel) {
+			t.Errorf("Access log entry contains generated content: %v", s)
+		}
+	}
+	if infos[0].Status != http.StatusOK || infos[0].Count != 3 || infos[0].Length != 6 || !infos[0].Prudish {
+		t.Errorf("Unexpected access log entry: %+v", infos[0])
+	}
+	if infos[1].Status != http.StatusBadRequest || infos[1].ErrorCode != "ErrWorkLimitExceeded" {
+		t.Errorf("Unexpected access log entry: %+v", infos[1])
+	}
+	if infos[2].ErrorCode != "ErrInvalidParameter" {
+		t.Errorf("Unexpected access log entry: %+v", infos[2])
+	}
+}
@@ -0,0 +1,40 @@
+package wordentropy
+
+import (
+	"fmt"
+)
+
+// Hash of a passphrase in the form it will be stored, e.g. a bcrypt hash or an Argon2 PHC
+// string. Supplied by the caller, so the package does not depend on a particular KDF.
+type HashFunc func(phrase string) (string, error)
+
+// Generated passphrase along with its hash
+type HashedPassphrase struct {
+	Plaintext string
+	Hash      string
+}
+
+// Generate passphrases as GeneratePassphrases does and hash each with h, for provisioning
+// systems that store only the hash and hand the plaintext to the user once. If h fails, its
+// error is returned naming the passphrase, with no results. Passphrases returned along with
+// an error (ErrDeadlineExceeded, or a *BatchError with AllowPartial) are hashed too. A
+// panic in h is returned as an InternalError.
+func (g *Generator) GenerateHashedPassphrases(o *GenerateOptions, h HashFunc) (_ []HashedPassphrase, err error) {
+	defer recover_internal(&err)
+	if h == nil {
+		return nil, fmt.Errorf("%w: HashFunc is nil", ErrInvalidParameter)
+	}
+	p, err := g.GeneratePassphrases(o)
+	if p == nil {
+		return nil, err
+	}
+	hashed := make([]HashedPassphrase, len(p))
+	for i, phrase := range p {
+		hash, herr := h(phrase)
+		if herr != nil {
+			return nil, fmt.Errorf("error hashing passphrase %v: %w", i, herr)
+		}
+		hashed[i] = HashedPassphrase{Plaintext: phrase, Hash: hash}
+	}
+	return hashed, err
+}
@@ -0,0 +1,59 @@
+package wordentropy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGenerateHashedPassphrases(t *testing.T) {
+	g := load_test_generator(t)
+	var calls int
+	stub := func(phrase string) (string, error) {
+		calls++
+		return "hash(" + phrase + ")", nil
+	}
+	hashed, err := g.GenerateHashedPassphrases(&GenerateOptions{Count: 5}, stub)
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	if len(hashed) != 5 || calls != 5 {
+		t.Fatalf("Expected 5 hashed passphrases from 5 calls, got %v from %v", len(hashed), calls)
+	}
+	for _, h := range hashed {
+		if h.Plaintext == "" || h.Hash != "hash("+h.Plaintext+")" {
+			t.Errorf("Hash %q does not belong to %q", h.Hash, h.Plaintext)
+		}
+	}
+
+	errHash := errors.New("out of memory")
+	fail := func(phrase string) (string, error) {
+		return "", errHash
+	}
+	if hashed, err := g.GenerateHashedPassphrases(&GenerateOptions{Count: 2}, fail); hashed != nil || !errors.Is(err, errHash) {
+		t.Errorf("Expected the hash error and no results, got %v, %v", hashed, err)
+	}
+
+	// Generation errors are passed through
+	o := GenerateOptions{Count: 2, MaxChars: 1, MaxRetries: 4}
+	if hashed, err := g.GenerateHashedPassphrases(&o, stub); hashed != nil || err != ErrRetriesExhausted {
+		t.Errorf("Expected ErrRetriesExhausted and no results, got %v, %v", hashed, err)
+	}
+	o.AllowPartial = true
+	var pe *BatchError
+	if hashed, err := g.GenerateHashedPassphrases(&o, stub); len(hashed) != 0 || !errors.As(err, &pe) {
+		t.Errorf("Expected a BatchError, got %v, %v", hashed, err)
+	}
+
+	if hashed, err := g.GenerateHashedPassphrases(&GenerateOptions{Count: 2}, nil); hashed != nil || !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for a nil HashFunc, got %v, %v", hashed, err)
+	}
+	if !recover_panics {
+		t.Skip("panic recovery disabled by build tag")
+	}
+	panicking := func(phrase string) (string, error) {
+		panic("hash table corrupted")
+	}
+	if hashed, err := g.GenerateHashedPassphrases(&GenerateOptions{Count: 2}, panicking); hashed != nil || !errors.Is(err, ErrInternal) {
+		t.Errorf("Expected ErrInternal from a panicking HashFunc, got %v, %v", hashed, err)
+	}
+}
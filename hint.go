@@ -0,0 +1,43 @@
+package wordentropy
+
+import (
+	"strings"
+)
+
+// Word type names shown in memorization hints
+var hint_names = map[string]string{
+	"snoun":    "noun",
+	"pnoun":    "plural noun",
+	"sarticle": "article",
+	"particle": "article",
+}
+
+// Format a passphrase with its part-of-speech skeleton on a second line, as a memory aid:
+//
+//	brave otter quietly sings
+//	[adjective] [noun] [adverb] [verb]
+//
+// A multiword entry gets a single bracket, and an appended digit or symbol is shown as
+// [digit] or [symbol].
+func FormatHint(p Passphrase) string {
+	hints := make([]string, 0, len(p.Entries)+2)
+	i := 0
+	for _, n := range p.Entries {
+		if i >= len(p.Types) {
+			break
+		}
+		name := p.Types[i]
+		if h, ok := hint_names[name]; ok {
+			name = h
+		}
+		hints = append(hints, "["+name+"]")
+		i += n
+	}
+	if p.Digit != "" {
+		hints = append(hints, "[digit]")
+	}
+	if p.Symbol != "" {
+		hints = append(hints, "[symbol]")
+	}
+	return p.Phrase + "\n" + strings.Join(hints, " ")
+}
@@ -0,0 +1,39 @@
+package wordentropy
+
+import (
+	"testing"
+)
+
+func TestFormatHint(t *testing.T) {
+	p := Passphrase{
+		Phrase:  "brave otter quietly sings",
+		Words:   []string{"brave", "otter", "quietly", "sings"},
+		Types:   []string{"adjective", "snoun", "adverb", "verb"},
+		Entries: []int{1, 1, 1, 1},
+	}
+	expected := "brave otter quietly sings\n[adjective] [noun] [adverb] [verb]"
+	if h := FormatHint(p); h != expected {
+		t.Fatalf("Expected %q, got %q", expected, h)
+	}
+
+	g := &Generator{}
+	g.data.Store(&word_data{
+		word_map: map[string][]string{"snoun": {"ice cream"}},
+		grammar:  map[string][]string{"snoun": {"snoun"}},
+		types:    []string{"snoun"},
+	})
+	ps, _, err := g.GeneratePassphrasesDetailed(&GenerateOptions{
+		Count:      1,
+		Length:     3,
+		Add_digit:  true,
+		Add_symbol: true,
+		Symbols:    []string{"!"},
+	})
+	if err != nil {
+		t.Fatalf("Error generating passphrase: %v", err)
+	}
+	expected = "ice cream ice" + ps[0].Digit + "!\n[noun] [noun] [digit] [symbol]"
+	if h := FormatHint(ps[0]); h != expected {
+		t.Fatalf("Expected %q, got %q", expected, h)
+	}
+}
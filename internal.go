@@ -0,0 +1,41 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+var ErrInternal = errors.New("internal error")
+
+// Panic recovered at an API boundary. Unwraps to ErrInternal.
+type InternalError struct {
+	Value interface{} // value passed to panic
+	Stack []byte      // stack trace of the panicking goroutine
+}
+
+func (e *InternalError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrInternal, e.Value)
+}
+
+func (e *InternalError) Unwrap() error {
+	return ErrInternal
+}
+
+// Disabled by the wordentropy_norecover build tag so fuzzing sees panics
+var recover_panics = true
+
+// Deferred by exported functions to convert a panic into an InternalError, or into
+// ErrRandomness if the randomness source failed
+func recover_internal(err *error) {
+	if !recover_panics {
+		return
+	}
+	if r := recover(); r != nil {
+		if f, ok := r.(randomness_failure); ok {
+			*err = fmt.Errorf("%w: %v", ErrRandomness, f.err)
+			return
+		}
+		*err = &InternalError{Value: r, Stack: debug.Stack()}
+	}
+}
@@ -0,0 +1,103 @@
+package wordentropy
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type panicking_reader struct{}
+
+func (panicking_reader) Read(p []byte) (int, error) {
+	panic("randomness source bug")
+}
+
+type failing_reader struct{}
+
+func (failing_reader) Read(p []byte) (int, error) {
+	return 0, errors.New("entropy pool closed")
+}
+
+type panicking_provider struct {
+	MapProvider
+}
+
+func (p panicking_provider) Words(word_type string) ([]string, error) {
+	panic("provider bug")
+}
+
+// Source always drawing the largest value allowed
+type last_source struct{}
+
+func (last_source) int_n(max int64) int64 {
+	return max - 1
+}
+
+// Random choices can pick the last element (they once drew from all but the last)
+func TestChoiceReachesLast(t *testing.T) {
+	s := &gen_state{rng: last_source{}}
+	if w := s.choice([]string{"otter", "badger", "wombat"}); w != "wombat" {
+		t.Errorf("Expected the last element, got %q", w)
+	}
+	if w := s.choice([]string{"otter"}); w != "otter" {
+		t.Errorf("Expected the only element, got %q", w)
+	}
+	if w := s.weighted_choice([]string{"otter", "wombat"}, map[string]uint{"otter": 1, "wombat": 1}); w != "wombat" {
+		t.Errorf("Expected the last weighted element, got %q", w)
+	}
+}
+
+// Inputs that used to panic now succeed or return an error
+func TestNoPanics(t *testing.T) {
+	g := generator_for(uniform_word_map("otter"))
+	ok := []struct {
+		name string
+		o    *GenerateOptions
+	}{
+		{"nil options", nil},
+		{"empty symbols", &GenerateOptions{Count: 1, Add_symbol: true, Symbols: []string{}}},
+		{"truncation below fragment length", &GenerateOptions{Count: 1, Length: 1, Magic_fragment_length: fragment_max}},
+		{"truncation at maximum length", &GenerateOptions{Count: 1, Length: length_max, Magic_fragment_length: 1}},
+	}
+	for _, c := range ok {
+		if _, err := g.GeneratePassphrases(c.o); err != nil {
+			t.Errorf("%v: unexpected error: %v", c.name, err)
+		}
+	}
+
+	if !recover_panics {
+		t.Skip("panic recovery disabled by build tag")
+	}
+
+	// A failing randomness source makes crypto/rand panic
+	broken := generator_for(uniform_word_map("otter"))
+	broken.rand = panicking_reader{}
+	_, err := broken.GeneratePassphrases(&GenerateOptions{Count: 1, Length: 3})
+	var ie *InternalError
+	if !errors.Is(err, ErrInternal) || !errors.As(err, &ie) {
+		t.Fatalf("Expected ErrInternal, got %v", err)
+	}
+	if ie.Value == nil || len(ie.Stack) == 0 {
+		t.Fatalf("Expected panic value and stack, got %+v", ie)
+	}
+	if rec := serve(NewHandler(broken, nil), "/?length=3"); rec.Code != http.StatusInternalServerError || error_code(t, rec) != "ErrInternal" {
+		t.Fatalf("Expected 500 ErrInternal from handler, got %v: %v", rec.Code, rec.Body.String())
+	}
+
+	// A randomness source returning an error is reported as such, not as a bug
+	broken.rand = failing_reader{}
+	if _, err := broken.GeneratePassphrases(&GenerateOptions{Count: 1, Length: 3}); !errors.Is(err, ErrRandomness) || errors.Is(err, ErrInternal) {
+		t.Fatalf("Expected ErrRandomness, got %v", err)
+	}
+	if rec := serve(NewHandler(broken, nil), "/?length=3"); rec.Code != http.StatusInternalServerError || error_code(t, rec) != "ErrRandomness" {
+		t.Fatalf("Expected 500 ErrRandomness from handler, got %v: %v", rec.Code, rec.Body.String())
+	}
+
+	var nil_generator *Generator
+	if _, _, err := nil_generator.GeneratePassphrasesDetailed(nil); !errors.Is(err, ErrInternal) {
+		t.Fatalf("Expected ErrInternal from nil generator, got %v", err)
+	}
+	if _, err := NewGeneratorFromProvider(panicking_provider{MapProvider(uniform_word_map("otter"))}); !errors.Is(err, ErrInternal) {
+		t.Fatalf("Expected ErrInternal from panicking provider, got %v", err)
+	}
+}
@@ -0,0 +1,741 @@
+package wordentropy
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrKeyspaceNotExact = errors.New("Keyspace cannot be counted exactly with these options")
+
+// Number of distinct passphrases the options can produce, e.g. to show "over 10^14 possible
+// passphrases". The count follows the generator's model, like KeyspaceUpperBound, but each
+// string is counted once however many ways it can be assembled: words listed under several
+// types, words that differ only in case once Lowercase and Capitalize are applied, multiword
+// entries cut to fit Length and fragments of different lengths with Fragments.Jitter add
+// nothing. It is the number of passphrases EnumerateAll lists.
+//
+// Returns ErrKeyspaceNotExact with options whose passphrases cannot be counted this way:
+// No_spaces, where words run together; MaxChars, MaxBytes, MaxSyllables, AvoidCommonPhrases
+// and Agreement, which reject or change passphrases once assembled; Digits or Symbols of
+// different lengths; and word list entries with words made only of unprintable characters.
+// KeyspaceUpperBound and 2^MinEntropy still bound the keyspace from above and below.
+//
+// The count keeps track of every way the words so far can be read, so it takes longer the
+// more ways there are: on the built-in list, whose multiword entries can often also be read
+// as several words, it takes a few seconds at Length 3 and about half a minute at Length 5,
+// about three times longer with each word. It stops with ErrDeadlineExceeded when the Timeout
+// in the options expires. Count, BestOf and Scorer in the options are ignored.
+func (g *Generator) Keyspace(o *GenerateOptions) (_ *big.Int, err error) {
+	defer recover_internal(&err)
+	var options GenerateOptions
+	if o != nil {
+		options = *o
+	}
+	var deadline time.Time
+	if options.Timeout > 0 {
+		deadline = time.Now().Add(options.Timeout)
+	}
+	options.Count, options.Timeout, options.BestOf, options.Scorer = 1, 0, 1, nil
+	s, err := g.prepare(&options, g.words(), nil)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case s.o.No_spaces:
+		return nil, fmt.Errorf("%w: words run together with No_spaces", ErrKeyspaceNotExact)
+	case s.o.MaxChars > 0 || s.o.MaxBytes > 0 || s.o.MaxSyllables > 0 || s.o.AvoidCommonPhrases || s.o.Agreement:
+		return nil, fmt.Errorf("%w: passphrases are rejected or changed once assembled", ErrKeyspaceNotExact)
+	case s.o.Add_digit && !same_lengths(s.o.Digits) || s.o.Add_symbol && !same_lengths(s.o.Symbols):
+		return nil, fmt.Errorf("%w: Digits or Symbols of different lengths", ErrKeyspaceNotExact)
+	}
+	k := new_exact_keyspace(s)
+	if k.inexact != "" {
+		return nil, fmt.Errorf("%w: entry %q", ErrKeyspaceNotExact, k.inexact)
+	}
+	k.deadline = deadline
+	n := new(big.Int).Set(k.count(k.closure(nil, exact_item{node: exact_node{start: true}})))
+	if k.expired {
+		return nil, ErrDeadlineExceeded
+	}
+	if s.o.Add_digit {
+		n.Mul(n, big.NewInt(int64(distinct(s.o.Digits))))
+	}
+	if s.o.Add_symbol {
+		n.Mul(n, big.NewInt(int64(distinct(s.o.Symbols))))
+	}
+	return n, nil
+}
+
+// Upper bound on the number of distinct passphrases the options can produce. The count
+// follows the generator's model: every word type sequence the grammar allows, times the
+// usable words of each type, times the digit and symbol choices. Capitalize and Lowercase
+// transform every passphrase the same way and add nothing.
+//
+// Word sequences are counted apart by the word list entries and word types they are drawn
+// from, so one string is counted several times when a word is listed under several types or
+// differs from another only in case, when multiword entries are cut to fit Length, or, with
+// Fragments.Jitter, when it can be assembled from fragments of different lengths. MaxChars,
+// MaxBytes, MaxSyllables, AvoidCommonPhrases and UnambiguousConcat rejections and Agreement
+// are not taken into account. Count, Timeout, BestOf and Scorer in the options are ignored.
+//
+// The entropy of a passphrase is at most log2 of the bound, and lower still when not every
+// passphrase is equally likely (e.g. with StartTypeWeights or ShortWordBias, or word types
+// with fewer words than others).
+func (g *Generator) KeyspaceUpperBound(o *GenerateOptions) (_ *big.Int, err error) {
+	defer recover_internal(&err)
+	var options GenerateOptions
+	if o != nil {
+		options = *o
+	}
+	options.Count, options.Timeout, options.BestOf, options.Scorer = 1, 0, 1, nil
+	s, err := g.prepare(&options, g.words(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return new_keyspace(s).size(), nil
+}
+
+// log2 of KeyspaceUpperBound: an upper bound on the bits of entropy of a passphrase generated
+// with the options. Being an upper bound, it cannot tell that options are strong enough.
+func (g *Generator) KeyspaceUpperBoundBits(o *GenerateOptions) (float64, error) {
+	n, err := g.KeyspaceUpperBound(o)
+	if err != nil {
+		return 0, err
+	}
+	return log2(n), nil
+}
+
+// Base 2 logarithm of a positive integer of any size
+func log2(n *big.Int) float64 {
+	mant := new(big.Float)
+	exp := new(big.Float).SetInt(n).MantExp(mant)
+	m, _ := mant.Float64()
+	return float64(exp) + math.Log2(m)
+}
+
+func new_keyspace(s *gen_state) *keyspace {
+	return &keyspace{
+		s:         s,
+		length:    int(s.o.Length),
+		fragments: fragment_lengths(s.o.Fragments),
+		pools:     make(map[string][]string),
+		sizes:     make(map[string][]int64),
+		memo:      make(map[keyspace_key]*big.Int),
+	}
+}
+
+// Number of distinct passphrases, including the padding
+func (k *keyspace) size() *big.Int {
+	s := k.s
+	n := k.fragment("", 0)
+	if s.o.Add_digit {
+		n.Mul(n, big.NewInt(int64(distinct(s.o.Digits))))
+	}
+	if s.o.Add_symbol {
+		n.Mul(n, big.NewInt(int64(distinct(s.o.Symbols))))
+	}
+	return n
+}
+
+// Keyspace counting state for one call
+type keyspace struct {
+	s         *gen_state
+	length    int                 // Length in words
+	fragments []int               // lengths a fragment may be drawn with
+	pools     map[string][]string // by word type: words that satisfy the options (nil if none)
+	sizes     map[string][]int64  // by word type: usable entries by words counted (capped at length)
+	memo      map[keyspace_key]*big.Int
+}
+
+// Position of the next entry to draw: length of its fragment and position in it, type of
+// the entry before it ("" if none) and words so far (less than length)
+type keyspace_key struct {
+	fragment, position int
+	before             string
+	words              int
+}
+
+// Number of distinct endings for passphrases starting a fragment after an entry of type
+// before. With Jitter, endings with different fragment lengths are counted separately.
+func (k *keyspace) fragment(before string, words int) *big.Int {
+	n := new(big.Int)
+	for _, f := range k.fragments {
+		n.Add(n, k.count(f, 0, before, words))
+	}
+	return n
+}
+
+// Number of distinct endings for passphrases from the position on. Generation stops once
+// the passphrase has Length words.
+func (k *keyspace) count(fragment int, position int, before string, words int) *big.Int {
+	key := keyspace_key{fragment, position, before, words}
+	if n, ok := k.memo[key]; ok {
+		return n
+	}
+	total := new(big.Int)
+	// Add the draws of entries of a type, times the endings after each
+	add := func(t string, ending func(words int) *big.Int) {
+		for n, c := range k.entries(t) {
+			if c == 0 {
+				continue
+			}
+			rest := big.NewInt(1)
+			if w := min(words+n, k.length); w < k.length {
+				rest = ending(w)
+			}
+			total.Add(total, new(big.Int).Mul(big.NewInt(c), rest))
+		}
+	}
+	nast := k.s.o.NoAdjacentSameType
+	candidates := k.s.start
+	if position > 0 {
+		candidates = k.s.rules[before]
+	}
+	if nast {
+		candidates = exclude_types(candidates, before)
+	}
+
+	if position < fragment-1 {
+		for _, t := range candidates {
+			add(t, func(w int) *big.Int { return k.count(fragment, position+1, t, w) })
+		}
+	} else {
+		for _, t := range candidates {
+			joints, direct := k.seams(t)
+			if len(joints) == 0 && !direct {
+				continue // the seam chosen before the entry always excludes t
+			}
+			add(t, func(w int) *big.Int {
+				if k.length-w == 1 {
+					return k.fragment(t, w)
+				}
+				rest := new(big.Int)
+				for _, j := range joints {
+					for n, c := range k.entries(j) {
+						if c == 0 {
+							continue
+						}
+						ending := big.NewInt(1)
+						if w := min(w+n, k.length); w < k.length {
+							ending = k.fragment(j, w)
+						}
+						rest.Add(rest, new(big.Int).Mul(big.NewInt(c), ending))
+					}
+				}
+				if direct {
+					rest.Add(rest, k.fragment(t, w))
+				}
+				return rest
+			})
+		}
+	}
+	k.memo[key] = total
+	return total
+}
+
+// Joint types with usable words that may follow the last entry of a fragment when it is of
+// type t, and whether the fragments may instead be joined directly. The seam's joint type is
+// chosen before the entry is drawn, as NoAdjacentSameType keeps them apart; a joint type
+// with no usable words joins the fragments directly.
+func (k *keyspace) seams(t string) ([]string, bool) {
+	nast := k.s.o.NoAdjacentSameType
+	var joints, unusable []string
+	for _, j := range k.s.joints {
+		if k.entries(j) == nil {
+			unusable = append(unusable, j)
+		} else if !nast || j != t {
+			joints = append(joints, j)
+		}
+	}
+	// With several unusable joint types, every entry is allowed by one of them
+	direct := len(k.s.joints) == 0 || len(unusable) > 1 || (len(unusable) == 1 && !(nast && unusable[0] == t))
+	return joints, direct
+}
+
+// Usable entries of a type by the words they count for (capped at length), or nil if no
+// word of the type satisfies the options
+func (k *keyspace) entries(t string) []int64 {
+	if sizes, ok := k.sizes[t]; ok {
+		return sizes
+	}
+	var sizes []int64
+	if words := k.words(t); words != nil {
+		sizes = make([]int64, k.length+1)
+		for _, w := range words {
+			sizes[min(entry_words(w), k.length)]++
+		}
+	}
+	k.sizes[t] = sizes
+	return sizes
+}
+
+// Words of a type that satisfy the options, or nil if there are none
+func (k *keyspace) words(t string) []string {
+	if words, ok := k.pools[t]; ok {
+		return words
+	}
+	words, n, constraint := k.s.pool(t, false)
+	if constraint != "" || words != nil {
+		k.pools[t] = words
+		return words
+	}
+	// Copied out of a lazy word list, skipping any offensive words
+	words = make([]string, n)
+	filter := k.s.d.filter(k.s.o)
+	for i := range words {
+		j := i
+		if filter.level > 0 {
+			j = skip_excluded(k.s.d.excluded(filter)[t], i)
+		}
+		words[i] = k.s.d.lazy.word(t, j)
+	}
+	k.pools[t] = words
+	return words
+}
+
+// Number of distinct values in l
+func distinct(l []string) int {
+	seen := make(map[string]bool, len(l))
+	for _, v := range l {
+		seen[v] = true
+	}
+	return len(seen)
+}
+
+// Whether every value in l has the same length in bytes. Padding of one length can only be
+// split off a passphrase one way.
+func same_lengths(l []string) bool {
+	for _, v := range l {
+		if len(v) != len(l[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+func new_exact_keyspace(s *gen_state) *exact_keyspace {
+	e := &exact_keyspace{
+		k:       new_keyspace(s),
+		sep:     separator(s.o),
+		allowed: make(map[exact_node]uint64),
+		ids:     make(map[exact_node]uint64),
+		pending: make(map[string]uint64),
+		memo:    make(map[string]*big.Int),
+	}
+	seen := make(map[string]bool)
+	add := func(l []string) {
+		for _, t := range l {
+			if !seen[t] {
+				seen[t] = true
+				e.types = append(e.types, t)
+			}
+		}
+	}
+	add(s.start)
+	add(s.joints)
+	for _, l := range s.rules {
+		add(l)
+	}
+	sort.Strings(e.types)
+	e.parts[0] = e.partition(0)
+	e.parts[1] = e.parts[0]
+	if s.o.Capitalize == "sentence" {
+		e.parts[1] = e.partition(1)
+	}
+	return e
+}
+
+// Exact keyspace counting state for one call. Passphrases are read as the pieces between
+// separators: as check_separator keeps separators from running into words, two passphrases
+// are the same string exactly when they have the same pieces. The count is that of the
+// distinct piece sequences the model can produce, following every position a sequence can
+// be in at once (the subset construction of a deterministic automaton).
+type exact_keyspace struct {
+	k        *keyspace
+	sep      string
+	types    []string              // word types that can be drawn, sorted
+	parts    [2]*exact_partition   // for entries starting the passphrase, and the others
+	allowed  map[exact_node]uint64 // word types that can be drawn at a position, by bit
+	ids      map[exact_node]uint64
+	pending  map[string]uint64   // ids of pieces still to come, for keys
+	memo     map[string]*big.Int // by key of the set of positions
+	inexact  string              // entry whose words cannot be told apart (none if "")
+	deadline time.Time           // Timeout of the call (zero if none)
+	expired  bool                // the count stopped at the deadline
+}
+
+// Position in the model, as in keyspace.count: the start of a fragment, the next entry of a
+// fragment, the seam after the last entry of a fragment, or the end of the passphrase
+type exact_node struct {
+	start, seam, done  bool
+	fragment, position int
+	before             string // type of the entry before a seam or the start of a fragment
+	words              int
+	types              uint64 // word types the next entry of a fragment may be of, by bit
+}
+
+// Pieces of an entry still to come, joined by NUL, then the position to go on from
+type exact_item struct {
+	pending string
+	node    exact_node
+}
+
+// First pieces of the usable entries, grouped by the entries they start: pieces starting
+// the same entries lead to the same positions, and are counted together.
+type exact_partition struct {
+	profiles []exact_profile
+	by_piece map[string][]exact_move // entries starting with each piece
+}
+
+type exact_profile struct {
+	pieces int64  // number of first pieces
+	types  uint64 // word types of the moves, by bit
+	moves  []exact_move
+}
+
+// Entry of a type (by index in types) starting with a piece: its other pieces, joined by
+// NUL within a word and by ETX between words, and its number of words
+type exact_move struct {
+	t     int
+	rest  string
+	words int
+}
+
+// Group the first pieces of every usable entry (part 0: starting the passphrase)
+func (e *exact_keyspace) partition(part int) *exact_partition {
+	by_first := make(map[string][]exact_move)
+	for t, name := range e.types {
+		for _, entry := range e.k.words(name) {
+			fields := strings.Fields(strip_unprintable(entry))
+			if len(fields) != entry_words(entry) {
+				e.inexact = entry
+				continue
+			}
+			var first string
+			var rest strings.Builder
+			for i, w := range case_entry(fields, part, e.k.s.o) {
+				for j, piece := range strings.Split(w, e.sep) {
+					switch {
+					case i == 0 && j == 0:
+						first = piece
+						continue
+					case j == 0:
+						rest.WriteByte('\x03')
+					case i > 0 || j > 1:
+						rest.WriteByte('\x00')
+					}
+					rest.WriteString(piece)
+				}
+			}
+			by_first[first] = append(by_first[first], exact_move{t, rest.String(), len(fields)})
+		}
+	}
+
+	p := &exact_partition{by_piece: make(map[string][]exact_move, len(by_first))}
+	index := make(map[string]int)
+	for first, moves := range by_first {
+		sort.Slice(moves, func(i, j int) bool {
+			a, b := moves[i], moves[j]
+			return a.t < b.t || a.t == b.t && (a.rest < b.rest || a.rest == b.rest && a.words < b.words)
+		})
+		moves = slices.Compact(moves)
+		p.by_piece[first] = moves
+		if len(moves) == 1 {
+			// The other pieces of the only entry a piece starts can only be read one
+			// way, so which they are does not change the count
+			moves = []exact_move{{moves[0].t, placeholders(moves[0].rest), moves[0].words}}
+		}
+		var key strings.Builder
+		for _, m := range moves {
+			fmt.Fprintf(&key, "%v\x00%q\x00%v\x00", m.t, m.rest, m.words)
+		}
+		i, ok := index[key.String()]
+		if !ok {
+			i = len(p.profiles)
+			index[key.String()] = i
+			p.profiles = append(p.profiles, exact_profile{moves: moves})
+			for _, m := range moves {
+				p.profiles[i].types |= 1 << m.t
+			}
+		}
+		p.profiles[i].pieces++
+	}
+	return p
+}
+
+// Pieces of an entry after its first, each replaced with the same placeholder
+func placeholders(rest string) string {
+	var b strings.Builder
+	piece := false
+	for i := 0; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case c == '\x00' || c == '\x03':
+			b.WriteByte(c)
+			piece = false
+		case !piece:
+			b.WriteByte('\x05')
+			piece = true
+		}
+	}
+	return b.String()
+}
+
+// Number of distinct piece sequences from a set of positions, with the same pieces read so far
+func (e *exact_keyspace) count(items []exact_item) *big.Int {
+	if len(items) == 1 && items[0].pending != "" {
+		// The rest of the entry can only be read one way
+		return e.count(e.closure(nil, exact_item{node: items[0].node}))
+	}
+	key := e.key(items)
+	if n, ok := e.memo[key]; ok {
+		return n
+	}
+	if e.expired || !e.deadline.IsZero() && time.Now().After(e.deadline) {
+		e.expired = true
+		return new(big.Int)
+	}
+	n := new(big.Int)
+	var nodes, pending []exact_item
+	done := false
+	for _, item := range items {
+		switch {
+		case item.pending != "":
+			pending = append(pending, item)
+		case item.node.done:
+			done = true
+		default:
+			nodes = append(nodes, item)
+		}
+	}
+	if len(pending) == 0 {
+		if done {
+			n.SetInt64(1)
+		}
+		if len(nodes) > 0 {
+			part := e.part(nodes[0].node)
+			for i := range part.profiles {
+				p := &part.profiles[i]
+				var to []exact_item
+				for _, item := range nodes {
+					if e.allowed_at(item.node)&p.types != 0 {
+						to = e.draw(to, item.node, p.moves)
+					}
+				}
+				if len(to) > 0 {
+					n.Add(n, new(big.Int).Mul(big.NewInt(p.pieces), e.count(to)))
+				}
+			}
+		}
+	} else {
+		// Only the first pieces of the pending entries read differently with them: the
+		// count from the other positions, corrected for those pieces
+		if done {
+			nodes = append(nodes, exact_item{node: exact_node{done: true}})
+		}
+		n.Set(e.count(nodes))
+		firsts := make(map[string]bool)
+		for _, item := range pending {
+			first, _, _ := strings.Cut(item.pending, "\x00")
+			firsts[first] = true
+		}
+		for piece := range firsts {
+			n.Add(n, e.count(e.step(items, piece)))
+			if without := e.step(nodes, piece); len(without) > 0 {
+				n.Sub(n, e.count(without))
+			}
+		}
+	}
+	e.memo[key] = n
+	return n
+}
+
+// Positions after reading a piece from a set of positions
+func (e *exact_keyspace) step(items []exact_item, piece string) []exact_item {
+	var to []exact_item
+	for _, item := range items {
+		switch {
+		case item.pending != "":
+			if first, rest, _ := strings.Cut(item.pending, "\x00"); first == piece {
+				to = e.closure(to, exact_item{rest, item.node})
+			}
+		case !item.node.done:
+			to = e.draw(to, item.node, e.part(item.node).by_piece[piece])
+		}
+	}
+	return to
+}
+
+// Partition for the entries drawn at a position
+func (e *exact_keyspace) part(node exact_node) *exact_partition {
+	if node.words == 0 {
+		return e.parts[0]
+	}
+	return e.parts[1]
+}
+
+// Add the positions after drawing the moves that a position allows to a set of positions
+func (e *exact_keyspace) draw(to []exact_item, node exact_node, moves []exact_move) []exact_item {
+	length := e.k.length
+	allowed := e.allowed_at(node)
+	for _, m := range moves {
+		if allowed&(1<<m.t) == 0 {
+			continue
+		}
+		next := exact_node{done: true}
+		if w := node.words + m.words; w < length {
+			t := e.types[m.t]
+			switch {
+			case node.seam:
+				next = exact_node{start: true, before: t, words: w}
+			case node.position < node.fragment-1:
+				next = e.entry_at(node.fragment, node.position+1, t, w)
+			default:
+				next = exact_node{seam: true, before: t, words: w}
+			}
+		}
+		rest := m.rest
+		if m.words > 1 {
+			rest = cut_words(rest, length-node.words)
+		}
+		to = e.closure(to, exact_item{rest, next})
+	}
+	return to
+}
+
+// Pieces of an entry after its first, with the entry cut to the given number of words
+func cut_words(rest string, words int) string {
+	for i := 0; i < len(rest); i++ {
+		if rest[i] == '\x03' {
+			if words--; words == 0 {
+				rest = rest[:i]
+				break
+			}
+		}
+	}
+	return strings.TrimPrefix(strings.ReplaceAll(rest, "\x03", "\x00"), "\x00")
+}
+
+// Word types that can be drawn at a position, by bit
+func (e *exact_keyspace) allowed_at(node exact_node) uint64 {
+	if !node.seam {
+		return node.types
+	}
+	if allowed, ok := e.allowed[node]; ok {
+		return allowed
+	}
+	joints, _ := e.k.seams(node.before)
+	allowed := e.type_bits(joints)
+	e.allowed[node] = allowed
+	return allowed
+}
+
+// Position of the next entry of a fragment after an entry of type before, as in
+// keyspace.count. Positions are told apart by the word types they allow rather than by the
+// type before them, as that is all their endings depend on.
+func (e *exact_keyspace) entry_at(fragment int, position int, before string, words int) exact_node {
+	key := exact_node{fragment: fragment, position: position, before: before}
+	allowed, ok := e.allowed[key]
+	if !ok {
+		k := e.k
+		candidates := k.s.start
+		if position > 0 {
+			candidates = k.s.rules[before]
+		}
+		if k.s.o.NoAdjacentSameType {
+			candidates = exclude_types(candidates, before)
+		}
+		if position == fragment-1 {
+			candidates = slices.DeleteFunc(slices.Clone(candidates), func(t string) bool {
+				joints, direct := k.seams(t)
+				return len(joints) == 0 && !direct
+			})
+		}
+		allowed = e.type_bits(candidates)
+		e.allowed[key] = allowed
+	}
+	return exact_node{fragment: fragment, position: position, words: words, types: allowed}
+}
+
+// Word types by bit
+func (e *exact_keyspace) type_bits(types []string) uint64 {
+	var bits uint64
+	for _, t := range types {
+		if i, ok := slices.BinarySearch(e.types, t); ok {
+			bits |= 1 << i
+		}
+	}
+	return bits
+}
+
+// Add an item to a set of positions, with those it leads to without reading a piece
+func (e *exact_keyspace) closure(items []exact_item, item exact_item) []exact_item {
+	for _, i := range items {
+		if i == item {
+			return items
+		}
+	}
+	node := item.node
+	if item.pending != "" || node.done || !node.start && !node.seam {
+		return append(items, item)
+	}
+	if node.start {
+		for _, f := range e.k.fragments {
+			items = e.closure(items, exact_item{node: e.entry_at(f, 0, node.before, node.words)})
+		}
+		return items
+	}
+	// A seam: a joining entry, or the next fragment directly
+	joints, direct := e.k.seams(node.before)
+	last := e.k.length-node.words == 1
+	if !last && len(joints) > 0 {
+		items = append(items, item)
+	}
+	if last || direct {
+		items = e.closure(items, exact_item{node: exact_node{start: true, before: node.before, words: node.words}})
+	}
+	return items
+}
+
+// Key of a set of positions, the same for any order
+func (e *exact_keyspace) key(items []exact_item) string {
+	ids := make([]uint64, len(items))
+	for i, item := range items {
+		pending, ok := e.pending[item.pending]
+		if !ok {
+			pending = uint64(len(e.pending))
+			e.pending[item.pending] = pending
+		}
+		ids[i] = pending<<32 | e.id(item.node)
+	}
+	slices.Sort(ids)
+	key := make([]byte, 0, 8*len(ids))
+	for _, id := range ids {
+		key = binary.LittleEndian.AppendUint64(key, id)
+	}
+	return string(key)
+}
+
+// Number of a position, for keys
+func (e *exact_keyspace) id(node exact_node) uint64 {
+	id, ok := e.ids[node]
+	if !ok {
+		id = uint64(len(e.ids))
+		e.ids[node] = id
+	}
+	return id
+}
+
+// Case transform of the words of an entry that starts at word index at
+func case_entry(words []string, at int, o *GenerateOptions) []string {
+	if at == 0 {
+		return transform_case(words, o)
+	}
+	return transform_case(append([]string{""}, words...), o)[1:]
+}
@@ -0,0 +1,199 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	mrand "math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestKeyspaceUpperBound(t *testing.T) {
+	word_map := map[string][]string{
+		"snoun":       []string{"otter", "badger", "sea lion"},
+		"verb":        []string{"runs", "swims"},
+		"adverb":      []string{"slowly"},
+		"pronoun":     []string{"she", "they"},
+		"conjunction": []string{"and", "or"},
+	}
+	allowed := []string{"snoun", "verb", "adverb", "pronoun", "conjunction"}
+	cases := []GenerateOptions{
+		{Length: 3},
+		{Length: 4, Magic_fragment_length: 2},
+		{Length: 4, Magic_fragment_length: 2, NoAdjacentSameType: true},
+		{Length: 4, Magic_fragment_length: 2, JointTypes: map[string]uint{"conjunction": 1, "pronoun": 2}},
+		{Length: 4, Magic_fragment_length: 2, MinWordLength: 4},
+		{Length: 4, Magic_fragment_length: 2, MinWordLength: 4, NoAdjacentSameType: true},
+		{Length: 4, Magic_fragment_length: 2, NoJoints: true, StartTypeWeights: map[string]uint{"verb": 0}},
+		{Length: 2, Add_digit: true, Digits: []string{"1", "2"}, Add_symbol: true, Symbols: []string{"!", "?", "!"}},
+		{Length: 5, Fragments: FragmentPolicy{TargetFragmentWords: 2, JoinType: "pronoun"}, MinWordLength: 4},
+		{Length: 4, Fragments: FragmentPolicy{TargetFragmentWords: 1, JoinType: "none"}, MinWordLength: 4, NoAdjacentSameType: true},
+	}
+	for i, o := range cases {
+		o.AllowedTypes = allowed
+		name := fmt.Sprintf("case %v", i)
+		g := generator_for(word_map)
+		g.rand = mrand.NewChaCha8([32]byte{9})
+		n, err := g.KeyspaceUpperBound(&o)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		if !n.IsInt64() || n.Int64() > 5000 {
+			t.Fatalf("%v: keyspace %v too large to enumerate", name, n)
+		}
+
+		// Brute force: draw until every passphrase has been seen, or give up
+		o.Count = count_max
+		seen := make(map[string]bool)
+		for j := 0; j < 2000 && int64(len(seen)) < n.Int64(); j++ {
+			p, _, err := g.GeneratePassphrasesDetailed(&o)
+			if err != nil {
+				t.Fatalf("%v: %v", name, err)
+			}
+			for _, pp := range p {
+				seen[fmt.Sprint(pp.Words, pp.Types, pp.Entries, pp.Digit, pp.Symbol)] = true
+			}
+		}
+		if int64(len(seen)) != n.Int64() {
+			t.Errorf("%v: keyspace %v, but %v distinct passphrases generated", name, n, len(seen))
+		}
+	}
+}
+
+func TestKeyspaceUpperBoundBits(t *testing.T) {
+	g := load_test_generator(t)
+	for _, length := range []uint{1, 4, 99} {
+		o := GenerateOptions{Length: length}
+		n, err := g.KeyspaceUpperBound(&o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bits, err := g.KeyspaceUpperBoundBits(&o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f, _ := new(big.Float).SetInt(n).Float64(); math.Abs(bits-math.Log2(f)) > 1e-9*bits {
+			t.Errorf("Length %v: expected log2(%v) = %v bits, got %v", length, n, math.Log2(f), bits)
+		}
+	}
+}
+
+func TestKeyspacePadding(t *testing.T) {
+	g := load_test_generator(t)
+	o := GenerateOptions{Length: 8}
+	words, err := g.KeyspaceUpperBound(&o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Add_digit, o.Add_symbol = true, true
+	padded, err := g.KeyspaceUpperBound(&o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bits, err := g.PaddingEntropy(&o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ratio, _ := new(big.Rat).SetFrac(padded, words).Float64()
+	if math.Abs(math.Log2(ratio)-bits) > 1e-9 {
+		t.Errorf("Expected the padding to add %v bits, got %v", bits, math.Log2(ratio))
+	}
+
+	if _, err := (&Generator{}).KeyspaceUpperBound(nil); !errors.Is(err, ErrWordlistNotLoaded) {
+		t.Errorf("Expected ErrWordlistNotLoaded, got %v", err)
+	}
+}
+
+func TestKeyspace(t *testing.T) {
+	// Words under several types, words that differ only in case, hyphenated words and
+	// multiword entries, so that many strings can be assembled several ways
+	g := generator_for(map[string][]string{
+		"snoun":       []string{"otter", "Otter", "sea lion", "sea", "hell-bent"},
+		"pnoun":       []string{"otters", "sea lions"},
+		"verb":        []string{"runs", "swims", "sea"},
+		"adverb":      []string{"slowly", "lion"},
+		"pronoun":     []string{"she", "they"},
+		"conjunction": []string{"and", "or", "sea"},
+	})
+	allowed := []string{"snoun", "pnoun", "verb", "adverb", "pronoun", "conjunction"}
+	cases := []GenerateOptions{
+		{Length: 1},
+		{Length: 3},
+		{Length: 3, Lowercase: true},
+		{Length: 3, Capitalize: "sentence"},
+		{Length: 3, Capitalize: "words", Separator: "-"},
+		{Length: 4, Magic_fragment_length: 2},
+		{Length: 4, Magic_fragment_length: 2, NoAdjacentSameType: true, Lowercase: true},
+		{Length: 4, Magic_fragment_length: 2, JointTypes: map[string]uint{"conjunction": 1, "pronoun": 2}},
+		{Length: 4, Magic_fragment_length: 1, NoJoints: true},
+		{Length: 4, Fragments: FragmentPolicy{TargetFragmentWords: 2, Jitter: 1}},
+		{Length: 5, Fragments: FragmentPolicy{TargetFragmentWords: 2, JoinType: "verb"}, Separator: "."},
+		{Length: 3, Add_digit: true, Digits: []string{"1", "2", "1"}, Add_symbol: true, Symbols: []string{"!!", "??"}},
+	}
+	for i, o := range cases {
+		o.AllowedTypes = allowed
+		name := fmt.Sprintf("case %v", i)
+		n, err := g.Keyspace(&o)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		// Brute force
+		all, err := g.EnumerateAll(&o, 1000000)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		if n.Int64() != int64(len(all)) {
+			t.Errorf("%v: keyspace %v, but %v passphrases enumerated", name, n, len(all))
+		}
+		if upper, _ := g.KeyspaceUpperBound(&o); n.Cmp(upper) > 0 {
+			t.Errorf("%v: keyspace %v above its upper bound %v", name, n, upper)
+		}
+	}
+
+	for _, o := range []GenerateOptions{
+		{No_spaces: true},
+		{MaxChars: 20},
+		{Agreement: true},
+		{Add_symbol: true, Symbols: []string{"!", "??"}},
+	} {
+		o.AllowedTypes = allowed
+		if _, err := g.Keyspace(&o); !errors.Is(err, ErrKeyspaceNotExact) {
+			t.Errorf("%+v: expected ErrKeyspaceNotExact, got %v", o, err)
+		}
+	}
+	if _, err := (&Generator{}).Keyspace(nil); !errors.Is(err, ErrWordlistNotLoaded) {
+		t.Errorf("Expected ErrWordlistNotLoaded, got %v", err)
+	}
+}
+
+func TestKeyspaceBounds(t *testing.T) {
+	g := load_test_generator(t)
+	for _, length := range []uint{1, 2, 4} {
+		o := GenerateOptions{Length: length, Add_digit: true}
+		n, err := g.Keyspace(&o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		upper, err := g.KeyspaceUpperBound(&o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bits, err := g.MinEntropy(&o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n.Cmp(upper) > 0 || log2(n) < bits-1e-9 {
+			t.Errorf("Length %v: keyspace %v outside [2^%v, %v]", length, n, bits, upper)
+		}
+	}
+}
+
+func TestKeyspaceTimeout(t *testing.T) {
+	g := load_test_generator(t)
+	_, err := g.Keyspace(&GenerateOptions{Length: 4, Timeout: time.Nanosecond})
+	if !errors.Is(err, ErrDeadlineExceeded) {
+		t.Fatalf("Expected ErrDeadlineExceeded, got %v", err)
+	}
+}
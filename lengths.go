@@ -0,0 +1,87 @@
+package wordentropy
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+const length_samples_default = 10000
+
+// Estimate the distribution of passphrase lengths in characters (runes, including any digit
+// and symbol) for the options, e.g. to size a database column. This is an empirical
+// estimate, not an exact calculation: samples passphrases (default 10000) are generated
+// with the fast non-cryptographic generator, so no cryptographic randomness is consumed
+// and the samples are not counted in Counters. Count, Timeout and InsecureFastRandom in the
+// options are ignored.
+//
+// Returns a histogram of length to number of samples, the 50th and 95th percentile
+// lengths and the longest sample.
+func (g *Generator) LengthDistribution(o *GenerateOptions, samples uint) (histogram map[int]uint, p50 int, p95 int, max int, err error) {
+	defer recover_internal(&err)
+	if samples == 0 {
+		samples = length_samples_default
+	}
+	var sample GenerateOptions
+	if o != nil {
+		sample = *o
+	}
+	sample.Count, sample.Timeout, sample.InsecureFastRandom = 1, 0, true
+	// Errors are reported for the options as given, before padding is moved out of generation
+	if _, err := g.prepare(&sample, g.words(), nil); err != nil {
+		return nil, 0, 0, 0, err
+	}
+	// Padding and the length limits are applied here so digits and symbols come from the
+	// fast source
+	add_digit, add_symbol, max_chars, max_bytes, max_syllables := sample.Add_digit, sample.Add_symbol, sample.MaxChars, sample.MaxBytes, sample.MaxSyllables
+	sample.Add_digit, sample.Add_symbol, sample.MaxChars, sample.MaxBytes, sample.MaxSyllables = false, false, 0, 0, 0
+	s, err := g.prepare(&sample, g.words(), nil)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+
+	lengths := make([]int, 0, samples)
+	for uint(len(lengths)) < samples {
+		for attempt := uint(0); ; attempt++ {
+			p, err := g.generate_one(s)
+			if err != nil {
+				return nil, 0, 0, 0, err
+			}
+			n, b := utf8.RuneCountInString(p.Phrase), len(p.Phrase)
+			if add_digit {
+				n++
+				if max_bytes > 0 || max_syllables > 0 {
+					p.Digit = s.choice(s.o.Digits)
+					b += len(p.Digit)
+				}
+			}
+			if add_symbol {
+				p.Symbol = s.choice(s.o.Symbols)
+				n += utf8.RuneCountInString(p.Symbol)
+				b += len(p.Symbol)
+			}
+			if (max_chars == 0 || uint(n) <= max_chars) && (max_bytes == 0 || uint(b) <= max_bytes) && (max_syllables == 0 || uint(syllables(p)) <= max_syllables) {
+				lengths = append(lengths, n)
+				break
+			}
+			if attempt == s.o.MaxRetries {
+				return nil, 0, 0, 0, ErrRetriesExhausted
+			}
+		}
+	}
+
+	sort.Ints(lengths)
+	histogram = make(map[int]uint)
+	for _, n := range lengths {
+		histogram[n]++
+	}
+	return histogram, percentile(lengths, 50), percentile(lengths, 95), lengths[len(lengths)-1], nil
+}
+
+// Nearest-rank percentile of sorted values
+func percentile(sorted []int, p int) int {
+	rank := (len(sorted)*p + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
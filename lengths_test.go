@@ -0,0 +1,71 @@
+package wordentropy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLengthDistribution(t *testing.T) {
+	g := load_test_generator(t)
+	g.rand = panicking_reader{} // sampling must not touch the cryptographic source
+
+	prev := 0
+	for _, length := range []uint{1, 2, 4, 8, 16} {
+		histogram, p50, p95, max, err := g.LengthDistribution(&GenerateOptions{Length: length}, 2000)
+		if err != nil {
+			t.Fatalf("Length %v: %v", length, err)
+		}
+		total := uint(0)
+		for n, c := range histogram {
+			total += c
+			if n > max {
+				t.Errorf("Length %v: histogram entry %v above max %v", length, n, max)
+			}
+		}
+		if total != 2000 {
+			t.Errorf("Length %v: expected 2000 samples in histogram, got %v", length, total)
+		}
+		if !(max >= p95 && p95 >= p50 && p50 > 0) {
+			t.Errorf("Length %v: expected max >= p95 >= p50 > 0, got %v, %v, %v", length, max, p95, p50)
+		}
+		if p50 <= prev {
+			t.Errorf("Length %v: median %v not above median %v for fewer words", length, p50, prev)
+		}
+		prev = p50
+	}
+
+	plain, _, _, _, err := g.LengthDistribution(&GenerateOptions{Length: 3}, 0)
+	if err != nil {
+		t.Fatalf("Error sampling: %v", err)
+	}
+	n := uint(0)
+	for _, c := range plain {
+		n += c
+	}
+	if n != length_samples_default {
+		t.Errorf("Expected %v samples by default, got %v", length_samples_default, n)
+	}
+	_, _, _, max, err := g.LengthDistribution(&GenerateOptions{Length: 6, Add_digit: true, Add_symbol: true, Symbols: []string{"!!!"}, MaxChars: 40}, 500)
+	if err != nil || max > 40 {
+		t.Errorf("Expected samples within MaxChars, got max %v, %v", max, err)
+	}
+	if _, _, _, _, err := g.LengthDistribution(&GenerateOptions{Length: 6, Add_symbol: true, Symbols: []string{"!!!!"}, MaxChars: 4}, 10); err != ErrRetriesExhausted {
+		t.Errorf("Expected ErrRetriesExhausted, got %v", err)
+	}
+	if _, _, _, _, err := g.LengthDistribution(&GenerateOptions{Count: count_max + 1}, 10); err != nil {
+		t.Errorf("Expected Count to be ignored, got %v", err)
+	}
+	if _, _, _, _, err := g.LengthDistribution(&GenerateOptions{Capitalize: "shout"}, 10); !errors.Is(err, ErrUnknownCapitalize) {
+		t.Errorf("Expected ErrUnknownCapitalize, got %v", err)
+	}
+	if c := g.Counters(); c != (Counters{}) {
+		t.Errorf("Expected sampling to leave counters alone, got %+v", c)
+	}
+
+	if p := percentile([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 50); p != 5 {
+		t.Errorf("Expected p50 5, got %v", p)
+	}
+	if p := percentile([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 95); p != 10 {
+		t.Errorf("Expected p95 10, got %v", p)
+	}
+}
@@ -0,0 +1,518 @@
+package wordentropy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Bits of min-entropy of a passphrase generated with the options: -log2 of an upper bound
+// on the likelihood of the likeliest passphrase. Unlike KeyspaceUpperBoundBits, this is a
+// lower bound on the entropy, e.g. for refusing options that cannot produce strong enough
+// passphrases: no guess is right more often than once in 2^bits passphrases.
+//
+// The bound follows the generator's model: each word type is picked uniformly (or by
+// StartTypeWeights, or JointTypes for seams) among the usable ones, then a word uniformly
+// (or per ShortWordBias) within its pool. Words listed under several types, words that
+// differ only in case, multiword entries cut to fit Length and fragments of different
+// lengths with Fragments.Jitter all add to the likelihood of the strings they collide in,
+// and BestOf makes any passphrase up to BestOf times as likely. The words are assumed to
+// be told apart in the passphrase, which with No_spaces takes UnambiguousConcat.
+// MaxChars, MaxBytes, MaxSyllables, AvoidCommonPhrases and UnambiguousConcat rejections and
+// backtracking beyond dead ends are not taken into account. Count, Timeout and Scorer in
+// the options are ignored.
+func (g *Generator) MinEntropy(o *GenerateOptions) (_ float64, err error) {
+	defer recover_internal(&err)
+	var options GenerateOptions
+	if o != nil {
+		options = *o
+	}
+	best_of := max(options.BestOf, 1)
+	options.Count, options.Timeout, options.BestOf, options.Scorer = 1, 0, 1, nil
+	s, err := g.prepare(&options, g.words(), nil)
+	if err != nil {
+		return 0, err
+	}
+	p := new_peak(s).likeliest() + math.Log2(float64(best_of))
+	if math.IsInf(p, -1) {
+		return 0, nil
+	}
+	return max(-p, 0), nil
+}
+
+func new_peak(s *gen_state) *peak {
+	return &peak{
+		k:       new_keyspace(s),
+		odds:    make(map[odds_key]map[string]float64),
+		classes: make(map[int][]entry_class),
+		memo:    make(map[keyspace_key]float64),
+	}
+}
+
+// Likelihood bounding state for one call. Likelihoods are kept as base 2 logarithms, so
+// that long passphrases do not underflow; -Inf is impossible.
+type peak struct {
+	k       *keyspace // usable pools and seams, as counted by KeyspaceUpperBound
+	odds    map[odds_key]map[string]float64
+	classes map[int][]entry_class // by room in words, capped at the longest entry
+	memo    map[keyspace_key]float64
+	most    int // words of the longest usable entry (0 until first used)
+}
+
+// Draw of a word of a type, with entries cut as by entry_odds. Joining words drawn with
+// VaryJoints are uniform within their pool.
+type odds_key struct {
+	word_type string
+	cut       int
+	vary      bool
+}
+
+// Entries that the same word types can produce, with the same number of words. Entries are
+// grouped to keep the walk short; each type's likelihood is that of its likeliest entry of
+// the class, so the bound holds for every entry of the class.
+type entry_class struct {
+	words int
+	types []string           // sorted
+	odds  map[string]float64 // by word type: likelihood of the likeliest entry
+}
+
+// Upper bound on the likelihood of the likeliest passphrase, including the padding. The
+// bound on the words is shared with other calls with the same pools and grammar.
+func (p *peak) likeliest() float64 {
+	s := p.k.s
+	key := fmt.Sprintf("%v/min_entropy/%v/%v/%v/%v/%v/%v/%v/%v/%v/%v/%v", s.pools_key(), s.o.Length, p.k.fragments,
+		s.start, s.weights, s.rules, s.joints, s.jweights, s.o.NoAdjacentSameType, s.o.ShortWordBias, s.o.Agreement, s.o.VaryJoints)
+	l := s.d.derived(key, func() interface{} { return p.fragment("", 0) }).(float64)
+	if s.o.Add_digit {
+		digits := s.o.Digits
+		if len(digits) == 0 {
+			digits = default_digits
+		}
+		l += likeliest_choice(digits)
+	}
+	if s.o.Add_symbol {
+		l += likeliest_choice(s.o.Symbols)
+	}
+	return l
+}
+
+// Upper bound on the likelihood of any ending starting a fragment after an entry of type
+// before, summed over the fragment lengths it can be drawn with
+func (p *peak) fragment(before string, words int) float64 {
+	l := math.Inf(-1)
+	share := -math.Log2(float64(len(p.k.fragments)))
+	for _, f := range p.k.fragments {
+		l = log2_add(l, share+p.bound(f, 0, before, words))
+	}
+	return l
+}
+
+// Upper bound on the likelihood of any ending of a passphrase from the position on
+func (p *peak) bound(fragment int, position int, before string, words int) float64 {
+	key := keyspace_key{fragment, position, before, words}
+	if l, ok := p.memo[key]; ok {
+		return l
+	}
+	s := p.k.s
+	candidates := s.start
+	weights := s.weights
+	if position > 0 {
+		candidates, weights = s.rules[before], nil
+	}
+	if s.o.NoAdjacentSameType {
+		candidates = exclude_types(candidates, before)
+	}
+
+	var l float64
+	if position < fragment-1 {
+		l = p.draw(candidates, weights, words, func(t string, w int) float64 {
+			return p.bound(fragment, position+1, t, w)
+		})
+	} else {
+		l = math.Inf(-1)
+		for _, j := range p.seams() {
+			c := candidates
+			if s.o.NoAdjacentSameType {
+				c = exclude_types(candidates, j.word_type)
+			}
+			l = log2_add(l, j.odds+p.draw(c, weights, words, func(t string, w int) float64 {
+				return p.seam(j.word_type, t, w)
+			}))
+		}
+	}
+	p.memo[key] = l
+	return l
+}
+
+// Upper bound on the likelihood of any ending drawing an entry of one of the candidate
+// types, then ending(type, words so far) for the rest. Types with no usable words, or that
+// lead only to dead ends, are redrawn; types that may yet turn out unusable (see usable)
+// are not counted as alternatives.
+func (p *peak) draw(candidates []string, weights map[string]uint, words int, ending func(t string, words int) float64) float64 {
+	length := p.k.length
+	rest := make(map[string][]float64, len(candidates))
+	total := 0.0
+	for _, t := range candidates {
+		if weights != nil && weights[t] == 0 {
+			continue
+		}
+		entries := p.k.entries(t)
+		if entries == nil {
+			continue
+		}
+		// Entries cut to fit Length end the passphrase whatever words they count for
+		after := make([]float64, length+1)
+		viable := false
+		for n, c := range entries {
+			switch {
+			case words+n >= length:
+				after[n] = 0
+			case c == 0:
+				after[n] = math.Inf(-1)
+			default:
+				after[n] = ending(t, words+n)
+			}
+			viable = viable || (c > 0 && !math.IsInf(after[n], -1))
+		}
+		if !viable {
+			continue
+		}
+		rest[t] = after
+		if p.usable(t) {
+			total += float64(type_weight(t, weights))
+		}
+	}
+	share := func(t string) float64 {
+		w := float64(type_weight(t, weights))
+		return math.Log2(w / max(total, w))
+	}
+
+	// The likeliest class of each number of words, summed over the numbers of words
+	likeliest := make([]float64, length+1)
+	for n := range likeliest {
+		likeliest[n] = math.Inf(-1)
+	}
+	for _, c := range p.entry_classes(length - words) {
+		l := math.Inf(-1)
+		for _, t := range c.types {
+			if after, ok := rest[t]; ok {
+				l = log2_add(l, share(t)+c.odds[t]+after[c.words])
+			}
+		}
+		likeliest[c.words] = max(likeliest[c.words], l)
+	}
+	l := math.Inf(-1)
+	for _, m := range likeliest {
+		l = log2_add(l, m)
+	}
+	return l
+}
+
+// Upper bound on the likelihood of any ending after a fragment ending in an entry of type
+// t, with joint type j for the seam ("" to join directly)
+func (p *peak) seam(j string, t string, words int) float64 {
+	length := p.k.length
+	if j == "" || length-words == 1 || p.k.entries(j) == nil {
+		return p.fragment(t, words)
+	}
+	l := math.Inf(-1)
+	for n, odds := range p.likeliest_by_words(j, length-words) {
+		if math.IsInf(odds, -1) {
+			continue
+		}
+		if w := min(words+n, length); w < length {
+			l = log2_add(l, odds+p.fragment(j, w))
+		} else {
+			l = log2_add(l, odds)
+		}
+	}
+	if !p.usable(j) {
+		// The joining word may not be drawn after all
+		l = log2_add(l, p.fragment(t, words))
+	}
+	return l
+}
+
+// Joint type of a seam and its likelihood
+type seam_odds struct {
+	word_type string
+	odds      float64
+}
+
+// Joint types that may be chosen for a seam ("" to join directly), with their likelihoods
+func (p *peak) seams() []seam_odds {
+	s := p.k.s
+	if len(s.joints) == 0 {
+		return []seam_odds{{"", 0}}
+	}
+	seams := make([]seam_odds, 0, len(s.joints))
+	total := 0.0
+	for _, j := range s.joints {
+		total += float64(type_weight(j, s.jweights))
+	}
+	for _, j := range s.joints {
+		if w := type_weight(j, s.jweights); w > 0 {
+			seams = append(seams, seam_odds{j, math.Log2(float64(w) / total)})
+		}
+	}
+	return seams
+}
+
+// Whether a word of a type with usable words can always be drawn. With Agreement, a verb
+// pool may have no words that agree with the subject.
+func (p *peak) usable(t string) bool {
+	s := p.k.s
+	if p.k.entries(t) == nil {
+		return false
+	}
+	if !s.o.Agreement || t != "verb" {
+		return true
+	}
+	for _, number := range []string{"singular", "plural"} {
+		if pool, _ := s.agreeing(t, number, p.k.words(t)); len(pool) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Likelihood of the likeliest entry of a joining type by the words it counts for, with
+// room words left
+func (p *peak) likeliest_by_words(j string, room int) []float64 {
+	vary := p.k.s.o.VaryJoints
+	room = min(room, p.longest())
+	odds := make([]float64, room+1)
+	for i := range odds {
+		odds[i] = math.Inf(-1)
+	}
+	for key, l := range p.entry_odds(j, 0, vary) {
+		if n := entry_words(key); n < room {
+			odds[n] = max(odds[n], l)
+		}
+	}
+	for _, l := range p.entry_odds(j, room, vary) {
+		odds[room] = max(odds[room], l)
+	}
+	return odds
+}
+
+// Entries of the types in the grammar grouped into classes, with room words left. Entries
+// of fewer words are as they are with any room, so only longer ones are classed again.
+func (p *peak) entry_classes(room int) []entry_class {
+	room = min(room, p.longest())
+	if classes, ok := p.classes[room]; ok {
+		return classes
+	}
+	var classes []entry_class
+	if room == p.longest() {
+		classes = p.classify(0)
+	} else {
+		for _, c := range p.entry_classes(p.longest()) {
+			if c.words < room {
+				classes = append(classes, c)
+			}
+		}
+		classes = append(classes, p.classify(room)...)
+	}
+	p.classes[room] = classes
+	return classes
+}
+
+// Group the entries of the types in the grammar into classes, as cut by entry_odds
+func (p *peak) classify(cut int) []entry_class {
+	s := p.k.s
+	types := make(map[string]bool)
+	for _, t := range s.start {
+		types[t] = true
+	}
+	for _, next := range s.rules {
+		for _, t := range next {
+			types[t] = true
+		}
+	}
+	var names []string
+	var odds []map[string]float64
+	for t := range types {
+		if p.k.entries(t) != nil {
+			names = append(names, t)
+		}
+	}
+	sort.Strings(names)
+	for _, t := range names {
+		odds = append(odds, p.entry_odds(t, cut, false))
+	}
+
+	// Each entry is classed when seen under the first type it can be drawn from
+	type signature struct {
+		words int
+		types uint64 // bit i set for names[i]
+	}
+	index := make(map[signature]int)
+	var classes []entry_class
+	for i := range names {
+		for key := range odds[i] {
+			sig := signature{words: entry_words(key)}
+			first := true
+			for j := range names {
+				if _, ok := odds[j][key]; ok {
+					first = first && j >= i
+					sig.types |= 1 << j
+				}
+			}
+			if !first {
+				continue
+			}
+			c, ok := index[sig]
+			if !ok {
+				c = len(classes)
+				index[sig] = c
+				classes = append(classes, entry_class{words: sig.words, odds: make(map[string]float64)})
+				for j, t := range names {
+					if sig.types&(1<<j) != 0 {
+						classes[c].types = append(classes[c].types, t)
+						classes[c].odds[t] = math.Inf(-1)
+					}
+				}
+			}
+			for j, t := range names {
+				if sig.types&(1<<j) != 0 {
+					classes[c].odds[t] = max(classes[c].odds[t], odds[j][key])
+				}
+			}
+		}
+	}
+	return classes
+}
+
+// Likelihood of each entry of a type as it appears in a passphrase, lowercased. With cut
+// above 0, only entries of at least cut words are included, cut to cut words as when they
+// reach Length. Entries that appear the same add up. With Agreement, a verb's likelihood is
+// the larger of those in the singular and plural pools.
+func (p *peak) entry_odds(t string, cut int, vary bool) map[string]float64 {
+	s := p.k.s
+	vary = vary && s.o.ShortWordBias > 0
+	key := odds_key{t, cut, vary}
+	if odds, ok := p.odds[key]; ok {
+		return odds
+	}
+	words := p.k.words(t)
+	pools := [][]string{words}
+	keys := []string{t}
+	if s.o.Agreement && t == "verb" {
+		pools, keys = nil, nil
+		for _, number := range []string{"singular", "plural"} {
+			if pool, k := s.agreeing(t, number, words); len(pool) > 0 {
+				pools, keys = append(pools, pool), append(keys, k)
+			}
+		}
+	}
+	odds := make(map[string]float64)
+	for i, pool := range pools {
+		sums := make(map[string]float64)
+		add := func(w string, q float64) {
+			if key, ok := entry_key(w, cut); ok {
+				sums[key] += q
+			}
+		}
+		if s.o.ShortWordBias > 0 && !vary {
+			b := s.buckets(keys[i], pool)
+			total, prev := b.cumulative[len(b.cumulative)-1], 0.0
+			for j, bucket := range b.words {
+				q := (b.cumulative[j] - prev) / total / float64(len(bucket))
+				prev = b.cumulative[j]
+				for _, w := range bucket {
+					add(w, q)
+				}
+			}
+		} else {
+			for _, w := range pool {
+				add(w, 1/float64(len(pool)))
+			}
+		}
+		for w, q := range sums {
+			if l, ok := odds[w]; !ok || math.Log2(q) > l {
+				odds[w] = math.Log2(q)
+			}
+		}
+	}
+	p.odds[key] = odds
+	return odds
+}
+
+// An entry as it appears in a passphrase, lowercased and cut to cut words if above 0, and
+// whether it has at least cut words
+func entry_key(entry string, cut int) (string, bool) {
+	fields := strings.Fields(strip_unprintable(entry))
+	if max(len(fields), 1) < cut {
+		return "", false
+	}
+	if cut > 0 {
+		fields = fields[:min(len(fields), cut)]
+	}
+	if len(fields) == 1 {
+		return strings.ToLower(fields[0]), true
+	}
+	return strings.ToLower(strings.Join(fields, " ")), true
+}
+
+// Most words any usable entry counts for, or Length+1 if entries may be cut to fit Length
+func (p *peak) longest() int {
+	if p.most > 0 {
+		return p.most
+	}
+	s := p.k.s
+	longest := 1
+	types := append(append([]string{}, s.start...), s.joints...)
+	for t, next := range s.rules {
+		types = append(append(types, t), next...)
+	}
+	for _, t := range types {
+		longest = max(longest, last_nonzero(p.k.entries(t)))
+	}
+	if longest >= p.k.length {
+		longest = p.k.length + 1
+	}
+	p.most = longest
+	return longest
+}
+
+// Index of the last nonzero count (0 if none)
+func last_nonzero(counts []int64) int {
+	for i := len(counts) - 1; i > 0; i-- {
+		if counts[i] > 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// Weight of a type in a choice with weights (1 each if nil)
+func type_weight(t string, weights map[string]uint) uint {
+	if weights == nil {
+		return 1
+	}
+	return weights[t]
+}
+
+// Base 2 logarithm of the likelihood of the likeliest value in a uniform choice from l
+func likeliest_choice(l []string) float64 {
+	counts := make(map[string]int, len(l))
+	most := 0
+	for _, v := range l {
+		counts[v]++
+		most = max(most, counts[v])
+	}
+	return math.Log2(float64(most) / float64(len(l)))
+}
+
+// Base 2 logarithm of 2^a + 2^b
+func log2_add(a, b float64) float64 {
+	if a < b {
+		a, b = b, a
+	}
+	if math.IsInf(b, -1) {
+		return a
+	}
+	return a + math.Log1p(math.Exp2(b-a))/math.Ln2
+}
@@ -0,0 +1,85 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	mrand "math/rand/v2"
+	"testing"
+)
+
+func TestMinEntropy(t *testing.T) {
+	word_map := map[string][]string{
+		"snoun": []string{"otter", "badger", "sea lion", "sea otter"},
+		"verb":  []string{"runs", "swims", "Runs"},
+	}
+	nouns, verbs := map[string]uint{"snoun": 1, "verb": 0}, map[string]uint{"snoun": 0, "verb": 1}
+	cases := []struct {
+		o    GenerateOptions
+		bits float64
+	}{
+		// "runs" and "Runs" read the same once lowercased
+		{GenerateOptions{Length: 1, StartTypeWeights: verbs}, math.Log2(3.0 / 2)},
+		// "sea" is cut from both multiword entries to fit Length
+		{GenerateOptions{Length: 1, StartTypeWeights: nouns}, 1},
+		{GenerateOptions{Length: 2, StartTypeWeights: verbs}, math.Log2(3)},
+		{GenerateOptions{Length: 2, StartTypeWeights: verbs, Add_digit: true, Digits: []string{"1", "2", "2", "2"}}, 2},
+		{GenerateOptions{Length: 2, StartTypeWeights: verbs, BestOf: 2}, math.Log2(3.0 / 2)},
+	}
+	for i, c := range cases {
+		g := generator_for(word_map)
+		c.o.AllowedTypes = []string{"snoun", "verb"}
+		bits, err := g.MinEntropy(&c.o)
+		if err != nil {
+			t.Fatalf("case %v: %v", i, err)
+		}
+		if math.Abs(bits-c.bits) > 1e-9 {
+			t.Errorf("case %v: expected %v bits, got %v", i, c.bits, bits)
+		}
+	}
+
+	if _, err := (&Generator{}).MinEntropy(nil); !errors.Is(err, ErrWordlistNotLoaded) {
+		t.Errorf("Expected ErrWordlistNotLoaded, got %v", err)
+	}
+}
+
+// The likeliest passphrase must turn up no more often than the bound allows
+func TestMinEntropySampled(t *testing.T) {
+	g := load_test_generator(t)
+	g.rand = mrand.NewChaCha8([32]byte{5})
+	cases := []GenerateOptions{
+		{Length: 2},
+		{Length: 3, ShortWordBias: 1},
+		{Length: 3, Agreement: true},
+		{Length: 4, NoAdjacentSameType: true, JointTypes: map[string]uint{"conjunction": 1, "pronoun": 3}},
+		{Length: 4, Fragments: FragmentPolicy{TargetFragmentWords: 2, Jitter: 1}, VaryJoints: true},
+		{Length: 2, Add_digit: true, StartTypeWeights: map[string]uint{"pronoun": 5}},
+	}
+	for _, o := range cases {
+		name := fmt.Sprintf("%+v", o)
+		bits, err := g.MinEntropy(&o)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		if upper, _ := g.KeyspaceUpperBoundBits(&o); bits > upper {
+			t.Errorf("%v: %v bits is above the upper bound %v", name, bits, upper)
+		}
+		o.Count = 99
+		seen := make(map[string]int)
+		top, n := 0, 0
+		for i := 0; i < 200; i++ {
+			phrases, err := g.GeneratePassphrases(&o)
+			if err != nil {
+				t.Fatalf("%v: %v", name, err)
+			}
+			for _, p := range phrases {
+				seen[p]++
+				top = max(top, seen[p])
+				n++
+			}
+		}
+		if sampled := -math.Log2(float64(top) / float64(n)); bits > sampled+0.3 {
+			t.Errorf("%v: bound of %v bits, but the likeliest passphrase came up once in 2^%v", name, bits, sampled)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+//go:build wordentropy_norecover
+
+package wordentropy
+
+func init() {
+	recover_panics = false
+}
@@ -0,0 +1,95 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bkeroack/libwordentropy/wordlist"
+	"io"
+	"os"
+)
+
+// Size limit for wordlist files when WordListOptions.MaxFileBytes is 0
+const max_file_bytes_default = 64 << 20
+
+// Error loading a wordlist file, locating the problem as precisely as the format allows
+type ParseError = wordlist.ParseError
+
+// Wrap an error opening or reading a wordlist with its path
+func open_error(p string, err error) error {
+	reason := err.Error()
+	var pe *os.PathError
+	if errors.As(err, &pe) {
+		reason = pe.Err.Error() // the path is already in the ParseError
+	}
+	return &ParseError{Path: p, Reason: reason, Err: err}
+}
+
+// Effective size limit for a MaxFileBytes setting: 0 means the default, negative no limit
+func file_limit(max int64) int64 {
+	switch {
+	case max == 0:
+		return max_file_bytes_default
+	case max < 0:
+		return -1
+	}
+	return max
+}
+
+// Open a wordlist file of at most max bytes (see file_limit). Files that are larger when
+// opened fail at once; reads also fail with ErrWordlistTooLarge past the limit, in case the
+// file grows or its size is not known in advance.
+func open_limited(p string, max int64) (io.ReadCloser, error) {
+	f, size, err := open_file(p)
+	if err != nil {
+		return nil, open_error(p, err)
+	}
+	max = file_limit(max)
+	if max < 0 {
+		return f, nil
+	}
+	if size > max {
+		f.Close()
+		return nil, too_large_error(p, max)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{&limited_reader{r: f, max: max}, f}, nil
+}
+
+// Read a whole wordlist file of at most max bytes (see open_limited)
+func read_limited(p string, max int64) ([]byte, error) {
+	f, err := open_limited(p, max)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if errors.Is(err, ErrWordlistTooLarge) {
+		return nil, too_large_error(p, file_limit(max))
+	}
+	if err != nil {
+		return nil, open_error(p, err)
+	}
+	return data, nil
+}
+
+func too_large_error(p string, max int64) error {
+	return &ParseError{Path: p, Reason: fmt.Sprintf("file is larger than MaxFileBytes (%v bytes)", max), Err: ErrWordlistTooLarge}
+}
+
+// Reader failing with ErrWordlistTooLarge once more than max bytes have been read
+type limited_reader struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+func (l *limited_reader) Read(b []byte) (int, error) {
+	n, err := l.r.Read(b)
+	l.read += int64(n)
+	if l.read > l.max {
+		return n, ErrWordlistTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,266 @@
+package wordentropy
+
+import (
+	"bytes"
+	"errors"
+	"github.com/bkeroack/libwordentropy/wordlist"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parse_error(t *testing.T, err error) *ParseError {
+	t.Helper()
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expected ParseError, got %v", err)
+	}
+	return pe
+}
+
+func TestParseError(t *testing.T) {
+	for _, o := range []WordListOptions{
+		{Wordlist: "testdata/missing.txt"},
+		{Wordlist: "testdata/missing.txt", Format: "json"},
+		{Wordlist: "testdata/missing.txt", Format: "binary", Lazy: true},
+		{Wordlist: "testdata/pos.txt", Offensive: "testdata/missing.txt"},
+	} {
+		_, err := LoadGenerator(&o)
+		pe := parse_error(t, err)
+		if pe.Path != "testdata/missing.txt" || pe.Line != 0 || !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%+v: unexpected error %#v", o, pe)
+		}
+		if strings.Count(err.Error(), "missing.txt") != 1 {
+			t.Errorf("Expected the path once in %q", err.Error())
+		}
+	}
+
+	_, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/bad.json", Format: "json"})
+	if pe := parse_error(t, err); pe.Path != "testdata/bad.json" || pe.Line != 3 {
+		t.Errorf("Expected testdata/bad.json line 3, got %#v", pe)
+	}
+	if !strings.HasPrefix(err.Error(), "testdata/bad.json:3: ") {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+
+	_, err = LoadGenerator(&WordListOptions{Wordlist: "testdata/pos.txt", Format: "binary"})
+	if pe := parse_error(t, err); pe.Path != "testdata/pos.txt" || !errors.Is(err, ErrInvalidBinary) {
+		t.Errorf("Unexpected error %#v", pe)
+	}
+
+	// A line longer than the scanner buffer
+	p := filepath.Join(t.TempDir(), "long.txt")
+	if err := os.WriteFile(p, []byte("otter\tN\nruns\tV\n"+strings.Repeat("x", 70000)+"\tN\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadGenerator(&WordListOptions{Wordlist: p})
+	if pe := parse_error(t, err); pe.Path != p || pe.Line != 3 {
+		t.Errorf("Expected %v line 3, got %#v", p, pe)
+	}
+}
+
+func TestParseErrorLog(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	if _, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/corrupt.txt"}); err != nil {
+		t.Fatalf("Expected lenient load to succeed: %v", err)
+	}
+	for _, expected := range []string{
+		`testdata/corrupt.txt:2: expected 2 tab-separated columns, got 1: "badger N"`,
+		`testdata/corrupt.txt:4: zero-length snoun: "\tN"`,
+		`testdata/corrupt.txt:6: unknown part of speech "Q": "xyzzy\tQ"`,
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected %q in log output:\n%v", expected, buf.String())
+		}
+	}
+}
+
+func TestStrict(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	g, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/corrupt.txt"})
+	if err != nil {
+		t.Fatalf("Expected lenient load to succeed: %v", err)
+	}
+	if st := g.Stats(); st.Words["snoun"] != 1 || st.Words["verb"] != 1 || st.Words["adverb"] != 1 {
+		t.Errorf("Expected the well-formed lines to be loaded, got %v", st.Words)
+	}
+
+	_, err = LoadGenerator(&WordListOptions{Wordlist: "testdata/corrupt.txt", Strict: true})
+	pe := parse_error(t, err)
+	if pe.Path != "testdata/corrupt.txt" || pe.Line != 2 || !strings.Contains(pe.Reason, `"badger N"`) {
+		t.Errorf("Expected the bad column count on line 2, got %#v", pe)
+	}
+
+	// Each kind of malformed line on its own
+	cases := []struct {
+		content string
+		err     error
+	}{
+		{"otter\tN\n\tN\n", ErrEmptyWord},
+		{"otter\tN\nxyzzy\tQ\n", ErrUnknownWordType},
+		{"otter\tN\nruns\tV\tx\n", nil},
+	}
+	for _, c := range cases {
+		p := filepath.Join(t.TempDir(), "bad.txt")
+		if err := os.WriteFile(p, []byte(c.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := NewGeneratorFromProvider(&POSFileProvider{Path: p, Strict: true})
+		if pe := parse_error(t, err); pe.Line != 2 || pe.Err != c.err {
+			t.Errorf("%q: unexpected error %#v", c.content, pe)
+		}
+	}
+}
+
+func TestMaxFileBytes(t *testing.T) {
+	dir := t.TempDir()
+	src, err := os.ReadFile("testdata/pos.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bin bytes.Buffer
+	if err := ConvertWordlist(bytes.NewReader(src), &bin); err != nil {
+		t.Fatal(err)
+	}
+	binary := filepath.Join(dir, "pos.bin")
+	if err := os.WriteFile(binary, bin.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// An offensive list larger than the wordlist
+	offensive := filepath.Join(dir, "offensive.txt")
+	if err := os.WriteFile(offensive, bytes.Repeat([]byte("xyzzy\n"), 200), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, o := range []WordListOptions{
+		{Wordlist: "testdata/pos.txt"},
+		{Wordlist: "testdata/pos.json", Format: "json"},
+		{Wordlist: binary, Format: "binary"},
+		{Wordlist: binary, Format: "binary", Lazy: true},
+	} {
+		o.MaxFileBytes = 100
+		_, err := LoadGenerator(&o)
+		if pe := parse_error(t, err); pe.Path != o.Wordlist || !errors.Is(err, ErrWordlistTooLarge) {
+			t.Errorf("%+v: unexpected error %#v", o, pe)
+		}
+		o.MaxFileBytes = -1
+		if _, err := LoadGenerator(&o); err != nil {
+			t.Errorf("%+v: expected no limit, got %v", o, err)
+		}
+	}
+
+	if len(src) >= 1200 {
+		t.Fatalf("testdata/pos.txt is too large for the test: %v bytes", len(src))
+	}
+	o := WordListOptions{Wordlist: "testdata/pos.txt", Offensive: offensive, MaxFileBytes: int64(len(src))}
+	_, err = LoadGenerator(&o)
+	if pe := parse_error(t, err); pe.Path != offensive || !errors.Is(err, ErrWordlistTooLarge) {
+		t.Errorf("Unexpected error %#v", pe)
+	}
+	o.MaxFileBytes = 0
+	if _, err := LoadGenerator(&o); err != nil {
+		t.Errorf("Expected the default limit to allow the files, got %v", err)
+	}
+}
+
+func TestLimitedReader(t *testing.T) {
+	r := &limited_reader{r: strings.NewReader(strings.Repeat("x", 10)), max: 10}
+	if data, err := io.ReadAll(r); err != nil || len(data) != 10 {
+		t.Errorf("Expected 10 bytes within the limit, got %v, %v", len(data), err)
+	}
+	r = &limited_reader{r: strings.NewReader(strings.Repeat("x", 11)), max: 10}
+	if _, err := io.ReadAll(r); !errors.Is(err, ErrWordlistTooLarge) {
+		t.Errorf("Expected ErrWordlistTooLarge, got %v", err)
+	}
+
+	// A file growing past the limit after the size check fails when read
+	_, _, err := parse_wordmap(&limited_reader{r: strings.NewReader("otter\tN\nruns\tV\n"), max: 8}, wordlist.Parser{Path: "growing.txt"})
+	if pe := parse_error(t, err); !errors.Is(err, ErrWordlistTooLarge) {
+		t.Errorf("Unexpected error %#v", pe)
+	}
+}
+
+func TestCustomClassification(t *testing.T) {
+	table := []wordlist.Class{
+		{Tags: "n", Singular: "snoun", Plural: "pnoun", PluralTags: "s"},
+		{Tags: "v", Singular: "verb"},
+	}
+	g, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/twotag.txt", Classification: table, PruneEmptyTypes: true})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	m := g.GetWordMap()
+	if !reflect.DeepEqual(m["snoun"], []string{"badger", "otter"}) || !reflect.DeepEqual(m["pnoun"], []string{"otters"}) || !reflect.DeepEqual(m["verb"], []string{"runs", "swims"}) {
+		t.Errorf("Unexpected word map %v", m)
+	}
+	// The default table reads "v" as an adverb and knows no "n"
+	g, err = LoadGenerator(&WordListOptions{Wordlist: "testdata/twotag.txt"})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	if m := g.GetWordMap(); len(m["snoun"]) != 0 || !reflect.DeepEqual(m["adverb"], []string{"runs", "swims"}) {
+		t.Errorf("Unexpected word map with the default table: %v", m)
+	}
+
+	table[1].Singular = "action"
+	_, err = LoadGenerator(&WordListOptions{Wordlist: "testdata/twotag.txt", Classification: table})
+	if pe := parse_error(t, err); !errors.Is(err, ErrUnknownWordType) || pe.Path != "testdata/twotag.txt" {
+		t.Errorf("Expected a ParseError for an unknown word type in the table, got %v", err)
+	}
+}
+
+// testdata/pos_tags.txt has a word for every distinct tag in data/part-of-speech.txt
+func TestPOSTags(t *testing.T) {
+	f, err := os.ReadFile("testdata/pos_tags.txt")
+	if err != nil {
+		t.Fatalf("Could not read fixture: %v", err)
+	}
+	expected := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSuffix(string(f), "\n"), "\n") {
+		word, tag, _ := strings.Cut(line, "\t")
+		expected[word] = wordlist.Classify(tag)
+	}
+
+	g, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos_tags.txt", Strict: true})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	n := 0
+	for word_type, words := range g.GetWordMap() {
+		if len(words) == 0 {
+			t.Errorf("No words of type %v", word_type)
+		}
+		for _, w := range words {
+			if expected[w] != word_type {
+				t.Errorf("%q: expected %v, got %v", w, expected[w], word_type)
+			}
+		}
+		n += len(words)
+	}
+	if n != len(expected) {
+		t.Errorf("Expected %v words, got %v", len(expected), n)
+	}
+
+	g, err = LoadGenerator(&WordListOptions{Wordlist: "testdata/pos_tags.txt", ExcludeProperNouns: true})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	st := g.Stats()
+	n = 0
+	for _, c := range st.Words {
+		n += int(c)
+	}
+	if st.ProperNouns == 0 || n+int(st.ProperNouns) != len(expected) {
+		t.Errorf("Expected proper nouns to be dropped, got %v words and %v proper nouns", n, st.ProperNouns)
+	}
+}
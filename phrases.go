@@ -0,0 +1,75 @@
+package wordentropy
+
+import (
+	"bufio"
+	"strings"
+)
+
+// Trie of lowercased phrases by word, for AvoidCommonPhrases
+type phrase_trie struct {
+	next map[string]*phrase_trie
+	end  bool // a phrase ends here
+}
+
+// Add a phrase given as its lowercased words
+func (t *phrase_trie) add(words []string) {
+	for _, w := range words {
+		n := t.next[w]
+		if n == nil {
+			if t.next == nil {
+				t.next = make(map[string]*phrase_trie)
+			}
+			n = &phrase_trie{}
+			t.next[w] = n
+		}
+		t = n
+	}
+	t.end = true
+}
+
+// Whether words contain a phrase of the trie as a contiguous run, ignoring case. A nil trie
+// contains nothing.
+func (t *phrase_trie) contains(words []string) bool {
+	if t == nil {
+		return false
+	}
+	lower := make([]string, len(words))
+	for i, w := range words {
+		lower[i] = strings.ToLower(w)
+	}
+	for i := range lower {
+		n := t
+		for _, w := range lower[i:] {
+			if n = n.next[w]; n == nil {
+				break
+			}
+			if n.end {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Load a common phrase list: one phrase per line, words separated by whitespace
+func load_common_phrases(p string, max_bytes int64) (*phrase_trie, error) {
+	f, err := open_limited(p, max_bytes)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t := &phrase_trie{}
+	scanner := bufio.NewScanner(f)
+	n := 0
+	for scanner.Scan() {
+		n++
+		if words := strings.Fields(strings.ToLower(scanner.Text())); len(words) > 0 {
+			t.add(words)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, &ParseError{Path: p, Line: n + 1, Reason: err.Error(), Err: err}
+	}
+	return t, nil
+}
@@ -0,0 +1,79 @@
+package wordentropy
+
+import (
+	"errors"
+	mrand "math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestPhraseTrie(t *testing.T) {
+	trie := &phrase_trie{}
+	trie.add([]string{"the", "quick", "brown", "fox"})
+	trie.add([]string{"brown", "bear"})
+	cases := []struct {
+		words    []string
+		expected bool
+	}{
+		{[]string{"The", "Quick", "Brown", "Fox", "jumps"}, true},
+		{[]string{"a", "the", "quick", "brown", "fox"}, true},
+		{[]string{"the", "quick", "brown"}, false},
+		{[]string{"quick", "brown", "bear"}, true},
+		{[]string{"the", "quick", "brown", "bears"}, false},
+		{nil, false},
+	}
+	for _, c := range cases {
+		if got := trie.contains(c.words); got != c.expected {
+			t.Errorf("%v: expected %v, got %v", c.words, c.expected, got)
+		}
+	}
+	if (*phrase_trie)(nil).contains([]string{"fox"}) {
+		t.Errorf("Expected a nil trie to contain nothing")
+	}
+}
+
+func TestAvoidCommonPhrases(t *testing.T) {
+	g, err := LoadGenerator(&WordListOptions{
+		Wordlist:      write_temp(t, "pos.txt", "otter\tN\nbadger\tN\nruns\tV\n"),
+		CommonPhrases: write_temp(t, "common.txt", "\nOtter  Runs\n"),
+	})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	o := GenerateOptions{Count: count_max, Length: 2, AllowedTypes: []string{"snoun", "verb"}}
+	g.rand = mrand.NewChaCha8([32]byte{4})
+	p, err := g.GeneratePassphrases(&o)
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	if !strings.Contains(strings.Join(p, ","), "otter runs") {
+		t.Fatalf("Expected \"otter runs\" without AvoidCommonPhrases, got %v", p)
+	}
+
+	o.AvoidCommonPhrases = true
+	g.rand = mrand.NewChaCha8([32]byte{4})
+	p, err = g.GeneratePassphrases(&o)
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	for _, pp := range p {
+		if pp == "otter runs" {
+			t.Fatalf("Expected \"otter runs\" to be rejected, got %v", p)
+		}
+	}
+	if all, err := g.EnumerateAll(&o, 10); err != nil || len(all) != 3 {
+		t.Errorf("Expected 3 passphrases without \"otter runs\", got %v, %v", all, err)
+	}
+
+	// Every passphrase is common
+	g, err = LoadGenerator(&WordListOptions{
+		Wordlist:      write_temp(t, "pos.txt", "otter\tN\nruns\tV\n"),
+		CommonPhrases: write_temp(t, "common.txt", "otter\nruns\n"),
+	})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	if _, err := g.GeneratePassphrases(&o); !errors.Is(err, ErrRetriesExhausted) {
+		t.Errorf("Expected ErrRetriesExhausted, got %v", err)
+	}
+}
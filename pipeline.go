@@ -0,0 +1,262 @@
+package wordentropy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Turn generated word list entries and their types into a passphrase. The steps always run
+// in this order:
+//
+//  1. strip unprintable characters (see RejectNonPrintable), split multiword entries, drop
+//     empty ones and truncate to Length words
+//  2. case transforms (Lowercase, then Capitalize)
+//  3. join with the separator
+//  4. padding (digit, then symbol)
+//  5. syllable count and constraint check (MaxChars, MaxBytes and MaxSyllables, which
+//     count the padding)
+//
+// Returns false if the passphrase fails the constraint check and must be regenerated.
+func post_process(r raw_passphrase, o *GenerateOptions, rng int_source) (Passphrase, bool) {
+	p := split_entries(r, o.Length)
+	p.Insecure = o.InsecureFastRandom
+	p.Phrase = join_words(transform_case(p.Words, o), separator(o))
+	pad(&p, o, rng)
+	p.Syllables = syllables(p)
+	return p, check_constraints(p, o)
+}
+
+// Split entries into words, stripping unprintable characters and dropping empty words, and
+// truncate to length words. Word lists are stripped when loaded, so this only matters for
+// lazy ones.
+func split_entries(r raw_passphrase, length uint) Passphrase {
+	words := make([]string, 0, len(r.entries))
+	types := make([]string, 0, len(r.types))
+	entries := make([]int, 0, len(r.entries))
+	ends := make([]int, len(r.entries)) // number of words up to and including each entry
+	for j := range r.entries {
+		fields := strings.Fields(strip_unprintable(r.entries[j]))
+		for _, w := range fields {
+			words = append(words, w)
+			types = append(types, r.types[j])
+		}
+		if len(fields) > 0 {
+			entries = append(entries, len(fields))
+		}
+		ends[j] = len(words)
+	}
+	if uint(len(words)) > length {
+		words = words[:length]
+		types = types[:length]
+		entries = truncate_entries(entries, int(length))
+	}
+	return Passphrase{
+		Words:         words,
+		Types:         types,
+		Entries:       entries,
+		FragmentSpans: fragment_spans(r.fragments, ends, len(words)),
+	}
+}
+
+// Word index ranges of fragments, clamped to n words. Empty spans are dropped.
+func fragment_spans(fragments []int, ends []int, n int) [][2]int {
+	spans := make([][2]int, 0, len(fragments))
+	start, entry := 0, 0
+	for _, f := range fragments {
+		entry += f
+		end := 0
+		if entry > 0 {
+			end = ends[entry-1]
+		}
+		if end > n {
+			end = n
+		}
+		if end > start {
+			spans = append(spans, [2]int{start, end})
+			start = end
+		}
+	}
+	return spans
+}
+
+// Entry sizes covering only the first n words
+func truncate_entries(entries []int, n int) []int {
+	for i, e := range entries {
+		if e >= n {
+			entries[i] = n
+			return entries[:i+1]
+		}
+		n -= e
+	}
+	return entries
+}
+
+// Return a copy of words with Lowercase applied, then Capitalize
+func transform_case(words []string, o *GenerateOptions) []string {
+	if !o.Lowercase && o.Capitalize == "" {
+		return words
+	}
+	out := make([]string, len(words))
+	for i, w := range words {
+		if o.Lowercase {
+			w = strings.ToLower(w)
+		}
+		if o.Capitalize == "words" || (o.Capitalize == "sentence" && i == 0) {
+			w = capitalize_first(w)
+		}
+		out[i] = w
+	}
+	return out
+}
+
+// Title-case the first letter of a word, rune by rune and without locale rules: leading
+// apostrophes are skipped ("'tis" becomes "'Tis"), a word starting with anything else that
+// is not a letter (e.g. "4x4") is left alone, and digraphs such as "ǆ" take their title
+// form ("ǅ"). Shared by every capitalization option.
+func capitalize_first(w string) string {
+	i := 0
+	for i < len(w) {
+		r, n := utf8.DecodeRuneInString(w[i:])
+		if r == '\'' || r == '\u2019' {
+			i += n
+			continue
+		}
+		if unicode.IsLetter(r) {
+			return w[:i] + string(unicode.ToTitle(r)) + w[i+n:]
+		}
+		break
+	}
+	return w
+}
+
+// Separator between words in effect for the options
+func separator(o *GenerateOptions) string {
+	if o.No_spaces {
+		return ""
+	}
+	return o.Separator
+}
+
+// Join words with sep, dropping empty ones. Words are kept as they are: separators that
+// could run into them are rejected up front (see check_separator).
+func join_words(words []string, sep string) string {
+	kept := make([]string, 0, len(words))
+	for _, w := range words {
+		if w != "" {
+			kept = append(kept, w)
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+// Whether joining w with sep could put a separator at either end of a passphrase or two in
+// a row: w starts or ends with a character of sep, or contains sep twice in a row. Otherwise
+// every run of separator characters between two words is exactly one separator.
+func separator_clash(w string, sep string) bool {
+	first, _ := utf8.DecodeRuneInString(w)
+	last, _ := utf8.DecodeLastRuneInString(w)
+	return strings.ContainsRune(sep, first) || strings.ContainsRune(sep, last) || strings.Contains(w, sep+sep)
+}
+
+// Return ErrInvalidParameter if a word the call may use clashes with its separator (see
+// separator_clash), in any of the forms the case transforms can give it
+func (s *gen_state) check_separator() error {
+	sep := separator(s.o)
+	if sep == "" {
+		return nil
+	}
+	types := make([]string, 0, len(s.rules))
+	for t := range s.rules {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	k := new_keyspace(s)
+	for _, t := range types {
+		key := fmt.Sprintf("%v/separator/%v\x00%q\x00%v\x00%v", s.pools_key(), t, sep, s.o.Lowercase, s.o.Capitalize)
+		clash := s.d.derived(key, func() interface{} {
+			for _, entry := range k.words(t) {
+				for _, w := range strings.Fields(strip_unprintable(entry)) {
+					for _, form := range []string{w, transform_case([]string{w}, s.o)[0]} {
+						if separator_clash(form, sep) || separator_clash(capitalize_first(form), sep) {
+							return form
+						}
+					}
+				}
+			}
+			return ""
+		}).(string)
+		if clash != "" {
+			return fmt.Errorf("%w: Separator %q would run into the %v %q", ErrInvalidParameter, sep, t, clash)
+		}
+	}
+	return nil
+}
+
+// Append the digit and symbol requested by the options, drawn from rng
+func pad(p *Passphrase, o *GenerateOptions, rng int_source) {
+	if o.Add_digit {
+		digits := o.Digits
+		if len(digits) == 0 {
+			digits = default_digits
+		}
+		p.Digit = digits[rng.int_n(int64(len(digits)))]
+		p.Phrase += p.Digit
+	}
+	if o.Add_symbol {
+		p.Symbol = o.Symbols[rng.int_n(int64(len(o.Symbols)))]
+		p.Phrase += p.Symbol
+	}
+}
+
+// Estimated syllables of a passphrase read aloud: its words, then the digit and symbol as
+// Spellout names them ("Seven", "exclamation mark")
+func syllables(p Passphrase) int {
+	n := Syllables(strings.Join(p.Words, " "))
+	if padding := p.Digit + p.Symbol; padding != "" {
+		n += Syllables(Spellout(padding))
+	}
+	return n
+}
+
+// Check the finished passphrase against the constraints in the options
+func check_constraints(p Passphrase, o *GenerateOptions) bool {
+	if o.MaxBytes > 0 && uint(len(p.Phrase)) > o.MaxBytes {
+		return false
+	}
+	if o.MaxSyllables > 0 && uint(p.Syllables) > o.MaxSyllables {
+		return false
+	}
+	return o.MaxChars == 0 || uint(utf8.RuneCountInString(p.Phrase)) <= o.MaxChars
+}
+
+// Lower bound on the bytes of a passphrase for the call: the shortest word of any type in
+// the grammar plus the shortest digit and symbol if padding is requested. Used to fail
+// early when MaxBytes can never be met.
+func (s *gen_state) min_bytes() uint {
+	shortest := s.d.shortest(s.d.filter(s.o))
+	min := 0
+	for t := range s.rules {
+		if n, ok := shortest[t]; ok && (min == 0 || n < min) {
+			min = n
+		}
+	}
+	if s.o.Add_digit {
+		min += shortest_bytes(s.o.Digits)
+	}
+	if s.o.Add_symbol {
+		min += shortest_bytes(s.o.Symbols)
+	}
+	return uint(min)
+}
+
+// Length in bytes of the shortest string in l
+func shortest_bytes(l []string) int {
+	shortest := len(l[0])
+	for _, v := range l[1:] {
+		shortest = min(shortest, len(v))
+	}
+	return shortest
+}
@@ -0,0 +1,212 @@
+package wordentropy
+
+import (
+	"errors"
+	"math"
+	mrand "math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPostProcess(t *testing.T) {
+	pw := []string{"ice cream", "", "  ", "sings", "loudly"}
+	pt := []string{"snoun", "verb", "adverb", "verb", "adverb"}
+
+	o := GenerateOptions{Length: 3, Separator: " ", Capitalize: "words"}
+	p, ok := post_process(raw_passphrase{entries: pw, types: pt}, &o, new_fast_source())
+	if !ok {
+		t.Fatalf("Unexpected constraint failure")
+	}
+	if p.Phrase != "Ice Cream Sings" {
+		t.Errorf("Expected truncation before case transforms, got %q", p.Phrase)
+	}
+	if !reflect.DeepEqual(p.Words, []string{"ice", "cream", "sings"}) || !reflect.DeepEqual(p.Types, []string{"snoun", "snoun", "verb"}) {
+		t.Errorf("Unexpected words %v / types %v", p.Words, p.Types)
+	}
+	if !reflect.DeepEqual(p.Entries, []int{2, 1}) {
+		t.Errorf("Expected entries [2 1], got %v", p.Entries)
+	}
+
+	// Truncation inside a multiword entry
+	o = GenerateOptions{Length: 1, Separator: " "}
+	if p, _ := post_process(raw_passphrase{entries: pw, types: pt}, &o, new_fast_source()); p.Phrase != "ice" || !reflect.DeepEqual(p.Entries, []int{1}) {
+		t.Errorf("Unexpected truncated passphrase: %+v", p)
+	}
+
+	// Padding follows the separator step, so it is never separated
+	o = GenerateOptions{Length: 5, Separator: "-", Add_digit: true, Add_symbol: true, Symbols: []string{"-"}}
+	p, _ = post_process(raw_passphrase{entries: []string{"otter", "sings"}, types: []string{"snoun", "verb"}}, &o, new_fast_source())
+	if expected := "otter-sings" + p.Digit + "-"; p.Phrase != expected || p.Digit == "" || p.Symbol != "-" {
+		t.Errorf("Expected %q, got %+v", expected, p)
+	}
+	o.No_spaces = true
+	if p, _ := post_process(raw_passphrase{entries: []string{"otter", "sings"}, types: []string{"snoun", "verb"}}, &o, new_fast_source()); p.Phrase != "ottersings"+p.Digit+"-" {
+		t.Errorf("Unexpected No_spaces passphrase: %q", p.Phrase)
+	}
+
+	// A separator inside a word is left alone
+	o = GenerateOptions{Length: 5, Separator: "o"}
+	p, _ = post_process(raw_passphrase{entries: []string{"good", "food", "sings"}, types: []string{"adjective", "snoun", "verb"}}, &o, new_fast_source())
+	if p.Phrase != "goodofoodosings" || !reflect.DeepEqual(p.Words, []string{"good", "food", "sings"}) {
+		t.Errorf("Expected %q from %v, got %+v", "goodofoodosings", p.Words, p)
+	}
+	if phrase := join_words([]string{"oboe", "", "o", "zoo"}, "o"); phrase != "oboeooozoo" {
+		t.Errorf("Expected only the empty word dropped, got %q", phrase)
+	}
+	for w, clash := range map[string]bool{"dog": false, "sings": false, "oboe": true, "zoo": true, "o": true, "good": true} {
+		if separator_clash(w, "o") != clash {
+			t.Errorf("Expected separator_clash(%q, \"o\") = %v", w, clash)
+		}
+	}
+	if separator_clash("hell-bent", "-") || separator_clash("a-b", "--") || !separator_clash("a----b", "--") {
+		t.Errorf("Expected separators inside words to clash only when doubled")
+	}
+
+	// The constraint check sees the padded phrase
+	o = GenerateOptions{Length: 5, Separator: " ", MaxChars: 11}
+	if _, ok := post_process(raw_passphrase{entries: []string{"otter", "sings"}, types: []string{"snoun", "verb"}}, &o, new_fast_source()); !ok {
+		t.Errorf("Expected 11 characters to satisfy MaxChars 11")
+	}
+	o.Add_digit = true
+	if _, ok := post_process(raw_passphrase{entries: []string{"otter", "sings"}, types: []string{"snoun", "verb"}}, &o, new_fast_source()); ok {
+		t.Errorf("Expected the digit to count towards MaxChars")
+	}
+}
+
+func TestFragmentSpans(t *testing.T) {
+	cases := []struct {
+		word     string
+		o        GenerateOptions
+		expected [][2]int
+	}{
+		{"otter", GenerateOptions{Length: 10, Magic_fragment_length: 3}, [][2]int{{0, 3}, {3, 7}, {7, 10}}},
+		{"otter", GenerateOptions{Length: 4, Magic_fragment_length: 4}, [][2]int{{0, 4}}},
+		{"otter", GenerateOptions{Length: 2, Magic_fragment_length: 4}, [][2]int{{0, 2}}},
+		{"ice cream", GenerateOptions{Length: 10, Magic_fragment_length: 3}, [][2]int{{0, 6}, {6, 10}}},
+	}
+	for _, c := range cases {
+		g := generator_for(uniform_word_map(c.word))
+		o := c.o
+		o.Count = 10
+		p, _, err := g.GeneratePassphrasesDetailed(&o)
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		for _, pp := range p {
+			if !reflect.DeepEqual(pp.FragmentSpans, c.expected) {
+				t.Fatalf("%v %+v: expected spans %v, got %v", c.word, c.o, c.expected, pp.FragmentSpans)
+			}
+			next := 0
+			for _, span := range pp.FragmentSpans {
+				if span[0] != next || span[1] <= span[0] {
+					t.Fatalf("Spans do not tile words: %v", pp.FragmentSpans)
+				}
+				next = span[1]
+			}
+			if next != len(pp.Words) {
+				t.Fatalf("Spans %v do not cover %v words", pp.FragmentSpans, len(pp.Words))
+			}
+		}
+	}
+}
+
+func TestDigits(t *testing.T) {
+	g := generator_for(uniform_word_map("otter"))
+	p, _, err := g.GeneratePassphrasesDetailed(&GenerateOptions{Count: count_max, Length: 2, Add_digit: true, Digits: []string{"2", "3", "\u00e4"}})
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, pp := range p {
+		seen[pp.Digit] = true
+		if !strings.HasSuffix(pp.Phrase, pp.Digit) {
+			t.Errorf("Digit %q missing from %q", pp.Digit, pp.Phrase)
+		}
+	}
+	if !reflect.DeepEqual(seen, map[string]bool{"2": true, "3": true, "\u00e4": true}) {
+		t.Errorf("Expected only the configured digits, got %v", seen)
+	}
+
+	for _, digits := range [][]string{{"1", "23"}, {""}, {"a", "\u0301a"}} {
+		if _, err := g.GeneratePassphrases(&GenerateOptions{Add_digit: true, Digits: digits}); !errors.Is(err, ErrInvalidDigits) {
+			t.Errorf("%q: expected ErrInvalidDigits, got %v", digits, err)
+		}
+	}
+
+	cases := []struct {
+		o    GenerateOptions
+		bits float64
+	}{
+		{GenerateOptions{}, 0},
+		{GenerateOptions{Add_digit: true, Digits: []string{"0", "1"}}, 1},
+		{GenerateOptions{Add_digit: true, Digits: []string{"a", "b", "c", "d"}, Add_symbol: true, Symbols: []string{"!", "?"}}, 3},
+		{GenerateOptions{Add_digit: true}, math.Log2(10)},
+	}
+	for _, c := range cases {
+		if bits, err := g.PaddingEntropy(&c.o); err != nil || bits != c.bits {
+			t.Errorf("%+v: expected %v bits, got %v, %v", c.o, c.bits, bits, err)
+		}
+	}
+}
+
+func TestCapitalizeFirst(t *testing.T) {
+	cases := []struct {
+		word, expected string
+	}{
+		{"otter", "Otter"},
+		{"Otter", "Otter"},
+		{"\u00fcber", "\u00dcber"},                   // über
+		{"\u03c3\u03bf\u03c6", "\u03a3\u03bf\u03c6"}, // σοφ
+		{"ijsberg", "Ijsberg"},                       // no locale rules: Dutch "ij" is two letters
+		{"\u0133sberg", "\u0132sberg"},               // but the "ĳ" ligature has a capital
+		{"\u01c6ungla", "\u01c5ungla"},               // "ǆ" takes its title form "ǅ", not "Ǆ"
+		{"'tis", "'Tis"},                             // leading apostrophes are skipped
+		{"\u2019twas", "\u2019Twas"},                 // including typographic ones
+		{"4x4", "4x4"},                               // digit-leading tokens are left alone
+		{"2nd", "2nd"},
+		{"-ish", "-ish"}, // as is other leading punctuation
+		{"'", "'"},
+		{"", ""},
+		{"\xffotter", "\xffotter"}, // invalid UTF-8
+	}
+	for _, c := range cases {
+		if w := capitalize_first(c.word); w != c.expected {
+			t.Errorf("%q: expected %q, got %q", c.word, c.expected, w)
+		}
+	}
+}
+
+func TestRaw(t *testing.T) {
+	g := load_test_generator(t)
+	var expected []string
+	// Digits and symbols are drawn from the same source as the words; with a single choice
+	// they draw nothing, so the words stay the same
+	for _, o := range []GenerateOptions{
+		{},
+		{Capitalize: "words", Digits: []string{"3"}, Add_digit: true},
+		{Lowercase: true, Capitalize: "sentence", Symbols: []string{"!"}, Add_symbol: true},
+		{No_spaces: true, Digits: []string{"3"}, Add_digit: true, Symbols: []string{"!"}, Add_symbol: true},
+		{Separator: "-", Capitalize: "words", Digits: []string{"7"}, Add_digit: true},
+	} {
+		o.Count, o.Length = 10, 6
+		g.rand = mrand.NewChaCha8([32]byte{7})
+		p, _, err := g.GeneratePassphrasesDetailed(&o)
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		raw := make([]string, len(p))
+		for i := range p {
+			raw[i] = p[i].Raw()
+		}
+		if expected == nil {
+			expected = raw
+		} else if !reflect.DeepEqual(raw, expected) {
+			t.Errorf("%+v: Raw() changed with output options:\n%q\n%q", o, raw, expected)
+		}
+	}
+	p := Passphrase{Phrase: "Ice-Cream-Sings7", Words: []string{"ice", "cream", "sings"}}
+	if p.Raw() != "ice cream sings" {
+		t.Errorf("Unexpected Raw() %q", p.Raw())
+	}
+}
@@ -0,0 +1,44 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrUnknownPreset = errors.New("Unknown preset")
+
+// Named set of options for a notable passphrase style. Count and Length are left unset.
+type Preset struct {
+	Name        string
+	Description string
+	Options     GenerateOptions
+}
+
+// Presets in display order
+var presets = []Preset{
+	{"default", "words separated by spaces", GenerateOptions{}},
+	{"no_spaces", "words run together", GenerateOptions{No_spaces: true}},
+	{"camel", "capitalized words run together", GenerateOptions{No_spaces: true, Capitalize: "words"}},
+	{"sentence", "first word capitalized", GenerateOptions{Capitalize: "sentence"}},
+	{"short_words", "shorter words preferred", GenerateOptions{ShortWordBias: 1}},
+	{"padded", "a digit and a symbol appended for password rules", GenerateOptions{Add_digit: true, Add_symbol: true}},
+}
+
+// Names of the presets, in display order
+func PresetNames() []string {
+	names := make([]string, len(presets))
+	for i, p := range presets {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// Preset of the given name (ErrUnknownPreset if there is none)
+func DescribePreset(name string) (Preset, error) {
+	for _, p := range presets {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return Preset{}, fmt.Errorf("%w: %v", ErrUnknownPreset, name)
+}
@@ -0,0 +1,35 @@
+package wordentropy
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	g, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos.txt"})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	names := PresetNames()
+	if len(names) == 0 || names[0] != "default" {
+		t.Fatalf("Expected the default preset first, got %v", names)
+	}
+	for _, name := range names {
+		p, err := DescribePreset(name)
+		if err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		if p.Name != name || p.Description == "" {
+			t.Errorf("%v: unexpected preset %+v", name, p)
+		}
+		o := p.Options
+		o.Count = 5
+		if _, err := g.GeneratePassphrases(&o); err != nil {
+			t.Errorf("%v: error generating passphrases: %v", name, err)
+		}
+	}
+
+	if _, err := DescribePreset("diceware"); !errors.Is(err, ErrUnknownPreset) {
+		t.Errorf("Expected ErrUnknownPreset, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrUnprintableWord = errors.New("Word contains unprintable characters")
+
+// Whether a character may appear in a passphrase: unicode.IsPrint, which allows the ASCII
+// space but no other whitespace, control characters (e.g. ANSI escapes), format characters
+// (e.g. zero-width spaces) or the replacement character of invalid UTF-8
+func printable(r rune) bool {
+	return r != utf8.RuneError && unicode.IsPrint(r)
+}
+
+func is_printable(s string) bool {
+	for _, r := range s {
+		if !printable(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Remove unprintable characters from s, turning whitespace such as tabs into spaces so
+// multiword entries stay split
+func strip_unprintable(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case printable(r):
+			return r
+		case unicode.IsSpace(r):
+			return ' '
+		}
+		return -1
+	}, s)
+}
+
+// Check the words of a new snapshot for unprintable characters. With reject, fail with
+// ErrUnprintableWord naming the first; otherwise strip them, dropping words left empty or
+// duplicated. Lazy word lists are only checked with reject: their words are stripped as
+// passphrases are assembled instead.
+func sanitize_words(d *word_data, reject bool) error {
+	if d.lazy != nil {
+		if !reject {
+			return nil
+		}
+		for _, t := range word_types {
+			for i := range d.lazy.offsets[t] {
+				if w := d.lazy.word(t, i); !is_printable(w) {
+					return fmt.Errorf("%w: %q (word type: %v)", ErrUnprintableWord, w, t)
+				}
+			}
+		}
+		return nil
+	}
+	stripped := 0
+	for _, t := range word_types {
+		words := d.word_map[t]
+		var clean []string // copy made at the first unprintable word, as slices may be shared
+		for i, w := range words {
+			if is_printable(w) {
+				if clean != nil {
+					clean = append(clean, w)
+				}
+				continue
+			}
+			if reject {
+				return fmt.Errorf("%w: %q (word type: %v)", ErrUnprintableWord, w, t)
+			}
+			if clean == nil {
+				clean = append(make([]string, 0, len(words)), words[:i]...)
+			}
+			stripped++
+			if w = strings.Join(strings.Fields(strip_unprintable(w)), " "); w != "" {
+				clean = append(clean, w)
+			}
+		}
+		if clean != nil {
+			clean = dedup_words(clean)
+			sort.Strings(clean)
+			d.word_map[t] = clean
+		}
+	}
+	if stripped > 0 {
+		log.Printf("WARNING: stripped unprintable characters from %v words\n", stripped)
+	}
+	return nil
+}
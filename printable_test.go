@@ -0,0 +1,82 @@
+package wordentropy
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnprintableWords(t *testing.T) {
+	// Stripped by default, dropping words left empty
+	g, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/unprintable.txt"})
+	if err != nil {
+		t.Fatalf("Error loading wordlist: %v", err)
+	}
+	want := map[string][]string{
+		"snoun":     {"[31mbadger[0m", "damn fool", "otter"},
+		"verb":      {"Damn", "runs", "sings"},
+		"adjective": {"brave", "hell-bent", "quiet"},
+	}
+	word_map := g.GetWordMap()
+	for tp, words := range want {
+		if !reflect.DeepEqual(word_map[tp], words) {
+			t.Errorf("Expected %v %q, got %q", tp, words, word_map[tp])
+		}
+	}
+	p, err := g.GeneratePassphrases(&GenerateOptions{Count: count_max, AllowedTypes: []string{"snoun", "verb", "adjective", "conjunction"}})
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	for _, phrase := range p {
+		if !is_printable(phrase) {
+			t.Fatalf("Unprintable passphrase %q", phrase)
+		}
+	}
+
+	// Rejected with RejectNonPrintable, naming the word, also by AddWords
+	_, err = LoadGenerator(&WordListOptions{Wordlist: "testdata/unprintable.txt", RejectNonPrintable: true})
+	if !errors.Is(err, ErrUnprintableWord) || !strings.Contains(err.Error(), `"\x1b[31mbadger\x1b[0m"`) {
+		t.Fatalf("Expected ErrUnprintableWord naming the word, got %v", err)
+	}
+	strict, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos.txt", RejectNonPrintable: true})
+	if err != nil {
+		t.Fatalf("Error loading wordlist: %v", err)
+	}
+	if err := strict.AddWords("snoun", "wom\u200dbat"); !errors.Is(err, ErrUnprintableWord) {
+		t.Errorf("Expected ErrUnprintableWord from AddWords, got %v", err)
+	}
+	if err := g.AddWords("snoun", "wom\u200dbat", "wombat\t"); err != nil || !reflect.DeepEqual(g.GetWordMap()["snoun"], append(want["snoun"], "wombat")) {
+		t.Errorf("Expected AddWords to strip, got %q, %v", g.GetWordMap()["snoun"], err)
+	}
+
+	// Lazy word lists are checked when rejecting and stripped as passphrases are assembled
+	var buf bytes.Buffer
+	if err := write_binary_wordmap(&buf, map[string][]string{"snoun": {"ot\x1bter"}, "verb": {"ru\u200bns"}}); err != nil {
+		t.Fatalf("Error writing binary wordlist: %v", err)
+	}
+	lazy := &Generator{}
+	if err := lazy.LoadBinaryWords(buf.Bytes(), &WordListOptions{RejectNonPrintable: true}); !errors.Is(err, ErrUnprintableWord) {
+		t.Fatalf("Expected ErrUnprintableWord from a lazy list, got %v", err)
+	}
+	if err := lazy.LoadBinaryWords(buf.Bytes(), nil); err != nil {
+		t.Fatalf("Error loading binary wordlist: %v", err)
+	}
+	p, err = lazy.GeneratePassphrases(&GenerateOptions{Count: 10, Length: 2, Fragments: FragmentPolicy{TargetFragmentWords: 2}, AllowedTypes: []string{"snoun", "verb"}, NoJoints: true})
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	for _, phrase := range p {
+		if phrase != "otter runs" && phrase != "runs otter" && phrase != "otter otter" && phrase != "runs runs" {
+			t.Errorf("Expected stripped words, got %q", phrase)
+		}
+	}
+
+	// Separator and Digits must be printable too
+	for _, o := range []GenerateOptions{{Separator: "\t"}, {Separator: "-\u200b"}, {Add_digit: true, Digits: []string{"\x07"}}} {
+		if _, err := g.GeneratePassphrases(&o); err == nil {
+			t.Errorf("Expected an error for %q %q", o.Separator, o.Digits)
+		}
+	}
+}
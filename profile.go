@@ -0,0 +1,73 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Returned when a GeneratorSet has no profile of the requested name
+var ErrUnknownProfile = errors.New("unknown profile")
+
+// A named Generator with its own request defaults and limits (see GeneratorSet)
+type Profile struct {
+	Generator *Generator
+	Defaults  *GenerateOptions // options a request starts from before its query parameters (nil = the GenerateOptions defaults)
+	MaxWork   uint             // maximum count × length (× BestOf) per request, instead of HandlerOptions.MaxWork (0 = that)
+}
+
+// Named Generators, e.g. passphrase flavors backed by different word lists, served by one
+// handler from NewSetHandler. Each profile has its own Generator, so its own Stats and
+// Counters. The zero value is an empty set ready to use; it is safe for concurrent use.
+type GeneratorSet struct {
+	mu       sync.RWMutex
+	profiles map[string]Profile
+}
+
+// Register g as the profile name with the default options and limits, replacing any
+// profile of that name
+func (s *GeneratorSet) RegisterGenerator(name string, g *Generator) {
+	s.RegisterProfile(name, Profile{Generator: g})
+}
+
+// Register p as the profile name, replacing any profile of that name. Its Defaults are
+// copied. Panics if name is empty or p has no Generator.
+func (s *GeneratorSet) RegisterProfile(name string, p Profile) {
+	if name == "" || p.Generator == nil {
+		panic(fmt.Sprintf("wordentropy: bad profile %q", name))
+	}
+	if p.Defaults != nil {
+		defaults := *p.Defaults
+		p.Defaults = &defaults
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.profiles == nil {
+		s.profiles = make(map[string]Profile)
+	}
+	s.profiles[name] = p
+}
+
+// The profile registered as name, or ErrUnknownProfile
+func (s *GeneratorSet) Profile(name string) (Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("%w: %q", ErrUnknownProfile, name)
+	}
+	return p, nil
+}
+
+// Names of the registered profiles, sorted
+func (s *GeneratorSet) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.profiles))
+	for name := range s.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,97 @@
+package wordentropy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGeneratorSet(t *testing.T) {
+	var s GeneratorSet
+	if _, err := s.Profile("short"); !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("Expected ErrUnknownProfile from an empty set, got %v", err)
+	}
+	short, long := load_test_generator(t), load_test_generator(t)
+	defaults := GenerateOptions{Count: 2, Length: 3, No_spaces: true}
+	s.RegisterProfile("short", Profile{Generator: short, Defaults: &defaults, MaxWork: 20})
+	s.RegisterGenerator("long", long)
+	defaults.Count = 50 // registration copies the defaults
+	if names := s.Names(); !reflect.DeepEqual(names, []string{"long", "short"}) {
+		t.Fatalf("Expected profiles [long short], got %v", names)
+	}
+	h := NewSetHandler(&s, nil)
+
+	phrases := func(target string) []string {
+		rec := serve(h, target)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%v: expected 200, got %v: %v", target, rec.Code, rec.Body.String())
+		}
+		var resp Response
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Could not decode body: %v", err)
+		}
+		return resp.Passphrases
+	}
+	p := phrases("/?profile=short")
+	if len(p) != 2 || strings.Contains(p[0], " ") {
+		t.Errorf("Expected the short profile's defaults, got %+v", p)
+	}
+	p = phrases("/?profile=short&count=1&no_spaces=false")
+	if len(p) != 1 || len(strings.Fields(p[0])) != 3 {
+		t.Errorf("Expected query parameters to override the defaults, got %+v", p)
+	}
+	if p := phrases("/?profile=long&count=3"); len(p) != 3 || !strings.Contains(p[0], " ") {
+		t.Errorf("Expected the long profile without defaults, got %+v", p)
+	}
+
+	// Each profile has its own limits and counters
+	if rec := serve(h, "/?profile=short&count=5&length=5"); rec.Code != http.StatusBadRequest || error_code(t, rec) != "ErrWorkLimitExceeded" {
+		t.Errorf("Expected the short profile's MaxWork to apply, got %v", rec.Code)
+	}
+	phrases("/?profile=long&count=5&length=5")
+	if c := short.Counters(); c.Phrases != 3 {
+		t.Errorf("Expected 3 passphrases from the short profile, got %v", c.Phrases)
+	}
+	if c := long.Counters(); c.Phrases != 8 {
+		t.Errorf("Expected 8 passphrases from the long profile, got %v", c.Phrases)
+	}
+
+	rec := serve(h, "/?profile=medium")
+	if rec.Code != http.StatusNotFound || error_code(t, rec) != "ErrUnknownProfile" {
+		t.Errorf("Expected 404 with ErrUnknownProfile, got %v: %v", rec.Code, rec.Body.String())
+	}
+	rec = serve(h, "/")
+	if rec.Code != http.StatusBadRequest || error_code(t, rec) != "ErrInvalidParameter" {
+		t.Errorf("Expected 400 without a profile, got %v: %v", rec.Code, rec.Body.String())
+	}
+}
+
+func TestGeneratorSetConcurrent(t *testing.T) {
+	var s GeneratorSet
+	g := load_test_generator(t)
+	h := NewSetHandler(&s, nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				name := fmt.Sprintf("p%v", (i+j)%4)
+				s.RegisterGenerator(name, g)
+				if rec := serve(h, "/?count=1&profile="+name); rec.Code != http.StatusOK {
+					t.Errorf("%v: expected 200, got %v", name, rec.Code)
+				}
+				s.Names()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if names := s.Names(); len(names) != 4 {
+		t.Errorf("Expected 4 profiles, got %v", names)
+	}
+}
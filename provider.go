@@ -0,0 +1,134 @@
+package wordentropy
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/bkeroack/libwordentropy/wordlist"
+	"slices"
+	"sort"
+)
+
+var (
+	ErrUnknownWordType       = wordlist.ErrUnknownWordType
+	ErrEmptyWord             = wordlist.ErrEmptyWord
+	ErrEmptyWordType         = wordlist.ErrEmptyWordType
+	ErrInvalidClassification = wordlist.ErrInvalidClassification
+)
+
+// Source of words for a Generator. Words are snapshotted when the Generator is created, so
+// providers backed by a database or remote service are only queried once per load.
+type WordProvider interface {
+	WordTypes() []string                      // word types the provider has words for
+	Words(word_type string) ([]string, error) // words of the given type
+}
+
+// Create a Generator from the words supplied by a provider.
+func NewGeneratorFromProvider(p WordProvider) (_ *Generator, err error) {
+	defer recover_internal(&err)
+	g := Generator{}
+	err = g.load_provider(p, &WordListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// Copy words from a provider into a word map, validating word types and words. Each type's
+// words are deduplicated and sorted, so generation with a given random stream depends only
+// on which words are in the list, not on their order.
+func snapshot_provider(p WordProvider) (map[string][]string, error) {
+	word_map := make(map[string][]string, len(word_types))
+	for _, t := range word_types {
+		word_map[t] = []string{}
+	}
+
+	total := 0
+	for _, t := range p.WordTypes() {
+		if _, ok := word_map[t]; !ok {
+			return nil, fmt.Errorf("%w: %v", ErrUnknownWordType, t)
+		}
+		words, err := p.Words(t)
+		if err != nil {
+			return nil, fmt.Errorf("word type %v: %w", t, err)
+		}
+		for _, w := range words {
+			if w == "" {
+				return nil, fmt.Errorf("%w in word type %v", ErrEmptyWord, t)
+			}
+		}
+		word_map[t] = dedup_words(append(word_map[t], words...))
+		sort.Strings(word_map[t])
+		total += len(word_map[t])
+	}
+	if total == 0 {
+		return nil, ErrEmptyWordlist
+	}
+	return word_map, nil
+}
+
+// Remove duplicate words, keeping the first occurrence of each
+func dedup_words(words []string) []string {
+	seen := make(map[string]bool, len(words))
+	unique := words[:0]
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			unique = append(unique, w)
+		}
+	}
+	return unique
+}
+
+// Load a JSON wordlist: an object mapping word types to non-empty arrays of words
+func load_json_wordmap(p string, ignore_unknown bool, max_bytes int64) (map[string][]string, error) {
+	data, err := read_limited(p, max_bytes)
+	if err != nil {
+		return nil, err
+	}
+	parser := wordlist.Parser{Path: p, IgnoreUnknownTypes: ignore_unknown}
+	word_map, _, err := parser.ParseJSON(bytes.NewReader(data))
+	return word_map, err
+}
+
+// WordProvider backed by an in-memory map of word type to words
+type MapProvider map[string][]string
+
+func (m MapProvider) WordTypes() []string {
+	types := make([]string, 0, len(m))
+	for t := range m {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func (m MapProvider) Words(word_type string) ([]string, error) {
+	return m[word_type], nil
+}
+
+// WordProvider that parses a POS wordlist file (see WordListOptions) on first use
+type POSFileProvider struct {
+	Path               string
+	ExcludeProperNouns bool             // drop proper nouns (see WordListOptions)
+	Strict             bool             // fail on malformed lines (see WordListOptions)
+	MaxFileBytes       int64            // size limit (see WordListOptions)
+	Classification     []wordlist.Class // POS tag classification (see WordListOptions)
+	word_map           map[string][]string
+	proper_excluded    uint
+}
+
+func (p *POSFileProvider) WordTypes() []string {
+	return slices.Clone(word_types)
+}
+
+func (p *POSFileProvider) Words(word_type string) ([]string, error) {
+	if p.word_map == nil {
+		parser := wordlist.Parser{Path: p.Path, Strict: p.Strict, ExcludeProperNouns: p.ExcludeProperNouns, Classification: p.Classification}
+		word_map, proper_excluded, err := load_wordmap(parser, p.MaxFileBytes)
+		if err != nil {
+			return nil, err
+		}
+		p.word_map, p.proper_excluded = word_map, proper_excluded
+	}
+	return p.word_map[word_type], nil
+}
@@ -0,0 +1,226 @@
+package wordentropy
+
+import (
+	"encoding/binary"
+	"errors"
+	mrand "math/rand/v2"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var errProviderUnavailable = errors.New("provider unavailable")
+
+type failing_provider struct {
+	MapProvider
+	fail string
+}
+
+func (p failing_provider) Words(word_type string) ([]string, error) {
+	if word_type == p.fail {
+		return nil, errProviderUnavailable
+	}
+	return p.MapProvider.Words(word_type)
+}
+
+func TestNewGeneratorFromProvider(t *testing.T) {
+	g, err := NewGeneratorFromProvider(MapProvider(uniform_word_map("otter")))
+	if err != nil {
+		t.Fatalf("Error creating generator: %v", err)
+	}
+	p, err := g.GeneratePassphrases(&GenerateOptions{Count: 1, Length: 3})
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	if p[0] != "otter otter otter" {
+		t.Fatalf("Unexpected passphrase: %v", p[0])
+	}
+
+	// words are snapshotted at creation
+	m := MapProvider{"snoun": []string{"otter"}}
+	g, err = NewGeneratorFromProvider(m)
+	if err != nil {
+		t.Fatalf("Error creating generator: %v", err)
+	}
+	m["snoun"][0] = "badger"
+	if w := g.GetWordMap()["snoun"][0]; w != "otter" {
+		t.Fatalf("Generator shares storage with provider: %v", w)
+	}
+}
+
+func TestNewGeneratorFromProviderErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		p        WordProvider
+		expected error
+	}{
+		{"provider error", failing_provider{MapProvider(uniform_word_map("otter")), "verb"}, errProviderUnavailable},
+		{"unknown type", MapProvider{"noun": []string{"otter"}}, ErrUnknownWordType},
+		{"empty word", MapProvider{"snoun": []string{"otter", ""}}, ErrEmptyWord},
+		{"no words", MapProvider{"snoun": []string{}}, ErrEmptyWordlist},
+	}
+	for _, c := range cases {
+		g, err := NewGeneratorFromProvider(c.p)
+		if !errors.Is(err, c.expected) {
+			t.Errorf("%v: expected %v, got %v", c.name, c.expected, err)
+		}
+		if g != nil {
+			t.Errorf("%v: expected nil generator on error", c.name)
+		}
+	}
+}
+
+func TestPOSFileProvider(t *testing.T) {
+	g1, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos.txt"})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	g2, err := NewGeneratorFromProvider(&POSFileProvider{Path: "testdata/pos.txt"})
+	if err != nil {
+		t.Fatalf("Error creating generator: %v", err)
+	}
+	if !reflect.DeepEqual(g1.GetWordMap(), g2.GetWordMap()) {
+		t.Fatalf("Word maps differ between LoadGenerator and POSFileProvider")
+	}
+	if _, err := NewGeneratorFromProvider(&POSFileProvider{Path: "testdata/missing.txt"}); err == nil {
+		t.Fatalf("Expected error for missing wordlist")
+	}
+
+	// Callers get their own copy of the word types
+	types := (&POSFileProvider{}).WordTypes()
+	types[0] = "otter"
+	if word_types[0] == "otter" {
+		t.Errorf("WordTypes returned the package's own word types")
+	}
+}
+
+func write_temp(t *testing.T, name string, content string) string {
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatalf("Could not write %v: %v", name, err)
+	}
+	return p
+}
+
+func TestJSONWordlist(t *testing.T) {
+	text, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos.txt"})
+	if err != nil {
+		t.Fatalf("Could not load text wordlist: %v", err)
+	}
+	js, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos.json", Format: "json"})
+	if err != nil {
+		t.Fatalf("Could not load JSON wordlist: %v", err)
+	}
+	if !reflect.DeepEqual(text.GetWordMap(), js.GetWordMap()) {
+		t.Fatalf("JSON and text word maps differ:\n%v\n%v", text.GetWordMap(), js.GetWordMap())
+	}
+	if n := len(js.GetWordMap()["snoun"]); n != 3 {
+		t.Fatalf("Expected duplicate snoun to be removed, got %v words", n)
+	}
+}
+
+func TestJSONWordlistErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		content  string
+		ignore   bool
+		expected error
+	}{
+		{"malformed", `{"snoun": ["otter"`, false, nil},
+		{"unknown type", `{"snoun": ["otter"], "noun": ["badger"]}`, false, ErrUnknownWordType},
+		{"empty array", `{"snoun": ["otter"], "verb": []}`, false, ErrEmptyWordType},
+		{"empty word", `{"snoun": ["otter", ""]}`, false, ErrEmptyWord},
+	}
+	for _, c := range cases {
+		p := write_temp(t, "words.json", c.content)
+		_, err := LoadGenerator(&WordListOptions{Wordlist: p, Format: "json", IgnoreUnknownTypes: c.ignore})
+		if err == nil {
+			t.Errorf("%v: expected error", c.name)
+		} else if c.expected != nil && !errors.Is(err, c.expected) {
+			t.Errorf("%v: expected %v, got %v", c.name, c.expected, err)
+		}
+	}
+
+	p := write_temp(t, "words.json", `{"snoun": ["otter"], "noun": ["badger"]}`)
+	g, err := LoadGenerator(&WordListOptions{Wordlist: p, Format: "json", IgnoreUnknownTypes: true})
+	if err != nil {
+		t.Fatalf("Expected unknown type to be ignored, got %v", err)
+	}
+	if n := g.Stats().Words["snoun"]; n != 1 {
+		t.Fatalf("Expected 1 snoun, got %v", n)
+	}
+	if _, err := LoadGenerator(&WordListOptions{Wordlist: p, Format: "yaml"}); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+// Binary wordlist with words in the given order, as an older converter might have written it
+func unsorted_binary(word_map map[string][]string) []byte {
+	le := binary.LittleEndian
+	buf := []byte(binary_magic)
+	buf = le.AppendUint16(buf, binary_version)
+	buf = le.AppendUint16(buf, uint16(len(word_types)))
+	for _, t := range word_types {
+		buf = append(buf, byte(len(t)))
+		buf = append(buf, t...)
+		buf = le.AppendUint32(buf, uint32(len(word_map[t])))
+	}
+	for _, t := range word_types {
+		for _, w := range word_map[t] {
+			buf = le.AppendUint16(buf, uint16(len(w)))
+			buf = append(buf, w...)
+		}
+	}
+	return buf
+}
+
+func TestCanonicalOrder(t *testing.T) {
+	original, err := os.ReadFile("testdata/pos.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(original)), "\n")
+	mrand.New(mrand.NewPCG(1, 2)).Shuffle(len(lines), func(i, j int) { lines[i], lines[j] = lines[j], lines[i] })
+	shuffled := filepath.Join(t.TempDir(), "shuffled.txt")
+	if err := os.WriteFile(shuffled, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var generators []*Generator
+	for _, p := range []string{"testdata/pos.txt", shuffled} {
+		g, err := LoadGenerator(&WordListOptions{Wordlist: p})
+		if err != nil {
+			t.Fatalf("Could not load %v: %v", p, err)
+		}
+		generators = append(generators, g)
+	}
+	reversed := make(map[string][]string)
+	for t, words := range generators[0].GetWordMap() {
+		reversed[t] = append([]string{}, words...)
+		slices.Reverse(reversed[t])
+	}
+	lazy := &Generator{}
+	if err := lazy.LoadBinaryWords(unsorted_binary(reversed), nil); err != nil {
+		t.Fatalf("Could not load binary wordlist: %v", err)
+	}
+	generators = append(generators, lazy)
+
+	var outputs []string
+	for _, g := range generators {
+		g.rand = mrand.NewChaCha8([32]byte{7})
+		p, err := g.GeneratePassphrases(&GenerateOptions{Count: 50, Length: 12})
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		outputs = append(outputs, strings.Join(p, "\n"))
+	}
+	if outputs[0] != outputs[1] {
+		t.Errorf("Output differs for a shuffled wordlist:\n%v\nvs\n%v", outputs[0], outputs[1])
+	}
+	if outputs[0] != outputs[2] {
+		t.Errorf("Output differs for an unsorted binary wordlist:\n%v\nvs\n%v", outputs[0], outputs[2])
+	}
+}
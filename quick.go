@@ -0,0 +1,96 @@
+package wordentropy
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Word lists registered with RegisterBuiltin, by name
+var builtin_wordlists = struct {
+	sync.RWMutex
+	m map[string][]byte
+}{m: make(map[string][]byte)}
+
+// Make data, a wordlist in the binary format (see ConvertWordlist), loadable by name through
+// WordListOptions.Builtin and LoadEmbeddedWords; "full" is the one used by Quick. data is
+// kept as is, not copied. Importing the embedded package registers the lists that come with
+// this one: "full", derived from the Moby part-of-speech database, and "common", a curated
+// list of common, easily spelled words of 3 to 8 letters (see data/common.txt).
+func RegisterBuiltin(name string, data []byte) {
+	builtin_wordlists.Lock()
+	defer builtin_wordlists.Unlock()
+	builtin_wordlists.m[name] = data
+}
+
+// Data of the built-in word list named name ("" for "full")
+func builtin_wordlist(name string) ([]byte, error) {
+	if name == "" {
+		name = "full"
+	}
+	builtin_wordlists.RLock()
+	data, ok := builtin_wordlists.m[name]
+	builtin_wordlists.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: builtin: %q is not registered (import github.com/bkeroack/libwordentropy/embedded for \"full\" and \"common\")", ErrInvalidParameter, name)
+	}
+	return data, nil
+}
+
+// Process-wide default generator used by Quick, loaded on first use
+type quick_generator struct {
+	once  sync.Once
+	g     *Generator
+	err   error
+	loads int32 // number of loads, for tests
+}
+
+var quick = &quick_generator{}
+
+// Load a built-in wordlist registered with RegisterBuiltin, usually by importing the
+// embedded package: the one named by Builtin in o, by default the full one used by Quick.
+// Wordlist, Format and Lazy in o are ignored: the registered list is always kept in memory
+// as is.
+func (g *Generator) LoadEmbeddedWords(o *WordListOptions) (err error) {
+	defer recover_internal(&err)
+	if o == nil {
+		o = &WordListOptions{}
+	}
+	data, err := builtin_wordlist(o.Builtin)
+	if err != nil {
+		return err
+	}
+	return g.load_binary(data, "", o)
+}
+
+// Generate n passphrases with default options from the built-in "full" wordlist, which the
+// embedded package registers. The wordlist is loaded by the first call and shared by all
+// later ones. Calls made before "full" is registered fail with ErrInvalidParameter without
+// loading anything, so a later call can still load it once it is; any other load error is
+// returned by every call.
+func Quick(n int) (_ []string, err error) {
+	defer recover_internal(&err)
+	if n < 1 {
+		return nil, fmt.Errorf("%w: count: %v", ErrInvalidParameter, n)
+	}
+	return quick.generate(n)
+}
+
+// Generate n passphrases with default options, loading the built-in wordlist first if q
+// has not yet
+func (q *quick_generator) generate(n int) ([]string, error) {
+	if _, err := builtin_wordlist("full"); err != nil {
+		return nil, err // not cached, so registering the list later still works
+	}
+	q.once.Do(func() {
+		atomic.AddInt32(&q.loads, 1)
+		g := &Generator{}
+		if q.err = g.LoadEmbeddedWords(nil); q.err == nil {
+			q.g = g
+		}
+	})
+	if q.err != nil {
+		return nil, q.err
+	}
+	return q.g.GeneratePassphrases(&GenerateOptions{Count: uint(n)})
+}
@@ -0,0 +1,168 @@
+package wordentropy
+
+import (
+	"errors"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// The embedded package imports this one, so the tests here cannot import it and register
+// its files themselves
+var embedded_wordlist = register_test_builtin("full", "embedded/part-of-speech.bin")
+
+var _ = register_test_builtin("common", "embedded/common.bin")
+
+func register_test_builtin(name, path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+	RegisterBuiltin(name, data)
+	return data
+}
+
+func TestQuick(t *testing.T) {
+	if loads := atomic.LoadInt32(&quick.loads); loads != 0 {
+		t.Fatalf("Default generator loaded before first use")
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := Quick(2)
+			if err == nil && len(p) != 2 {
+				t.Errorf("Expected 2 passphrases, got %v", len(p))
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Error from Quick: %v", err)
+		}
+	}
+	if _, err := Quick(1); err != nil {
+		t.Fatalf("Error from Quick: %v", err)
+	}
+	if loads := atomic.LoadInt32(&quick.loads); loads != 1 {
+		t.Fatalf("Expected the default generator to be loaded once, got %v", loads)
+	}
+	if _, err := Quick(0); err == nil {
+		t.Fatalf("Expected error for zero count")
+	}
+}
+
+func TestQuickNotRegistered(t *testing.T) {
+	builtin_wordlists.Lock()
+	delete(builtin_wordlists.m, "full")
+	builtin_wordlists.Unlock()
+	defer RegisterBuiltin("full", embedded_wordlist)
+
+	q := &quick_generator{}
+	if _, err := q.generate(1); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("Expected ErrInvalidParameter before registering, got %v", err)
+	}
+	RegisterBuiltin("full", embedded_wordlist)
+	if p, err := q.generate(2); err != nil || len(p) != 2 {
+		t.Fatalf("Expected 2 passphrases once registered, got %v (%v)", p, err)
+	}
+	if loads := atomic.LoadInt32(&q.loads); loads != 1 {
+		t.Fatalf("Expected the generator to be loaded once, got %v", loads)
+	}
+}
+
+func TestLoadEmbeddedWords(t *testing.T) {
+	g := &Generator{}
+	if err := g.LoadEmbeddedWords(&WordListOptions{Wordlist: "missing.txt", MinWordsPerType: 1}); err != nil {
+		t.Fatalf("Error loading embedded wordlist: %v", err)
+	}
+	if p, err := g.GeneratePassphrases(&GenerateOptions{Count: 2}); err != nil || len(p) != 2 {
+		t.Fatalf("Expected 2 passphrases, got %v (%v)", p, err)
+	}
+}
+
+func TestRegisterBuiltin(t *testing.T) {
+	RegisterBuiltin("fixture", convert_fixture(t, "testdata/pos.txt"))
+	g, fixture := &Generator{}, &Generator{}
+	if err := g.LoadWords(&WordListOptions{Builtin: "fixture"}); err != nil {
+		t.Fatalf("Error loading registered wordlist: %v", err)
+	}
+	if err := fixture.LoadWords(&WordListOptions{Wordlist: "testdata/pos.txt"}); err != nil {
+		t.Fatalf("Error loading testdata/pos.txt: %v", err)
+	}
+	if !reflect.DeepEqual(g.GetWordMap(), fixture.GetWordMap()) {
+		t.Errorf("Expected the words of testdata/pos.txt, got %v", g.Stats().Words)
+	}
+}
+
+func TestBuiltinCommon(t *testing.T) {
+	g := &Generator{}
+	if err := g.LoadWords(&WordListOptions{Builtin: "common"}); err != nil {
+		t.Fatalf("Error loading common wordlist: %v", err)
+	}
+	st := g.Stats()
+	expected := map[string]uint{
+		"snoun": 1669, "pnoun": 822, "verb": 1408, "adjective": 1139, "adverb": 247,
+		"preposition": 40, "pronoun": 19, "conjunction": 14, "sarticle": 19, "particle": 21, "interjection": 12,
+	}
+	if !reflect.DeepEqual(st.Words, expected) {
+		t.Fatalf("Expected %v, got %v", expected, st.Words)
+	}
+	for word_type, n := range expected {
+		if bits := st.EntropyPerWord[word_type]; math.Abs(bits-math.Log2(float64(n))) > 1e-9 {
+			t.Errorf("Expected %v bits per %v, got %v", math.Log2(float64(n)), word_type, bits)
+		}
+	}
+	if bits := st.EntropyPerWord["snoun"]; bits < 10 {
+		t.Errorf("Expected at least 10 bits per noun, got %v", bits)
+	}
+	easy := regexp.MustCompile(`^[a-z]{3,8}$`)
+	for word_type, words := range g.GetWordMap() {
+		for _, w := range words {
+			if !easy.MatchString(w) {
+				t.Errorf("%v %q is not 3 to 8 lowercase letters", word_type, w)
+			}
+		}
+	}
+}
+
+func TestBuiltinOptions(t *testing.T) {
+	full, common := &Generator{}, &Generator{}
+	if err := full.LoadEmbeddedWords(&WordListOptions{Builtin: "full"}); err != nil {
+		t.Fatalf("Error loading full wordlist: %v", err)
+	}
+	if err := common.LoadEmbeddedWords(&WordListOptions{Builtin: "common"}); err != nil {
+		t.Fatalf("Error loading common wordlist: %v", err)
+	}
+	if n, m := common.Stats().Words["snoun"], full.Stats().Words["snoun"]; n >= m {
+		t.Errorf("Expected fewer common nouns than full ones, got %v and %v", n, m)
+	}
+	g := &Generator{}
+	if err := g.LoadWords(&WordListOptions{Builtin: "full", Deferred: true}); err != nil {
+		t.Fatalf("Error deferring full wordlist: %v", err)
+	}
+	if !reflect.DeepEqual(g.Stats().Words, full.Stats().Words) {
+		t.Errorf("Expected the full wordlist, got %v", g.Stats().Words)
+	}
+
+	for _, o := range []WordListOptions{{Builtin: "tiny"}, {Builtin: "common", Wordlist: "testdata/pos.txt"}} {
+		if err := g.LoadWords(&o); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("Expected ErrInvalidParameter for %+v, got %v", o, err)
+		}
+	}
+	if err := g.LoadEmbeddedWords(&WordListOptions{Builtin: "tiny"}); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter, got %v", err)
+	}
+	if err := g.LoadWords(&WordListOptions{}); err != ErrWordlistRequired {
+		t.Errorf("Expected ErrWordlistRequired, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package wordentropy
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const (
+	max_tracked_ips_default = 10000
+)
+
+// Per-client token bucket
+type bucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+// Token-bucket rate limiter keyed by client address. Memory is bounded by evicting the
+// least recently seen client once max clients are tracked.
+type rate_limiter struct {
+	rate    float64 // tokens added per second
+	burst   float64 // bucket capacity
+	max     int
+	now     func() time.Time
+	buckets map[string]*list.Element
+	lru     *list.List // front is most recently seen
+	sync.Mutex
+}
+
+func new_rate_limiter(rate float64, burst uint, max int) *rate_limiter {
+	if burst == 0 {
+		burst = 1
+	}
+	if max <= 0 {
+		max = max_tracked_ips_default
+	}
+	return &rate_limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		max:     max,
+		now:     time.Now,
+		buckets: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Take a token for key, returning false if the client is over its limit
+func (l *rate_limiter) allow(key string) bool {
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.now()
+	var b *bucket
+	if e, ok := l.buckets[key]; ok {
+		l.lru.MoveToFront(e)
+		b = e.Value.(*bucket)
+		b.tokens += now.Sub(b.last).Seconds() * l.rate
+		if b.tokens > l.burst {
+			b.tokens = l.burst
+		}
+		b.last = now
+	} else {
+		b = &bucket{key: key, tokens: l.burst, last: now}
+		l.buckets[key] = l.lru.PushFront(b)
+		for l.lru.Len() > l.max {
+			oldest := l.lru.Back()
+			l.lru.Remove(oldest)
+			delete(l.buckets, oldest.Value.(*bucket).key)
+		}
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Number of clients currently tracked
+func (l *rate_limiter) len() int {
+	l.Lock()
+	defer l.Unlock()
+	return l.lru.Len()
+}
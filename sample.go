@@ -0,0 +1,55 @@
+package wordentropy
+
+import (
+	"fmt"
+)
+
+// Draw n distinct words of a type from the loaded word list, uniformly at random and in
+// random order. The whole list of the type is sampled, without the options' filters. Returns
+// ErrTooFewWords if the type has fewer than n words.
+func (g *Generator) SampleWords(word_type string, n uint) (_ []string, err error) {
+	defer recover_internal(&err)
+	if g.closed.Load() {
+		return nil, ErrClosed
+	}
+	d := g.words()
+	if !d.loaded() {
+		return nil, ErrWordlistNotLoaded
+	}
+	size, ok := d.count(word_type)
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownWordType, word_type)
+	}
+	if uint64(n) > uint64(size) {
+		return nil, fmt.Errorf("%w: %v has %v words, %v requested", ErrTooFewWords, word_type, size, n)
+	}
+	words := make([]string, 0, n)
+	for _, i := range sample_indices(g.source(&GenerateOptions{}), size, int(n)) {
+		if d.lazy != nil {
+			words = append(words, d.lazy.word(word_type, i))
+		} else {
+			words = append(words, d.word_map[word_type][i])
+		}
+	}
+	return words, nil
+}
+
+// n distinct indices of [0, size) in random order: the first n steps of a Fisher–Yates
+// shuffle of the indices, with the swapped positions kept in a map so large word lists need
+// no index slice
+func sample_indices(rng int_source, size int, n int) []int {
+	swapped := make(map[int]int, n)
+	at := func(i int) int {
+		if v, ok := swapped[i]; ok {
+			return v
+		}
+		return i
+	}
+	indices := make([]int, n)
+	for i := range indices {
+		j := i + int(rng.int_n(int64(size-i)))
+		indices[i] = at(j)
+		swapped[j] = at(i)
+	}
+	return indices
+}
@@ -0,0 +1,95 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// Generator with ten distinct singular nouns
+func sample_generator(t *testing.T) (*Generator, []string) {
+	m := uniform_word_map("otter")
+	m["snoun"] = nil
+	for i := 0; i < 10; i++ {
+		m["snoun"] = append(m["snoun"], fmt.Sprintf("noun%v", i))
+	}
+	g, err := NewGeneratorFromProvider(MapProvider(m))
+	if err != nil {
+		t.Fatalf("Could not load word map: %v", err)
+	}
+	return g, m["snoun"]
+}
+
+func TestSampleWords(t *testing.T) {
+	g, nouns := sample_generator(t)
+
+	words, err := g.SampleWords("snoun", 4)
+	if err != nil {
+		t.Fatalf("Error sampling words: %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, w := range words {
+		if seen[w] {
+			t.Errorf("Duplicate word %q in %v", w, words)
+		}
+		seen[w] = true
+	}
+	if len(words) != 4 {
+		t.Errorf("Expected 4 words, got %v", words)
+	}
+
+	words, _ = g.SampleWords("snoun", 10)
+	sort.Strings(words)
+	if fmt.Sprint(words) != fmt.Sprint(nouns) {
+		t.Errorf("Expected every word for n == pool size, got %v", words)
+	}
+
+	if words, err := g.SampleWords("snoun", 0); err != nil || len(words) != 0 {
+		t.Errorf("Expected no words for n == 0, got %v, %v", words, err)
+	}
+	if _, err := g.SampleWords("snoun", 11); !errors.Is(err, ErrTooFewWords) {
+		t.Errorf("Expected ErrTooFewWords, got %v", err)
+	}
+	if _, err := g.SampleWords("noun", 1); !errors.Is(err, ErrUnknownWordType) {
+		t.Errorf("Expected ErrUnknownWordType, got %v", err)
+	}
+
+	lazy := &Generator{}
+	if err := lazy.LoadEmbeddedWords(nil); err != nil {
+		t.Fatalf("Could not load embedded wordlist: %v", err)
+	}
+	words, err = lazy.SampleWords("snoun", 500)
+	seen = make(map[string]bool)
+	for _, w := range words {
+		seen[w] = true
+	}
+	if err != nil || len(seen) != 500 {
+		t.Errorf("Expected 500 distinct words from the embedded wordlist, got %v (%v)", len(seen), err)
+	}
+	if _, err := (&Generator{}).SampleWords("snoun", 1); !errors.Is(err, ErrWordlistNotLoaded) {
+		t.Errorf("Expected ErrWordlistNotLoaded, got %v", err)
+	}
+}
+
+// Every word is equally likely to be drawn first
+func TestSampleWordsUniform(t *testing.T) {
+	g, words := sample_generator(t)
+	const runs = 20000
+	first := make(map[string]int)
+	for i := 0; i < runs; i++ {
+		sample, err := g.SampleWords("snoun", 3)
+		if err != nil {
+			t.Fatalf("Error sampling words: %v", err)
+		}
+		first[sample[0]]++
+	}
+	// Generous bounds: a fair draw falls outside ±30% of the expected count with negligible
+	// probability at these sizes
+	expected := float64(runs) / float64(len(words))
+	for _, w := range words {
+		if c := float64(first[w]); c < 0.7*expected || c > 1.3*expected {
+			t.Errorf("%q drawn first %v times, expected about %.0f", w, c, expected)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Returned by LoadWords when the wordlist and the offensive list are the same file
+var ErrSameFile = errors.New("Wordlist and offensive list are the same file")
+
+// Word lists with fewer words than this in all are likely a misconfiguration (e.g. the
+// offensive list passed as the wordlist) and are loaded with a warning
+const sane_word_count = 100
+
+// Check that the wordlist and offensive list paths do not name the same file, following
+// symbolic links. Paths that cannot be checked are left for loading to report.
+func check_paths(o *WordListOptions) error {
+	if o.Wordlist == "" || o.Offensive == "" || !same_file(o.Wordlist, o.Offensive) {
+		return nil
+	}
+	return fmt.Errorf("%w: %v and %v (swapped or repeated options?)", ErrSameFile, o.Wordlist, o.Offensive)
+}
+
+// Warnings about a word list that loaded but looks wrong: too few words for a useful
+// keyspace, or mostly offensive words, as when the wordlist and offensive list are swapped
+func sanity_warnings(d *word_data) []string {
+	total := 0
+	for _, t := range word_types {
+		n, _ := d.count(t)
+		total += n
+	}
+	var warnings []string
+	if total < sane_word_count {
+		warnings = append(warnings, fmt.Sprintf("wordlist has only %v words", total))
+	}
+	// Only a word list at most twice the size of the offensive list can be mostly offensive
+	if d.offensive != nil && total <= 2*len(d.offensive) {
+		offensive := 0
+		for _, words := range d.all() {
+			for _, w := range words {
+				if offensive_level(w, d.offensive) > 0 {
+					offensive++
+				}
+			}
+		}
+		if offensive*2 > total {
+			warnings = append(warnings, fmt.Sprintf("%v of %v wordlist words are in the offensive list (wordlist and offensive list swapped?)", offensive, total))
+		}
+	}
+	for _, w := range warnings {
+		log.Printf("WARNING: %v\n", w)
+	}
+	return warnings
+}
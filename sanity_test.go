@@ -0,0 +1,69 @@
+package wordentropy
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSameFile(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "pos.txt")
+	data, err := os.ReadFile("testdata/pos.txt")
+	if err != nil {
+		t.Fatalf("Could not read fixture: %v", err)
+	}
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		t.Fatalf("Could not write wordlist: %v", err)
+	}
+	link := filepath.Join(dir, "offensive.txt")
+	if err := os.Symlink(p, link); err != nil {
+		t.Skipf("Cannot create symbolic links: %v", err)
+	}
+
+	for _, offensive := range []string{p, link, filepath.Join(dir, ".", "pos.txt")} {
+		for _, deferred := range []bool{false, true} {
+			_, err := LoadGenerator(&WordListOptions{Wordlist: p, Offensive: offensive, Deferred: deferred})
+			if !errors.Is(err, ErrSameFile) {
+				t.Errorf("%v (deferred: %v): expected ErrSameFile, got %v", offensive, deferred, err)
+			}
+		}
+	}
+	if _, err := LoadGenerator(&WordListOptions{Wordlist: link, Offensive: "testdata/offensive.txt"}); err != nil {
+		t.Errorf("Expected a symbolic link to a wordlist to load, got %v", err)
+	}
+}
+
+func TestSanityWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	// An offensive list tagged as a wordlist, loaded as one by mistake
+	g, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/swapped.txt", Offensive: "testdata/offensive_levels.txt"})
+	if err != nil {
+		t.Fatalf("Expected the wordlist to load: %v", err)
+	}
+	warnings := g.Stats().LoadWarnings
+	if len(warnings) != 2 || warnings[0] != "wordlist has only 5 words" || !strings.HasPrefix(warnings[1], "4 of 5 wordlist words are in the offensive list") {
+		t.Fatalf("Expected warnings about a small, swapped wordlist, got %q", warnings)
+	}
+	for _, w := range warnings {
+		if !strings.Contains(buf.String(), "WARNING: "+w) {
+			t.Errorf("Expected %q in log output:\n%v", w, buf.String())
+		}
+	}
+
+	g = load_test_generator(t)
+	if warnings := g.Stats().LoadWarnings; len(warnings) != 1 || !strings.Contains(warnings[0], "only") {
+		t.Errorf("Expected a warning about a small wordlist only, got %q", warnings)
+	}
+	g = load_full_generator(t)
+	if warnings := g.Stats().LoadWarnings; warnings != nil {
+		t.Errorf("Expected no warnings for the full wordlist, got %q", warnings)
+	}
+}
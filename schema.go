@@ -0,0 +1,17 @@
+package wordentropy
+
+import (
+	_ "embed"
+)
+
+// JSON Schema for Response and ErrorResponse, generated from the struct definitions by
+// TestResponseSchema (go test -run TestResponseSchema -update_schema)
+//
+//go:embed response.schema.json
+var response_schema []byte
+
+// Get the JSON Schema document describing the bodies returned by the HTTP handler (a
+// Response or an ErrorResponse)
+func ResponseSchema() []byte {
+	return append([]byte{}, response_schema...)
+}
@@ -0,0 +1,215 @@
+package wordentropy
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var update_schema = flag.Bool("update_schema", false, "rewrite response.schema.json from the struct definitions")
+
+// JSON Schema for a Go type, following encoding/json struct tags
+func type_schema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": type_schema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" || !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			properties[name] = type_schema(f.Type)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	}
+	panic(fmt.Sprintf("no schema for %v", t))
+}
+
+func generate_response_schema() []byte {
+	defs := map[string]interface{}{}
+	refs := []interface{}{}
+	for _, v := range []interface{}{Response{}, ErrorResponse{}} {
+		t := reflect.TypeOf(v)
+		defs[t.Name()] = type_schema(t)
+		refs = append(refs, map[string]interface{}{"$ref": "#/$defs/" + t.Name()})
+	}
+	doc := map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "wordentropy HTTP response",
+		"oneOf":   refs,
+		"$defs":   defs,
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return append(b, '\n')
+}
+
+// Minimal JSON Schema validator covering the keywords generate_response_schema uses
+func validate_schema(root map[string]interface{}, schema map[string]interface{}, v interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/$defs/")
+		def, ok := root["$defs"].(map[string]interface{})[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%v: unresolved $ref %v", path, ref)
+		}
+		return validate_schema(root, def, v, path)
+	}
+	if one_of, ok := schema["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, s := range one_of {
+			if validate_schema(root, s.(map[string]interface{}), v, path) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fmt.Errorf("%v: matches %v of the oneOf schemas", path, matches)
+		}
+		return nil
+	}
+
+	switch schema["type"] {
+	case "string":
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("%v: expected string, got %T", path, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%v: expected boolean, got %T", path, v)
+		}
+	case "integer", "number":
+		n, ok := v.(float64)
+		if !ok || (schema["type"] == "integer" && n != float64(int64(n))) {
+			return fmt.Errorf("%v: expected %v, got %v", path, schema["type"], v)
+		}
+	case "array":
+		a, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%v: expected array, got %T", path, v)
+		}
+		for i, e := range a {
+			if err := validate_schema(root, schema["items"].(map[string]interface{}), e, fmt.Sprintf("%v[%v]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		o, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%v: expected object, got %T", path, v)
+		}
+		properties := schema["properties"].(map[string]interface{})
+		for _, r := range schema["required"].([]interface{}) {
+			if _, ok := o[r.(string)]; !ok {
+				return fmt.Errorf("%v: missing required property %v", path, r)
+			}
+		}
+		keys := make([]string, 0, len(o))
+		for k := range o {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p, ok := properties[k]
+			if !ok {
+				if schema["additionalProperties"] == false {
+					return fmt.Errorf("%v: unexpected property %v", path, k)
+				}
+				continue
+			}
+			if err := validate_schema(root, p.(map[string]interface{}), o[k], path+"."+k); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%v: unsupported schema %v", path, schema)
+	}
+	return nil
+}
+
+func validate_response(root map[string]interface{}, body []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return err
+	}
+	return validate_schema(root, root, v, "$")
+}
+
+func TestResponseSchema(t *testing.T) {
+	generated := generate_response_schema()
+	if *update_schema {
+		if err := os.WriteFile("response.schema.json", generated, 0644); err != nil {
+			t.Fatal(err)
+		}
+		response_schema = generated
+	}
+	if !bytes.Equal(ResponseSchema(), generated) {
+		t.Fatalf("response.schema.json is out of date with the struct definitions; run go test -run TestResponseSchema -update_schema")
+	}
+	var root map[string]interface{}
+	if err := json.Unmarshal(ResponseSchema(), &root); err != nil {
+		t.Fatalf("Invalid schema: %v", err)
+	}
+
+	g := load_test_generator(t)
+	handlers := []http.Handler{
+		NewHandler(g, nil),
+		NewHandler(g, &HandlerOptions{PadResponses: true, MaxWork: 20}),
+	}
+	for _, h := range handlers {
+		for _, target := range []string{"/?count=3&length=6", "/?count=1&add_digit=true&add_symbol=true", "/?count=abc", "/?count=10&length=10"} {
+			rec := serve(h, target)
+			if err := validate_response(root, rec.Body.Bytes()); err != nil {
+				t.Errorf("%v (%v): response does not validate: %v\n%v", target, rec.Code, err, rec.Body.String())
+			}
+		}
+	}
+
+	for _, bad := range []string{
+		`{}`,
+		`{"passphrases": "otter"}`,
+		`{"passphrases": [], "extra": 1}`,
+		`{"error": "x"}`,
+		`{"passphrases": [], "error": "x", "code": 1}`,
+	} {
+		if err := validate_response(root, []byte(bad)); err == nil {
+			t.Errorf("Expected %v not to validate", bad)
+		}
+	}
+	// Partial response (status 207)
+	partial := `{"passphrases": ["otter runs"], "error": "passphrase 1: x", "code": "ErrRetriesExhausted"}`
+	if err := validate_response(root, []byte(partial)); err != nil {
+		t.Errorf("Partial response does not validate: %v", err)
+	}
+}
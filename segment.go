@@ -0,0 +1,56 @@
+package wordentropy
+
+import (
+	"strings"
+)
+
+// Lowercased words of a word list, with multiword entries split, for segmenting
+// concatenated passphrases
+type word_set struct {
+	words   map[string]bool
+	longest int // bytes in the longest word
+}
+
+func new_word_set(word_map map[string][]string) *word_set {
+	ws := &word_set{words: make(map[string]bool)}
+	for _, words := range word_map {
+		for _, entry := range words {
+			for _, w := range strings.Fields(strings.ToLower(entry)) {
+				ws.words[w] = true
+				ws.longest = max(ws.longest, len(w))
+			}
+		}
+	}
+	return ws
+}
+
+func (d *word_data) word_set() *word_set {
+	return d.index("wordset", func(d *word_data) interface{} {
+		return new_word_set(d.all())
+	}).(*word_set)
+}
+
+// Number of ways to split s into words of the set, counting no further than limit.
+// ways[i] is the number of splits of s[:i].
+func (ws *word_set) segmentations(s string, limit int) int {
+	ways := make([]int, len(s)+1)
+	ways[0] = 1
+	for i := 1; i <= len(s); i++ {
+		for j := max(0, i-ws.longest); j < i; j++ {
+			if ways[j] > 0 && ws.words[s[j:i]] {
+				ways[i] = min(ways[i]+ways[j], limit)
+			}
+		}
+	}
+	return ways[len(s)]
+}
+
+// Whether UnambiguousConcat rejects p: its words are joined without a separator or
+// capitals marking the boundaries, and the joined words split into words of the word list
+// in more than one way
+func (s *gen_state) ambiguous(p Passphrase) bool {
+	if !s.o.UnambiguousConcat || separator(s.o) != "" || s.o.Capitalize == "words" {
+		return false
+	}
+	return s.d.word_set().segmentations(strings.ToLower(strings.Join(p.Words, "")), 2) > 1
+}
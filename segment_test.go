@@ -0,0 +1,83 @@
+package wordentropy
+
+import (
+	"testing"
+)
+
+func TestSegmentations(t *testing.T) {
+	ws := new_word_set(map[string][]string{
+		"snoun":    {"a", "Mend", "amend", "end", "ends"},
+		"verb":     {"send", "amends"},
+		"sarticle": {"the other"},
+	})
+	for _, c := range []struct {
+		s     string
+		limit int
+		want  int
+	}{
+		{"sendmend", 5, 1},
+		{"amendsend", 5, 3}, // amend send, a mend send, amends end
+		{"amendsend", 2, 2},
+		{"amendsends", 5, 1}, // amends ends
+		{"mendothersend", 5, 1},
+		{"xyz", 5, 0},
+		{"", 5, 1},
+	} {
+		if got := ws.segmentations(c.s, c.limit); got != c.want {
+			t.Errorf("%q (limit %v): expected %v segmentations, got %v", c.s, c.limit, c.want, got)
+		}
+	}
+}
+
+func TestUnambiguousConcat(t *testing.T) {
+	m := uniform_word_map("otter")
+	m["snoun"] = []string{"a", "amend", "mend"}
+	m["verb"] = []string{"sends", "ends"}
+	g := generator_for(m)
+	o := GenerateOptions{Count: 99, Length: 2, AllowedTypes: []string{"snoun", "verb"}, No_spaces: true}
+
+	ambiguous := func(phrase string) bool {
+		return g.words().word_set().segmentations(phrase, 2) > 1
+	}
+	found := false
+	for i := 0; i < 5 && !found; i++ {
+		p, err := g.GeneratePassphrases(&o)
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		for _, phrase := range p {
+			found = found || ambiguous(phrase)
+		}
+	}
+	if !found {
+		t.Fatalf("Expected ambiguous passphrases without UnambiguousConcat")
+	}
+
+	o.UnambiguousConcat = true
+	for i := 0; i < 5; i++ {
+		p, err := g.GeneratePassphrases(&o)
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		for _, phrase := range p {
+			if ambiguous(phrase) {
+				t.Fatalf("Ambiguous passphrase %q with UnambiguousConcat", phrase)
+			}
+		}
+	}
+
+	// Capitals mark the boundaries
+	o.Capitalize = "words"
+	all, err := g.EnumerateAll(&o, 100)
+	if err != nil {
+		t.Fatalf("Error enumerating passphrases: %v", err)
+	}
+	o.Capitalize = ""
+	unambiguous, err := g.EnumerateAll(&o, 100)
+	if err != nil {
+		t.Fatalf("Error enumerating passphrases: %v", err)
+	}
+	if len(unambiguous) >= len(all) {
+		t.Errorf("Expected fewer passphrases without capitals: %v, %v", unambiguous, all)
+	}
+}
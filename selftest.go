@@ -0,0 +1,222 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Wrapped by every failure reported by SelfTest
+var ErrSelfTestFailed = errors.New("Self-test failed")
+
+const selftest_samples = 2000
+
+// Run a quick battery of checks on passphrases generated with the options, e.g. after
+// loading a custom word list: no empty or parenthesized words, the requested number of
+// words, no offensive words when Prudish, every reachable word type and grammar transition
+// observed, and a roughly uniform digit and symbol padding. A few thousand passphrases are
+// generated; they are not counted in Counters. Count, Timeout, BestOf and Scorer in the
+// options are ignored.
+//
+// Returns nil if all checks pass, otherwise an error joining one error per failed check,
+// each wrapping ErrSelfTestFailed. Invalid options are returned as is.
+func (g *Generator) SelfTest(o *GenerateOptions) (err error) {
+	defer recover_internal(&err)
+	var sample GenerateOptions
+	if o != nil {
+		sample = *o
+	}
+	sample.Count, sample.Timeout, sample.BestOf, sample.Scorer = 1, 0, 1, nil
+	s, err := g.prepare(&sample, g.words(), nil)
+	if err != nil {
+		return err
+	}
+
+	var failures []error
+	fail := func(format string, a ...interface{}) {
+		failures = append(failures, fmt.Errorf("%w: %v", ErrSelfTestFailed, fmt.Sprintf(format, a...)))
+	}
+	filter := s.d.filter(s.o)
+	seen := make(map[string]bool)
+	transitions := make(map[[2]string]bool)
+	digits := make(map[string]uint)
+	symbols := make(map[string]uint)
+	var parens, short, offensive uint
+	for i := 0; i < selftest_samples; i++ {
+		p, err := g.generate_one(s)
+		if err != nil {
+			return err
+		}
+		if uint(len(p.Words)) != s.o.Length {
+			short++
+		}
+		for _, w := range p.Words {
+			if strings.ContainsAny(w, "()") {
+				parens++
+			}
+			if filter.level > 0 && s.d.offends(w, filter) {
+				offensive++
+			}
+		}
+		observe_transitions(p, len(s.joints) > 0, seen, transitions)
+		if s.o.Add_digit {
+			digits[p.Digit]++
+		}
+		if s.o.Add_symbol {
+			symbols[p.Symbol]++
+		}
+	}
+
+	// Empty entries are dropped from the passphrase, so are only seen in the warnings
+	for _, w := range s.warnings {
+		if w.Type == WarnEmptyWord {
+			fail("%v empty %v words", w.Count, w.WordType)
+		}
+	}
+	if parens > 0 {
+		fail("%v words containing parentheses", parens)
+	}
+	if short > 0 {
+		fail("%v of %v passphrases do not have %v words", short, selftest_samples, s.o.Length)
+	}
+	if offensive > 0 {
+		fail("%v offensive words with Prudish", offensive)
+	}
+	types, pairs := s.expected_transitions()
+	missing := []string{}
+	for _, t := range types {
+		if !seen[t] {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) > 0 {
+		fail("word types never generated: %v", strings.Join(missing, ", "))
+	}
+	missing = []string{}
+	for _, pair := range pairs {
+		if !transitions[pair] {
+			missing = append(missing, pair[0]+" -> "+pair[1])
+		}
+	}
+	if len(missing) > 0 {
+		fail("grammar transitions never generated: %v", strings.Join(missing, ", "))
+	}
+	if s.o.Add_digit {
+		if skewed := skewed_padding(s.o.Digits, digits); skewed != nil {
+			fail("digit frequencies far from uniform: %v", strings.Join(skewed, ", "))
+		}
+	}
+	if s.o.Add_symbol {
+		if skewed := skewed_padding(s.o.Symbols, symbols); skewed != nil {
+			fail("symbol frequencies far from uniform: %v", strings.Join(skewed, ", "))
+		}
+	}
+	return errors.Join(failures...)
+}
+
+// Record the word types of p's entries and the transitions between entries within its
+// fragments. With joints, the transition out of the word opening each later fragment is
+// skipped, as the fragment's first word follows it without a grammar rule.
+func observe_transitions(p Passphrase, joints bool, seen map[string]bool, transitions map[[2]string]bool) {
+	fragment := make([]int, len(p.Words))
+	for i, span := range p.FragmentSpans {
+		for j := span[0]; j < span[1]; j++ {
+			fragment[j] = i
+		}
+	}
+	prev := -1 // word index of the previous entry
+	for start, n := 0, 0; n < len(p.Entries); start, n = start+p.Entries[n], n+1 {
+		seen[p.Types[start]] = true
+		if prev >= 0 && fragment[prev] == fragment[start] {
+			f := fragment[start]
+			if !(joints && f > 0 && prev == p.FragmentSpans[f][0]) {
+				transitions[[2]string{p.Types[prev], p.Types[start]}] = true
+			}
+		}
+		prev = start
+	}
+}
+
+// Word types that can appear in passphrases for the call and the grammar transitions
+// between them that can occur within a fragment, in word_types order. Types without usable
+// words are left out.
+func (s *gen_state) expected_transitions() ([]string, [][2]string) {
+	usable := func(t string) bool {
+		_, _, constraint := s.pool(t, false)
+		return constraint == ""
+	}
+	f := s.o.Fragments
+	positions := int(min(f.TargetFragmentWords+f.Jitter, s.o.Length))
+	reached := make(map[string]bool)
+	can_lead := make(map[string]bool) // reached before the last position of a fragment
+	level := []string{}
+	for _, t := range s.start {
+		if usable(t) {
+			level = append(level, t)
+		}
+	}
+	for i := 0; i < positions && len(level) > 0; i++ {
+		next := make(map[string]bool)
+		for _, t := range level {
+			reached[t] = true
+			if i == positions-1 {
+				continue
+			}
+			can_lead[t] = true
+			for _, f := range s.rules[t] {
+				if usable(f) && !(s.o.NoAdjacentSameType && f == t) {
+					next[f] = true
+				}
+			}
+		}
+		level = level[:0]
+		for _, t := range word_types {
+			if next[t] {
+				level = append(level, t)
+			}
+		}
+	}
+	if s.o.Length > f.TargetFragmentWords-f.Jitter+1 { // room for a joining word and a word after it
+		for _, t := range s.joints {
+			if usable(t) {
+				reached[t] = true
+			}
+		}
+	}
+
+	types := []string{}
+	pairs := [][2]string{}
+	for _, t := range word_types {
+		if reached[t] {
+			types = append(types, t)
+		}
+		if !can_lead[t] {
+			continue
+		}
+		for _, f := range s.rules[t] {
+			if usable(f) && !(s.o.NoAdjacentSameType && f == t) {
+				pairs = append(pairs, [2]string{t, f})
+			}
+		}
+	}
+	return types, pairs
+}
+
+// Padding values drawn less than half or more than one and a half times as often as
+// expected, with their counts. Not checked when too few draws are expected per value for
+// the bounds to be meaningful.
+func skewed_padding(values []string, counts map[string]uint) []string {
+	expected := float64(selftest_samples) / float64(len(values))
+	if expected < 50 {
+		return nil
+	}
+	var skewed []string
+	for _, v := range values {
+		if n := float64(counts[v]); n < expected/2 || n > expected*3/2 {
+			skewed = append(skewed, fmt.Sprintf("%q (%v)", v, counts[v]))
+		}
+	}
+	sort.Strings(skewed)
+	return skewed
+}
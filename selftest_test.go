@@ -0,0 +1,36 @@
+package wordentropy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	g := load_test_generator(t)
+	for _, o := range []*GenerateOptions{
+		nil,
+		{Prudish: true, Add_digit: true, Add_symbol: true, Length: 8},
+		{NoAdjacentSameType: true, JointTypes: map[string]uint{"conjunction": 1, "preposition": 1}},
+	} {
+		if err := g.SelfTest(o); err != nil {
+			t.Errorf("Unexpected self-test failure with %+v: %v", o, err)
+		}
+	}
+
+	word_map := uniform_word_map("otter")
+	word_map["snoun"] = []string{"", "(otter)"}
+	err := generator_for(word_map).SelfTest(nil)
+	if !errors.Is(err, ErrSelfTestFailed) {
+		t.Fatalf("Expected ErrSelfTestFailed, got %v", err)
+	}
+	for _, s := range []string{"empty snoun words", "words containing parentheses"} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("Expected %q in %q", s, err)
+		}
+	}
+
+	if err := g.SelfTest(&GenerateOptions{Capitalize: "shout"}); !errors.Is(err, ErrUnknownCapitalize) {
+		t.Errorf("Expected ErrUnknownCapitalize, got %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+//go:build soak
+
+// Soak test for long-running services: generates continuously with varied options from
+// several goroutines, checking every passphrase and failing if the heap keeps growing.
+// Run with e.g. go test -tags soak -run Soak -timeout 0 -soak_duration 1h
+
+package wordentropy
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode"
+)
+
+var (
+	soak_duration   = flag.Duration("soak_duration", time.Minute, "how long to generate for")
+	soak_iterations = flag.Uint64("soak_iterations", 0, "stop after this many calls per goroutine (0 = run for -soak_duration)")
+	soak_goroutines = flag.Int("soak_goroutines", runtime.GOMAXPROCS(0), "goroutines generating at once")
+	soak_max_growth = flag.Int64("soak_max_growth", 64<<20, "bytes the live heap may grow by after warming up")
+	soak_reload     = flag.Duration("soak_reload", 10*time.Second, "reload the word list this often (0 = never)")
+	soak_budget     = flag.Int64("soak_budget", 64<<20, "derived index budget (0 = unlimited, when the pool cache alone may hold hundreds of MiB)")
+)
+
+// Live heap bytes after a forced collection
+func live_heap() int64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return int64(m.HeapAlloc)
+}
+
+// Random options, covering those that derive pools and indexes from the word list, with
+// word lengths and denied words drawn from a wide range so that the caches keep churning
+func soak_options() GenerateOptions {
+	n := func(max int64) uint { return uint(test_rng.int_n(max)) }
+	o := GenerateOptions{Count: n(10) + 1, Length: n(12) + 1}
+	switch n(8) {
+	case 0:
+		o.Prudish_level = n(4) + 1
+	case 1:
+		o.MinWordLength, o.MaxWordLength = n(5), n(10)+6
+	case 2:
+		o.ShortWordBias = float64(n(10)+1) / 10
+	case 3:
+		o.Agreement = true
+	case 4:
+		o.ExtraDenyWords = []string{fmt.Sprintf("word%v", n(100000)), "otter"}
+	case 5:
+		o.VaryJoints, o.Fragments.TargetFragmentWords = true, n(3)+1
+	case 6:
+		o.BestOf, o.Add_digit, o.Add_symbol, o.Symbols = n(3)+1, true, true, []string{"!", "#"}
+	}
+	o.Prudish = n(2) == 1
+	return o
+}
+
+// The problems with a call's passphrases, if any
+func soak_check(d *word_data, o *GenerateOptions, p []Passphrase) []string {
+	var problems []string
+	if uint(len(p)) != o.Count {
+		problems = append(problems, fmt.Sprintf("expected %v passphrases, got %v", o.Count, len(p)))
+	}
+	filter := d.filter(o)
+	for _, pp := range p {
+		bad := func(format string, a ...interface{}) {
+			problems = append(problems, fmt.Sprintf("%q: ", pp.Phrase)+fmt.Sprintf(format, a...))
+		}
+		if uint(len(pp.Words)) != o.Length {
+			bad("expected %v words, got %v", o.Length, len(pp.Words))
+		}
+		if len(pp.Types) != len(pp.Words) {
+			bad("%v word types for %v words", len(pp.Types), len(pp.Words))
+		}
+		entries := 0
+		for _, n := range pp.Entries {
+			entries += n
+		}
+		if entries != len(pp.Words) {
+			bad("entries add up to %v words, got %v", entries, len(pp.Words))
+		}
+		for _, w := range pp.Words {
+			if strings.TrimSpace(w) == "" {
+				bad("empty word")
+			}
+			if filter.level > 0 && d.offends(w, filter) {
+				bad("offensive word %q", w)
+			}
+			for _, denied := range o.ExtraDenyWords {
+				if strings.EqualFold(w, denied) {
+					bad("denied word %q", w)
+				}
+			}
+		}
+		if strings.IndexFunc(pp.Phrase, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0 {
+			bad("unprintable character")
+		}
+	}
+	return problems
+}
+
+func TestSoak(t *testing.T) {
+	g := load_full_generator(t)
+	g.SetDerivedIndexBudget(*soak_budget)
+	reload := func() error {
+		return g.LoadBinaryWords(embedded_wordlist, &WordListOptions{Offensive: "testdata/offensive.txt"})
+	}
+
+	var (
+		mu       sync.Mutex
+		problems []string
+		calls    atomic.Uint64
+	)
+	fail := func(p ...string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(problems) < 20 {
+			problems = append(problems, p...)
+		}
+	}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < *soak_goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := uint64(0); *soak_iterations == 0 || n < *soak_iterations; n++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				o := soak_options()
+				d := g.words()
+				p, _, err := g.GeneratePassphrasesDetailed(&o)
+				if err != nil {
+					fail(fmt.Sprintf("%+v: %v", o, err))
+					continue
+				}
+				resolved, err := g.ResolveOptions(&o)
+				if err != nil {
+					fail(fmt.Sprintf("%+v: %v", o, err))
+					continue
+				}
+				if p := soak_check(d, &resolved, p); len(p) > 0 {
+					fail(p...)
+				}
+				calls.Add(1)
+			}
+		}()
+	}
+
+	// Heap sampling, from the end of a warm-up tenth of the run in which the caches fill
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	start := time.Now()
+	var baseline, peak int64
+	sample := time.NewTicker(max(*soak_duration/20, time.Second))
+	defer sample.Stop()
+	var reloads <-chan time.Time
+	if *soak_reload > 0 {
+		r := time.NewTicker(*soak_reload)
+		defer r.Stop()
+		reloads = r.C
+	}
+	deadline := time.After(*soak_duration)
+loop:
+	for {
+		select {
+		case <-done:
+			break loop
+		case <-deadline:
+			if *soak_iterations == 0 {
+				close(stop)
+				<-done
+				break loop
+			}
+		case <-reloads:
+			if err := reload(); err != nil {
+				fail(fmt.Sprintf("reload: %v", err))
+			}
+		case <-sample.C:
+			heap := live_heap()
+			if baseline == 0 && time.Since(start) >= *soak_duration/10 {
+				baseline = heap
+			}
+			peak = max(peak, heap)
+			t.Logf("%v: %v calls, live heap %v MiB", time.Since(start).Round(time.Second), calls.Load(), heap>>20)
+		}
+	}
+
+	for _, p := range problems {
+		t.Error(p)
+	}
+	if calls.Load() == 0 {
+		t.Fatalf("No passphrases generated")
+	}
+	heap := live_heap()
+	runtime.KeepAlive(g)
+	if baseline == 0 {
+		t.Logf("Run too short to measure heap growth")
+		return
+	}
+	t.Logf("%v calls; live heap %v MiB after warming up, %v MiB peak, %v MiB at the end, %v MiB in derived indexes", calls.Load(), baseline>>20, peak>>20, heap>>20, g.Stats().DerivedIndexBytes>>20)
+	if heap-baseline > *soak_max_growth {
+		t.Errorf("Live heap grew by %v bytes, more than %v", heap-baseline, *soak_max_growth)
+	}
+}
@@ -0,0 +1,79 @@
+package wordentropy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var nato_letters = map[rune]string{
+	'a': "Alfa", 'b': "Bravo", 'c': "Charlie", 'd': "Delta", 'e': "Echo", 'f': "Foxtrot",
+	'g': "Golf", 'h': "Hotel", 'i': "India", 'j': "Juliett", 'k': "Kilo", 'l': "Lima",
+	'm': "Mike", 'n': "November", 'o': "Oscar", 'p': "Papa", 'q': "Quebec", 'r': "Romeo",
+	's': "Sierra", 't': "Tango", 'u': "Uniform", 'v': "Victor", 'w': "Whiskey", 'x': "X-ray",
+	'y': "Yankee", 'z': "Zulu",
+}
+
+var spoken_characters = map[rune]string{
+	'0': "Zero", '1': "One", '2': "Two", '3': "Three", '4': "Four",
+	'5': "Five", '6': "Six", '7': "Seven", '8': "Eight", '9': "Nine",
+	' ': "space", '!': "exclamation mark", '@': "at sign", '#': "hash", '$': "dollar sign",
+	'%': "percent sign", '^': "caret", '&': "ampersand", '*': "asterisk", '(': "open parenthesis",
+	')': "close parenthesis", '-': "dash", '+': "plus sign", '_': "underscore", '=': "equals sign",
+	'.': "period", ',': "comma", '/': "slash", '~': "tilde", '\'': "apostrophe", ':': "colon",
+	';': "semicolon", '?': "question mark",
+}
+
+// Render a passphrase for reading aloud, one item per comma-separated token. Lowercase
+// words are read as words, with "capital" in front if only the first letter is uppercase.
+// Other words are spelled in the NATO alphabet ("capital Bravo" for uppercase letters),
+// and every other character, including separators, is named one by one:
+//
+//	Brave-otter7! => capital brave, dash, otter, Seven, exclamation mark
+func Spellout(phrase string) string {
+	tokens := []string{}
+	for len(phrase) > 0 {
+		end := strings.IndexFunc(phrase, func(r rune) bool { return !unicode.IsLetter(r) })
+		if end == 0 {
+			r, n := utf8.DecodeRuneInString(phrase)
+			tokens = append(tokens, spell_character(r))
+			phrase = phrase[n:]
+			continue
+		}
+		if end < 0 {
+			end = len(phrase)
+		}
+		tokens = append(tokens, spell_word(phrase[:end])...)
+		phrase = phrase[end:]
+	}
+	return strings.Join(tokens, ", ")
+}
+
+func spell_word(w string) []string {
+	first, n := utf8.DecodeRuneInString(w)
+	if rest := w[n:]; rest != "" && strings.ToLower(rest) == rest {
+		if unicode.IsUpper(first) {
+			return []string{"capital " + strings.ToLower(w)}
+		}
+		return []string{w}
+	}
+	tokens := []string{}
+	for _, r := range w {
+		tokens = append(tokens, spell_character(r))
+	}
+	return tokens
+}
+
+func spell_character(r rune) string {
+	if name, ok := nato_letters[unicode.ToLower(r)]; ok {
+		if unicode.IsUpper(r) {
+			return "capital " + name
+		}
+		return name
+	}
+	if name, ok := spoken_characters[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("%q", r)
+}
@@ -0,0 +1,27 @@
+package wordentropy
+
+import (
+	"testing"
+)
+
+func TestSpellout(t *testing.T) {
+	cases := []struct {
+		phrase   string
+		expected string
+	}{
+		{"brave otter sings", "brave, space, otter, space, sings"},
+		{"Brave-otter7!", "capital brave, dash, otter, Seven, exclamation mark"},
+		{"BraveOtter", "capital Bravo, Romeo, Alfa, Victor, Echo, capital Oscar, Tango, Tango, Echo, Romeo"},
+		{"a.b", "Alfa, period, Bravo"},
+		{"otter~~heron", "otter, tilde, tilde, heron"},
+		{"Éclair", "capital éclair"},
+		{"ottersings90#", "ottersings, Nine, Zero, hash"},
+		{"otter§", "otter, '§'"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		if s := Spellout(c.phrase); s != c.expected {
+			t.Errorf("%q: expected %q, got %q", c.phrase, c.expected, s)
+		}
+	}
+}
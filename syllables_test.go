@@ -0,0 +1,87 @@
+package wordentropy
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testdata/syllables.txt lists words with their syllable counts from a dictionary
+func TestSyllables(t *testing.T) {
+	f, err := os.Open("testdata/syllables.txt")
+	if err != nil {
+		t.Fatalf("Could not open fixture: %v", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		word, count, _ := strings.Cut(scanner.Text(), "\t")
+		expected, err := strconv.Atoi(count)
+		if err != nil {
+			t.Fatalf("Bad fixture line %q", scanner.Text())
+		}
+		if n := Syllables(word); n != expected {
+			t.Errorf("%q: expected %v syllables, got %v", word, expected, n)
+		}
+		if n := Syllables(strings.ToUpper(word)); n != expected {
+			t.Errorf("%q: expected %v syllables in upper case, got %v", word, expected, n)
+		}
+	}
+
+	cases := []struct {
+		text     string
+		expected int
+	}{
+		{"", 0},
+		{"sea lion", 3},
+		{"hell-bent", 2},
+		{"Brave-otter7!", 3},
+		{"Seven, exclamation mark", 7},
+		{"'§'", 0},
+	}
+	for _, c := range cases {
+		if n := Syllables(c.text); n != c.expected {
+			t.Errorf("%q: expected %v syllables, got %v", c.text, c.expected, n)
+		}
+	}
+}
+
+func TestMaxSyllables(t *testing.T) {
+	g := load_test_generator(t)
+	o := GenerateOptions{Count: 50, Length: 4, Add_digit: true, MaxSyllables: 8}
+	near := false
+	for i := 0; i < 10; i++ {
+		p, _, err := g.GeneratePassphrasesDetailed(&o)
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		for _, pp := range p {
+			expected := Syllables(strings.Join(pp.Words, " ")) + Syllables(Spellout(pp.Digit))
+			if pp.Syllables != expected || pp.Syllables > 8 {
+				t.Fatalf("%q: expected %v syllables, at most 8, got %v", pp.Phrase, expected, pp.Syllables)
+			}
+			// The limit is reached, give or take a syllable
+			near = near || pp.Syllables >= 7
+		}
+	}
+	if !near {
+		t.Errorf("Expected some passphrases within a syllable of the limit")
+	}
+
+	p, _, err := g.GeneratePassphrasesDetailed(&GenerateOptions{Count: 5, Length: 4})
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	for _, pp := range p {
+		if pp.Syllables < 4 {
+			t.Errorf("%q: expected at least a syllable per word without MaxSyllables, got %v", pp.Phrase, pp.Syllables)
+		}
+	}
+
+	if _, err := g.GeneratePassphrases(&GenerateOptions{Length: 4, MaxSyllables: 3, MaxRetries: 10}); !errors.Is(err, ErrRetriesExhausted) {
+		t.Errorf("Expected ErrRetriesExhausted for fewer syllables than words, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrInvalidSymbol = errors.New("Invalid symbol")
+
+// Error for an unusable entry in GenerateOptions.Symbols
+type SymbolError struct {
+	Index  int    // position of the entry in Symbols
+	Symbol string // the entry itself
+	Reason string
+}
+
+func (e *SymbolError) Error() string {
+	return fmt.Sprintf("%v %v (%q): %v", ErrInvalidSymbol, e.Index, e.Symbol, e.Reason)
+}
+
+func (e *SymbolError) Unwrap() error {
+	return ErrInvalidSymbol
+}
+
+// Check that every symbol is non-empty, printable, free of whitespace and at most max runes
+// long (0 = 4), returning a *SymbolError for the first that is not.
+// Generation runs the same check on Symbols.
+func CheckSymbols(symbols []string, max uint) error {
+	if max == 0 {
+		max = symbol_length_default
+	}
+	for i, s := range symbols {
+		reason := ""
+		switch {
+		case s == "":
+			reason = "empty"
+		case !utf8.ValidString(s):
+			reason = "invalid UTF-8"
+		case uint(utf8.RuneCountInString(s)) > max:
+			reason = fmt.Sprintf("longer than %v characters", max)
+		}
+		for _, r := range s {
+			if reason != "" {
+				break
+			}
+			if unicode.IsSpace(r) {
+				reason = "contains whitespace"
+			} else if !unicode.IsPrint(r) {
+				reason = fmt.Sprintf("contains unprintable character %U", r)
+			}
+		}
+		if reason != "" {
+			return &SymbolError{Index: i, Symbol: s, Reason: reason}
+		}
+	}
+	return nil
+}
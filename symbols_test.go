@@ -0,0 +1,59 @@
+package wordentropy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckSymbols(t *testing.T) {
+	if err := CheckSymbols(default_symbols, 0); err != nil {
+		t.Fatalf("Default symbols rejected: %v", err)
+	}
+	if err := CheckSymbols([]string{"!?", "\u20ac", "\u00a1\u00bf!?"}, 4); err != nil {
+		t.Fatalf("Valid symbols rejected: %v", err)
+	}
+
+	cases := []struct {
+		symbols []string
+		max     uint
+		index   int
+		reason  string
+	}{
+		{[]string{"!", ""}, 0, 1, "empty"},
+		{[]string{" "}, 0, 0, "contains whitespace"},
+		{[]string{"!", "#", "a b"}, 0, 2, "contains whitespace"},
+		{[]string{"!\t"}, 0, 0, "contains whitespace"},
+		{[]string{"\u00a0"}, 0, 0, "contains whitespace"},
+		{[]string{"!\x07"}, 0, 0, "contains unprintable character U+0007"},
+		{[]string{"\u200b"}, 0, 0, "contains unprintable character U+200B"},
+		{[]string{"\xff"}, 0, 0, "invalid UTF-8"},
+		{[]string{"!!!!!"}, 0, 0, "longer than 4 characters"},
+		{[]string{"!", "!!"}, 1, 1, "longer than 1 characters"},
+	}
+	for _, c := range cases {
+		err := CheckSymbols(c.symbols, c.max)
+		var se *SymbolError
+		if !errors.As(err, &se) || !errors.Is(err, ErrInvalidSymbol) {
+			t.Errorf("%q: expected a SymbolError, got %v", c.symbols, err)
+			continue
+		}
+		if se.Index != c.index || se.Symbol != c.symbols[c.index] || se.Reason != c.reason {
+			t.Errorf("%q: expected index %v (%v), got %+v", c.symbols, c.index, c.reason, se)
+		}
+	}
+
+	err := CheckSymbols([]string{"!", "a b"}, 0)
+	if s := err.Error(); s != `Invalid symbol 1 ("a b"): contains whitespace` {
+		t.Errorf("Unexpected error text %q", s)
+	}
+
+	// Generation runs the same check, even without Add_symbol
+	g := generator_for(uniform_word_map("otter"))
+	if _, err := g.GeneratePassphrases(&GenerateOptions{Symbols: []string{"!", " "}}); !errors.Is(err, ErrInvalidSymbol) || !strings.Contains(err.Error(), "Invalid symbol 1") {
+		t.Errorf("Expected ErrInvalidSymbol from generation, got %v", err)
+	}
+	if _, err := g.GeneratePassphrases(&GenerateOptions{Add_symbol: true, Symbols: []string{"!!"}, MaxSymbolLength: 2}); err != nil {
+		t.Errorf("Unexpected error with MaxSymbolLength 2: %v", err)
+	}
+}
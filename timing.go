@@ -0,0 +1,29 @@
+package wordentropy
+
+import (
+	"io"
+	"time"
+)
+
+// Reader adding the time spent in each Read to wait, for CollectTimings. Like the state of
+// a generation call, not safe for concurrent use.
+type timed_reader struct {
+	r    io.Reader
+	wait *time.Duration
+}
+
+func (t timed_reader) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := t.r.Read(p)
+	*t.wait += time.Since(start)
+	return n, err
+}
+
+// Measure the randomness source of s for CollectTimings. The fast source reads randomness
+// only to seed itself, so it is left as is and its passphrases never wait.
+func (s *gen_state) collect_timings() {
+	s.waited = new(time.Duration)
+	if r, ok := s.rng.(reader_source); ok {
+		s.rng = reader_source{timed_reader{r.r, s.waited}}
+	}
+}
@@ -0,0 +1,79 @@
+package wordentropy
+
+import (
+	mrand "math/rand/v2"
+	"testing"
+	"time"
+)
+
+// Randomness source taking delay to fill each read, like a blocked entropy pool
+type slow_reader struct {
+	r     *mrand.ChaCha8
+	delay time.Duration
+	reads int
+}
+
+func (s *slow_reader) Read(p []byte) (int, error) {
+	s.reads++
+	time.Sleep(s.delay)
+	return s.r.Read(p)
+}
+
+func TestCollectTimings(t *testing.T) {
+	g := load_test_generator(t)
+	slow := &slow_reader{r: mrand.NewChaCha8([32]byte{7}), delay: time.Millisecond}
+	g.rand = slow
+
+	p, _, err := g.GeneratePassphrasesDetailed(&GenerateOptions{Count: 3, Length: 4, CollectTimings: true})
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	total := time.Duration(0)
+	for _, pp := range p {
+		// Every word is drawn from the slow source, each read taking at least the delay
+		if pp.RandWait < 4*slow.delay || pp.GenDuration < pp.RandWait {
+			t.Errorf("%q: expected at least %v waiting for randomness, within the generation time, got %v of %v", pp.Phrase, 4*slow.delay, pp.RandWait, pp.GenDuration)
+		}
+		total += pp.RandWait
+	}
+	if min := time.Duration(slow.reads) * slow.delay; total < min {
+		t.Errorf("Expected at least %v waiting for %v reads, got %v", min, slow.reads, total)
+	}
+
+	p, _, err = g.GeneratePassphrasesDetailed(&GenerateOptions{Count: 3, Length: 4})
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	for _, pp := range p {
+		if pp.GenDuration != 0 || pp.RandWait != 0 {
+			t.Errorf("%q: expected no timings without CollectTimings, got %v, %v", pp.Phrase, pp.GenDuration, pp.RandWait)
+		}
+	}
+
+	p, _, err = g.GeneratePassphrasesDetailed(&GenerateOptions{Count: 3, Length: 4, CollectTimings: true, InsecureFastRandom: true})
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	for _, pp := range p {
+		if pp.GenDuration <= 0 || pp.RandWait != 0 {
+			t.Errorf("%q: expected a generation time and no wait with InsecureFastRandom, got %v, %v", pp.Phrase, pp.GenDuration, pp.RandWait)
+		}
+	}
+}
+
+func BenchmarkCollectTimings(b *testing.B) {
+	g := load_full_generator(b)
+	for _, c := range []struct {
+		name    string
+		collect bool
+	}{{"off", false}, {"on", true}} {
+		o := GenerateOptions{Count: 10, CollectTimings: c.collect}
+		b.Run(c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, _, err := g.GeneratePassphrasesDetailed(&o); err != nil {
+					b.Fatalf("Error generating passphrases: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -2,23 +2,90 @@ package wordentropy
 
 import (
 	"crypto/rand"
-	"log"
+	"encoding/binary"
+	"errors"
+	"io"
 	"math/big"
+	mrand "math/rand/v2"
 )
 
-func random_range(max int64) int64 {
+var ErrRandomness = errors.New("Cannot read from the randomness source")
+
+// Panic value for a failing randomness source, returned as ErrRandomness by
+// recover_internal
+type randomness_failure struct {
+	err error
+}
+
+// Source of uniform random integers in [0, max). Every random choice of a generation call
+// (word types, words, digits and symbols) is drawn from the call's source.
+type int_source interface {
+	int_n(max int64) int64
+}
+
+// Source reading from a cryptographically secure reader
+type reader_source struct {
+	r io.Reader
+}
+
+func (s reader_source) int_n(max int64) int64 {
 	max_big := *big.NewInt(max)
-	n, err := rand.Int(rand.Reader, &max_big)
+	n, err := rand.Int(s.r, &max_big)
 	if err != nil {
-		log.Fatalf("ERROR: cannot get random integer!\n")
+		panic(randomness_failure{err})
 	}
 	return n.Int64()
 }
 
-func random_choice(l []string) string {
-	return l[random_range(int64(len(l)-1))]
+// Fast non-cryptographic source, seeded from crypto/rand. Not safe for concurrent use.
+type fast_source struct {
+	r *mrand.Rand
 }
 
-func random_digit() string {
-	return random_choice([]string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"})
+func new_fast_source() fast_source {
+	var seed [16]byte
+	if _, err := rand.Read(seed[:]); err != nil {
+		panic(randomness_failure{err})
+	}
+	pcg := mrand.NewPCG(binary.LittleEndian.Uint64(seed[:8]), binary.LittleEndian.Uint64(seed[8:]))
+	return fast_source{mrand.New(pcg)}
+}
+
+func (s fast_source) int_n(max int64) int64 {
+	return s.r.Int64N(max)
+}
+
+// Randomness source for a generation call
+func (g *Generator) source(o *GenerateOptions) int_source {
+	if o.InsecureFastRandom {
+		return new_fast_source()
+	}
+	if g.rand != nil {
+		return reader_source{g.rand}
+	}
+	return reader_source{rand.Reader}
+}
+
+// Random element of l drawn from the call's randomness source
+func (s *gen_state) choice(l []string) string {
+	return l[s.rng.int_n(int64(len(l)))]
+}
+
+// Random element of l with likelihood proportional to its weight (uniform if weights is nil)
+func (s *gen_state) weighted_choice(l []string, weights map[string]uint) string {
+	if weights == nil {
+		return s.choice(l)
+	}
+	total := int64(0)
+	for _, t := range l {
+		total += int64(weights[t])
+	}
+	r := s.rng.int_n(total)
+	for _, t := range l {
+		if r < int64(weights[t]) {
+			return t
+		}
+		r -= int64(weights[t])
+	}
+	return l[len(l)-1]
 }
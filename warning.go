@@ -0,0 +1,61 @@
+package wordentropy
+
+import (
+	"fmt"
+)
+
+// Kind of non-fatal anomaly encountered during generation
+type WarningType int
+
+const (
+	WarnUnknownWordType  WarningType = iota // a word type was requested that isn't in the word map
+	WarnEmptyWord                           // a zero-length word was drawn from the word map
+	WarnPrudishExhausted                    // no non-offensive words are available for a word type
+	WarnEmptyWordType                       // the word map has no words of a word type
+	WarnNoMatchingWords                     // no words of a word type match the word length limits
+	WarnAvoidedExhausted                    // every word of a word type is in the previous phrase (GenerateAvoiding)
+	WarnNoAgreeingVerbs                     // no verb agrees in number with the noun before it (Agreement)
+)
+
+func (t WarningType) String() string {
+	switch t {
+	case WarnUnknownWordType:
+		return "unknown word type"
+	case WarnEmptyWord:
+		return "zero-length word"
+	case WarnPrudishExhausted:
+		return "no non-offensive words"
+	case WarnEmptyWordType:
+		return "no words of type"
+	case WarnNoMatchingWords:
+		return "no words within length limits"
+	case WarnAvoidedExhausted:
+		return "no words not in previous phrase"
+	case WarnNoAgreeingVerbs:
+		return "no verbs agreeing in number"
+	default:
+		return fmt.Sprintf("WarningType(%d)", int(t))
+	}
+}
+
+// Non-fatal anomaly accumulated during a generation call. Warnings never include
+// generated words, so they are safe to log.
+type Warning struct {
+	Type     WarningType
+	WordType string // word type that triggered the warning
+	Count    uint   // number of times the anomaly occurred during the call
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%v (word type: %v, count: %v)", w.Type, w.WordType, w.Count)
+}
+
+func (s *gen_state) warn(t WarningType, word_type string) {
+	for i := range s.warnings {
+		if s.warnings[i].Type == t && s.warnings[i].WordType == word_type {
+			s.warnings[i].Count++
+			return
+		}
+	}
+	s.warnings = append(s.warnings, Warning{Type: t, WordType: word_type, Count: 1})
+}
@@ -0,0 +1,83 @@
+package wordentropy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Generator over a word map as-is, bypassing provider validation
+func generator_for(word_map map[string][]string) *Generator {
+	g := &Generator{}
+	g.data.Store(&word_data{word_map: word_map})
+	return g
+}
+
+func uniform_word_map(word string) map[string][]string {
+	m := make(map[string][]string)
+	for _, t := range word_types {
+		m[t] = []string{word}
+	}
+	return m
+}
+
+func has_warning(warnings []Warning, wt WarningType) bool {
+	for _, w := range warnings {
+		if w.Type == wt {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWarnings(t *testing.T) {
+	// Each generator has one unusable word type, which generation steps around
+	with_adverbs := func(adverbs []string) map[string][]string {
+		m := uniform_word_map("otter")
+		m["adverb"] = adverbs
+		return m
+	}
+	unknown := uniform_word_map("otter")
+	delete(unknown, "adverb")
+	all_offensive := generator_for(with_adverbs([]string{"secretword"}))
+	all_offensive.words().offensive = map[string]uint{"secretword": 1}
+
+	cases := []struct {
+		name     string
+		g        *Generator
+		o        GenerateOptions
+		expected WarningType
+	}{
+		{"unknown type", generator_for(unknown), GenerateOptions{}, WarnUnknownWordType},
+		{"empty word", generator_for(uniform_word_map("")), GenerateOptions{}, WarnEmptyWord},
+		{"prudish exhausted", all_offensive, GenerateOptions{Prudish: true}, WarnPrudishExhausted},
+		{"empty type", generator_for(with_adverbs([]string{})), GenerateOptions{}, WarnEmptyWordType},
+		{"no matching words", generator_for(with_adverbs([]string{"secretword"})), GenerateOptions{MaxWordLength: 5}, WarnNoMatchingWords},
+	}
+
+	for _, c := range cases {
+		o := c.o
+		o.Count, o.Length = count_max, 20
+		_, warnings, err := c.g.GeneratePassphrasesDetailed(&o)
+		if err != nil {
+			t.Fatalf("%v: error generating passphrases: %v", c.name, err)
+		}
+		if !has_warning(warnings, c.expected) {
+			t.Errorf("%v: expected warning %v, got %v", c.name, c.expected, warnings)
+		}
+		if strings.Contains(fmt.Sprint(warnings), "secretword") {
+			t.Errorf("%v: warning leaked generated content: %v", c.name, warnings)
+		}
+	}
+}
+
+func TestNoWarnings(t *testing.T) {
+	g := generator_for(uniform_word_map("otter"))
+	_, warnings, err := g.GeneratePassphrasesDetailed(&GenerateOptions{Count: 10, Length: 20})
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("Unexpected warnings: %v", warnings)
+	}
+}
@@ -0,0 +1,154 @@
+// Package wasmapi exposes passphrase generation to JavaScript through WebAssembly, so that
+// pages can generate passphrases in the browser without sending them over the network:
+//
+//	GOOS=js GOARCH=wasm go build -o wordentropy.wasm ./cmd/wewasm
+//
+// Once the module runs (with wasm_exec.js from the Go distribution), JavaScript calls
+// wordentropy.generate(optionsJSON) and gets a JSON string back. The JSON bridge in this
+// file builds on every platform; only the syscall/js binding needs js/wasm.
+package wasmapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bkeroack/libwordentropy"
+	"strings"
+	"time"
+)
+
+// Options accepted by generate, named as in the gRPC GenerateRequest. All fields are
+// optional; unset ones take the GenerateOptions defaults. InsecureFastRandom is not
+// accepted.
+type Request struct {
+	Count              uint            `json:"count"`
+	Length             uint            `json:"length"`
+	FragmentLength     uint            `json:"fragment_length"`
+	FragmentJitter     uint            `json:"fragment_jitter"`
+	JoinType           string          `json:"join_type"`
+	Prudish            bool            `json:"prudish"`
+	PrudishLevel       uint            `json:"prudish_level"`
+	FilterCategories   []string        `json:"filter_categories"`
+	NoSpaces           bool            `json:"no_spaces"`
+	AddDigit           bool            `json:"add_digit"`
+	AddSymbol          bool            `json:"add_symbol"`
+	Symbols            []string        `json:"symbols"`
+	Digits             []string        `json:"digits"`
+	MaxSymbolLength    uint            `json:"max_symbol_length"`
+	Separator          string          `json:"separator"`
+	Lowercase          bool            `json:"lowercase"`
+	Capitalize         string          `json:"capitalize"`
+	MaxChars           uint            `json:"max_chars"`
+	MaxBytes           uint            `json:"max_bytes"`
+	MaxSyllables       uint            `json:"max_syllables"`
+	MaxRetries         uint            `json:"max_retries"`
+	TimeoutMs          uint64          `json:"timeout_ms"`
+	MinWordLength      uint            `json:"min_word_length"`
+	MaxWordLength      uint            `json:"max_word_length"`
+	NoAdjacentSameType bool            `json:"no_adjacent_same_type"`
+	AllowedTypes       []string        `json:"allowed_types"`
+	StartTypeWeights   map[string]uint `json:"start_type_weights"`
+	JointTypes         map[string]uint `json:"joint_types"`
+	NoJoints           bool            `json:"no_joints"`
+	VaryJoints         bool            `json:"vary_joints"`
+	AvoidCommonPhrases bool            `json:"avoid_common_phrases"`
+	ExtraDenyWords     []string        `json:"extra_deny_words"`
+	ShortWordBias      float64         `json:"short_word_bias"`
+	UnambiguousConcat  bool            `json:"unambiguous_concat"`
+	Agreement          bool            `json:"agreement"`
+	AllowPartial       bool            `json:"allow_partial"`
+	BestOf             uint            `json:"best_of"`
+}
+
+// Generate passphrases from options JSON (a Request; "" or "{}" for the defaults) and
+// return a JSON wordentropy.Response, or a wordentropy.ErrorResponse if generation fails.
+// Unknown option names are an ErrInvalidParameter. With allow_partial, passphrases
+// generated before a failure are returned with the error and its code.
+func Generate(g *wordentropy.Generator, options string) string {
+	o, err := parse_request(options)
+	if err != nil {
+		return error_json(err)
+	}
+	p, err := g.GeneratePassphrases(o)
+	var partial *wordentropy.BatchError
+	switch {
+	case err == nil:
+		return encode(wordentropy.Response{Passphrases: p})
+	case errors.As(err, &partial) && len(p) > 0:
+		return encode(wordentropy.Response{Passphrases: p, Error: err.Error(), Code: wordentropy.ErrorCode(err)})
+	}
+	return error_json(err)
+}
+
+// Decode options JSON into GenerateOptions
+func parse_request(options string) (*wordentropy.GenerateOptions, error) {
+	var req Request
+	if strings.TrimSpace(options) != "" {
+		d := json.NewDecoder(strings.NewReader(options))
+		d.DisallowUnknownFields()
+		if err := d.Decode(&req); err != nil {
+			return nil, fmt.Errorf("%w: options: %v", wordentropy.ErrInvalidParameter, err)
+		}
+		if d.More() {
+			return nil, fmt.Errorf("%w: options: trailing data after the object", wordentropy.ErrInvalidParameter)
+		}
+	}
+	return generate_options(&req), nil
+}
+
+func generate_options(req *Request) *wordentropy.GenerateOptions {
+	return &wordentropy.GenerateOptions{
+		Count:  req.Count,
+		Length: req.Length,
+		Fragments: wordentropy.FragmentPolicy{
+			TargetFragmentWords: req.FragmentLength,
+			JoinType:            req.JoinType,
+			Jitter:              req.FragmentJitter,
+		},
+		Prudish:            req.Prudish,
+		Prudish_level:      req.PrudishLevel,
+		FilterCategories:   req.FilterCategories,
+		No_spaces:          req.NoSpaces,
+		Add_digit:          req.AddDigit,
+		Add_symbol:         req.AddSymbol,
+		Symbols:            req.Symbols,
+		Digits:             req.Digits,
+		MaxSymbolLength:    req.MaxSymbolLength,
+		Separator:          req.Separator,
+		Lowercase:          req.Lowercase,
+		Capitalize:         req.Capitalize,
+		MaxChars:           req.MaxChars,
+		MaxBytes:           req.MaxBytes,
+		MaxSyllables:       req.MaxSyllables,
+		MaxRetries:         req.MaxRetries,
+		Timeout:            time.Duration(req.TimeoutMs) * time.Millisecond,
+		MinWordLength:      req.MinWordLength,
+		MaxWordLength:      req.MaxWordLength,
+		NoAdjacentSameType: req.NoAdjacentSameType,
+		AllowedTypes:       req.AllowedTypes,
+		StartTypeWeights:   req.StartTypeWeights,
+		JointTypes:         req.JointTypes,
+		NoJoints:           req.NoJoints,
+		VaryJoints:         req.VaryJoints,
+		AvoidCommonPhrases: req.AvoidCommonPhrases,
+		ExtraDenyWords:     req.ExtraDenyWords,
+		ShortWordBias:      req.ShortWordBias,
+		UnambiguousConcat:  req.UnambiguousConcat,
+		Agreement:          req.Agreement,
+		AllowPartial:       req.AllowPartial,
+		BestOf:             req.BestOf,
+	}
+}
+
+func error_json(err error) string {
+	return encode(wordentropy.ErrorResponse{Error: err.Error(), Code: wordentropy.ErrorCode(err)})
+}
+
+func encode(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		// Only strings and string slices are marshalled, which cannot fail
+		panic(err)
+	}
+	return string(b)
+}
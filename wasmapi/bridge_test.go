@@ -0,0 +1,94 @@
+package wasmapi
+
+import (
+	"encoding/json"
+	"github.com/bkeroack/libwordentropy"
+	_ "github.com/bkeroack/libwordentropy/embedded"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func embedded_generator(t *testing.T) *wordentropy.Generator {
+	g := &wordentropy.Generator{}
+	if err := g.LoadEmbeddedWords(nil); err != nil {
+		t.Fatalf("Error loading embedded wordlist: %v", err)
+	}
+	return g
+}
+
+func TestGenerate(t *testing.T) {
+	g := embedded_generator(t)
+	cases := []struct {
+		options string
+		count   int
+	}{
+		{"", 4},
+		{" {} ", 4},
+		{`{"count": 2, "length": 5, "no_spaces": true, "fragment_length": 2, "join_type": "none"}`, 2},
+		{`{"count": 1, "start_type_weights": {"snoun": 1}, "timeout_ms": 10000}`, 1},
+	}
+	for _, c := range cases {
+		var resp wordentropy.Response
+		out := Generate(g, c.options)
+		if err := json.Unmarshal([]byte(out), &resp); err != nil {
+			t.Fatalf("%q: invalid response %q: %v", c.options, out, err)
+		}
+		if len(resp.Passphrases) != c.count || resp.Error != "" {
+			t.Errorf("%q: expected %v passphrases, got %q", c.options, c.count, out)
+		}
+		for _, p := range resp.Passphrases {
+			if strings.Contains(c.options, "no_spaces") && strings.Contains(p, " ") {
+				t.Errorf("%q: passphrase %q has spaces", c.options, p)
+			}
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	g := embedded_generator(t)
+	cases := []struct {
+		options string
+		code    string
+	}{
+		{`{"count": 2`, "ErrInvalidParameter"},
+		{`{"count": "2"}`, "ErrInvalidParameter"},
+		{`{"insecure_fast_random": true}`, "ErrInvalidParameter"},
+		{`{} {}`, "ErrInvalidParameter"},
+		{`{"count": 100000}`, "ErrCountExceedsMax"},
+		{`{"join_type": "xyzzy"}`, "ErrUnknownWordType"},
+		{`{"count": 2, "max_chars": 1, "max_retries": 2, "allow_partial": true}`, "ErrRetriesExhausted"},
+	}
+	for _, c := range cases {
+		var resp wordentropy.ErrorResponse
+		out := Generate(g, c.options)
+		if err := json.Unmarshal([]byte(out), &resp); err != nil {
+			t.Fatalf("%q: invalid response %q: %v", c.options, out, err)
+		}
+		if resp.Code != c.code || resp.Error == "" {
+			t.Errorf("%q: expected %v, got %v", c.options, c.code, out)
+		}
+	}
+}
+
+// The js/wasm build (library, binding and command) type-checks. Needs the go command.
+func TestWasmBuild(t *testing.T) {
+	if testing.Short() || runtime.GOOS == "js" {
+		t.Skip("skipping js/wasm vet in short mode or under js")
+	}
+	gobin := filepath.Join(runtime.GOROOT(), "bin", "go")
+	if _, err := os.Stat(gobin); err != nil {
+		if gobin, err = exec.LookPath("go"); err != nil {
+			t.Skip("go command not found")
+		}
+	}
+	cmd := exec.Command(gobin, "vet", ".", "./wordlist", "./wasmapi", "./cmd/wewasm")
+	cmd.Dir = ".."
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go vet for js/wasm failed: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build js && wasm
+
+package wasmapi
+
+import (
+	"fmt"
+	"github.com/bkeroack/libwordentropy"
+	"syscall/js"
+)
+
+// Set the global wordentropy object with a generate(optionsJSON) function calling Generate
+// on g. A call with a non-string argument gets an ErrInvalidParameter response.
+func Register(g *wordentropy.Generator) {
+	api := js.Global().Get("Object").New()
+	api.Set("generate", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		options := ""
+		if len(args) > 0 && args[0].Type() != js.TypeUndefined {
+			if args[0].Type() != js.TypeString {
+				return error_json(fmt.Errorf("%w: options must be a JSON string, got %v", wordentropy.ErrInvalidParameter, args[0].Type()))
+			}
+			options = args[0].String()
+		}
+		return Generate(g, options)
+	}))
+	js.Global().Set("wordentropy", api)
+}
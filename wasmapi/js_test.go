@@ -0,0 +1,36 @@
+//go:build js && wasm
+
+package wasmapi
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/bkeroack/libwordentropy"
+	"syscall/js"
+	"testing"
+)
+
+// Run with a JavaScript host, e.g.
+//
+//	GOOS=js GOARCH=wasm go test -exec "$(go env GOROOT)/lib/wasm/go_js_wasm_exec" ./wasmapi
+func TestRegister(t *testing.T) {
+	Register(embedded_generator(t))
+	generate := js.Global().Get("wordentropy").Get("generate")
+	var resp wordentropy.Response
+	out := generate.Invoke(`{"count": 2}`).String()
+	if err := json.Unmarshal([]byte(out), &resp); err != nil || len(resp.Passphrases) != 2 {
+		t.Fatalf("Expected 2 passphrases, got %q (%v)", out, err)
+	}
+	var eresp wordentropy.ErrorResponse
+	out = generate.Invoke(2).String()
+	if err := json.Unmarshal([]byte(out), &eresp); err != nil || eresp.Code != "ErrInvalidParameter" {
+		t.Fatalf("Expected ErrInvalidParameter for a non-string argument, got %q (%v)", out, err)
+	}
+}
+
+func TestNoFiles(t *testing.T) {
+	g := &wordentropy.Generator{}
+	if err := g.LoadWords(&wordentropy.WordListOptions{Wordlist: "../testdata/pos.txt"}); !errors.Is(err, wordentropy.ErrNoFiles) {
+		t.Fatalf("Expected ErrNoFiles, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package wordentropy
+
+import (
+	"crypto/sha256"
+	"time"
+)
+
+// Word list files watched for changes by LoadWords with WatchInterval
+type watcher struct {
+	o     WordListOptions
+	files []string
+	seen  []file_state // last state of each file
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// State of a watched file. The hash is only computed when the modification time or size
+// change, so a file touched without changing its contents is not reloaded.
+type file_state struct {
+	mod  time.Time
+	size int64
+	hash [sha256.Size]byte
+	err  error // stat or read error (the file is missing or unreadable)
+}
+
+func new_watcher(o *WordListOptions) *watcher {
+	w := &watcher{o: *o, stop: make(chan struct{}), done: make(chan struct{})}
+	w.o.Deferred = false
+	for _, p := range []string{o.Wordlist, o.Offensive, o.CommonPhrases} {
+		if p != "" {
+			w.files = append(w.files, p)
+			w.seen = append(w.seen, w.state(p, nil))
+		}
+	}
+	return w
+}
+
+// Current state of file p, last seen as prev (nil if never)
+func (w *watcher) state(p string, prev *file_state) file_state {
+	mod, size, err := stat_file(p)
+	if err != nil {
+		return file_state{err: err}
+	}
+	st := file_state{mod: mod, size: size}
+	if prev != nil && prev.err == nil && prev.mod.Equal(mod) && prev.size == size {
+		st.hash = prev.hash
+		return st
+	}
+	data, err := read_limited(p, w.o.MaxFileBytes)
+	if err != nil {
+		return file_state{err: err}
+	}
+	st.hash = sha256.Sum256(data)
+	return st
+}
+
+// Whether any watched file changed since the last check, and the first error reading one
+// that newly failed
+func (w *watcher) changed() (bool, error) {
+	changed := false
+	var first error
+	for i, p := range w.files {
+		st := w.state(p, &w.seen[i])
+		prev := w.seen[i]
+		w.seen[i] = st
+		switch {
+		case st.err != nil:
+			if prev.err == nil && first == nil {
+				first = st.err
+			}
+		case prev.err != nil || st.hash != prev.hash:
+			changed = true
+		}
+	}
+	return changed, first
+}
+
+// Check the files every WatchInterval until stopped, reloading when one changes
+func (g *Generator) watch(w *watcher) {
+	defer close(w.done)
+	t := time.NewTicker(w.o.WatchInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-t.C:
+		}
+		changed, err := w.changed()
+		if err == nil && changed {
+			err = g.reload(w)
+		}
+		select {
+		case <-w.stop:
+			return // replaced or closed while reloading: the result was discarded
+		default:
+		}
+		if (err != nil || changed) && w.o.OnReload != nil {
+			w.o.OnReload(err)
+		}
+	}
+}
+
+// Load the watched word list aside and publish it, unless the watcher was replaced or
+// stopped meanwhile. On error the current word list stays in use.
+func (g *Generator) reload(w *watcher) (err error) {
+	defer recover_internal(&err)
+	loader := &Generator{}
+	if err := loader.load_words(&w.o); err != nil {
+		return err
+	}
+	d := loader.data.Load()
+	g.Lock()
+	defer g.Unlock()
+	if g.watcher != w {
+		return nil
+	}
+	g.deferred.Store(nil)
+	d.usage.budget = &g.index_budget
+	g.data.Store(d)
+	return nil
+}
+
+// Replace the watcher (nil to stop watching). Called with g locked; the old watcher's
+// goroutine exits on its own, as it may be waiting for the lock.
+func (g *Generator) set_watcher(w *watcher) {
+	if g.watcher != nil {
+		close(g.watcher.stop)
+	}
+	g.watcher = w
+	if w != nil {
+		go g.watch(w)
+	}
+}
@@ -0,0 +1,152 @@
+package wordentropy
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Wait for the next OnReload call, failing after a few seconds
+func next_reload(t *testing.T, reloads chan error) error {
+	t.Helper()
+	select {
+	case err := <-reloads:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for a reload")
+	}
+	return nil
+}
+
+// Check that OnReload is not called for a while
+func no_reload(t *testing.T, reloads chan error) {
+	t.Helper()
+	select {
+	case err := <-reloads:
+		t.Fatalf("Unexpected reload: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWatchInterval(t *testing.T) {
+	src, err := os.ReadFile("testdata/pos.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	p := filepath.Join(dir, "pos.txt")
+	offensive := filepath.Join(dir, "offensive.txt")
+	write := func(path string, data string) {
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(p, string(src))
+	write(offensive, "xyzzy\n")
+
+	reloads := make(chan error, 10)
+	g := &Generator{}
+	o := WordListOptions{Wordlist: p, Offensive: offensive, WatchInterval: 5 * time.Millisecond, OnReload: func(err error) { reloads <- err }}
+	if err := g.LoadWords(&o); err != nil {
+		t.Fatalf("Error loading wordlist: %v", err)
+	}
+	defer g.Close()
+	no_reload(t, reloads)
+
+	// A changed wordlist is swapped in
+	write(p, string(src)+"wombat\tN\n")
+	if err := next_reload(t, reloads); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if !slices.Contains(g.GetWordMap()["snoun"], "wombat") {
+		t.Fatalf("Expected the reloaded wordlist, got %q", g.GetWordMap()["snoun"])
+	}
+
+	// Touching a file without changing it does not reload
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(p, later, later); err != nil {
+		t.Fatal(err)
+	}
+	no_reload(t, reloads)
+
+	// So does a change to the offensive list
+	write(offensive, "xyzzy\nwombat\n")
+	if err := next_reload(t, reloads); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if n := g.Stats().OffensiveFiltered["snoun"]; n != 1 {
+		t.Errorf("Expected 1 offensive noun after reloading, got %v", n)
+	}
+
+	// A missing file is reported once and the word list stays in use
+	if err := os.Remove(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := next_reload(t, reloads); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected a missing file error, got %v", err)
+	}
+	no_reload(t, reloads)
+	if _, err := g.GeneratePassphrases(nil); err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	write(p, string(src))
+	if err := next_reload(t, reloads); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if slices.Contains(g.GetWordMap()["snoun"], "wombat") {
+		t.Fatalf("Expected the restored wordlist")
+	}
+
+	// Close stops watching and generation
+	if err := g.Close(); err != nil {
+		t.Fatalf("Error closing: %v", err)
+	}
+	write(p, string(src)+"wombat\tN\n")
+	no_reload(t, reloads)
+	if _, err := g.GeneratePassphrases(nil); err != ErrClosed {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+	if rec := serve(NewHandler(g, nil), "/"); rec.Code != http.StatusServiceUnavailable || error_code(t, rec) != "ErrClosed" {
+		t.Errorf("Expected 503 ErrClosed from handler, got %v: %v", rec.Code, rec.Body.String())
+	}
+	if err := g.LoadWords(&o); err != ErrClosed {
+		t.Errorf("Expected ErrClosed watching a closed generator, got %v", err)
+	}
+	if err := g.Close(); err != nil {
+		t.Errorf("Error closing again: %v", err)
+	}
+}
+
+func TestWatchReplaced(t *testing.T) {
+	src, err := os.ReadFile("testdata/pos.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(t.TempDir(), "pos.txt")
+	if err := os.WriteFile(p, src, 0644); err != nil {
+		t.Fatal(err)
+	}
+	reloads := make(chan error, 10)
+	g := &Generator{}
+	defer g.Close()
+	if err := g.LoadWords(&WordListOptions{Wordlist: p, WatchInterval: 5 * time.Millisecond, OnReload: func(err error) { reloads <- err }}); err != nil {
+		t.Fatalf("Error loading wordlist: %v", err)
+	}
+
+	// Loading another word list stops watching the file
+	if err := g.LoadEmbeddedWords(nil); err != nil {
+		t.Fatalf("Error loading embedded wordlist: %v", err)
+	}
+	embedded := g.GetWordMap()
+	if err := os.WriteFile(p, append(src, "wombat\tN\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	no_reload(t, reloads)
+	if n := len(g.GetWordMap()["snoun"]); n != len(embedded["snoun"]) {
+		t.Errorf("Expected the embedded wordlist to stay, got %v nouns", n)
+	}
+}
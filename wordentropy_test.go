@@ -1,24 +1,67 @@
 package wordentropy
 
 import (
+	"crypto/rand"
+	"errors"
+	"math"
+	mrand "math/rand/v2"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
+// Randomness for test inputs, e.g. random options
+var test_rng = reader_source{rand.Reader}
+
+func load_test_generator(t testing.TB) *Generator {
+	g, err := LoadGenerator(&WordListOptions{
+		Wordlist:  "testdata/pos.txt",
+		Offensive: "testdata/offensive.txt",
+	})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	return g
+}
+
+// Load the full wordlist built into the package, eagerly as LoadWords would from the text
+// file, for benchmarks that need realistic pool sizes
+func load_full_generator(t testing.TB) *Generator {
+	p := filepath.Join(t.TempDir(), "part-of-speech.bin")
+	if err := os.WriteFile(p, embedded_wordlist, 0644); err != nil {
+		t.Fatalf("Could not write binary wordlist: %v", err)
+	}
+	g, err := LoadGenerator(&WordListOptions{
+		Wordlist:  p,
+		Format:    "binary",
+		Offensive: "testdata/offensive.txt",
+	})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	return g
+}
+
 func TestPassphrases(t *testing.T) {
 
 	var ops GenerateOptions
 
 	g, err := LoadGenerator(&WordListOptions{
-		Wordlist:  "data/part-of-speech.txt",
-		Offensive: "data/offensive.txt",
+		Wordlist:  "testdata/pos_tags.txt",
+		Offensive: "testdata/offensive.txt",
 	})
 	if err != nil {
 		t.Fatalf("Could not load wordlist: %v", err)
 	}
 
 	for i := 0; i < 20; i++ {
-		ops.Length = uint(random_range(int64(20)))
-		ops.Count = uint(random_range(int64(20)))
+		ops.Length = uint(test_rng.int_n(int64(20)))
+		ops.Count = uint(test_rng.int_n(int64(20)))
 		_, err := g.GeneratePassphrases(&ops)
 		if err != nil {
 			t.Fatalf("Error generating passphrases (i: %v): %v", i, err)
@@ -26,15 +69,224 @@ func TestPassphrases(t *testing.T) {
 	}
 }
 
-func BenchmarkPassphraseGeneration(b *testing.B) {
+func TestOffensivePrefilter(t *testing.T) {
+	g, err := LoadGenerator(&WordListOptions{
+		Wordlist:  "testdata/pos.txt",
+		Offensive: "testdata/offensive.txt",
+	})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+
+	expected := map[string]uint{"snoun": 1, "verb": 1, "adjective": 1}
+	st := g.Stats()
+	for word_type, n := range st.OffensiveFiltered {
+		if n != expected[word_type] {
+			t.Errorf("Filtered count for %v: expected %v, got %v", word_type, expected[word_type], n)
+		}
+	}
+	for word_type, n := range expected {
+		if st.Words[word_type]-n != uint(len(g.words().prudish(at_level(1)).pools[word_type])) {
+			t.Errorf("Prudish pool size mismatch for %v", word_type)
+		}
+	}
+
+	p, err := g.GeneratePassphrases(&GenerateOptions{Count: 99, Length: 20, Prudish: true})
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	for i := range p {
+		l := strings.ToLower(p[i])
+		if strings.Contains(l, "damn") || strings.Contains(l, "hell") {
+			t.Fatalf("Offensive word in prudish passphrase: %v", p[i])
+		}
+	}
+}
 
+func TestPrudishLevel(t *testing.T) {
 	g, err := LoadGenerator(&WordListOptions{
-		Wordlist:  "data/part-of-speech.txt",
-		Offensive: "data/offensive.txt",
+		Wordlist:  "testdata/pos.txt",
+		Offensive: "testdata/offensive_levels.txt",
 	})
 	if err != nil {
-		b.Fatalf("Could not load wordlist: %v", err)
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	d := g.words()
+	if !reflect.DeepEqual(d.offensive, map[string]uint{"damn": 1, "hell": 3, "brave": 1, "sings": 2}) {
+		t.Fatalf("Unexpected severities %v", d.offensive)
+	}
+
+	cases := []struct {
+		level   uint
+		removed []string
+	}{
+		{1, []string{"Damn", "brave", "damn fool", "hell-bent", "sings"}},
+		{2, []string{"hell-bent", "sings"}},
+		{3, []string{"hell-bent"}},
+		{4, []string{}},
+	}
+	for _, c := range cases {
+		removed := []string{}
+		pools := d.prudish(at_level(c.level)).pools
+		for t, words := range d.all() {
+			kept := make(map[string]bool)
+			for _, w := range pools[t] {
+				kept[w] = true
+			}
+			for _, w := range words {
+				if !kept[w] {
+					removed = append(removed, w)
+				}
+			}
+		}
+		sort.Strings(removed)
+		if !reflect.DeepEqual(removed, c.removed) {
+			t.Errorf("Level %v: expected %q removed, got %q", c.level, c.removed, removed)
+		}
+	}
+
+	o, err := g.ResolveOptions(&GenerateOptions{Prudish_level: 2})
+	if err != nil || !o.Prudish || d.filter(&o).level != 2 {
+		t.Errorf("Expected Prudish_level to imply Prudish, got %+v, %v", o, err)
+	}
+	if level := d.filter(&GenerateOptions{Prudish: true}).level; level != 1 {
+		t.Errorf("Expected Prudish alone to filter every severity, got level %v", level)
+	}
+	seen := false
+	for i := 0; i < 20 && !seen; i++ {
+		p, err := g.GeneratePassphrases(&GenerateOptions{Count: 99, Length: 20, Prudish_level: 3})
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		for _, phrase := range p {
+			if strings.Contains(phrase, "hell") {
+				t.Fatalf("Level 3 word in passphrase: %v", phrase)
+			}
+			seen = seen || strings.Contains(phrase, "sings")
+		}
+	}
+	if !seen {
+		t.Errorf("Expected level 2 words to be used with Prudish_level 3")
+	}
+
+	bad := filepath.Join(t.TempDir(), "offensive.txt")
+	os.WriteFile(bad, []byte("damn\t1\nhell\tsevere\n"), 0644)
+	_, err = LoadGenerator(&WordListOptions{Wordlist: "testdata/pos.txt", Offensive: bad})
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Line != 2 || !strings.Contains(pe.Reason, "severe") {
+		t.Errorf("Expected a ParseError for line 2, got %v", err)
+	}
+}
+
+// Entries of d removed by an offensive word filter, sorted
+func removed_by(d *word_data, f offense_filter) []string {
+	removed := []string{}
+	pools := d.prudish(f).pools
+	for t, words := range d.all() {
+		kept := make(map[string]bool)
+		for _, w := range pools[t] {
+			kept[w] = true
+		}
+		for _, w := range words {
+			if !kept[w] {
+				removed = append(removed, w)
+			}
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+func TestFilterCategories(t *testing.T) {
+	wo := WordListOptions{Wordlist: "testdata/pos.txt", Offensive: "testdata/offensive_categories.txt"}
+	g, err := LoadGenerator(&wo)
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
 	}
+	d := g.words()
+	expected := map[string][]string{"damn": {"profanity"}, "hell": {"profanity", "violence"}, "badger": {"innuendo"}, "sings": {"innuendo"}, "runs": {"violence"}}
+	if !reflect.DeepEqual(d.categories, expected) {
+		t.Fatalf("Unexpected categories %v", d.categories)
+	}
+	if !reflect.DeepEqual(d.offensive, map[string]uint{"damn": 1, "hell": 3, "badger": 1, "brave": 1, "sings": 2, "runs": 1}) {
+		t.Fatalf("Unexpected severities %v", d.offensive)
+	}
+
+	cases := []struct {
+		level      uint
+		categories []string
+		removed    []string
+	}{
+		{1, nil, []string{"Damn", "badger", "brave", "damn fool", "hell-bent", "runs", "sings"}},
+		{1, []string{"profanity"}, []string{"Damn", "damn fool", "hell-bent"}},
+		{1, []string{"violence"}, []string{"hell-bent", "runs"}},
+		{1, []string{"innuendo", "violence"}, []string{"badger", "hell-bent", "runs", "sings"}},
+		{2, []string{"innuendo", "profanity"}, []string{"hell-bent", "sings"}},
+	}
+	for _, c := range cases {
+		if removed := removed_by(d, offense_filter{level: c.level, categories: c.categories}); !reflect.DeepEqual(removed, c.removed) {
+			t.Errorf("Level %v, %q: expected %q removed, got %q", c.level, c.categories, c.removed, removed)
+		}
+	}
+
+	// Category combinations come from callers, so their pools share the bounded cache
+	if _, err := g.GeneratePassphrases(&GenerateOptions{FilterCategories: []string{"Innuendo", "violence", "innuendo"}}); err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	if _, ok := d.derived_pools.entries["prudish/1/innuendo,violence"]; !ok {
+		t.Errorf("Expected the filtered pools in the pool cache")
+	}
+	if _, ok := d.indexes.entries["prudish/1/innuendo,violence"]; ok {
+		t.Errorf("Expected no index for a category combination")
+	}
+
+	o, err := g.ResolveOptions(&GenerateOptions{FilterCategories: []string{"Violence", "innuendo", "violence"}})
+	if err != nil || !o.Prudish || !reflect.DeepEqual(o.FilterCategories, []string{"innuendo", "violence"}) {
+		t.Errorf("Expected FilterCategories to be normalized and imply Prudish, got %+v, %v", o, err)
+	}
+	if _, err := g.ResolveOptions(&GenerateOptions{FilterCategories: []string{"gore"}}); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for an unknown category, got %v", err)
+	}
+
+	data := convert_fixture(t, "testdata/pos.txt")
+	lazy := &Generator{}
+	if err := lazy.LoadBinaryWords(data, &wo); err != nil {
+		t.Fatalf("Could not load binary wordlist: %v", err)
+	}
+	for _, g := range []*Generator{g, lazy} {
+		seen := map[string]bool{}
+		for i := 0; i < 20; i++ {
+			p, _, err := g.GeneratePassphrasesDetailed(&GenerateOptions{Count: 99, Length: 20, FilterCategories: []string{"innuendo"}})
+			if err != nil {
+				t.Fatalf("Error generating passphrases: %v", err)
+			}
+			for _, phrase := range p {
+				for _, w := range phrase.Words {
+					seen[w] = true
+				}
+			}
+		}
+		if seen["badger"] || seen["sings"] {
+			t.Errorf("Innuendo word generated with FilterCategories innuendo")
+		}
+		if !seen["brave"] || !seen["runs"] {
+			t.Errorf("Expected uncategorized and other category words to be used, got %v", seen)
+		}
+	}
+
+	for _, line := range []string{"damn\t1\tbad word\n", "damn\t1\tprofanity\textra\n", "damn\tprofanity\n"} {
+		bad := filepath.Join(t.TempDir(), "offensive.txt")
+		os.WriteFile(bad, []byte(line), 0644)
+		_, err = LoadGenerator(&WordListOptions{Wordlist: "testdata/pos.txt", Offensive: bad})
+		var pe *ParseError
+		if !errors.As(err, &pe) || pe.Line != 1 {
+			t.Errorf("%q: expected a ParseError for line 1, got %v", line, err)
+		}
+	}
+}
+
+func BenchmarkPassphraseGeneration(b *testing.B) {
+	g := load_full_generator(b)
 	for i := 0; i < b.N; i++ {
 		_, err := g.GeneratePassphrases(&GenerateOptions{})
 		if err != nil {
@@ -43,15 +295,500 @@ func BenchmarkPassphraseGeneration(b *testing.B) {
 	}
 }
 
-func BenchmarkWordlistLoading(b *testing.B) {
-	wo := WordListOptions{
-		Wordlist:  "data/part-of-speech.txt",
-		Offensive: "data/offensive.txt",
+func BenchmarkGeneratePassphrases(b *testing.B) {
+	g := load_full_generator(b)
+	benchmarks := []struct {
+		name string
+		o    GenerateOptions
+	}{
+		{"Length5", GenerateOptions{Length: 5}},
+		{"Length20", GenerateOptions{Length: 20}},
+		{"Length60", GenerateOptions{Length: 60}},
+		{"Prudish", GenerateOptions{Prudish: true}},
+		{"NotPrudish", GenerateOptions{Prudish: false}},
+		{"NoSpaces", GenerateOptions{No_spaces: true}},
+		{"DigitSymbol", GenerateOptions{Add_digit: true, Add_symbol: true}},
+		{"Count1", GenerateOptions{Count: 1}},
+		{"Count99", GenerateOptions{Count: 99}},
+		{"Count99InsecureFastRandom", GenerateOptions{Count: 99, InsecureFastRandom: true}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				o := bm.o
+				if _, err := g.GeneratePassphrases(&o); err != nil {
+					b.Fatalf("Error generating passphrases: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// Repeated calls with options that need pools derived from the word list, which are built
+// by the first call and reused by later ones, against a call without them
+func BenchmarkFilteredPools(b *testing.B) {
+	g := load_full_generator(b)
+	benchmarks := []struct {
+		name string
+		o    GenerateOptions
+	}{
+		{"Unfiltered", GenerateOptions{}},
+		{"WordLength", GenerateOptions{MinWordLength: 4, MaxWordLength: 8}},
+		{"ShortWordBias", GenerateOptions{ShortWordBias: 0.5}},
+		{"Agreement", GenerateOptions{Agreement: true}},
+		{"ExtraDenyWords", GenerateOptions{ExtraDenyWords: []string{"alice", "example"}}},
+		{"All", GenerateOptions{Prudish: true, MinWordLength: 4, MaxWordLength: 8, ShortWordBias: 0.5, Agreement: true, ExtraDenyWords: []string{"alice", "example"}}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				o := bm.o
+				if _, err := g.GeneratePassphrases(&o); err != nil {
+					b.Fatalf("Error generating passphrases: %v", err)
+				}
+			}
+		})
 	}
+}
+
+func BenchmarkRandomRange(b *testing.B) {
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
-		_, err := LoadGenerator(&wo)
+		test_rng.int_n(300000)
+	}
+}
+
+func TestNoSeparatorArtifacts(t *testing.T) {
+	words := []string{"otter", "", "two  words", " lead", "trail ", "st.", "~tilde~", "a/b/", "hell-bent"}
+	m := make(map[string][]string)
+	for _, wt := range word_types {
+		m[wt] = words
+	}
+	g := generator_for(m)
+	// Separators that words start or end with are rejected, not trimmed out of the words
+	separators := map[string]bool{"": false, " ": false, "-": false, "--": false, ".": true, "~": true, "~~": true, "/": true}
+	seps := make([]string, 0, len(separators))
+	for sep := range separators {
+		seps = append(seps, sep)
+	}
+	sort.Strings(seps)
+	bool_opt := func() bool { return test_rng.int_n(2) == 1 }
+
+	for i := 0; i < 2000; i++ {
+		o := GenerateOptions{
+			Count:                 uint(test_rng.int_n(3)) + 1,
+			Length:                uint(test_rng.int_n(30)) + 1,
+			Magic_fragment_length: uint(test_rng.int_n(8)) + 1,
+			No_spaces:             bool_opt(),
+			Add_digit:             bool_opt(),
+			Add_symbol:            bool_opt(),
+			Symbols:               []string{"!", "#"},
+			Separator:             seps[test_rng.int_n(int64(len(seps)))],
+		}
+		sep := o.Separator
+		if sep == "" {
+			sep = " "
+		}
+		q := regexp.QuoteMeta(sep)
+		re := regexp.MustCompile("^" + q + "|" + q + "$|" + q + q)
+		if o.No_spaces {
+			re = regexp.MustCompile(`\s`)
+		}
+
+		p, _, err := g.GeneratePassphrasesDetailed(&o)
+		if rejected := separators[o.Separator] && !o.No_spaces; rejected != errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("Separator %q: expected rejection %v, got %v", o.Separator, rejected, err)
+		} else if rejected {
+			continue
+		}
 		if err != nil {
-			b.Fatalf("Error loading wordlist: %v\n", err)
+			t.Fatalf("Error generating passphrases (options: %+v): %v", o, err)
+		}
+		for _, pp := range p {
+			phrase := strings.TrimRight(pp.Phrase, "0123456789!#")
+			if re.MatchString(phrase) {
+				t.Fatalf("Separator artifact in %q (options: %+v)", pp.Phrase, o)
+			}
+			for _, w := range pp.Words {
+				if w == "" {
+					t.Fatalf("Empty word in %q (options: %+v)", pp.Phrase, o)
+				}
+			}
+		}
+	}
+}
+
+func TestResolveOptions(t *testing.T) {
+	g := generator_for(uniform_word_map("otter"))
+
+	o, err := g.ResolveOptions(nil)
+	if err != nil {
+		t.Fatalf("Error resolving nil options: %v", err)
+	}
+	expected := GenerateOptions{
+		Count:                 count_default,
+		Length:                length_default,
+		Magic_fragment_length: fragment_default,
+		Fragments:             FragmentPolicy{TargetFragmentWords: fragment_default},
+		Symbols:               default_symbols,
+		Digits:                default_digits,
+		MaxSymbolLength:       symbol_length_default,
+		Separator:             " ",
+		MaxRetries:            retries_default,
+		Delimiter:             '\n',
+	}
+	if !reflect.DeepEqual(o, expected) {
+		t.Fatalf("Expected defaults %+v, got %+v", expected, o)
+	}
+
+	caller := GenerateOptions{Length: 7, Add_digit: true}
+	before := caller
+	o, err = g.ResolveOptions(&caller)
+	if err != nil {
+		t.Fatalf("Error resolving options: %v", err)
+	}
+	if o.Length != 7 || o.Count != count_default || !o.Add_digit {
+		t.Fatalf("Unexpected resolved options: %+v", o)
+	}
+	if _, err := g.GeneratePassphrases(&caller); err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	if !reflect.DeepEqual(caller, before) {
+		t.Fatalf("Caller options were modified: %+v", caller)
+	}
+
+	if _, err := g.ResolveOptions(&GenerateOptions{Count: count_max + 1}); !errors.Is(err, ErrCountExceedsMax) {
+		t.Fatalf("Expected ErrCountExceedsMax, got %v", err)
+	}
+	if _, err := (&Generator{}).ResolveOptions(nil); err != ErrWordlistNotLoaded {
+		t.Fatalf("Expected ErrWordlistNotLoaded, got %v", err)
+	}
+}
+
+func TestExcludeProperNouns(t *testing.T) {
+	g, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/proper.txt"})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	all := []string{"English", "NASA", "New York", "Pennsylvania", "TV", "otter"}
+	if words := g.GetWordMap()["snoun"]; !reflect.DeepEqual(words, all) {
+		t.Fatalf("Expected all nouns with ExcludeProperNouns off, got %v", words)
+	}
+	if n := g.Stats().ProperNouns; n != 0 {
+		t.Fatalf("Expected no proper nouns excluded, got %v", n)
+	}
+
+	g, err = LoadGenerator(&WordListOptions{Wordlist: "testdata/proper.txt", ExcludeProperNouns: true})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	m := g.GetWordMap()
+	if words := m["snoun"]; !reflect.DeepEqual(words, []string{"NASA", "TV", "otter"}) {
+		t.Fatalf("Unexpected nouns with ExcludeProperNouns on: %v", words)
+	}
+	if words := m["pnoun"]; !reflect.DeepEqual(words, []string{"otters"}) {
+		t.Fatalf("Unexpected plural nouns with ExcludeProperNouns on: %v", words)
+	}
+	if words := m["adjective"]; !reflect.DeepEqual(words, []string{"Brave"}) {
+		t.Fatalf("Capitalized non-noun should be kept: %v", words)
+	}
+	if n := g.Stats().ProperNouns; n != 4 {
+		t.Fatalf("Expected 4 proper nouns excluded, got %v", n)
+	}
+}
+
+func TestMinWordsPerType(t *testing.T) {
+	// testdata/pos.txt has at least 2 words of every type, and only 2 conjunctions
+	for _, min := range []uint{0, 1, 2} {
+		if _, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos.txt", MinWordsPerType: min}); err != nil {
+			t.Errorf("MinWordsPerType %v: unexpected error %v", min, err)
+		}
+	}
+	g := load_test_generator(t)
+	err := g.LoadWords(&WordListOptions{Wordlist: "testdata/pos.txt", MinWordsPerType: 3})
+	if !errors.Is(err, ErrTooFewWords) {
+		t.Fatalf("Expected ErrTooFewWords, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "conjunction (2)") || strings.Contains(err.Error(), "snoun") {
+		t.Errorf("Expected deficient types only, got %v", err)
+	}
+	if !g.words().loaded() || g.Stats().Words["snoun"] == 0 {
+		t.Errorf("Failed load should keep the previous word list")
+	}
+
+	// Pruned types are not in the grammar, so they are not counted
+	if _, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos3.txt", MinWordsPerType: 3}); !errors.Is(err, ErrTooFewWords) {
+		t.Errorf("Expected ErrTooFewWords for empty types, got %v", err)
+	}
+	if _, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos3.txt", MinWordsPerType: 3, PruneEmptyTypes: true}); err != nil {
+		t.Errorf("Unexpected error with pruning: %v", err)
+	}
+	if _, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos3.txt", MinWordsPerType: 4, PruneEmptyTypes: true}); !errors.Is(err, ErrTooFewWords) {
+		t.Errorf("Expected ErrTooFewWords above fixture counts, got %v", err)
+	}
+}
+
+func TestCasePrecedence(t *testing.T) {
+	m := make(map[string][]string)
+	for _, wt := range word_types {
+		m[wt] = []string{"OTTER"}
+	}
+	g := generator_for(m)
+
+	cases := []struct {
+		lowercase  bool
+		capitalize string
+		expected   string
+	}{
+		{false, "", "OTTER OTTER OTTER"},
+		{true, "", "otter otter otter"},
+		{false, "words", "OTTER OTTER OTTER"},
+		{true, "words", "Otter Otter Otter"},
+		{true, "sentence", "Otter otter otter"},
+	}
+	for _, c := range cases {
+		p, err := g.GeneratePassphrases(&GenerateOptions{Count: 1, Length: 3, Lowercase: c.lowercase, Capitalize: c.capitalize})
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		if p[0] != c.expected {
+			t.Errorf("Lowercase: %v, Capitalize: %q: expected %q, got %q", c.lowercase, c.capitalize, c.expected, p[0])
+		}
+	}
+
+	if _, err := g.GeneratePassphrases(&GenerateOptions{Capitalize: "title"}); !errors.Is(err, ErrUnknownCapitalize) {
+		t.Fatalf("Expected ErrUnknownCapitalize, got %v", err)
+	}
+}
+
+func TestMaxChars(t *testing.T) {
+	m := make(map[string][]string)
+	for _, wt := range word_types {
+		m[wt] = []string{"a", "otter"}
+	}
+	g := generator_for(m)
+
+	p, err := g.GeneratePassphrases(&GenerateOptions{Count: 20, Length: 3, MaxChars: 7, Add_digit: true})
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	for _, pp := range p {
+		if len(pp) > 7 {
+			t.Fatalf("Passphrase exceeds MaxChars: %q", pp)
+		}
+	}
+
+	_, err = g.GeneratePassphrases(&GenerateOptions{Length: 3, MaxChars: 4, MaxRetries: 10})
+	if err != ErrRetriesExhausted {
+		t.Fatalf("Expected ErrRetriesExhausted, got %v", err)
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	m := make(map[string][]string)
+	for _, wt := range word_types {
+		m[wt] = []string{"\u00e9t\u00e9", "otterhound"} // 5 and 10 bytes
+	}
+	g := generator_for(m)
+	o := GenerateOptions{Count: 20, Length: 1, Add_symbol: true, Symbols: []string{"\u20ac"}, MaxBytes: 8}
+	p, err := g.GeneratePassphrases(&o)
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	for _, pp := range p {
+		if pp != "\u00e9t\u00e9\u20ac" {
+			t.Fatalf("Expected the only passphrase within 8 bytes, got %q", pp)
+		}
+	}
+
+	o.MaxBytes = 7
+	if _, err := g.GeneratePassphrases(&o); !errors.Is(err, ErrMaxBytesTooSmall) {
+		t.Fatalf("Expected ErrMaxBytesTooSmall, got %v", err)
+	}
+	// Each word fits, but no two words with a separator and symbol do
+	o.Length, o.MaxBytes, o.MaxRetries = 2, 13, 10
+	if _, err := g.GeneratePassphrases(&o); err != ErrRetriesExhausted {
+		t.Fatalf("Expected ErrRetriesExhausted, got %v", err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	g := load_test_generator(t)
+
+	start := time.Now()
+	p, err := g.GeneratePassphrases(&GenerateOptions{
+		Length:     20,
+		MaxChars:   1, // impossible
+		MaxRetries: 1 << 30,
+		Timeout:    50 * time.Millisecond,
+	})
+	if err != ErrDeadlineExceeded {
+		t.Fatalf("Expected ErrDeadlineExceeded, got %v", err)
+	}
+	if p != nil {
+		t.Fatalf("Expected no passphrases, got %v", p)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("Generation did not stop promptly: %v", elapsed)
+	}
+
+	p, err = g.GeneratePassphrases(&GenerateOptions{Count: 10, Timeout: time.Minute})
+	if err != nil || len(p) != 10 {
+		t.Fatalf("Expected 10 passphrases within timeout, got %v (err: %v)", len(p), err)
+	}
+}
+
+func TestAllowPartial(t *testing.T) {
+	g := load_test_generator(t)
+
+	// A scorer that stalls past the Timeout on the third passphrase's last candidate, so the
+	// fourth passphrase fails
+	var scored []string
+	stall := func(p Passphrase) float64 {
+		if scored = append(scored, p.Phrase); len(scored) == 6 {
+			time.Sleep(300 * time.Millisecond)
+		}
+		return 0
+	}
+	o := GenerateOptions{Count: 10, BestOf: 2, Scorer: stall, Timeout: 200 * time.Millisecond, AllowPartial: true}
+	p, err := g.GeneratePassphrases(&o)
+	var pe *BatchError
+	if !errors.As(err, &pe) || !errors.Is(err, ErrDeadlineExceeded) {
+		t.Fatalf("Expected a BatchError wrapping ErrDeadlineExceeded, got %v", err)
+	}
+	// With a constant score, each passphrase is its first candidate
+	expected := []string{scored[0], scored[2], scored[4]}
+	if pe.Index != 3 || !reflect.DeepEqual(pe.Passphrases, expected) || !reflect.DeepEqual(p, expected) {
+		t.Fatalf("Expected passphrases %q before index 3, got %q before %v (returned %q)", expected, pe.Passphrases, pe.Index, p)
+	}
+	for _, phrase := range expected {
+		if strings.Contains(err.Error(), phrase) {
+			t.Errorf("Passphrase %q in error message %q", phrase, err)
+		}
+	}
+
+	// A failure on the first passphrase is partial too, with nothing generated
+	o = GenerateOptions{Count: 3, MaxChars: 1, MaxRetries: 4, AllowPartial: true}
+	p, err = g.GeneratePassphrases(&o)
+	if !errors.As(err, &pe) || !errors.Is(err, ErrRetriesExhausted) || pe.Index != 0 || len(pe.Passphrases) != 0 || len(p) != 0 {
+		t.Errorf("Expected a BatchError at index 0 wrapping ErrRetriesExhausted, got %q, %v", p, err)
+	}
+	// Without AllowPartial, nothing is returned
+	o.AllowPartial = false
+	if p, err = g.GeneratePassphrases(&o); p != nil || err != ErrRetriesExhausted {
+		t.Errorf("Expected ErrRetriesExhausted and no passphrases, got %q, %v", p, err)
+	}
+}
+
+func TestInsecureFastRandom(t *testing.T) {
+	g := generator_for(uniform_word_map("otter"))
+	if src, ok := g.source(&GenerateOptions{}).(reader_source); !ok || src.r != rand.Reader {
+		t.Fatalf("Expected crypto/rand source by default, got %#v", g.source(&GenerateOptions{}))
+	}
+	p, _, err := g.GeneratePassphrasesDetailed(nil)
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	for _, pp := range p {
+		if pp.Insecure {
+			t.Fatalf("Default passphrase marked insecure: %+v", pp)
+		}
+	}
+
+	o := GenerateOptions{InsecureFastRandom: true}
+	if _, ok := g.source(&o).(fast_source); !ok {
+		t.Fatalf("Expected fast source, got %#v", g.source(&o))
+	}
+	p, _, err = g.GeneratePassphrasesDetailed(&o)
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	for _, pp := range p {
+		if !pp.Insecure || pp.Phrase == "" {
+			t.Fatalf("Expected insecure passphrase, got %+v", pp)
+		}
+	}
+}
+
+func TestBestOf(t *testing.T) {
+	m := uniform_word_map("ox")
+	m["snoun"] = []string{"ox", "emu", "otter", "badger", "hippopotamus"}
+	m["adjective"] = []string{"red", "brave", "quiet", "enormous"}
+	g := generator_for(m)
+
+	var candidates []string
+	longest := func(p Passphrase) float64 {
+		candidates = append(candidates, p.Phrase)
+		return float64(len(p.Phrase))
+	}
+	g.rand = mrand.NewChaCha8([32]byte{5})
+	p, err := g.GeneratePassphrases(&GenerateOptions{Count: 10, Length: 6, BestOf: 5, Scorer: longest})
+	if err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+	if len(p) != 10 || len(candidates) != 50 {
+		t.Fatalf("Expected 10 passphrases from 50 candidates, got %v from %v", len(p), len(candidates))
+	}
+	for i := range p {
+		best := ""
+		for _, c := range candidates[i*5 : i*5+5] {
+			if len(c) > len(best) {
+				best = c
+			}
+		}
+		if p[i] != best {
+			t.Errorf("Passphrase %v: expected longest candidate %q, got %q", i, best, p[i])
+		}
+	}
+
+	// Candidates are ordinary draws from the same stream
+	g.rand = mrand.NewChaCha8([32]byte{5})
+	plain, err := g.GeneratePassphrases(&GenerateOptions{Count: 50, Length: 6})
+	if err != nil || !reflect.DeepEqual(plain, candidates) {
+		t.Errorf("Expected candidates %q to match plain generation %q (%v)", candidates, plain, err)
+	}
+
+	// Without a scorer, candidates are ranked by the entropy of their word choices
+	s, err := g.prepare(&GenerateOptions{}, g.words(), nil)
+	if err != nil {
+		t.Fatalf("Error preparing state: %v", err)
+	}
+	pp := Passphrase{Types: []string{"snoun", "verb", "adjective", "snoun", "snoun"}, Entries: []int{1, 1, 1, 2}}
+	if bits := s.measured_entropy(pp); bits != math.Log2(5)+0+2+math.Log2(5) {
+		t.Errorf("Unexpected measured entropy %v", bits)
+	}
+	g.rand = nil
+	if _, err := g.GeneratePassphrases(&GenerateOptions{Count: 5, BestOf: 3}); err != nil {
+		t.Errorf("Error generating with the default scorer: %v", err)
+	}
+
+	// Regenerations for all candidates share MaxRetries
+	g.ResetCounters()
+	candidates = nil
+	_, err = g.GeneratePassphrases(&GenerateOptions{Count: 1, BestOf: 3, MaxChars: 1, MaxRetries: 4, Scorer: longest})
+	if err != ErrRetriesExhausted || len(candidates) != 0 || g.Counters().Retries != 4 {
+		t.Errorf("Expected ErrRetriesExhausted after 4 retries, got %v, %v retries", err, g.Counters().Retries)
+	}
+}
+
+// Digits and symbols come from the same source as the words, so a seeded source reproduces
+// whole passphrases
+func TestSeededPadding(t *testing.T) {
+	g, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos.txt"})
+	if err != nil {
+		t.Fatalf("Could not load wordlist: %v", err)
+	}
+	golden := []string{"she but Damn0(", "those quiet badgers3(", "runs but those3*", "wow and these5(", "oh and otter5!"}
+	for i := 0; i < 2; i++ {
+		g.rand = mrand.NewChaCha8([32]byte{5})
+		p, err := g.GeneratePassphrases(&GenerateOptions{Count: 5, Length: 3, Add_digit: true, Add_symbol: true})
+		if err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+		if !reflect.DeepEqual(p, golden) {
+			t.Errorf("Run %v: expected %q, got %q", i, golden, p)
 		}
 	}
 }
@@ -0,0 +1,155 @@
+package wordlist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Wordlist parser. The zero value is lenient: malformed lines are skipped and listed in the
+// Report.
+type Parser struct {
+	Path               string  // wordlist path, only used in errors
+	Strict             bool    // fail with a ParseError on the first malformed line instead of skipping it
+	ExcludeProperNouns bool    // drop capitalized nouns (e.g. "Pennsylvania") from POS wordlists; all-caps acronyms are kept
+	IgnoreUnknownTypes bool    // skip unknown word types in JSON wordlists instead of failing
+	Classification     []Class // table classifying the tags of POS wordlists (nil = the default Classification)
+}
+
+// What a parse found besides the words
+type Report struct {
+	Lines       int             // lines read (0 for JSON)
+	Malformed   []*ParseError   // malformed lines skipped, in order (always empty with Strict)
+	ProperNouns uint            // proper nouns dropped by ExcludeProperNouns
+	UnknownTags map[string]uint // unclassified POS tags and the number of lines with each
+}
+
+// Report a malformed line: returned as the error if strict, otherwise recorded
+func (p *Parser) malformed(rep *Report, pe *ParseError) error {
+	if p.Strict {
+		return pe
+	}
+	rep.Malformed = append(rep.Malformed, pe)
+	return nil
+}
+
+// Parse a POS wordlist: one "word<TAB>tags" line per word, with tags classified per the
+// Parser's classification table. The map has every known word type, possibly without words.
+func (p *Parser) ParsePOS(r io.Reader) (Map, Report, error) {
+	var rep Report
+	table := Classification
+	if p.Classification != nil {
+		if err := CheckClassification(p.Classification); err != nil {
+			return nil, rep, &ParseError{Path: p.Path, Reason: err.Error(), Err: err}
+		}
+		table = p.Classification
+	}
+	word_map := make(Map, len(word_types))
+	for _, t := range word_types {
+		word_map[t] = []string{}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		rep.Lines++
+		line := scanner.Text()
+		columns := strings.Split(line, "\t")
+		if len(columns) != 2 {
+			if err := p.malformed(&rep, &ParseError{Path: p.Path, Line: rep.Lines, Reason: fmt.Sprintf("expected 2 tab-separated columns, got %v: %v", len(columns), Excerpt(line))}); err != nil {
+				return nil, rep, err
+			}
+			continue
+		}
+		word, tag := columns[0], columns[1]
+		word_type := ClassifyWith(table, tag)
+		if word_type == "" {
+			if rep.UnknownTags == nil {
+				rep.UnknownTags = make(map[string]uint)
+			}
+			rep.UnknownTags[tag]++
+			if err := p.malformed(&rep, &ParseError{Path: p.Path, Line: rep.Lines, Reason: fmt.Sprintf("unknown part of speech %q: %v", tag, Excerpt(line)), Err: ErrUnknownWordType}); err != nil {
+				return nil, rep, err
+			}
+			continue
+		}
+		if p.ExcludeProperNouns && (word_type == "snoun" || word_type == "pnoun") && is_proper_noun(word) {
+			rep.ProperNouns++
+			continue
+		}
+		if word == "" {
+			if err := p.malformed(&rep, &ParseError{Path: p.Path, Line: rep.Lines, Reason: fmt.Sprintf("zero-length %v: %v", word_type, Excerpt(line)), Err: ErrEmptyWord}); err != nil {
+				return nil, rep, err
+			}
+			continue
+		}
+		word_map[word_type] = append(word_map[word_type], word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, rep, &ParseError{Path: p.Path, Line: rep.Lines + 1, Reason: err.Error(), Err: err}
+	}
+	return word_map, rep, nil
+}
+
+// Parse a plain wordlist of words of one type, one per line. Surrounding whitespace is
+// trimmed and blank lines are skipped.
+func (p *Parser) ParsePlain(r io.Reader, word_type string) (Map, Report, error) {
+	var rep Report
+	if !IsType(word_type) {
+		return nil, rep, fmt.Errorf("%w: %v", ErrUnknownWordType, word_type)
+	}
+	words := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		rep.Lines++
+		if word := strings.TrimSpace(scanner.Text()); word != "" {
+			words = append(words, word)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, rep, &ParseError{Path: p.Path, Line: rep.Lines + 1, Reason: err.Error(), Err: err}
+	}
+	return Map{word_type: words}, rep, nil
+}
+
+// Parse a JSON wordlist: an object mapping word types to non-empty arrays of words
+func (p *Parser) ParseJSON(r io.Reader) (Map, Report, error) {
+	var rep Report
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, rep, &ParseError{Path: p.Path, Reason: err.Error(), Err: err}
+	}
+
+	word_map := Map{}
+	if err := json.Unmarshal(data, &word_map); err != nil {
+		pe := &ParseError{Path: p.Path, Reason: err.Error(), Err: err}
+		var se *json.SyntaxError
+		if errors.As(err, &se) {
+			pe.Line = 1 + bytes.Count(data[:se.Offset], []byte("\n"))
+		}
+		return nil, rep, pe
+	}
+	for t, words := range word_map {
+		if !IsType(t) {
+			if p.IgnoreUnknownTypes {
+				delete(word_map, t)
+				continue
+			}
+			return nil, rep, &ParseError{Path: p.Path, Reason: fmt.Sprintf("%v: %v", ErrUnknownWordType, t), Err: ErrUnknownWordType}
+		}
+		if len(words) == 0 {
+			return nil, rep, &ParseError{Path: p.Path, Reason: fmt.Sprintf("%v: %v", ErrEmptyWordType, t), Err: ErrEmptyWordType}
+		}
+	}
+	return word_map, rep, nil
+}
+
+func is_proper_noun(word string) bool {
+	r, _ := utf8.DecodeRuneInString(word)
+	return unicode.IsUpper(r) && strings.ToUpper(word) != word
+}
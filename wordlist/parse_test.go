@@ -0,0 +1,104 @@
+package wordlist
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parse_error(t *testing.T, err error) *ParseError {
+	t.Helper()
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expected ParseError, got %v", err)
+	}
+	return pe
+}
+
+func TestParsePOS(t *testing.T) {
+	f, err := os.Open("../testdata/corrupt.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	p := Parser{Path: "corrupt.txt"}
+	m, rep, err := p.ParsePOS(f)
+	if err != nil {
+		t.Fatalf("Expected lenient parse to succeed: %v", err)
+	}
+	if len(m) != len(word_types) {
+		t.Errorf("Expected every word type in the map, got %v", m)
+	}
+	for typ, expected := range map[string][]string{"snoun": {"otter"}, "verb": {"runs"}, "adverb": {"quickly"}, "sarticle": {"the"}} {
+		if !reflect.DeepEqual(m[typ], expected) {
+			t.Errorf("%v: expected %v, got %v", typ, expected, m[typ])
+		}
+	}
+	if rep.Lines != 7 || len(rep.Malformed) != 3 || rep.UnknownTags["Q"] != 1 {
+		t.Fatalf("Expected 7 lines with 3 malformed, got %+v", rep)
+	}
+	for i, expected := range []struct {
+		line int
+		err  error
+	}{{2, nil}, {4, ErrEmptyWord}, {6, ErrUnknownWordType}} {
+		if pe := rep.Malformed[i]; pe.Path != "corrupt.txt" || pe.Line != expected.line || pe.Err != expected.err {
+			t.Errorf("Unexpected malformed line %#v", pe)
+		}
+	}
+
+	p.Strict = true
+	_, _, err = p.ParsePOS(strings.NewReader("otter\tN\nbadger N\n"))
+	if pe := parse_error(t, err); pe.Line != 2 {
+		t.Errorf("Expected line 2, got %#v", pe)
+	}
+
+	p = Parser{ExcludeProperNouns: true}
+	m, rep, err = p.ParsePOS(strings.NewReader("Pennsylvania\tN\nNASA\tN\notter\tN\nBrave\tA\n"))
+	if err != nil || rep.ProperNouns != 1 || !reflect.DeepEqual(m["snoun"], []string{"NASA", "otter"}) || len(m["adjective"]) != 1 {
+		t.Errorf("Unexpected proper noun handling: %v, %+v, %v", m, rep, err)
+	}
+}
+
+func TestParsePlain(t *testing.T) {
+	var p Parser
+	m, rep, err := p.ParsePlain(strings.NewReader("otter\n\n  badger \n"), "snoun")
+	if err != nil || rep.Lines != 3 || !reflect.DeepEqual(m, Map{"snoun": {"otter", "badger"}}) {
+		t.Errorf("Unexpected result %v, %+v, %v", m, rep, err)
+	}
+	if _, _, err := p.ParsePlain(strings.NewReader("otter\n"), "noun"); !errors.Is(err, ErrUnknownWordType) {
+		t.Errorf("Expected ErrUnknownWordType, got %v", err)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	p := Parser{Path: "words.json"}
+	m, _, err := p.ParseJSON(strings.NewReader(`{"snoun": ["otter"], "verb": ["runs"]}`))
+	if err != nil || !reflect.DeepEqual(m, Map{"snoun": {"otter"}, "verb": {"runs"}}) {
+		t.Errorf("Unexpected result %v, %v", m, err)
+	}
+
+	f, err := os.Open("../testdata/bad.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	_, _, err = p.ParseJSON(f)
+	if pe := parse_error(t, err); pe.Path != "words.json" || pe.Line != 3 {
+		t.Errorf("Expected line 3, got %#v", pe)
+	}
+
+	_, _, err = p.ParseJSON(strings.NewReader(`{"noun": ["otter"]}`))
+	if pe := parse_error(t, err); pe.Err != ErrUnknownWordType {
+		t.Errorf("Expected ErrUnknownWordType, got %#v", pe)
+	}
+	p.IgnoreUnknownTypes = true
+	if m, _, err := p.ParseJSON(strings.NewReader(`{"noun": ["otter"], "verb": ["runs"]}`)); err != nil || len(m) != 1 {
+		t.Errorf("Expected the unknown type skipped, got %v, %v", m, err)
+	}
+	_, _, err = p.ParseJSON(strings.NewReader(`{"verb": []}`))
+	if pe := parse_error(t, err); pe.Err != ErrEmptyWordType {
+		t.Errorf("Expected ErrEmptyWordType, got %#v", pe)
+	}
+}
@@ -0,0 +1,146 @@
+// Package wordlist parses the word lists used by libwordentropy: POS wordlists as
+// described at http://wordlist.aspell.net/pos-readme, plain lists of one word per line and
+// JSON objects mapping word types to words. It has no dependency on passphrase generation,
+// so it can be used on its own, e.g. by a wordlist linter.
+package wordlist
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnknownWordType       = errors.New("Unknown word type")
+	ErrEmptyWord             = errors.New("Zero-length word")
+	ErrEmptyWordType         = errors.New("No words for word type")
+	ErrInvalidClassification = errors.New("Invalid classification table")
+)
+
+// Map of word type to words of that type
+type Map map[string][]string
+
+var word_types = []string{"snoun", "pnoun", "verb", "adjective", "adverb", "preposition", "pronoun", "conjunction", "sarticle", "particle", "interjection"}
+
+// Word types known to the grammar, in a fixed order
+func Types() []string {
+	return append([]string{}, word_types...)
+}
+
+// Whether t is a known word type
+func IsType(t string) bool {
+	for _, known := range word_types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// One row of a POS classification table: a tag containing any of Tags is of type Singular,
+// or Plural (if set) when the tag also contains every one of PluralTags
+type Class struct {
+	Tags       string
+	Singular   string
+	Plural     string
+	PluralTags string
+}
+
+// How POS tags map to word types. Rows are tried in order and the first match wins, so a
+// tag with several parts of speech ("NV") gets the type of the earliest row. A tag marks a
+// plural if it contains "P" along with "N", "D" or "I".
+var Classification = []Class{
+	{Tags: "DI", Singular: "sarticle", Plural: "particle", PluralTags: "P"},
+	{Tags: "Nho", Singular: "snoun", Plural: "pnoun", PluralTags: "NP"},
+	{Tags: "Vti", Singular: "verb"},
+	{Tags: "A", Singular: "adjective"},
+	{Tags: "v", Singular: "adverb"},
+	{Tags: "C", Singular: "conjunction"},
+	{Tags: "pP", Singular: "preposition"},
+	{Tags: "r", Singular: "pronoun"},
+	{Tags: "!", Singular: "interjection"},
+}
+
+// Word type of a POS tag per the Classification table, or "" if no row matches
+func Classify(tag string) string {
+	return ClassifyWith(Classification, tag)
+}
+
+// Word type of a POS tag per a classification table, or "" if no row matches
+func ClassifyWith(table []Class, tag string) string {
+	for _, c := range table {
+		if !strings.ContainsAny(tag, c.Tags) {
+			continue
+		}
+		if c.Plural != "" && contains_all(tag, c.PluralTags) {
+			return c.Plural
+		}
+		return c.Singular
+	}
+	return ""
+}
+
+// Check that every row of a classification table has tags and only known word types
+func CheckClassification(table []Class) error {
+	for i, c := range table {
+		switch {
+		case c.Tags == "":
+			return fmt.Errorf("%w: row %v has no tags", ErrInvalidClassification, i)
+		case !IsType(c.Singular):
+			return fmt.Errorf("%w: %q in classification row %v", ErrUnknownWordType, c.Singular, i)
+		case c.Plural != "" && !IsType(c.Plural):
+			return fmt.Errorf("%w: %q in classification row %v", ErrUnknownWordType, c.Plural, i)
+		}
+	}
+	return nil
+}
+
+func contains_all(s string, chars string) bool {
+	for _, r := range chars {
+		if !strings.ContainsRune(s, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Longest copy of an offending line kept in a ParseError, in runes
+const excerpt_length = 40
+
+// Error loading a wordlist file, locating the problem as precisely as the format allows
+type ParseError struct {
+	Path   string // wordlist path ("" if read from a reader)
+	Line   int    // 1-based line number (0 if the problem is not tied to a line)
+	Reason string // what was wrong, with a truncated copy of the line where there is one
+	Err    error  // underlying error or sentinel such as ErrUnknownWordType (may be nil)
+}
+
+func (e *ParseError) Error() string {
+	switch {
+	case e.Line == 0:
+		return fmt.Sprintf("%v: %v", e.Path, e.Reason)
+	case e.Path == "":
+		return fmt.Sprintf("line %v: %v", e.Line, e.Reason)
+	}
+	return fmt.Sprintf("%v:%v: %v", e.Path, e.Line, e.Reason)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Quoted copy of a line for error messages, truncated to 40 runes
+func Excerpt(line string) string {
+	if utf8.RuneCountInString(line) <= excerpt_length {
+		return fmt.Sprintf("%q", line)
+	}
+	n := 0
+	for i := range line {
+		if n == excerpt_length {
+			return fmt.Sprintf("%q...", line[:i])
+		}
+		n++
+	}
+	return fmt.Sprintf("%q", line)
+}
@@ -0,0 +1,150 @@
+package wordlist
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	cases := map[string]string{
+		"N":   "snoun",
+		"NP":  "pnoun",
+		"h":   "snoun",
+		"hP":  "snoun", // plural only with N, D or I
+		"D":   "sarticle",
+		"IP":  "particle",
+		"V":   "verb",
+		"t":   "verb",
+		"A":   "adjective",
+		"v":   "adverb",
+		"C":   "conjunction",
+		"P":   "preposition",
+		"r":   "pronoun",
+		"!":   "interjection",
+		"NV":  "snoun", // first matching row wins
+		"Q":   "",
+		"":    "",
+		"VNA": "snoun",
+	}
+	for tag, expected := range cases {
+		if got := Classify(tag); got != expected {
+			t.Errorf("%q: expected %q, got %q", tag, expected, got)
+		}
+	}
+	for _, c := range Classification {
+		if !IsType(c.Singular) || (c.Plural != "" && !IsType(c.Plural)) {
+			t.Errorf("Unknown word type in %+v", c)
+		}
+	}
+}
+
+func TestClassifyWith(t *testing.T) {
+	// Tags of a made-up format: n or v, with s marking plural nouns
+	table := []Class{
+		{Tags: "n", Singular: "snoun", Plural: "pnoun", PluralTags: "s"},
+		{Tags: "v", Singular: "verb"},
+	}
+	if err := CheckClassification(table); err != nil {
+		t.Fatalf("Unexpected error checking table: %v", err)
+	}
+	for tag, expected := range map[string]string{"n": "snoun", "ns": "pnoun", "v": "verb", "vs": "verb", "nv": "snoun", "N": "", "s": ""} {
+		if got := ClassifyWith(table, tag); got != expected {
+			t.Errorf("%q: expected %q, got %q", tag, expected, got)
+		}
+	}
+
+	for _, bad := range [][]Class{
+		{{Tags: "n", Singular: "noun"}},
+		{{Tags: "n", Singular: "snoun", Plural: "nouns", PluralTags: "s"}},
+		{{Singular: "snoun"}},
+	} {
+		if err := CheckClassification(bad); err == nil {
+			t.Errorf("Expected an error for %+v", bad)
+		}
+	}
+	if err := CheckClassification(Classification); err != nil {
+		t.Errorf("Error checking the default table: %v", err)
+	}
+}
+
+// Classification before it was a table of rules, for TestDefaultClassification
+func classify_legacy(tag string) string {
+	plural := strings.Contains(tag, "P") && strings.ContainsAny(tag, "NDI")
+	switch {
+	case strings.ContainsAny(tag, "DI"):
+		if plural {
+			return "particle"
+		}
+		return "sarticle"
+	case strings.ContainsAny(tag, "Nho"):
+		if plural {
+			return "pnoun"
+		}
+		return "snoun"
+	case strings.ContainsAny(tag, "Vti"):
+		return "verb"
+	case strings.ContainsAny(tag, "A"):
+		return "adjective"
+	case strings.ContainsAny(tag, "v"):
+		return "adverb"
+	case strings.ContainsAny(tag, "C"):
+		return "conjunction"
+	case strings.ContainsAny(tag, "pP"):
+		return "preposition"
+	case strings.ContainsAny(tag, "r"):
+		return "pronoun"
+	case strings.ContainsAny(tag, "!"):
+		return "interjection"
+	}
+	return ""
+}
+
+func TestDefaultClassification(t *testing.T) {
+	// A word for every distinct tag in the bundled wordlist
+	f, err := os.Open("../testdata/pos_tags.txt")
+	if err != nil {
+		t.Fatalf("Could not open fixture: %v", err)
+	}
+	defer f.Close()
+	tags := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if _, tag, ok := strings.Cut(scanner.Text(), "\t"); ok {
+			tags[tag] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Error reading fixture: %v", err)
+	}
+	if len(tags) < 100 {
+		t.Fatalf("Expected the fixture to have many distinct tags, got %v", len(tags))
+	}
+	for tag := range tags {
+		if got, expected := Classify(tag), classify_legacy(tag); got != expected {
+			t.Errorf("%q: expected %q, got %q", tag, expected, got)
+		}
+	}
+}
+
+func TestTypes(t *testing.T) {
+	types := Types()
+	if len(types) != 11 || types[0] != "snoun" {
+		t.Fatalf("Unexpected types %v", types)
+	}
+	types[0] = "noun"
+	if Types()[0] != "snoun" || IsType("noun") {
+		t.Fatalf("Expected Types to return a copy")
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	if e := Excerpt("otter\tN"); e != `"otter\tN"` {
+		t.Errorf("Unexpected excerpt %v", e)
+	}
+	long := strings.Repeat("ö", 100)
+	if e := Excerpt(long); e != `"`+strings.Repeat("ö", excerpt_length)+`"...` {
+		t.Errorf("Unexpected excerpt %v", e)
+	}
+}
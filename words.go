@@ -0,0 +1,414 @@
+package wordentropy
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// Loaded word list and everything derived from it. A word_data is never modified after it
+// is published, so generation uses it without locking; reloading publishes a new one, which
+// also discards all derived indexes at once.
+type word_data struct {
+	word_map           map[string][]string // nil if lazy
+	lazy               *lazy_words         // binary wordlist loaded with Lazy (nil otherwise)
+	offensive          map[string]uint     // severity of each offensive word (nil if no offensive list was loaded)
+	categories         map[string][]string // sorted categories of each categorized offensive word (nil if none are)
+	common             *phrase_trie        // phrases rejected by AvoidCommonPhrases (nil if no list was loaded)
+	verb_exceptions    map[string]bool     // VerbExceptions, lowercased (nil if none)
+	grammar            map[string][]string // grammar rules after pruning (nil for the defaults)
+	types              []string            // word types a fragment may start with (nil for the defaults)
+	pruned             []string            // word types removed from the grammar
+	proper_excluded    uint                // proper nouns dropped by ExcludeProperNouns
+	reject_unprintable bool                // RejectNonPrintable, also applied to AddWords
+	warnings           []string            // from sanity_warnings when loaded
+	indexes            index_registry
+	derived_pools      pool_cache
+	usage              derived_usage // memory held by indexes and derived_pools
+}
+
+var empty_word_data = &word_data{}
+
+// Word list load requested with Deferred, run once by the first call that needs the words
+type deferred_load struct {
+	once sync.Once
+	o    WordListOptions
+	err  error // load error, returned by every later generation call
+}
+
+// Current word list snapshot (never nil), loading a deferred word list first
+func (g *Generator) words() *word_data {
+	if l := g.deferred.Load(); l != nil {
+		l.once.Do(func() {
+			defer recover_internal(&l.err)
+			// Loaded aside and published only if no later load has replaced this one
+			loader := &Generator{}
+			if l.err = loader.load_words(&l.o); l.err != nil {
+				return
+			}
+			g.Lock()
+			defer g.Unlock()
+			if g.deferred.Load() == l {
+				d := loader.data.Load()
+				d.usage.budget = &g.index_budget
+				g.data.Store(d)
+			}
+		})
+	}
+	if d := g.data.Load(); d != nil {
+		return d
+	}
+	return empty_word_data
+}
+
+// Whether a word list has been loaded
+func (d *word_data) loaded() bool {
+	return d.word_map != nil || d.lazy != nil
+}
+
+// Number of words of a type, and whether the type is in the word list
+func (d *word_data) count(word_type string) (int, bool) {
+	if d.lazy != nil {
+		offsets, ok := d.lazy.offsets[word_type]
+		return len(offsets), ok
+	}
+	words, ok := d.word_map[word_type]
+	return len(words), ok
+}
+
+// Word map, copied out of a lazily loaded binary wordlist on first use
+func (d *word_data) all() map[string][]string {
+	if d.lazy == nil {
+		return d.word_map
+	}
+	return d.index("words", func(d *word_data) interface{} {
+		return d.lazy.materialize()
+	}).(map[string][]string)
+}
+
+// Grammar rules in effect (the defaults unless pruned)
+func (d *word_data) rules() map[string][]string {
+	if d.grammar != nil {
+		return d.grammar
+	}
+	return grammar_rules
+}
+
+// Word types a fragment may start with
+func (d *word_data) start_types() []string {
+	if d.types != nil {
+		return d.types
+	}
+	return word_types
+}
+
+// Lazily built structures derived from a word list snapshot. Each index is built at most
+// once, by whichever caller asks for it first; concurrent callers wait for that build.
+type index_registry struct {
+	entries map[string]*index_entry
+	builds  int64 // number of index builds, for tests
+	sync.Mutex
+}
+
+type index_entry struct {
+	once  sync.Once
+	value interface{}
+	item  derived_item
+}
+
+// Return the named index, building it on first use or after it was evicted
+func (d *word_data) index(name string, build func(*word_data) interface{}) interface{} {
+	r := &d.indexes
+	r.Lock()
+	if r.entries == nil {
+		r.entries = make(map[string]*index_entry)
+	}
+	e, ok := r.entries[name]
+	if !ok {
+		e = &index_entry{}
+		r.entries[name] = e
+	}
+	r.Unlock()
+
+	e.once.Do(func() {
+		atomic.AddInt64(&r.builds, 1)
+		e.value = build(d)
+		// Words copied out of a lazy list are the only strings not shared with the list
+		d.usage.add(&e.item, approx_size(e.value, name == "words"), func() { r.remove(name, e) })
+	})
+	d.usage.touch(&e.item)
+	return e.value
+}
+
+// Drop an evicted index
+func (r *index_registry) remove(name string, e *index_entry) {
+	r.Lock()
+	defer r.Unlock()
+	if r.entries[name] == e {
+		delete(r.entries, name)
+	}
+}
+
+// Most option-dependent pools kept per word list snapshot
+const pool_cache_size = 256
+
+// Pools derived from a word list snapshot for particular option values (see pools_key),
+// shared by every call with the same values. Unlike indexes, their keys come from the
+// caller's options, so only the most recently used are kept.
+type pool_cache struct {
+	entries map[string]*list.Element // of *pool_entry
+	order   list.List                // most recently used first
+	builds  int64                    // number of pool builds, for tests
+	sync.Mutex
+}
+
+type pool_entry struct {
+	key   string
+	once  sync.Once
+	value interface{}
+	item  derived_item
+}
+
+// Return the pool cached under key, building it on first use and evicting the least
+// recently used pool if the cache is full (or the index budget is exceeded)
+func (d *word_data) derived(key string, build func() interface{}) interface{} {
+	c := &d.derived_pools
+	c.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string]*list.Element)
+	}
+	var e, evicted *pool_entry
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		e = el.Value.(*pool_entry)
+	} else {
+		e = &pool_entry{key: key}
+		c.entries[key] = c.order.PushFront(e)
+		if c.order.Len() > pool_cache_size {
+			evicted = c.order.Remove(c.order.Back()).(*pool_entry)
+			delete(c.entries, evicted.key)
+		}
+	}
+	c.Unlock()
+	if evicted != nil {
+		d.usage.forget(&evicted.item)
+	}
+
+	e.once.Do(func() {
+		atomic.AddInt64(&c.builds, 1)
+		e.value = build()
+		d.usage.add(&e.item, approx_size(e.value, false), func() { c.remove(e) })
+	})
+	d.usage.touch(&e.item)
+	return e.value
+}
+
+// Drop an evicted pool
+func (c *pool_cache) remove(e *pool_entry) {
+	c.Lock()
+	defer c.Unlock()
+	if el, ok := c.entries[e.key]; ok && el.Value == e {
+		c.order.Remove(el)
+		delete(c.entries, e.key)
+	}
+}
+
+// Offensive words filtered for a call: those listed at a severity level or above (0 = no
+// filtering) and, if categories are given, in at least one of them
+type offense_filter struct {
+	level      uint
+	categories []string // sorted, without duplicates (nil = every word, categorized or not)
+}
+
+// Filter of every offensive word at a severity level or above
+func at_level(level uint) offense_filter {
+	return offense_filter{level: level}
+}
+
+// Name of the filter in index and pool keys
+func (f offense_filter) String() string {
+	if f.categories == nil {
+		return fmt.Sprint(f.level)
+	}
+	return fmt.Sprintf("%v/%v", f.level, strings.Join(f.categories, ","))
+}
+
+// Whether the filter removes a word map entry: the entry or one of its component words is
+// listed at the filter's severity or above, in one of its categories if it has any
+func (d *word_data) offends(word string, f offense_filter) bool {
+	if f.categories == nil {
+		return offensive_level(word, d.offensive) >= f.level
+	}
+	listed := func(w string) bool {
+		if d.offensive[w] < f.level {
+			return false
+		}
+		for _, c := range d.categories[w] {
+			if i := sort.SearchStrings(f.categories, c); i < len(f.categories) && f.categories[i] == c {
+				return true
+			}
+		}
+		return false
+	}
+	word = strings.ToLower(word)
+	if listed(word) {
+		return true
+	}
+	for _, c := range strings.FieldsFunc(word, func(r rune) bool { return r == ' ' || r == '-' }) {
+		if listed(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the structure named name derived for an offensive word filter. Filters by severity
+// alone are few and kept as indexes; category combinations come from callers' options, so
+// theirs share the bounded pool cache.
+func (d *word_data) filtered(name string, f offense_filter, build func(*word_data) interface{}) interface{} {
+	key := fmt.Sprintf("%v/%v", name, f)
+	if f.categories == nil {
+		return d.index(key, build)
+	}
+	return d.derived(key, func() interface{} { return build(d) })
+}
+
+// Word pools with the offensive entries a filter removes taken out
+type prudish_index struct {
+	pools    map[string][]string
+	filtered map[string]uint // number of offensive entries removed per word type
+}
+
+func (d *word_data) prudish(f offense_filter) *prudish_index {
+	return d.filtered("prudish", f, func(d *word_data) interface{} {
+		pools, filtered := prefilter_offensive(d.all(), func(w string) bool { return d.offends(w, f) })
+		return &prudish_index{pools: pools, filtered: filtered}
+	}).(*prudish_index)
+}
+
+// Offensive words filtered for a call (level 0 = no filtering)
+func (d *word_data) filter(o *GenerateOptions) offense_filter {
+	if !o.Prudish || d.offensive == nil {
+		return offense_filter{}
+	}
+	return offense_filter{level: max(o.Prudish_level, 1), categories: o.FilterCategories}
+}
+
+// Categories named in the offensive list
+func (d *word_data) category_names() map[string]bool {
+	return d.index("categories", func(d *word_data) interface{} {
+		names := make(map[string]bool)
+		for _, categories := range d.categories {
+			for _, c := range categories {
+				names[c] = true
+			}
+		}
+		return names
+	}).(map[string]bool)
+}
+
+// FilterCategories lowercased, sorted and without duplicates, failing on categories the
+// offensive list does not name (unchecked if no offensive list was loaded)
+func (d *word_data) filter_categories(categories []string) ([]string, error) {
+	sorted := make([]string, 0, len(categories))
+	for _, c := range categories {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if d.offensive != nil && !d.category_names()[c] {
+			return nil, fmt.Errorf("%w: FilterCategories: unknown category %q", ErrInvalidParameter, c)
+		}
+		sorted = append(sorted, c)
+	}
+	sort.Strings(sorted)
+	return dedup_words(sorted), nil
+}
+
+// Word pools used for a call: prefiltered with the given filter (level 0 = unfiltered)
+func (d *word_data) pools(f offense_filter) map[string][]string {
+	if f.level > 0 && d.offensive != nil {
+		return d.prudish(f).pools
+	}
+	return d.all()
+}
+
+// Sorted indexes of the offensive words a filter removes in each word type of a lazy word
+// list, so prudish draws can skip them without copying any words out
+func (d *word_data) excluded(f offense_filter) map[string][]int {
+	return d.filtered("excluded", f, func(d *word_data) interface{} {
+		excluded := make(map[string][]int)
+		for t, offsets := range d.lazy.offsets {
+			for i := range offsets {
+				if d.offends(d.lazy.word(t, i), f) {
+					excluded[t] = append(excluded[t], i)
+				}
+			}
+		}
+		return excluded
+	}).(map[string][]int)
+}
+
+// Index of the r-th word not in excluded (sorted). The answer is r plus the number of
+// excluded indexes below it, which is the first j with excluded[j]-j > r.
+func skip_excluded(excluded []int, r int) int {
+	return r + sort.Search(len(excluded), func(j int) bool { return excluded[j]-j > r })
+}
+
+// Bytes in the shortest word (after splitting multiword entries) of each word type, with
+// the offensive words a filter removes taken out
+func (d *word_data) shortest(f offense_filter) map[string]int {
+	return d.filtered("shortest", f, func(d *word_data) interface{} {
+		shortest := make(map[string]int)
+		for t, words := range d.pools(f) {
+			for _, w := range words {
+				for _, field := range strings.Fields(w) {
+					if n, ok := shortest[t]; !ok || len(field) < n {
+						shortest[t] = len(field)
+					}
+				}
+			}
+		}
+		return shortest
+	}).(map[string]int)
+}
+
+// Words of each type sorted by length in runes, so a length range is a contiguous slice
+type length_index map[string][]string
+
+func (d *word_data) by_length(f offense_filter) length_index {
+	build := func(d *word_data) interface{} {
+		idx := make(length_index)
+		for t, words := range d.pools(f) {
+			sorted := append([]string{}, words...)
+			sort.SliceStable(sorted, func(i, j int) bool {
+				return utf8.RuneCountInString(sorted[i]) < utf8.RuneCountInString(sorted[j])
+			})
+			idx[t] = sorted
+		}
+		return idx
+	}
+	if f.level == 0 || d.offensive == nil {
+		return d.index("length", build).(length_index)
+	}
+	return d.filtered("length/prudish", f, build).(length_index)
+}
+
+// Words of a type with length in [min, max] runes (max of 0 means no upper bound)
+func (idx length_index) within(word_type string, min uint, max uint) []string {
+	words := idx[word_type]
+	lo := sort.Search(len(words), func(i int) bool {
+		return uint(utf8.RuneCountInString(words[i])) >= min
+	})
+	hi := len(words)
+	if max > 0 {
+		hi = sort.Search(len(words), func(i int) bool {
+			return uint(utf8.RuneCountInString(words[i])) > max
+		})
+	}
+	if hi < lo {
+		return nil
+	}
+	return words[lo:hi]
+}
@@ -0,0 +1,256 @@
+package wordentropy
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"unicode/utf8"
+)
+
+// Exercise the three index-backed features: prudish pools, length pools and the word set
+// of UnambiguousConcat
+func use_indexes(t *testing.T, g *Generator, i int) {
+	switch i % 3 {
+	case 0:
+		p, err := g.GeneratePassphrases(&GenerateOptions{Count: 10, Length: 10, Prudish: true})
+		if err != nil {
+			t.Errorf("Error generating passphrases: %v", err)
+			return
+		}
+		for _, pp := range p {
+			if strings.Contains(strings.ToLower(pp), "damn") {
+				t.Errorf("Offensive word in prudish passphrase: %v", pp)
+			}
+		}
+	case 1:
+		p, _, err := g.GeneratePassphrasesDetailed(&GenerateOptions{Count: 10, Length: 10, MinWordLength: 5, MaxWordLength: 6})
+		if err != nil {
+			t.Errorf("Error generating passphrases: %v", err)
+			return
+		}
+		for _, pp := range p {
+			for _, w := range pp.Words {
+				if n := utf8.RuneCountInString(w); n < 5 || n > 6 {
+					t.Errorf("Word %q outside length limits in %v", w, pp.Phrase)
+				}
+			}
+		}
+	case 2:
+		p, _, err := g.GeneratePassphrasesDetailed(&GenerateOptions{Count: 10, Length: 3, No_spaces: true, UnambiguousConcat: true})
+		if err != nil {
+			t.Errorf("Error generating passphrases: %v", err)
+			return
+		}
+		for _, pp := range p {
+			if pp.Phrase != strings.Join(pp.Words, "") {
+				t.Errorf("Unexpected concatenation %q of %v", pp.Phrase, pp.Words)
+			}
+		}
+	}
+}
+
+func TestIndexesAfterHotReload(t *testing.T) {
+	wo := &WordListOptions{Wordlist: "testdata/pos.txt", Offensive: "testdata/offensive.txt"}
+	g := load_test_generator(t)
+
+	for round := 0; round < 5; round++ {
+		if err := g.LoadWords(wo); err != nil {
+			t.Fatalf("Error reloading wordlist: %v", err)
+		}
+		d := g.words()
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < 30; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				use_indexes(t, g, i)
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+		if builds := atomic.LoadInt64(&d.indexes.builds); builds != 3 {
+			t.Fatalf("Round %v: expected each of 3 indexes to be built once, got %v builds", round, builds)
+		}
+	}
+
+	// Reload continuously while indexes are in use
+	stop := make(chan struct{})
+	reloaded := make(chan struct{})
+	go func() {
+		defer close(reloaded)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := g.LoadWords(wo); err != nil {
+				t.Errorf("Error reloading wordlist: %v", err)
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				use_indexes(t, g, i)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(stop)
+	<-reloaded
+}
+
+func TestLengthIndex(t *testing.T) {
+	g := generator_for(map[string][]string{"snoun": []string{"otter", "ox", "badger", "über", "emu"}})
+	idx := g.words().by_length(offense_filter{})
+	cases := []struct {
+		min, max uint
+		expected []string
+	}{
+		{0, 0, []string{"ox", "emu", "über", "otter", "badger"}},
+		{3, 4, []string{"emu", "über"}},
+		{6, 0, []string{"badger"}},
+		{7, 0, []string{}},
+		{0, 1, []string{}},
+	}
+	for _, c := range cases {
+		words := idx.within("snoun", c.min, c.max)
+		if len(words) != len(c.expected) || (len(words) > 0 && !reflect.DeepEqual(words, c.expected)) {
+			t.Errorf("Length %v-%v: expected %v, got %v", c.min, c.max, c.expected, words)
+		}
+	}
+	if _, err := g.GeneratePassphrases(&GenerateOptions{MinWordLength: 5, MaxWordLength: 4}); err != ErrInvalidWordLength {
+		t.Fatalf("Expected ErrInvalidWordLength, got %v", err)
+	}
+}
+
+func TestDeferredLoad(t *testing.T) {
+	if _, err := (&Generator{}).GeneratePassphrases(nil); err != ErrWordlistNotLoaded || !errors.Is(err, ErrEmptyWordlist) {
+		t.Fatalf("Expected ErrWordlistNotLoaded wrapping ErrEmptyWordlist, got %v", err)
+	}
+
+	g, err := LoadGenerator(&WordListOptions{Wordlist: "testdata/pos.txt", Offensive: "testdata/offensive.txt", Deferred: true})
+	if err != nil {
+		t.Fatalf("Error from deferred LoadGenerator: %v", err)
+	}
+	if g.data.Load() != nil {
+		t.Fatalf("Wordlist loaded before first use")
+	}
+	var wg sync.WaitGroup
+	snapshots := make([]*word_data, 20)
+	for i := range snapshots {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := g.GeneratePassphrases(&GenerateOptions{Prudish: true}); err != nil {
+				t.Errorf("Error generating passphrases: %v", err)
+			}
+			snapshots[i] = g.words()
+		}(i)
+	}
+	wg.Wait()
+	for _, d := range snapshots {
+		if d != snapshots[0] || d.offensive == nil {
+			t.Fatalf("Expected a single load with the offensive list")
+		}
+	}
+
+	g, err = LoadGenerator(&WordListOptions{Wordlist: "testdata/missing.txt", Deferred: true})
+	if err != nil {
+		t.Fatalf("Error from deferred LoadGenerator: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		var pe *ParseError
+		if _, err := g.GeneratePassphrases(nil); !errors.As(err, &pe) || !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("Expected the deferred load error, got %v", err)
+		}
+	}
+	// Loading again replaces the failed deferred load
+	if err := g.LoadWords(&WordListOptions{Wordlist: "testdata/pos.txt"}); err != nil {
+		t.Fatalf("Error loading wordlist: %v", err)
+	}
+	if _, err := g.GeneratePassphrases(nil); err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+
+	if err := g.LoadWords(&WordListOptions{Wordlist: "testdata/pos.txt", Format: "xml", Deferred: true}); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestDerivedPoolCache(t *testing.T) {
+	g := load_test_generator(t)
+	// Derive every pool a call with o can use, rather than those its draws happen to need
+	derive := func(o GenerateOptions) *gen_state {
+		t.Helper()
+		s, err := g.prepare(&o, g.words(), nil)
+		if err != nil {
+			t.Fatalf("Error preparing call: %v", err)
+		}
+		for _, wt := range word_types {
+			words, _, _ := s.pool(wt, false)
+			s.buckets(wt, words)
+			for _, number := range []string{"singular", "plural"} {
+				s.agreeing(wt, number, words)
+			}
+		}
+		return s
+	}
+	o := GenerateOptions{Agreement: true, ShortWordBias: 0.5, ExtraDenyWords: []string{"otter"}}
+	derive(o)
+	d := g.words()
+	builds := atomic.LoadInt64(&d.derived_pools.builds)
+	if builds == 0 {
+		t.Fatalf("Expected derived pools to be built")
+	}
+	// Same options, with the denied words given differently: the pools are reused
+	derive(o)
+	o.ExtraDenyWords = []string{" Otter"}
+	derive(o)
+	if n := atomic.LoadInt64(&d.derived_pools.builds); n != builds {
+		t.Errorf("Expected %v pool builds for repeated options, got %v", builds, n)
+	}
+	o.ShortWordBias = 1
+	derive(o)
+	if n := atomic.LoadInt64(&d.derived_pools.builds); n == builds {
+		t.Errorf("Expected new pools for a different ShortWordBias")
+	}
+	if _, err := g.GeneratePassphrases(&GenerateOptions{Count: 10, Agreement: true, ShortWordBias: 0.5, ExtraDenyWords: []string{"otter"}}); err != nil {
+		t.Fatalf("Error generating passphrases: %v", err)
+	}
+
+	// AddWords publishes a snapshot with its own, empty cache
+	if err := g.AddWords("snoun", "wombat"); err != nil {
+		t.Fatalf("Error adding words: %v", err)
+	}
+	if g.words().derived_pools.entries != nil {
+		t.Fatalf("Expected no derived pools after AddWords")
+	}
+	words, _, _ := derive(o).pool("snoun", false)
+	if !reflect.DeepEqual(words, []string{"badger", "damn fool", "wombat"}) {
+		t.Errorf("Unexpected pool after AddWords: %v", words)
+	}
+
+	// The least recently used pools are evicted
+	d = g.words()
+	for i := 0; i < pool_cache_size+10; i++ {
+		if _, err := g.GeneratePassphrases(&GenerateOptions{Count: 1, ExtraDenyWords: []string{fmt.Sprintf("word%v", i)}}); err != nil {
+			t.Fatalf("Error generating passphrases: %v", err)
+		}
+	}
+	if n := len(d.derived_pools.entries); n != pool_cache_size || d.derived_pools.order.Len() != n {
+		t.Errorf("Expected %v cached pools, got %v", pool_cache_size, n)
+	}
+}
@@ -0,0 +1,138 @@
+package wordentropy
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Generate passphrases according to the options and write them to w as WritePassphrases
+// does. Passphrases generated before a failure (see GeneratePassphrases) are written before
+// the failure is returned.
+func (g *Generator) GeneratePassphrasesTo(w io.Writer, o *GenerateOptions) (err error) {
+	defer recover_internal(&err)
+	p, err := g.GeneratePassphrases(o)
+	if p == nil {
+		return err
+	}
+	if werr := WritePassphrases(w, p, o); werr != nil {
+		return werr
+	}
+	return err
+}
+
+// Write passphrases to w, each followed by the delimiter in the options (Delimiter, by
+// default a newline, or NUL with NulDelimiter) except the last, which is only followed by it
+// with TrailingDelimiter. Passphrases containing the delimiter are refused, as they could
+// not be told apart.
+func WritePassphrases(w io.Writer, passphrases []string, o *GenerateOptions) error {
+	var options GenerateOptions
+	if o != nil {
+		options = *o
+	}
+	d, err := check_delimiter(&options)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for i, p := range passphrases {
+		if err := delimit(&b, p, i, d, i < len(passphrases)-1 || options.TrailingDelimiter); err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// Append passphrase p, number i from 0, to b, followed by the delimiter d if more is set
+func delimit(b *strings.Builder, p string, i int, d byte, more bool) error {
+	if strings.IndexByte(p, d) >= 0 {
+		return fmt.Errorf("%w: passphrase %v contains the delimiter %q", ErrInvalidParameter, i+1, d)
+	}
+	b.WriteString(p)
+	if more {
+		b.WriteByte(d)
+	}
+	return nil
+}
+
+// Delimiter to end passphrases with under options o: NUL with NulDelimiter, else the
+// Delimiter, a newline if 0. It must be a control character, such as a tab, so that no
+// passphrase can contain it: passphrases are made of printable characters only.
+func check_delimiter(o *GenerateOptions) (byte, error) {
+	if o.NulDelimiter {
+		if o.Delimiter != 0 {
+			return 0, fmt.Errorf("%w: NulDelimiter cannot be combined with Delimiter %q", ErrInvalidParameter, o.Delimiter)
+		}
+		return 0, nil
+	}
+	if o.Delimiter == 0 {
+		return '\n', nil
+	}
+	if o.Delimiter >= ' ' && o.Delimiter != 0x7f {
+		return o.Delimiter, fmt.Errorf("%w: Delimiter %q must be a control character, e.g. '\\n' or '\\t'", ErrInvalidParameter, o.Delimiter)
+	}
+	return o.Delimiter, nil
+}
+
+// Reader of passphrases generated as they are read, written as WritePassphrases writes
+// them. Each passphrase is generated by a call of its own to GeneratePassphrases, so
+// Timeout and VaryJoints apply to each separately; after Count passphrases, Read returns
+// io.EOF. A generation error is returned by Read once the passphrases before it have been
+// read, and by every later Read.
+type PhraseReader struct {
+	g     *Generator
+	o     GenerateOptions // generating one passphrase at a time
+	d     byte
+	count uint // passphrases to generate
+	n     uint // passphrases generated so far
+	buf   []byte
+	err   error
+}
+
+// Make a PhraseReader of passphrases generated by g according to the options, which are
+// checked here but not copied deeply: they must not be changed while the reader is in use.
+func NewPhraseReader(g *Generator, o *GenerateOptions) (_ *PhraseReader, err error) {
+	defer recover_internal(&err)
+	options, err := g.check_options(o)
+	if err != nil {
+		return nil, err
+	}
+	d, err := check_delimiter(&options)
+	if err != nil {
+		return nil, err
+	}
+	r := &PhraseReader{g: g, o: options, d: d, count: options.Count}
+	r.o.Count = 1
+	return r, nil
+}
+
+func (r *PhraseReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// Generate the next passphrase into the buffer, or set the error to return once it is empty
+func (r *PhraseReader) fill() {
+	if r.n == r.count {
+		r.err = io.EOF
+		return
+	}
+	p, err := r.g.GeneratePassphrases(&r.o)
+	if err != nil {
+		r.err = err
+		return
+	}
+	r.n++
+	var b strings.Builder
+	if r.err = delimit(&b, p[0], int(r.n-1), r.d, r.n < r.count || r.o.TrailingDelimiter); r.err == nil {
+		r.buf = []byte(b.String())
+	}
+}
@@ -0,0 +1,111 @@
+package wordentropy
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestGeneratePassphrasesTo(t *testing.T) {
+	g := load_test_generator(t)
+	var b bytes.Buffer
+	if err := g.GeneratePassphrasesTo(&b, &GenerateOptions{Count: 5, Length: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); strings.HasSuffix(s, "\n") || strings.Count(s, "\n") != 4 {
+		t.Errorf("Expected 5 newline-separated passphrases, got %q", s)
+	}
+
+	// NUL-delimited, as for xargs -0, parses back into the same passphrases
+	b.Reset()
+	o := GenerateOptions{Count: 5, Length: 3, NulDelimiter: true, TrailingDelimiter: true}
+	if err := g.GeneratePassphrasesTo(&b, &o); err != nil {
+		t.Fatal(err)
+	}
+	s, ok := strings.CutSuffix(b.String(), "\x00")
+	if !ok {
+		t.Fatalf("Expected a trailing NUL, got %q", b.String())
+	}
+	if p := strings.Split(s, "\x00"); len(p) != 5 || len(strings.Fields(p[0])) != 3 {
+		t.Errorf("Expected 5 passphrases of 3 words, got %q", p)
+	}
+	phrases := []string{"otter swims", "badger digs"}
+	b.Reset()
+	if err := WritePassphrases(&b, phrases, &o); err != nil || b.String() != "otter swims\x00badger digs\x00" {
+		t.Errorf("Expected NUL-terminated passphrases, got %q, %v", b.String(), err)
+	}
+	b.Reset()
+	if err := WritePassphrases(&b, phrases, &GenerateOptions{Delimiter: '\t'}); err != nil || b.String() != "otter swims\tbadger digs" {
+		t.Errorf("Expected tab-separated passphrases, got %q, %v", b.String(), err)
+	}
+
+	for _, bad := range []GenerateOptions{
+		{Delimiter: ','},
+		{Delimiter: 0xe2},
+		{Delimiter: '\t', NulDelimiter: true},
+		{NulDelimiter: true, Separator: "\x00"},
+	} {
+		b.Reset()
+		if err := g.GeneratePassphrasesTo(&b, &bad); !errors.Is(err, ErrInvalidParameter) || b.Len() != 0 {
+			t.Errorf("%q: expected ErrInvalidParameter and no output, got %v, %q", bad.Delimiter, err, b.String())
+		}
+	}
+	if err := WritePassphrases(&b, []string{"otter\tswims"}, &GenerateOptions{Delimiter: '\t'}); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for a passphrase containing the delimiter, got %v", err)
+	}
+}
+
+func TestPhraseReader(t *testing.T) {
+	g := load_test_generator(t)
+	r, err := NewPhraseReader(g, &GenerateOptions{Count: 4, Length: 3, NulDelimiter: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(iotest.OneByteReader(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := strings.Split(string(data), "\x00")
+	if len(p) != 4 {
+		t.Fatalf("Expected 4 NUL-separated passphrases, got %q", data)
+	}
+	for _, phrase := range p {
+		if len(strings.Fields(phrase)) != 3 {
+			t.Errorf("Expected 3 words, got %q", phrase)
+		}
+	}
+	if n, err := r.Read(make([]byte, 8)); n != 0 || err != io.EOF {
+		t.Errorf("Expected io.EOF after the last passphrase, got %v, %v", n, err)
+	}
+
+	r, err = NewPhraseReader(g, &GenerateOptions{Count: 2, TrailingDelimiter: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := io.ReadAll(r); err != nil || strings.Count(string(data), "\n") != 2 || !strings.HasSuffix(string(data), "\n") {
+		t.Errorf("Expected 2 newline-terminated passphrases, got %q, %v", data, err)
+	}
+
+	if _, err := NewPhraseReader(g, &GenerateOptions{Delimiter: ' '}); !errors.Is(err, ErrInvalidParameter) {
+		t.Errorf("Expected ErrInvalidParameter for a printable delimiter, got %v", err)
+	}
+	if _, err := NewPhraseReader(&Generator{}, nil); !errors.Is(err, ErrWordlistNotLoaded) {
+		t.Errorf("Expected ErrWordlistNotLoaded, got %v", err)
+	}
+
+	// Generation errors come from Read, every time
+	closing := load_test_generator(t)
+	r, err = NewPhraseReader(closing, &GenerateOptions{Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closing.Close()
+	for i := 0; i < 2; i++ {
+		if _, err := r.Read(make([]byte, 8)); !errors.Is(err, ErrClosed) {
+			t.Errorf("Expected ErrClosed from Read, got %v", err)
+		}
+	}
+}